@@ -0,0 +1,241 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider/fakeprovider"
+)
+
+// fakeProviderAPIKey is the key exchangers registered by the scenarios send to the fake provider
+const fakeProviderAPIKey = "integration-fake-provider-key"
+
+// fakeProvider serves rates to the API under test. It is started by the first scenario that needs
+// it, on INTEGRATION_FAKE_PROVIDER_ADDR (a free loopback port by default). The API reaches it at
+// INTEGRATION_FAKE_PROVIDER_URL, which defaults to the listening address; set it when the API runs
+// in a container, e.g. http://host.docker.internal:9090.
+var fakeProvider *fakeprovider.Server
+
+// scenarioCurrencyCodes are the codes the current scenario made the fake provider quote; refreshes
+// store them as currencies, which are deleted when the scenario ends
+var scenarioCurrencyCodes []string
+
+func fakeProviderConfig() fakeprovider.Config {
+	return fakeprovider.Config{APIKey: fakeProviderAPIKey, Rates: map[string]float64{}}
+}
+
+func ensureFakeProvider() error {
+	if fakeProvider != nil {
+		return nil
+	}
+	addr := os.Getenv("INTEGRATION_FAKE_PROVIDER_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	server, err := fakeprovider.NewServerOn(addr, fakeProviderConfig())
+	if err != nil {
+		return fmt.Errorf("starting the fake provider on %s: %w", addr, err)
+	}
+	fakeProvider = server
+	url := server.URL(fakeprovider.FormatData)
+	if external := os.Getenv("INTEGRATION_FAKE_PROVIDER_URL"); external != "" {
+		url = strings.TrimSuffix(external, "/") + "/" + string(fakeprovider.FormatData)
+	}
+	savedVars["fakeProviderURL"] = url
+	logger.Printf("Fake provider listening, the API reaches it at %s", url)
+	return nil
+}
+
+// resetFakeProvider makes every scenario start from a fake provider quoting nothing
+func resetFakeProvider() {
+	if fakeProvider != nil {
+		fakeProvider.Configure(fakeProviderConfig())
+	}
+}
+
+func stopFakeProvider() {
+	if fakeProvider != nil {
+		fakeProvider.Close()
+		fakeProvider = nil
+	}
+}
+
+func updateFakeProvider(change func(config *fakeprovider.Config)) error {
+	if err := ensureFakeProvider(); err != nil {
+		return err
+	}
+	fakeProvider.Update(change)
+	return nil
+}
+
+func theFakeProviderQuotes(code string, rate float64) error {
+	scenarioCurrencyCodes = append(scenarioCurrencyCodes, code)
+	return updateFakeProvider(func(config *fakeprovider.Config) { config.Rates[code] = rate })
+}
+
+// deleteScenarioCurrencies deletes the currencies refreshed from the codes the scenario quoted, so
+// the next scenario stores its rates from scratch
+func deleteScenarioCurrencies() {
+	for _, code := range scenarioCurrencyCodes {
+		req, _ := http.NewRequest(http.MethodGet, base+"/v1/currency/search?code_match="+code, nil)
+		addAuthHeader(req)
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			logger.Printf("Looking up currency %s for cleanup failed: %v", code, err)
+			continue
+		}
+		var found struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&found)
+		response.Body.Close()
+		if err != nil {
+			logger.Printf("Looking up currency %s for cleanup failed: %v", code, err)
+			continue
+		}
+		for _, currency := range found.Data {
+			logger.Printf("Cleaning up scenario currency %s: /v1/currency/%d", code, currency.ID)
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v1/currency/%d", base, currency.ID), nil)
+			addAuthHeader(req)
+			if response, err := http.DefaultClient.Do(req); err == nil {
+				response.Body.Close()
+			}
+		}
+	}
+	scenarioCurrencyCodes = nil
+}
+
+func theFakeProviderFailsPercentOfRequests(percent int) error {
+	return updateFakeProvider(func(config *fakeprovider.Config) { config.ErrorRate = float64(percent) / 100 })
+}
+
+func theFakeProviderAnswersMalformedJSON() error {
+	return updateFakeProvider(func(config *fakeprovider.Config) { config.MalformedRate = 1 })
+}
+
+func theFakeProviderAnswersAfter(milliseconds int) error {
+	return updateFakeProvider(func(config *fakeprovider.Config) {
+		config.Latency = time.Duration(milliseconds) * time.Millisecond
+	})
+}
+
+func theFakeProviderRatesDrift(percent float64) error {
+	return updateFakeProvider(func(config *fakeprovider.Config) { config.DriftPercent = percent })
+}
+
+// theFakeProviderIsRegisteredAsAnExchanger creates an active exchanger calling the fake provider,
+// saved as ${fakeExchangerID} and deleted when the scenario ends
+func theFakeProviderIsRegisteredAsAnExchanger() error {
+	if err := ensureFakeProvider(); err != nil {
+		return err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"name":     generateUniqueValue("fake-provider"),
+		"url":      savedVars["fakeProviderURL"],
+		"apiKey":   fakeProviderAPIKey,
+		"isActive": true,
+	})
+	req, _ := http.NewRequest(http.MethodPost, base+"/v1/exchanger/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeader(req)
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("registering the fake provider answered %d: %s", response.StatusCode, responseBody)
+	}
+	var created struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(responseBody, &created); err != nil || created.ID == 0 {
+		return fmt.Errorf("registering the fake provider answered no id: %s", responseBody)
+	}
+	savedVars["fakeExchangerID"] = strconv.Itoa(created.ID)
+	scenarioResources = append(scenarioResources, fmt.Sprintf("/v1/exchanger/%d", created.ID))
+	logger.Printf("Registered the fake provider as exchanger %d", created.ID)
+	return nil
+}
+
+func theFakeProviderShouldHaveReceivedAtLeast(count int) error {
+	if fakeProvider == nil {
+		return fmt.Errorf("the fake provider was not started")
+	}
+	if calls := fakeProvider.Calls(); calls < count {
+		return fmt.Errorf("expected the fake provider to receive at least %d requests, got %d", count, calls)
+	}
+	return nil
+}
+
+func jsonResponseNumber(field string) (float64, error) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+	value, exists := getNestedValue(response, field)
+	if !exists {
+		return 0, fmt.Errorf("key '%s' not found in response", field)
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("field '%s' is not a number: %v", field, value)
+	}
+	return number, nil
+}
+
+func theJSONResponseShouldMatchTheFakeProviderRate(field, code string) error {
+	if fakeProvider == nil {
+		return fmt.Errorf("the fake provider was not started")
+	}
+	actual, err := jsonResponseNumber(field)
+	if err != nil {
+		return err
+	}
+	expected, quoted := fakeProvider.Rates()[code]
+	if !quoted {
+		return fmt.Errorf("the fake provider does not quote %s", code)
+	}
+	if math.Abs(actual-expected) > 1e-9*expected {
+		return fmt.Errorf("expected '%s' to be the fake provider rate of %s, %v, got %v", field, code, expected, actual)
+	}
+	return nil
+}
+
+func theJSONResponseShouldBeAtLeast(field string, minimum float64) error {
+	actual, err := jsonResponseNumber(field)
+	if err != nil {
+		return err
+	}
+	if actual < minimum {
+		return fmt.Errorf("expected '%s' to be at least %v, got %v", field, minimum, actual)
+	}
+	return nil
+}
+
+func initializeFakeProviderScenario(ctx *godog.ScenarioContext) {
+	ctx.Step(`^the fake provider quotes "([A-Z]{3})" at (\d+(?:\.\d+)?)$`, theFakeProviderQuotes)
+	ctx.Step(`^the fake provider fails (\d+)% of requests$`, theFakeProviderFailsPercentOfRequests)
+	ctx.Step(`^the fake provider answers malformed JSON$`, theFakeProviderAnswersMalformedJSON)
+	ctx.Step(`^the fake provider answers after (\d+)ms$`, theFakeProviderAnswersAfter)
+	ctx.Step(`^the fake provider rates drift by up to (\d+(?:\.\d+)?)% per request$`, theFakeProviderRatesDrift)
+	ctx.Step(`^the fake provider is registered as an exchanger$`, theFakeProviderIsRegisteredAsAnExchanger)
+	ctx.Step(`^the fake provider should have received at least (\d+) requests?$`, theFakeProviderShouldHaveReceivedAtLeast)
+	ctx.Step(`^the JSON response "([^"]*)" should match the fake provider rate of "([A-Z]{3})"$`, theJSONResponseShouldMatchTheFakeProviderRate)
+	ctx.Step(`^the JSON response "([^"]*)" should be at least (\d+(?:\.\d+)?)$`, theJSONResponseShouldBeAtLeast)
+}
@@ -0,0 +1,66 @@
+Feature: Rate Refresh
+  As an API consumer
+  I want the rates refreshed from the registered exchangers
+  So that conversions use what the providers quote
+
+  Background:
+    # Authentication handled globally. Exchangers call an in-process fake provider quoting XTS,
+    # the ISO code reserved for testing, so no other exchanger affects its rate.
+
+  Scenario: Refresh stores the rate quoted by the provider
+    Given the fake provider quotes "XTS" at 2.5
+    And the fake provider is registered as an exchanger
+    When I send a PUT request to "/v1/currency/rates"
+    Then the response code should be 200
+    And the fake provider should have received at least 1 request
+    When I send a GET request to "/v1/convert?from=USD&to=XTS&amount=10"
+    Then the response code should be 200
+    And the JSON response should contain "result": 25
+    And the JSON response "to.rate" should match the fake provider rate of "XTS"
+
+  Scenario: Refresh follows drifting rates
+    Given the fake provider quotes "XTS" at 2.5
+    And the fake provider rates drift by up to 5% per request
+    And the fake provider is registered as an exchanger
+    When I send a PUT request to "/v1/currency/rates"
+    Then the response code should be 200
+    When I send a PUT request to "/v1/currency/rates"
+    Then the response code should be 200
+    When I send a GET request to "/v1/convert?from=USD&to=XTS&amount=1"
+    Then the response code should be 200
+    And the JSON response "to.rate" should match the fake provider rate of "XTS"
+
+  Scenario: Slow provider answers are recorded in the exchanger health
+    Given the fake provider quotes "XTS" at 2.5
+    And the fake provider answers after 200ms
+    And the fake provider is registered as an exchanger
+    When I send a PUT request to "/v1/currency/rates"
+    Then the response code should be 200
+    When I send a GET request to "/v1/exchanger/${fakeExchangerID}/health"
+    Then the response code should be 200
+    And the JSON response should contain "successes": 1
+    And the JSON response "latency.p50Ms" should be at least 200
+
+  Scenario: A failing provider is retried and recorded as a failure
+    Given the fake provider quotes "XTS" at 2.5
+    And the fake provider fails 100% of requests
+    And the fake provider is registered as an exchanger
+    When I send a PUT request to "/v1/currency/rates"
+    # the refresh only fails when no other exchanger answered
+    Then the response code should be 200 or 500
+    And the fake provider should have received at least 2 requests
+    When I send a GET request to "/v1/exchanger/${fakeExchangerID}/health"
+    Then the response code should be 200
+    And the JSON response should contain "consecutiveFailures": 1
+    And the JSON response field "lastError" should contain string "provider error 503"
+
+  Scenario: Malformed provider answers are recorded as failures
+    Given the fake provider quotes "XTS" at 2.5
+    And the fake provider answers malformed JSON
+    And the fake provider is registered as an exchanger
+    When I send a PUT request to "/v1/currency/rates"
+    Then the response code should be 200 or 500
+    When I send a GET request to "/v1/exchanger/${fakeExchangerID}/health"
+    Then the response code should be 200
+    And the JSON response should contain "consecutiveFailures": 1
+    And the JSON response field "lastError" should contain string "JSON"
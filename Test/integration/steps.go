@@ -771,6 +771,7 @@ func InitializeTestSuite(ctx *godog.TestSuiteContext) {
 
 	ctx.AfterSuite(func() {
 		logger.Println("Cleaning up test suite...")
+		stopFakeProvider()
 		// Clean up any remaining resources
 		for _, resource := range createdResources {
 			logger.Printf("Cleaning up resource: %s", resource)
@@ -871,6 +872,7 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 		currentScenarioID = generateUUID()
 		scenarioResources = []string{}
 		skipNextTracking = false
+		resetFakeProvider()
 
 		// Ensure we have a valid authentication token for each scenario
 		if token, exists := savedVars["accessToken"]; !exists || token == "" {
@@ -890,6 +892,7 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 			addAuthHeader(req)
 			http.DefaultClient.Do(req)
 		}
+		deleteScenarioCurrencies()
 
 		// Clear scenario-specific variables
 		for key := range savedVars {
@@ -942,6 +945,9 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 
 	// Authentication steps
 	ctx.Step(`^I clear the authentication token$`, iClearTheAuthenticationToken)
+
+	// Fake rate provider steps
+	initializeFakeProviderScenario(ctx)
 }
 
 func iClearTheAuthenticationToken() error {
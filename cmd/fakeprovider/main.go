@@ -0,0 +1,89 @@
+// Command fakeprovider serves made-up exchange rates in the formats of the supported providers, so
+// that refreshes can run without calling a real one. Register http://<addr>/data as the URL of an
+// exchanger; http://<addr>/rates answers in the exchangerate-api format.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider/fakeprovider"
+	"go.uber.org/zap"
+)
+
+func main() {
+	defaults := fakeprovider.DefaultConfig()
+	addr := flag.String("addr", ":9090", "address to listen on")
+	rates := flag.String("rates", formatRates(defaults.Rates), "comma separated CODE=rate pairs")
+	config := fakeprovider.Config{}
+	flag.StringVar(&config.Base, "base", defaults.Base, "currency the rates are quoted against")
+	flag.DurationVar(&config.Latency, "latency", 0, "delay before every answer")
+	flag.Float64Var(&config.ErrorRate, "error-rate", 0, "share of requests answered with -error-status, from 0 to 1")
+	flag.IntVar(&config.ErrorStatus, "error-status", http.StatusServiceUnavailable, "status of failed requests")
+	flag.Float64Var(&config.MalformedRate, "malformed-rate", 0, "share of requests answered with malformed JSON, from 0 to 1")
+	flag.Float64Var(&config.DriftPercent, "drift", 0, "largest move of every rate per request, in percent")
+	flag.StringVar(&config.APIKey, "api-key", "", "API key requests must carry as apikey; any key when empty")
+	flag.Uint64Var(&config.Seed, "seed", 0, "seed of the random source; from the clock when 0")
+	flag.Parse()
+
+	loggerInstance, err := logger.NewDevelopmentLogger()
+	if err != nil {
+		panic(fmt.Errorf("error initializing logger: %w", err))
+	}
+	if config.Rates, err = parseRates(*rates); err != nil {
+		loggerInstance.Error("Invalid -rates", zap.Error(err))
+		os.Exit(2)
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           fakeprovider.New(config),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	loggerInstance.Info("Fake provider listening",
+		zap.String("addr", *addr),
+		zap.Int("currencies", len(config.Rates)),
+		zap.Duration("latency", config.Latency),
+		zap.Float64("errorRate", config.ErrorRate),
+		zap.Float64("malformedRate", config.MalformedRate),
+		zap.Float64("driftPercent", config.DriftPercent))
+	if err := server.ListenAndServe(); err != nil {
+		loggerInstance.Error("Fake provider stopped", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func parseRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		code, raw, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("%q is not a CODE=rate pair", pair)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("%q is not a positive rate", raw)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return rates, nil
+}
+
+func formatRates(rates map[string]float64) string {
+	pairs := make([]string, 0, len(rates))
+	for _, code := range slices.Sorted(maps.Keys(rates)) {
+		pairs = append(pairs, code+"="+strconv.FormatFloat(rates[code], 'f', -1, 64))
+	}
+	return strings.Join(pairs, ",")
+}
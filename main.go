@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -59,6 +60,11 @@ func main() {
 		loggerInstance.Panic("Error initializing application context", zap.Error(err))
 	}
 
+	// Start the webhook delivery worker
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go appContext.WebhookUseCase.Run(workerCtx)
+
 	// Setup router
 	router := setupRouter(appContext, loggerInstance)
 
@@ -83,6 +89,9 @@ func setupRouter(appContext *di.ApplicationContext, logger *logger.Logger) *gin.
 
 	// Crear el router después de configurar el logger
 	router := gin.New()
+	if err := router.SetTrustedProxies(middlewares.TrustedProxies()); err != nil {
+		logger.Panic("Error setting trusted proxies", zap.Error(err))
+	}
 
 	// Agregar middlewares de recuperación y logger personalizados
 	router.Use(gin.Recovery())
@@ -0,0 +1,202 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/notifier"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/alert"
+	"go.uber.org/zap"
+)
+
+type IAlertUseCase interface {
+	Create(userID int, pair string, condition string, threshold float64, cooldownMinutes int) (*domainAlert.Rule, error)
+	GetByUser(userID int) (*[]domainAlert.Rule, error)
+	Delete(userID int, id int) error
+	GetEvents(userID int) (*[]domainAlert.Event, error)
+	OnRatesUpdated(currencies []domainCurrency.Currency)
+}
+
+// AlertConfig holds the limits and defaults applied to alert rules
+type AlertConfig struct {
+	MaxRulesPerUser int
+	DefaultCooldown time.Duration
+	EventsLimit     int
+}
+
+type AlertUseCase struct {
+	AlertRepository alert.AlertRepositoryInterface
+	Notifier        notifier.INotifier
+	Config          AlertConfig
+	Logger          *logger.Logger
+	now             func() time.Time
+}
+
+func NewAlertUseCase(alertRepository alert.AlertRepositoryInterface, notifierInstance notifier.INotifier, loggerInstance *logger.Logger) IAlertUseCase {
+	return &AlertUseCase{
+		AlertRepository: alertRepository,
+		Notifier:        notifierInstance,
+		Config:          loadAlertConfig(),
+		Logger:          loggerInstance,
+		now:             time.Now,
+	}
+}
+
+// loadAlertConfig loads alert configuration from environment variables
+func loadAlertConfig() AlertConfig {
+	return AlertConfig{
+		MaxRulesPerUser: getEnvAsIntOrDefault("ALERT_MAX_PER_USER", 50),
+		DefaultCooldown: time.Duration(getEnvAsIntOrDefault("ALERT_DEFAULT_COOLDOWN_MINUTES", 60)) * time.Minute,
+		EventsLimit:     getEnvAsIntOrDefault("ALERT_EVENTS_LIMIT", 100),
+	}
+}
+
+func (s *AlertUseCase) Create(userID int, pair string, condition string, threshold float64, cooldownMinutes int) (*domainAlert.Rule, error) {
+	s.Logger.Info("Creating alert rule", zap.Int("userID", userID), zap.String("pair", pair), zap.String("condition", condition))
+	parsed, err := domainWatchlist.ParsePair(pair)
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	cond := domainAlert.Condition(condition)
+	if !cond.IsValid() {
+		return nil, domainErrors.NewAppError(fmt.Errorf("condition must be one of %s, %s or %s", domainAlert.ConditionAbove, domainAlert.ConditionBelow, domainAlert.ConditionChangePercent), domainErrors.ValidationError)
+	}
+	if threshold <= 0 || math.IsInf(threshold, 0) || math.IsNaN(threshold) {
+		return nil, domainErrors.NewAppError(errors.New("threshold must be a positive number"), domainErrors.ValidationError)
+	}
+	if cooldownMinutes < 0 {
+		return nil, domainErrors.NewAppError(errors.New("cooldownMinutes cannot be negative"), domainErrors.ValidationError)
+	}
+	cooldown := s.Config.DefaultCooldown
+	if cooldownMinutes > 0 {
+		cooldown = time.Duration(cooldownMinutes) * time.Minute
+	}
+
+	existing, err := s.AlertRepository.GetRulesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Config.MaxRulesPerUser > 0 && len(*existing) >= s.Config.MaxRulesPerUser {
+		return nil, domainErrors.NewAppError(fmt.Errorf("a user cannot have more than %d alerts", s.Config.MaxRulesPerUser), domainErrors.ValidationError)
+	}
+
+	created, err := s.AlertRepository.CreateRule(&domainAlert.Rule{
+		UserID:    userID,
+		Pair:      parsed,
+		Condition: cond,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Alert rule created", zap.Int("userID", userID), zap.Int("ruleID", created.ID))
+	return created, nil
+}
+
+func (s *AlertUseCase) GetByUser(userID int) (*[]domainAlert.Rule, error) {
+	s.Logger.Info("Getting alert rules", zap.Int("userID", userID))
+	return s.AlertRepository.GetRulesByUserID(userID)
+}
+
+func (s *AlertUseCase) Delete(userID int, id int) error {
+	s.Logger.Info("Deleting alert rule", zap.Int("userID", userID), zap.Int("ruleID", id))
+	return s.AlertRepository.DeleteRule(userID, id)
+}
+
+// GetEvents returns the most recent triggered events of a user
+func (s *AlertUseCase) GetEvents(userID int) (*[]domainAlert.Event, error) {
+	s.Logger.Info("Getting alert events", zap.Int("userID", userID))
+	return s.AlertRepository.GetEventsByUserID(userID, s.Config.EventsLimit)
+}
+
+// OnRatesUpdated evaluates every alert rule against the freshly refreshed rates.
+// Failures are logged per rule so one bad rule never blocks the others.
+func (s *AlertUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {
+	rules, err := s.AlertRepository.GetAllRules()
+	if err != nil {
+		s.Logger.Error("Error loading alert rules for evaluation", zap.Error(err))
+		return
+	}
+
+	rates := make(map[string]float64, len(currencies))
+	for _, c := range currencies {
+		rates[c.Code] = c.Rate
+	}
+
+	now := s.now()
+	for i := range *rules {
+		rule := &(*rules)[i]
+		rate, ok := rule.Pair.CrossRate(rates)
+		if !ok {
+			continue
+		}
+		if err := s.evaluate(rule, rate, now); err != nil {
+			s.Logger.Error("Error evaluating alert rule", zap.Error(err), zap.Int("ruleID", rule.ID))
+		}
+	}
+}
+
+// evaluate checks one rule against its current rate, storing the state change and the event when it triggers
+func (s *AlertUseCase) evaluate(rule *domainAlert.Rule, rate float64, now time.Time) error {
+	triggered := false
+	switch rule.Condition {
+	case domainAlert.ConditionAbove:
+		triggered = rate > rule.Threshold
+	case domainAlert.ConditionBelow:
+		triggered = rate < rule.Threshold
+	case domainAlert.ConditionChangePercent:
+		if rule.ReferenceAt == nil || rule.ReferenceRate <= 0 || now.Sub(*rule.ReferenceAt) >= domainAlert.ChangeWindow {
+			rule.ReferenceRate = rate
+			rule.ReferenceAt = &now
+			return s.AlertRepository.UpdateRuleState(rule)
+		}
+		triggered = math.Abs(rate-rule.ReferenceRate)/rule.ReferenceRate*100 >= rule.Threshold
+	default:
+		return nil
+	}
+	if !triggered || rule.InCooldown(now) {
+		return nil
+	}
+
+	rule.LastTriggeredAt = &now
+	if err := s.AlertRepository.UpdateRuleState(rule); err != nil {
+		return err
+	}
+	event, err := s.AlertRepository.CreateEvent(&domainAlert.Event{
+		RuleID:        rule.ID,
+		UserID:        rule.UserID,
+		Pair:          rule.Pair,
+		Condition:     rule.Condition,
+		Threshold:     rule.Threshold,
+		Rate:          rate,
+		ReferenceRate: rule.ReferenceRate,
+		TriggeredAt:   now,
+	})
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("Alert rule triggered", zap.Int("ruleID", rule.ID), zap.Int("userID", rule.UserID), zap.Float64("rate", rate))
+	if err := s.Notifier.Notify(event); err != nil {
+		s.Logger.Warn("Error delivering alert notification", zap.Error(err), zap.Int("eventID", event.ID))
+	}
+	return nil
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
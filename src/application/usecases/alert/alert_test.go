@@ -0,0 +1,211 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/notifier"
+)
+
+type mockAlertRepository struct {
+	rules   []domainAlert.Rule
+	created *domainAlert.Rule
+	states  []domainAlert.Rule
+	events  []domainAlert.Event
+}
+
+func (m *mockAlertRepository) CreateRule(rule *domainAlert.Rule) (*domainAlert.Rule, error) {
+	m.created = rule
+	saved := *rule
+	saved.ID = 1
+	return &saved, nil
+}
+func (m *mockAlertRepository) GetRulesByUserID(userID int) (*[]domainAlert.Rule, error) {
+	var rules []domainAlert.Rule
+	for _, r := range m.rules {
+		if r.UserID == userID {
+			rules = append(rules, r)
+		}
+	}
+	return &rules, nil
+}
+func (m *mockAlertRepository) GetAllRules() (*[]domainAlert.Rule, error) {
+	rules := make([]domainAlert.Rule, len(m.rules))
+	copy(rules, m.rules)
+	return &rules, nil
+}
+func (m *mockAlertRepository) UpdateRuleState(rule *domainAlert.Rule) error {
+	m.states = append(m.states, *rule)
+	return nil
+}
+func (m *mockAlertRepository) DeleteRule(userID int, id int) error {
+	return nil
+}
+func (m *mockAlertRepository) CreateEvent(event *domainAlert.Event) (*domainAlert.Event, error) {
+	saved := *event
+	saved.ID = len(m.events) + 1
+	m.events = append(m.events, saved)
+	return &saved, nil
+}
+func (m *mockAlertRepository) GetEventsByUserID(userID int, limit int) (*[]domainAlert.Event, error) {
+	return &m.events, nil
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(event *domainAlert.Event) error {
+	return errors.New("unreachable")
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, repo *mockAlertRepository, n notifier.INotifier, now time.Time) *AlertUseCase {
+	return &AlertUseCase{
+		AlertRepository: repo,
+		Notifier:        n,
+		Config:          AlertConfig{MaxRulesPerUser: 2, DefaultCooldown: time.Hour, EventsLimit: 10},
+		Logger:          setupLogger(t),
+		now:             func() time.Time { return now },
+	}
+}
+
+var eurUSD = domainWatchlist.Pair{Base: "EUR", Quote: "USD"}
+
+// eurRates returns refreshed rates in which one EUR is worth the given amount of USD
+func eurRates(eurInUSD float64) []domainCurrency.Currency {
+	return []domainCurrency.Currency{{Code: "EUR", Rate: 1 / eurInUSD}}
+}
+
+func TestAlertUseCase_Create(t *testing.T) {
+	repo := &mockAlertRepository{}
+	uc := newTestUseCase(t, repo, notifier.NewMemoryNotifier(), time.Now())
+
+	rule, err := uc.Create(3, "eur/usd", "above", 1.12, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Pair != eurUSD || rule.Cooldown != time.Hour {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+
+	rule, err = uc.Create(3, "EUR/USD", "change_percent", 2, 15)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Cooldown != 15*time.Minute {
+		t.Errorf("expected 15m cooldown, got %s", rule.Cooldown)
+	}
+}
+
+func TestAlertUseCase_CreateValidation(t *testing.T) {
+	repo := &mockAlertRepository{rules: []domainAlert.Rule{{ID: 1, UserID: 3}, {ID: 2, UserID: 3}}}
+	uc := newTestUseCase(t, repo, notifier.NewMemoryNotifier(), time.Now())
+
+	cases := []struct {
+		name      string
+		userID    int
+		pair      string
+		condition string
+		threshold float64
+		cooldown  int
+	}{
+		{"bad pair", 4, "EURUSD", "above", 1, 0},
+		{"bad condition", 4, "EUR/USD", "crosses", 1, 0},
+		{"zero threshold", 4, "EUR/USD", "below", 0, 0},
+		{"negative cooldown", 4, "EUR/USD", "below", 1, -1},
+		{"limit reached", 3, "EUR/USD", "below", 1, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := uc.Create(tc.userID, tc.pair, tc.condition, tc.threshold, tc.cooldown)
+			var appErr *domainErrors.AppError
+			if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAlertUseCase_OnRatesUpdated_Thresholds(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-10 * time.Minute)
+	repo := &mockAlertRepository{rules: []domainAlert.Rule{
+		{ID: 1, UserID: 3, Pair: eurUSD, Condition: domainAlert.ConditionAbove, Threshold: 1.12, Cooldown: time.Hour},
+		{ID: 2, UserID: 3, Pair: eurUSD, Condition: domainAlert.ConditionBelow, Threshold: 1.12, Cooldown: time.Hour},
+		{ID: 3, UserID: 4, Pair: eurUSD, Condition: domainAlert.ConditionAbove, Threshold: 1.10, Cooldown: time.Hour, LastTriggeredAt: &recent},
+		{ID: 4, UserID: 4, Pair: domainWatchlist.Pair{Base: "GBP", Quote: "USD"}, Condition: domainAlert.ConditionAbove, Threshold: 1},
+	}}
+	memory := notifier.NewMemoryNotifier()
+	uc := newTestUseCase(t, repo, memory, now)
+
+	uc.OnRatesUpdated(eurRates(1.13))
+
+	if len(repo.events) != 1 || repo.events[0].RuleID != 1 {
+		t.Fatalf("expected only rule 1 to trigger, got %+v", repo.events)
+	}
+	if len(repo.states) != 1 || repo.states[0].LastTriggeredAt == nil || !repo.states[0].LastTriggeredAt.Equal(now) {
+		t.Errorf("expected rule 1 to record its trigger time, got %+v", repo.states)
+	}
+	if len(memory.Events()) != 1 {
+		t.Errorf("expected 1 notification, got %d", len(memory.Events()))
+	}
+}
+
+func TestAlertUseCase_OnRatesUpdated_ChangePercent(t *testing.T) {
+	now := time.Now()
+	repo := &mockAlertRepository{rules: []domainAlert.Rule{
+		{ID: 1, UserID: 3, Pair: eurUSD, Condition: domainAlert.ConditionChangePercent, Threshold: 2, Cooldown: time.Hour},
+	}}
+	uc := newTestUseCase(t, repo, notifier.NewMemoryNotifier(), now)
+
+	uc.OnRatesUpdated(eurRates(1.10))
+	if len(repo.events) != 0 || len(repo.states) != 1 || repo.states[0].ReferenceAt == nil {
+		t.Fatalf("expected the first refresh to set the reference only, got events=%+v states=%+v", repo.events, repo.states)
+	}
+
+	repo.rules[0] = repo.states[0]
+	uc.now = func() time.Time { return now.Add(time.Hour) }
+	uc.OnRatesUpdated(eurRates(1.11))
+	if len(repo.events) != 0 {
+		t.Fatalf("expected a 0.9%% move not to trigger, got %+v", repo.events)
+	}
+
+	uc.OnRatesUpdated(eurRates(1.13))
+	if len(repo.events) != 1 {
+		t.Fatalf("expected a 2.7%% move to trigger, got %+v", repo.events)
+	}
+	if repo.events[0].ReferenceRate == 0 {
+		t.Error("expected the event to carry the reference rate")
+	}
+
+	repo.rules[0] = repo.states[len(repo.states)-1]
+	uc.now = func() time.Time { return now.Add(domainAlert.ChangeWindow + time.Minute) }
+	uc.OnRatesUpdated(eurRates(1.20))
+	if len(repo.events) != 1 {
+		t.Errorf("expected an expired reference to re-base instead of triggering, got %+v", repo.events)
+	}
+}
+
+func TestAlertUseCase_NotifierFailureKeepsEvent(t *testing.T) {
+	repo := &mockAlertRepository{rules: []domainAlert.Rule{
+		{ID: 1, UserID: 3, Pair: eurUSD, Condition: domainAlert.ConditionBelow, Threshold: 1.2, Cooldown: time.Hour},
+	}}
+	uc := newTestUseCase(t, repo, failingNotifier{}, time.Now())
+
+	uc.OnRatesUpdated(eurRates(1.1))
+	if len(repo.events) != 1 {
+		t.Errorf("expected the event to be recorded despite the notifier failing, got %d", len(repo.events))
+	}
+}
@@ -0,0 +1,185 @@
+package apitoken
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/apitoken"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+const (
+	tokenKeyLength = 32
+	// lastUsedResolution avoids writing to the database on every authenticated request
+	lastUsedResolution = time.Minute
+)
+
+type IAPITokenUseCase interface {
+	Create(userID int, name string, scopes []string, expiresAt *time.Time) (*domainAPIToken.APIToken, string, error)
+	GetByUser(userID int) (*[]domainAPIToken.APIToken, error)
+	Revoke(userID int, id int) error
+	Authenticate(token string) (*domainAPIToken.APIToken, error)
+}
+
+// APITokenConfig holds the limits applied to personal API tokens
+type APITokenConfig struct {
+	DefaultTTL       time.Duration
+	MaxTTL           time.Duration
+	MaxTokensPerUser int
+}
+
+type APITokenUseCase struct {
+	APITokenRepository apitoken.APITokenRepositoryInterface
+	UserRepository     user.UserRepositoryInterface
+	APIService         security.IAPIService
+	Config             APITokenConfig
+	Logger             *logger.Logger
+}
+
+func NewAPITokenUseCase(apiTokenRepository apitoken.APITokenRepositoryInterface, userRepository user.UserRepositoryInterface, apiService security.IAPIService, loggerInstance *logger.Logger) IAPITokenUseCase {
+	return &APITokenUseCase{
+		APITokenRepository: apiTokenRepository,
+		UserRepository:     userRepository,
+		APIService:         apiService,
+		Config:             loadAPITokenConfig(),
+		Logger:             loggerInstance,
+	}
+}
+
+// loadAPITokenConfig loads API token configuration from environment variables
+func loadAPITokenConfig() APITokenConfig {
+	return APITokenConfig{
+		DefaultTTL:       time.Duration(getEnvAsIntOrDefault("API_TOKEN_DEFAULT_TTL_DAYS", 90)) * 24 * time.Hour,
+		MaxTTL:           time.Duration(getEnvAsIntOrDefault("API_TOKEN_MAX_TTL_DAYS", 365)) * 24 * time.Hour,
+		MaxTokensPerUser: getEnvAsIntOrDefault("API_TOKEN_MAX_PER_USER", 20),
+	}
+}
+
+// Create issues a new token and returns it together with its plain value, which is never stored
+func (s *APITokenUseCase) Create(userID int, name string, scopes []string, expiresAt *time.Time) (*domainAPIToken.APIToken, string, error) {
+	s.Logger.Info("Creating API token", zap.Int("userID", userID), zap.String("name", name))
+	now := time.Now()
+
+	if len(scopes) == 0 {
+		return nil, "", domainErrors.NewAppError(errors.New("at least one scope is required"), domainErrors.ValidationError)
+	}
+	for _, requested := range scopes {
+		if !scope.IsValid(requested) {
+			return nil, "", domainErrors.NewAppError(fmt.Errorf("unknown scope %q", requested), domainErrors.ValidationError)
+		}
+	}
+
+	expiration := now.Add(s.Config.DefaultTTL)
+	if expiresAt != nil {
+		expiration = *expiresAt
+	}
+	if !expiration.After(now) {
+		return nil, "", domainErrors.NewAppError(errors.New("expiresAt must be in the future"), domainErrors.ValidationError)
+	}
+	if s.Config.MaxTTL > 0 && expiration.After(now.Add(s.Config.MaxTTL)) {
+		return nil, "", domainErrors.NewAppError(fmt.Errorf("expiresAt cannot be more than %d days ahead", int(s.Config.MaxTTL.Hours()/24)), domainErrors.ValidationError)
+	}
+
+	existing, err := s.APITokenRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if s.Config.MaxTokensPerUser > 0 && len(*existing) >= s.Config.MaxTokensPerUser {
+		return nil, "", domainErrors.NewAppError(fmt.Errorf("a user cannot have more than %d API tokens", s.Config.MaxTokensPerUser), domainErrors.ValidationError)
+	}
+
+	key, err := s.APIService.GenerateApiKey(tokenKeyLength)
+	if err != nil {
+		s.Logger.Error("Error generating API token", zap.Error(err), zap.Int("userID", userID))
+		return nil, "", err
+	}
+	plainToken := domainAPIToken.TokenPrefix + strings.TrimRight(key, "=")
+
+	token, err := s.APITokenRepository.Create(&domainAPIToken.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plainToken[:len(domainAPIToken.TokenPrefix)+8],
+		TokenHash: hashToken(plainToken),
+		Scopes:    scopes,
+		ExpiresAt: expiration,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	s.Logger.Info("API token created", zap.Int("userID", userID), zap.Int("tokenID", token.ID))
+	return token, plainToken, nil
+}
+
+func (s *APITokenUseCase) GetByUser(userID int) (*[]domainAPIToken.APIToken, error) {
+	s.Logger.Info("Getting API tokens", zap.Int("userID", userID))
+	return s.APITokenRepository.GetByUserID(userID)
+}
+
+func (s *APITokenUseCase) Revoke(userID int, id int) error {
+	s.Logger.Info("Revoking API token", zap.Int("userID", userID), zap.Int("tokenID", id))
+	return s.APITokenRepository.Delete(userID, id)
+}
+
+// Authenticate resolves a plain token presented by a client to the stored token
+func (s *APITokenUseCase) Authenticate(plainToken string) (*domainAPIToken.APIToken, error) {
+	token, err := s.APITokenRepository.GetByHash(hashToken(plainToken))
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return nil, domainErrors.NewAppError(errors.New("invalid API token"), domainErrors.NotAuthenticated)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.IsExpired(now) {
+		s.Logger.Warn("Expired API token used", zap.Int("tokenID", token.ID), zap.Int("userID", token.UserID))
+		return nil, domainErrors.NewAppError(errors.New("API token expired"), domainErrors.NotAuthenticated)
+	}
+
+	owner, err := s.UserRepository.GetByID(token.UserID)
+	if err != nil {
+		s.Logger.Warn("API token owner no longer exists", zap.Int("tokenID", token.ID), zap.Int("userID", token.UserID))
+		return nil, domainErrors.NewAppError(errors.New("invalid API token"), domainErrors.NotAuthenticated)
+	}
+	if !owner.Status {
+		s.Logger.Warn("API token owner is disabled", zap.Int("tokenID", token.ID), zap.Int("userID", token.UserID))
+		return nil, domainErrors.NewAppError(errors.New("account is disabled"), domainErrors.NotAuthenticated)
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := s.APITokenRepository.UpdateLastUsed(token.ID, now); err != nil {
+			s.Logger.Error("Error recording API token use", zap.Error(err), zap.Int("tokenID", token.ID))
+		}
+		token.LastUsedAt = &now
+	}
+	return token, nil
+}
+
+// hashToken uses a plain SHA-256: tokens are long random strings, so a slow hash adds nothing
+// and a deterministic one allows looking tokens up by hash
+func hashToken(plainToken string) string {
+	sum := sha256.Sum256([]byte(plainToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,224 @@
+package apitoken
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+)
+
+type mockAPITokenRepository struct {
+	created          *domainAPIToken.APIToken
+	tokens           []domainAPIToken.APIToken
+	getByHashFn      func(string) (*domainAPIToken.APIToken, error)
+	updateLastUsedFn func(int, time.Time) error
+	lastUsedUpdates  int
+}
+
+func (m *mockAPITokenRepository) Create(token *domainAPIToken.APIToken) (*domainAPIToken.APIToken, error) {
+	m.created = token
+	saved := *token
+	saved.ID = 1
+	return &saved, nil
+}
+func (m *mockAPITokenRepository) GetByHash(tokenHash string) (*domainAPIToken.APIToken, error) {
+	return m.getByHashFn(tokenHash)
+}
+func (m *mockAPITokenRepository) GetByUserID(userID int) (*[]domainAPIToken.APIToken, error) {
+	return &m.tokens, nil
+}
+func (m *mockAPITokenRepository) UpdateLastUsed(id int, lastUsedAt time.Time) error {
+	m.lastUsedUpdates++
+	if m.updateLastUsedFn != nil {
+		return m.updateLastUsedFn(id, lastUsedAt)
+	}
+	return nil
+}
+func (m *mockAPITokenRepository) Delete(userID int, id int) error {
+	return nil
+}
+
+type mockUserRepository struct {
+	user.UserRepositoryInterface
+	getByIDFn func(int) (*domainUser.User, error)
+}
+
+func (m *mockUserRepository) GetByID(id int) (*domainUser.User, error) {
+	return m.getByIDFn(id)
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, tokenRepo *mockAPITokenRepository, userRepo *mockUserRepository) *APITokenUseCase {
+	return &APITokenUseCase{
+		APITokenRepository: tokenRepo,
+		UserRepository:     userRepo,
+		APIService:         security.NewAPIService(),
+		Config: APITokenConfig{
+			DefaultTTL:       24 * time.Hour,
+			MaxTTL:           30 * 24 * time.Hour,
+			MaxTokensPerUser: 2,
+		},
+		Logger: setupLogger(t),
+	}
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected AppError of type %s, got %v", expected, err)
+	}
+	if appErr.Type != expected {
+		t.Errorf("expected error type %s, got %s", expected, appErr.Type)
+	}
+}
+
+func TestAPITokenUseCase_Create(t *testing.T) {
+	tokenRepo := &mockAPITokenRepository{}
+	uc := newTestUseCase(t, tokenRepo, &mockUserRepository{})
+
+	token, plain, err := uc.Create(7, "ci", []string{"currency:read"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(plain, domainAPIToken.TokenPrefix) {
+		t.Errorf("expected token with prefix %q, got %q", domainAPIToken.TokenPrefix, plain)
+	}
+	if token.UserID != 7 || token.ID != 1 {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if tokenRepo.created.TokenHash != hashToken(plain) || strings.Contains(tokenRepo.created.TokenHash, plain) {
+		t.Error("expected only the token hash to be stored")
+	}
+	if !strings.HasPrefix(plain, tokenRepo.created.Prefix) {
+		t.Errorf("expected prefix %q to identify the token", tokenRepo.created.Prefix)
+	}
+}
+
+func TestAPITokenUseCase_Create_Validation(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tooFar := time.Now().Add(365 * 24 * time.Hour)
+	tests := []struct {
+		name      string
+		scopes    []string
+		expiresAt *time.Time
+		existing  int
+	}{
+		{name: "no scopes", scopes: nil},
+		{name: "unknown scope", scopes: []string{"currency:admin"}},
+		{name: "expiration in the past", scopes: []string{"currency:read"}, expiresAt: &past},
+		{name: "expiration beyond max ttl", scopes: []string{"currency:read"}, expiresAt: &tooFar},
+		{name: "too many tokens", scopes: []string{"currency:read"}, existing: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRepo := &mockAPITokenRepository{tokens: make([]domainAPIToken.APIToken, tt.existing)}
+			uc := newTestUseCase(t, tokenRepo, &mockUserRepository{})
+			_, _, err := uc.Create(7, "ci", tt.scopes, tt.expiresAt)
+			assertErrorType(t, err, domainErrors.ValidationError)
+			if tokenRepo.created != nil {
+				t.Error("expected no token to be stored")
+			}
+		})
+	}
+}
+
+func TestAPITokenUseCase_Authenticate(t *testing.T) {
+	plain := domainAPIToken.TokenPrefix + "secret"
+	owner := func(id int) (*domainUser.User, error) { return &domainUser.User{ID: id, Status: true}, nil }
+	recent := time.Now().Add(-10 * time.Second)
+
+	tests := []struct {
+		name            string
+		token           *domainAPIToken.APIToken
+		getByIDFn       func(int) (*domainUser.User, error)
+		wantErr         bool
+		wantLastUpdated int
+	}{
+		{
+			name:            "valid token",
+			token:           &domainAPIToken.APIToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			getByIDFn:       owner,
+			wantLastUpdated: 1,
+		},
+		{
+			name:      "recently used token",
+			token:     &domainAPIToken.APIToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Hour), LastUsedAt: &recent},
+			getByIDFn: owner,
+		},
+		{
+			name:      "expired token",
+			token:     &domainAPIToken.APIToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(-time.Hour)},
+			getByIDFn: owner,
+			wantErr:   true,
+		},
+		{
+			name:  "owner deleted",
+			token: &domainAPIToken.APIToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			getByIDFn: func(int) (*domainUser.User, error) {
+				return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "owner disabled",
+			token: &domainAPIToken.APIToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			getByIDFn: func(id int) (*domainUser.User, error) {
+				return &domainUser.User{ID: id, Status: false}, nil
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRepo := &mockAPITokenRepository{
+				getByHashFn: func(hash string) (*domainAPIToken.APIToken, error) {
+					if hash != hashToken(plain) {
+						t.Errorf("unexpected hash lookup %q", hash)
+					}
+					return tt.token, nil
+				},
+			}
+			uc := newTestUseCase(t, tokenRepo, &mockUserRepository{getByIDFn: tt.getByIDFn})
+			token, err := uc.Authenticate(plain)
+			if tt.wantErr {
+				assertErrorType(t, err, domainErrors.NotAuthenticated)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.UserID != 7 {
+				t.Errorf("expected user 7, got %d", token.UserID)
+			}
+			if tokenRepo.lastUsedUpdates != tt.wantLastUpdated {
+				t.Errorf("expected %d last-used updates, got %d", tt.wantLastUpdated, tokenRepo.lastUsedUpdates)
+			}
+		})
+	}
+}
+
+func TestAPITokenUseCase_Authenticate_UnknownToken(t *testing.T) {
+	tokenRepo := &mockAPITokenRepository{
+		getByHashFn: func(string) (*domainAPIToken.APIToken, error) {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		},
+	}
+	uc := newTestUseCase(t, tokenRepo, &mockUserRepository{})
+	_, err := uc.Authenticate(domainAPIToken.TokenPrefix + "unknown")
+	assertErrorType(t, err, domainErrors.NotAuthenticated)
+}
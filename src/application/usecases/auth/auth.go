@@ -2,11 +2,15 @@ package auth
 
 import (
 	"errors"
+	"math"
+	"strconv"
 	"time"
 
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/loginattempt"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"go.uber.org/zap"
@@ -15,69 +19,246 @@ import (
 
 type IAuthUseCase interface {
 	Register(newUser *domainUser.User) (*domainUser.User, error)
-	Login(email, password string) (*domainUser.User, *AuthTokens, error)
+	Login(email, password, clientIP string) (*domainUser.User, *AuthTokens, error)
 	AccessTokenByRefreshToken(refreshToken string) (*domainUser.User, *AuthTokens, error)
+	VerifyTwoFactor(challengeToken, code, clientIP string) (*domainUser.User, *AuthTokens, error)
+	EnrollTwoFactor(userID int) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(userID int, code string) ([]string, error)
+	DisableTwoFactor(userID int, code string) error
+	EnrollTwoFactorWithChallenge(enrollmentToken string) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactorWithChallenge(enrollmentToken, code string) ([]string, error)
+	UnlockAccount(adminID int, userID int) error
+	GetLoginAttempts(adminID int, filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error)
 }
 
 type AuthUseCase struct {
-	UserRepository user.UserRepositoryInterface
-	JWTService     security.IJWTService
-	Logger         *logger.Logger
+	UserRepository         user.UserRepositoryInterface
+	LoginAttemptRepository loginattempt.LoginAttemptRepositoryInterface
+	JWTService             security.IJWTService
+	TOTPService            security.ITOTPService
+	APIService             security.IAPIService
+	ThrottleConfig         LoginThrottleConfig
+	TwoFactorConfig        TwoFactorConfig
+	Logger                 *logger.Logger
 }
 
-func NewAuthUseCase(userRepository user.UserRepositoryInterface, jwtService security.IJWTService, loggerInstance *logger.Logger) IAuthUseCase {
+func NewAuthUseCase(userRepository user.UserRepositoryInterface, loginAttemptRepository loginattempt.LoginAttemptRepositoryInterface, jwtService security.IJWTService, totpService security.ITOTPService, apiService security.IAPIService, loggerInstance *logger.Logger) IAuthUseCase {
 	return &AuthUseCase{
-		UserRepository: userRepository,
-		JWTService:     jwtService,
-		Logger:         loggerInstance,
+		UserRepository:         userRepository,
+		LoginAttemptRepository: loginAttemptRepository,
+		JWTService:             jwtService,
+		TOTPService:            totpService,
+		APIService:             apiService,
+		ThrottleConfig:         loadLoginThrottleConfig(),
+		TwoFactorConfig:        loadTwoFactorConfig(),
+		Logger:                 loggerInstance,
 	}
 }
 
+// AuthTokens holds either the issued access and refresh tokens, or, when a second factor is
+// still pending, the challenge token that has to be exchanged for them
 type AuthTokens struct {
-	AccessToken               string
-	RefreshToken              string
-	ExpirationAccessDateTime  time.Time
-	ExpirationRefreshDateTime time.Time
+	AccessToken                 string
+	RefreshToken                string
+	ExpirationAccessDateTime    time.Time
+	ExpirationRefreshDateTime   time.Time
+	ChallengeToken              string
+	ChallengeType               string
+	ExpirationChallengeDateTime time.Time
 }
 
-func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthTokens, error) {
-	s.Logger.Info("User login attempt", zap.String("email", email))
-	user, err := s.UserRepository.GetByEmail(email)
+func (s *AuthUseCase) Login(email, password, clientIP string) (*domainUser.User, *AuthTokens, error) {
+	s.Logger.Info("User login attempt", zap.String("email", email), zap.String("ip", clientIP))
+	now := time.Now()
+
+	ipStats, err := s.LoginAttemptRepository.GetFailureStatsByIP(clientIP, now.Add(-s.ThrottleConfig.IPWindow))
 	if err != nil {
-		s.Logger.Error("Error getting user for login", zap.Error(err), zap.String("email", email))
+		s.Logger.Error("Error getting login failure stats", zap.Error(err), zap.String("ip", clientIP))
 		return nil, nil, err
 	}
+	if s.ThrottleConfig.MaxFailedAttemptsPerIP > 0 && ipStats.Count >= int64(s.ThrottleConfig.MaxFailedAttemptsPerIP) {
+		s.Logger.Warn("Login throttled: too many failures from IP", zap.String("ip", clientIP), zap.Int64("failures", ipStats.Count))
+		s.recordAttempt(nil, email, clientIP, domainLoginAttempt.ReasonThrottled)
+		return nil, nil, newTooManyRequestsError(ipStats.LastFailureAt.Add(s.ThrottleConfig.IPWindow).Sub(now))
+	}
+	if wait := s.ThrottleConfig.retryAfter(ipStats.Count, ipStats.LastFailureAt, now); wait > 0 {
+		s.Logger.Warn("Login throttled: IP back-off in effect", zap.String("ip", clientIP), zap.Duration("retryAfter", wait))
+		s.recordAttempt(nil, email, clientIP, domainLoginAttempt.ReasonThrottled)
+		return nil, nil, newTooManyRequestsError(wait)
+	}
+
+	user, err := s.UserRepository.GetByEmail(email)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound {
+			s.Logger.Error("Error getting user for login", zap.Error(err), zap.String("email", email))
+			return nil, nil, err
+		}
+		user = &domainUser.User{}
+	}
 	if user.ID == 0 {
 		s.Logger.Warn("Login failed: user not found", zap.String("email", email))
+		s.recordAttempt(nil, email, clientIP, domainLoginAttempt.ReasonInvalidCredentials)
 		return nil, nil, domainErrors.NewAppError(errors.New("email or password does not match"), domainErrors.NotAuthenticated)
 	}
 
+	if user.IsLocked(now) {
+		s.Logger.Warn("Login failed: account locked", zap.String("email", email), zap.Int("userID", user.ID))
+		s.recordAttempt(&user.ID, email, clientIP, domainLoginAttempt.ReasonAccountLocked)
+		return nil, nil, newAccountLockedError(user.LockedUntil.Sub(now))
+	}
+
+	failedAttempts := currentFailedAttempts(user)
+	if wait := s.ThrottleConfig.retryAfter(int64(failedAttempts), user.LastFailedLoginAt, now); failedAttempts > 0 && wait > 0 {
+		s.Logger.Warn("Login throttled: account back-off in effect", zap.Int("userID", user.ID), zap.Duration("retryAfter", wait))
+		s.recordAttempt(&user.ID, email, clientIP, domainLoginAttempt.ReasonThrottled)
+		return nil, nil, newTooManyRequestsError(wait)
+	}
+
 	isAuthenticated := checkPasswordHash(password, user.HashPassword)
 	if !isAuthenticated {
 		s.Logger.Warn("Login failed: invalid password", zap.String("email", email))
+		s.registerFailedAttempt(user, now)
+		s.recordAttempt(&user.ID, email, clientIP, domainLoginAttempt.ReasonInvalidCredentials)
 		return nil, nil, domainErrors.NewAppError(errors.New("email or password does not match"), domainErrors.NotAuthenticated)
 	}
 
+	// only checked once the password matched, so the status of an account is not disclosed
+	if !user.Status {
+		s.Logger.Warn("Login failed: account disabled", zap.Int("userID", user.ID))
+		s.recordAttempt(&user.ID, email, clientIP, domainLoginAttempt.ReasonAccountDisabled)
+		return nil, nil, newAccountDisabledError()
+	}
+
+	if user.TwoFactorEnabled || s.TwoFactorConfig.isRequiredFor(user.Role) {
+		// failures are only cleared once the second factor has been verified as well
+		return s.issueTwoFactorChallenge(user, clientIP)
+	}
+
+	authTokens, err := s.generateAuthTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.resetLoginState(user)
+	s.recordAttempt(&user.ID, email, clientIP, domainLoginAttempt.ReasonSuccess)
+
+	s.Logger.Info("User login successful", zap.String("email", email), zap.Int("userID", user.ID))
+	return user, authTokens, nil
+}
+
+// currentFailedAttempts returns the consecutive failures that still count against the account
+func currentFailedAttempts(user *domainUser.User) int {
+	if user.LockedUntil != nil {
+		// the lock window has elapsed, so the account starts again from a clean slate
+		return 0
+	}
+	return user.FailedLoginAttempts
+}
+
+// registerFailedAttempt counts one more consecutive failure and locks the account once the count
+// returned by the repository reaches the threshold, so concurrent failures are all counted
+func (s *AuthUseCase) registerFailedAttempt(user *domainUser.User, now time.Time) {
+	failedAttempts, err := s.UserRepository.IncrementFailedLogins(user.ID, now)
+	if err != nil {
+		s.Logger.Error("Error updating login state", zap.Error(err), zap.Int("userID", user.ID))
+		return
+	}
+	if s.ThrottleConfig.MaxFailedAttempts <= 0 || failedAttempts < s.ThrottleConfig.MaxFailedAttempts {
+		return
+	}
+	until := now.Add(s.ThrottleConfig.LockoutDuration)
+	s.Logger.Warn("Account locked after repeated login failures", zap.Int("userID", user.ID), zap.Time("lockedUntil", until))
+	if err := s.UserRepository.LockAccount(user.ID, until); err != nil {
+		s.Logger.Error("Error locking account", zap.Error(err), zap.Int("userID", user.ID))
+	}
+}
+
+func (s *AuthUseCase) resetLoginState(user *domainUser.User) {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := s.UserRepository.UpdateLoginState(user.ID, 0, nil, nil); err != nil {
+		s.Logger.Error("Error resetting login state", zap.Error(err), zap.Int("userID", user.ID))
+	}
+}
+
+func (s *AuthUseCase) generateAuthTokens(user *domainUser.User) (*AuthTokens, error) {
 	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access")
 	if err != nil {
 		s.Logger.Error("Error generating access token", zap.Error(err), zap.Int("userID", user.ID))
-		return nil, nil, err
+		return nil, err
 	}
 	refreshTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "refresh")
 	if err != nil {
 		s.Logger.Error("Error generating refresh token", zap.Error(err), zap.Int("userID", user.ID))
-		return nil, nil, err
+		return nil, err
 	}
-
-	authTokens := &AuthTokens{
+	return &AuthTokens{
 		AccessToken:               accessTokenClaims.Token,
 		RefreshToken:              refreshTokenClaims.Token,
 		ExpirationAccessDateTime:  accessTokenClaims.ExpirationTime,
 		ExpirationRefreshDateTime: refreshTokenClaims.ExpirationTime,
+	}, nil
+}
+
+func (s *AuthUseCase) UnlockAccount(adminID int, userID int) error {
+	s.Logger.Info("Unlocking user account", zap.Int("adminID", adminID), zap.Int("userID", userID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
 	}
+	if _, err := s.UserRepository.GetByID(userID); err != nil {
+		return err
+	}
+	return s.UserRepository.UpdateLoginState(userID, 0, nil, nil)
+}
 
-	s.Logger.Info("User login successful", zap.String("email", email), zap.Int("userID", user.ID))
-	return user, authTokens, nil
+func (s *AuthUseCase) GetLoginAttempts(adminID int, filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error) {
+	s.Logger.Info("Getting login attempts", zap.Int("adminID", adminID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	return s.LoginAttemptRepository.List(filter)
+}
+
+func (s *AuthUseCase) requireAdmin(userID int) error {
+	actor, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != domainUser.RoleAdmin {
+		s.Logger.Warn("Admin operation rejected", zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return nil
+}
+
+func (s *AuthUseCase) recordAttempt(userID *int, email, clientIP string, reason domainLoginAttempt.Reason) {
+	attempt := &domainLoginAttempt.LoginAttempt{
+		UserID:  userID,
+		Email:   email,
+		IP:      clientIP,
+		Success: reason == domainLoginAttempt.ReasonSuccess,
+		Reason:  reason,
+	}
+	if _, err := s.LoginAttemptRepository.Create(attempt); err != nil {
+		s.Logger.Error("Error recording login attempt", zap.Error(err), zap.String("email", email))
+	}
+}
+
+func newTooManyRequestsError(retryAfter time.Duration) *domainErrors.AppError {
+	appErr := domainErrors.NewAppErrorWithType(domainErrors.TooManyRequests)
+	appErr.Meta = map[string]string{"retryAfter": strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))}
+	return appErr
+}
+
+func newAccountLockedError(retryAfter time.Duration) *domainErrors.AppError {
+	appErr := domainErrors.NewAppErrorWithType(domainErrors.AccountLocked)
+	appErr.Meta = map[string]string{"retryAfter": strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))}
+	return appErr
+}
+
+func newAccountDisabledError() *domainErrors.AppError {
+	return domainErrors.NewAppError(errors.New("account is disabled"), domainErrors.NotAuthenticated)
 }
 
 func (s *AuthUseCase) Register(newUser *domainUser.User) (*domainUser.User, error) {
@@ -129,6 +310,10 @@ func (s *AuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*domainUse
 		s.Logger.Error("Error getting user for token refresh", zap.Error(err), zap.Int("userID", userID))
 		return nil, nil, err
 	}
+	if !user.Status {
+		s.Logger.Warn("Token refresh rejected: account disabled", zap.Int("userID", userID))
+		return nil, nil, newAccountDisabledError()
+	}
 
 	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access")
 	if err != nil {
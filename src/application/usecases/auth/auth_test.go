@@ -2,11 +2,13 @@ package auth
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
@@ -17,6 +19,12 @@ import (
 type mockUserService struct {
 	getByEmailFn         func(string) (*domainUser.User, error)
 	getByIDFn            func(int) (*domainUser.User, error)
+	updateLoginStateFn   func(int, int, *time.Time, *time.Time) error
+	incrementFailedFn    func(int, time.Time) (int, error)
+	lockAccountFn        func(int, time.Time) error
+	lastTwoFactorStep    int64
+	consumedRecoveryCode []string
+	updateTwoFactorFn    func(int, bool, string, []string) error
 	callGetByEmailCalled bool
 	callGetByIDCalled    bool
 }
@@ -51,6 +59,88 @@ func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*domainUs
 func (m *mockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
 	return nil, nil
 }
+func (m *mockUserService) UpdateLoginState(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+	if m.updateLoginStateFn != nil {
+		return m.updateLoginStateFn(id, failedAttempts, lastFailedLoginAt, lockedUntil)
+	}
+	return nil
+}
+func (m *mockUserService) IncrementFailedLogins(id int, failedAt time.Time) (int, error) {
+	if m.incrementFailedFn != nil {
+		return m.incrementFailedFn(id, failedAt)
+	}
+	return 1, nil
+}
+func (m *mockUserService) LockAccount(id int, lockedUntil time.Time) error {
+	if m.lockAccountFn != nil {
+		return m.lockAccountFn(id, lockedUntil)
+	}
+	return nil
+}
+func (m *mockUserService) ClaimTwoFactorStep(id int, step int64) (bool, error) {
+	if step <= m.lastTwoFactorStep {
+		return false, nil
+	}
+	m.lastTwoFactorStep = step
+	return true, nil
+}
+func (m *mockUserService) ConsumeRecoveryCode(id int, hash string) (bool, error) {
+	for _, consumed := range m.consumedRecoveryCode {
+		if consumed == hash {
+			return false, nil
+		}
+	}
+	m.consumedRecoveryCode = append(m.consumedRecoveryCode, hash)
+	return true, nil
+}
+func (m *mockUserService) UpdatePassword(id int, hashPassword string) error {
+	return nil
+}
+func (m *mockUserService) UpdateTwoFactor(id int, enabled bool, secret string, recoveryCodes []string) error {
+	if m.updateTwoFactorFn != nil {
+		return m.updateTwoFactorFn(id, enabled, secret, recoveryCodes)
+	}
+	return nil
+}
+
+// mockAPIService "encrypts" by prefixing, so tests can tell stored secrets from plain ones
+type mockAPIService struct{}
+
+func (m *mockAPIService) GenerateApiKey(length int) (string, error) {
+	return "generated-api-key", nil
+}
+func (m *mockAPIService) EncryptApiKey(value string) (string, error) {
+	return "enc:" + value, nil
+}
+func (m *mockAPIService) DecryptApiKey(value string) (string, error) {
+	if !strings.HasPrefix(value, "enc:") {
+		return "", errors.New("message authentication failed")
+	}
+	return strings.TrimPrefix(value, "enc:"), nil
+}
+
+type mockLoginAttemptRepository struct {
+	failureStatsFn func(string, time.Time) (*domainLoginAttempt.FailureStats, error)
+	listFn         func(domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error)
+	recorded       []domainLoginAttempt.LoginAttempt
+}
+
+func (m *mockLoginAttemptRepository) Create(attempt *domainLoginAttempt.LoginAttempt) (*domainLoginAttempt.LoginAttempt, error) {
+	m.recorded = append(m.recorded, *attempt)
+	return attempt, nil
+}
+func (m *mockLoginAttemptRepository) GetFailureStatsByIP(ip string, since time.Time) (*domainLoginAttempt.FailureStats, error) {
+	if m.failureStatsFn != nil {
+		return m.failureStatsFn(ip, since)
+	}
+	return &domainLoginAttempt.FailureStats{}, nil
+}
+func (m *mockLoginAttemptRepository) List(filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error) {
+	if m.listFn != nil {
+		return m.listFn(filter)
+	}
+	return &[]domainLoginAttempt.LoginAttempt{}, nil
+}
 
 type mockJWTService struct {
 	generateTokenFn func(int, string) (*security.AppToken, error)
@@ -61,6 +151,14 @@ func (m *mockJWTService) GenerateJWTToken(userID int, tokenType string) (*securi
 	return m.generateTokenFn(userID, tokenType)
 }
 
+func (m *mockJWTService) GenerateClientToken(clientID string, scopes []string, audiences []string) (*security.AppToken, error) {
+	return nil, nil
+}
+
+func (m *mockJWTService) JWKS() security.JSONWebKeySet {
+	return security.JSONWebKeySet{}
+}
+
 func (m *mockJWTService) GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error) {
 	return m.verifyTokenFn(tokenString, tokenType)
 }
@@ -162,6 +260,21 @@ func TestAuthUseCase_Login(t *testing.T) {
 			inputPassword: "somePass",
 			wantErr:       true,
 		},
+		{
+			name: "Disabled account",
+			mockGetByEmailFn: func(email string) (*domainUser.User, error) {
+				hashed, _ := HashPasswordForTest("mySecretPass")
+				return &domainUser.User{ID: 10, HashPassword: hashed, Status: false}, nil
+			},
+			mockGenerateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
+				return &security.AppToken{Token: "test_token"}, nil
+			},
+			inputEmail:        "test@example.com",
+			inputPassword:     "mySecretPass",
+			wantErr:           true,
+			wantErrType:       domainErrors.NotAuthenticated,
+			wantEmptySecurity: true,
+		},
 		{
 			name: "OK - everything correct",
 			mockGetByEmailFn: func(email string) (*domainUser.User, error) {
@@ -170,6 +283,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 					ID:           10,
 					Email:        "test@example.com",
 					HashPassword: hashed,
+					Status:       true,
 				}, nil
 			},
 			mockGenerateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
@@ -196,9 +310,9 @@ func TestAuthUseCase_Login(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, logger)
+			uc := NewAuthUseCase(userRepoMock, &mockLoginAttemptRepository{}, jwtMock, security.NewTOTPService(), &mockAPIService{}, logger)
 
-			user, authTokens, err := uc.Login(tt.inputEmail, tt.inputPassword)
+			user, authTokens, err := uc.Login(tt.inputEmail, tt.inputPassword, "127.0.0.1")
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("[%s] got err = %v, wantErr = %v", tt.name, err, tt.wantErr)
 			}
@@ -285,7 +399,7 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 				return jwt.MapClaims{"id": float64(10), "exp": float64(time.Now().Add(time.Hour).Unix())}, nil
 			},
 			mockGetByIDFn: func(id int) (*domainUser.User, error) {
-				return &domainUser.User{ID: 10, Email: "test@example.com"}, nil
+				return &domainUser.User{ID: 10, Email: "test@example.com", Status: true}, nil
 			},
 			mockGenerateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
 				return &security.AppToken{Token: "new.token", TokenType: tokenType, ExpirationTime: time.Now().Add(time.Hour)}, nil
@@ -294,6 +408,20 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 			wantErr:           false,
 			wantSuccess:       true,
 		},
+		{
+			name: "Disabled account",
+			mockVerifyTokenFn: func(token string, tokenType string) (jwt.MapClaims, error) {
+				return jwt.MapClaims{"id": float64(10), "type": "refresh"}, nil
+			},
+			mockGetByIDFn: func(id int) (*domainUser.User, error) {
+				return &domainUser.User{ID: 10, Status: false}, nil
+			},
+			mockGenerateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
+				return &security.AppToken{Token: "new.token"}, nil
+			},
+			inputRefreshToken: "valid.refresh.token",
+			wantErr:           true,
+		},
 		{
 			name: "Refresh token generation fails",
 			mockVerifyTokenFn: func(token string, tokenType string) (jwt.MapClaims, error) {
@@ -314,7 +442,7 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 				return jwt.MapClaims{"id": float64(10), "type": "refresh", "exp": float64(time.Now().Add(time.Hour).Unix())}, nil
 			},
 			mockGetByIDFn: func(id int) (*domainUser.User, error) {
-				return &domainUser.User{ID: 10}, nil
+				return &domainUser.User{ID: 10, Status: true}, nil
 			},
 			mockGenerateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
 				return &security.AppToken{Token: "new.token", TokenType: tokenType, ExpirationTime: time.Now().Add(time.Hour)}, nil
@@ -337,7 +465,7 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, logger)
+			uc := NewAuthUseCase(userRepoMock, &mockLoginAttemptRepository{}, jwtMock, security.NewTOTPService(), &mockAPIService{}, logger)
 
 			user, authTokens, err := uc.AccessTokenByRefreshToken(tt.inputRefreshToken)
 			if (err != nil) != tt.wantErr {
@@ -362,3 +490,251 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+func newThrottledUseCase(userRepo *mockUserService, attemptRepo *mockLoginAttemptRepository, t *testing.T) *AuthUseCase {
+	return &AuthUseCase{
+		UserRepository:         userRepo,
+		LoginAttemptRepository: attemptRepo,
+		JWTService: &mockJWTService{
+			generateTokenFn: func(userID int, tokenType string) (*security.AppToken, error) {
+				return &security.AppToken{Token: "token_" + tokenType, ExpirationTime: time.Now().Add(time.Hour)}, nil
+			},
+		},
+		ThrottleConfig: LoginThrottleConfig{
+			MaxFailedAttempts:      3,
+			LockoutDuration:        15 * time.Minute,
+			MaxFailedAttemptsPerIP: 10,
+			IPWindow:               15 * time.Minute,
+			BackoffBase:            time.Second,
+			BackoffMax:             time.Minute,
+		},
+		TOTPService: security.NewTOTPService(),
+		APIService:  &mockAPIService{},
+		TwoFactorConfig: TwoFactorConfig{
+			RequiredRoles:     []domainUser.Role{domainUser.RoleAdmin},
+			RecoveryCodeCount: 3,
+		},
+		Logger: setupLogger(t),
+	}
+}
+
+func assertErrorType(t *testing.T, err error, want domainErrors.ErrorType) *domainErrors.AppError {
+	t.Helper()
+	appErr, ok := err.(*domainErrors.AppError)
+	if !ok || appErr.Type != want {
+		t.Fatalf("expected error type = %s, got = %v", want, err)
+	}
+	return appErr
+}
+
+func TestAuthUseCase_Login_LocksAccountAfterThreshold(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	lastFailure := time.Now().Add(-time.Hour)
+	incremented := 0
+	var gotLockedUntil *time.Time
+	userRepo := &mockUserService{
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			return &domainUser.User{ID: 10, HashPassword: hashed, FailedLoginAttempts: 2, LastFailedLoginAt: &lastFailure}, nil
+		},
+		incrementFailedFn: func(id int, failedAt time.Time) (int, error) {
+			incremented++
+			return 2 + incremented, nil
+		},
+		lockAccountFn: func(id int, lockedUntil time.Time) error {
+			gotLockedUntil = &lockedUntil
+			return nil
+		},
+	}
+	attemptRepo := &mockLoginAttemptRepository{}
+	uc := newThrottledUseCase(userRepo, attemptRepo, t)
+
+	_, _, err := uc.Login("test@example.com", "wrong", "10.0.0.1")
+	assertErrorType(t, err, domainErrors.NotAuthenticated)
+	if incremented != 1 {
+		t.Errorf("expected the failure to be counted once, got %d", incremented)
+	}
+	if gotLockedUntil == nil || gotLockedUntil.Before(time.Now().Add(14*time.Minute)) {
+		t.Errorf("expected account to be locked for the lockout window, got %v", gotLockedUntil)
+	}
+	if len(attemptRepo.recorded) != 1 || attemptRepo.recorded[0].Reason != domainLoginAttempt.ReasonInvalidCredentials {
+		t.Errorf("expected one invalid_credentials attempt to be recorded, got %+v", attemptRepo.recorded)
+	}
+}
+
+func TestAuthUseCase_Login_ConcurrentFailuresLock(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	stored := 0
+	locks := 0
+	userRepo := &mockUserService{
+		// every request reads the same stale count, as concurrent requests would
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			return &domainUser.User{ID: 10, HashPassword: hashed, FailedLoginAttempts: 1}, nil
+		},
+		incrementFailedFn: func(id int, failedAt time.Time) (int, error) {
+			stored++
+			return stored, nil
+		},
+		lockAccountFn: func(id int, lockedUntil time.Time) error {
+			locks++
+			return nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+
+	for i := 0; i < uc.ThrottleConfig.MaxFailedAttempts; i++ {
+		_, _, err := uc.Login("test@example.com", "wrong", "10.0.0.1")
+		assertErrorType(t, err, domainErrors.NotAuthenticated)
+	}
+	if stored != uc.ThrottleConfig.MaxFailedAttempts || locks != 1 {
+		t.Errorf("expected %d counted failures and one lock, got %d and %d", uc.ThrottleConfig.MaxFailedAttempts, stored, locks)
+	}
+}
+
+func TestAuthUseCase_Login_LockedAccount(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	userRepo := &mockUserService{
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			return &domainUser.User{ID: 10, HashPassword: hashed, FailedLoginAttempts: 3, LockedUntil: &lockedUntil}, nil
+		},
+	}
+	attemptRepo := &mockLoginAttemptRepository{}
+	uc := newThrottledUseCase(userRepo, attemptRepo, t)
+
+	_, _, err := uc.Login("test@example.com", "mySecretPass", "10.0.0.1")
+	appErr := assertErrorType(t, err, domainErrors.AccountLocked)
+	if appErr.Meta["retryAfter"] == "" {
+		t.Error("expected retryAfter to be set on locked account error")
+	}
+	if len(attemptRepo.recorded) != 1 || attemptRepo.recorded[0].Reason != domainLoginAttempt.ReasonAccountLocked {
+		t.Errorf("expected one account_locked attempt to be recorded, got %+v", attemptRepo.recorded)
+	}
+}
+
+func TestAuthUseCase_Login_ExpiredLockResetsState(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	lockedUntil := time.Now().Add(-time.Minute)
+	lastFailure := time.Now().Add(-16 * time.Minute)
+	resetCalled := false
+	userRepo := &mockUserService{
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			return &domainUser.User{ID: 10, HashPassword: hashed, Status: true, FailedLoginAttempts: 3, LastFailedLoginAt: &lastFailure, LockedUntil: &lockedUntil}, nil
+		},
+		updateLoginStateFn: func(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+			resetCalled = failedAttempts == 0 && lastFailedLoginAt == nil && lockedUntil == nil
+			return nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+
+	_, tokens, err := uc.Login("test@example.com", "mySecretPass", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("expected login to succeed after lock expired, got %v", err)
+	}
+	if tokens.AccessToken == "" {
+		t.Error("expected a non-empty AccessToken")
+	}
+	if !resetCalled {
+		t.Error("expected login state to be reset after successful login")
+	}
+}
+
+func TestAuthUseCase_Login_AccountBackoff(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	lastFailure := time.Now()
+	userRepo := &mockUserService{
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			return &domainUser.User{ID: 10, HashPassword: hashed, FailedLoginAttempts: 2, LastFailedLoginAt: &lastFailure}, nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+
+	_, _, err := uc.Login("test@example.com", "mySecretPass", "10.0.0.1")
+	assertErrorType(t, err, domainErrors.TooManyRequests)
+}
+
+func TestAuthUseCase_Login_ThrottledByIP(t *testing.T) {
+	lastFailure := time.Now().Add(-5 * time.Minute)
+	userRepo := &mockUserService{
+		getByEmailFn: func(email string) (*domainUser.User, error) {
+			t.Fatal("user lookup must not happen when the IP is throttled")
+			return nil, nil
+		},
+	}
+	attemptRepo := &mockLoginAttemptRepository{
+		failureStatsFn: func(ip string, since time.Time) (*domainLoginAttempt.FailureStats, error) {
+			return &domainLoginAttempt.FailureStats{Count: 10, LastFailureAt: &lastFailure}, nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, attemptRepo, t)
+
+	_, _, err := uc.Login("test@example.com", "mySecretPass", "10.0.0.1")
+	appErr := assertErrorType(t, err, domainErrors.TooManyRequests)
+	if appErr.Meta["retryAfter"] != "600" {
+		t.Errorf("expected retryAfter = 600, got %s", appErr.Meta["retryAfter"])
+	}
+	if len(attemptRepo.recorded) != 1 || attemptRepo.recorded[0].Reason != domainLoginAttempt.ReasonThrottled {
+		t.Errorf("expected one throttled attempt to be recorded, got %+v", attemptRepo.recorded)
+	}
+}
+
+func TestAuthUseCase_UnlockAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		adminRole   domainUser.Role
+		wantErrType domainErrors.ErrorType
+		wantReset   bool
+	}{
+		{name: "Admin unlocks account", adminRole: domainUser.RoleAdmin, wantReset: true},
+		{name: "Non-admin is rejected", adminRole: domainUser.RoleSubscriber, wantErrType: domainErrors.NotAuthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCalled := false
+			userRepo := &mockUserService{
+				getByIDFn: func(id int) (*domainUser.User, error) {
+					if id == 1 {
+						return &domainUser.User{ID: 1, Role: tt.adminRole}, nil
+					}
+					return &domainUser.User{ID: id}, nil
+				},
+				updateLoginStateFn: func(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+					resetCalled = id == 10 && failedAttempts == 0 && lockedUntil == nil
+					return nil
+				},
+			}
+			uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+
+			err := uc.UnlockAccount(1, 10)
+			if tt.wantErrType != "" {
+				assertErrorType(t, err, tt.wantErrType)
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resetCalled != tt.wantReset {
+				t.Errorf("expected reset = %v, got %v", tt.wantReset, resetCalled)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleConfig_Backoff(t *testing.T) {
+	cfg := LoginThrottleConfig{BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+	cases := map[int64]time.Duration{0: 0, 1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: 10 * time.Second}
+	for failures, want := range cases {
+		if got := cfg.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestLoginThrottleConfig_BackoffAfter(t *testing.T) {
+	cfg := LoginThrottleConfig{BackoffBase: time.Second, BackoffMax: time.Minute, BackoffAfter: 3}
+	cases := map[int64]time.Duration{1: 0, 2: 0, 3: time.Second, 4: 2 * time.Second}
+	for failures, want := range cases {
+		if got := cfg.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LoginThrottleConfig holds the brute-force protection settings applied to Login
+type LoginThrottleConfig struct {
+	MaxFailedAttempts      int
+	LockoutDuration        time.Duration
+	MaxFailedAttemptsPerIP int
+	IPWindow               time.Duration
+	BackoffBase            time.Duration
+	BackoffMax             time.Duration
+	// BackoffAfter is the number of consecutive failures tolerated before the back-off kicks in
+	BackoffAfter int
+}
+
+// loadLoginThrottleConfig loads login throttling configuration from environment variables
+func loadLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		MaxFailedAttempts:      getEnvAsIntOrDefault("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+		LockoutDuration:        time.Duration(getEnvAsIntOrDefault("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		MaxFailedAttemptsPerIP: getEnvAsIntOrDefault("LOGIN_MAX_FAILED_ATTEMPTS_PER_IP", 20),
+		IPWindow:               time.Duration(getEnvAsIntOrDefault("LOGIN_IP_WINDOW_MINUTES", 15)) * time.Minute,
+		BackoffBase:            time.Duration(getEnvAsIntOrDefault("LOGIN_BACKOFF_BASE_SECONDS", 1)) * time.Second,
+		BackoffMax:             time.Duration(getEnvAsIntOrDefault("LOGIN_BACKOFF_MAX_SECONDS", 300)) * time.Second,
+		BackoffAfter:           getEnvAsIntOrDefault("LOGIN_BACKOFF_AFTER_ATTEMPTS", 3),
+	}
+}
+
+// backoff returns how long a client has to wait after the given number of consecutive failures.
+// Once BackoffAfter failures are reached the delay doubles with every failure and is capped at BackoffMax.
+func (c LoginThrottleConfig) backoff(failures int64) time.Duration {
+	threshold := int64(c.BackoffAfter)
+	if threshold < 1 {
+		threshold = 1
+	}
+	if failures < threshold || c.BackoffBase <= 0 {
+		return 0
+	}
+	delay := float64(c.BackoffBase) * math.Pow(2, float64(failures-threshold))
+	if c.BackoffMax > 0 && delay > float64(c.BackoffMax) {
+		return c.BackoffMax
+	}
+	return time.Duration(delay)
+}
+
+// retryAfter returns the remaining wait once the back-off following lastFailure is applied
+func (c LoginThrottleConfig) retryAfter(failures int64, lastFailure *time.Time, now time.Time) time.Duration {
+	if lastFailure == nil {
+		return 0
+	}
+	wait := lastFailure.Add(c.backoff(failures)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorConfig holds the second factor policy applied to Login
+type TwoFactorConfig struct {
+	RequiredRoles     []domainUser.Role
+	RecoveryCodeCount int
+}
+
+// TwoFactorEnrollment is returned when a user starts enrolling an authenticator app
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// loadTwoFactorConfig loads the two-factor policy from environment variables
+func loadTwoFactorConfig() TwoFactorConfig {
+	config := TwoFactorConfig{
+		RecoveryCodeCount: getEnvAsIntOrDefault("TWO_FACTOR_RECOVERY_CODES", 10),
+	}
+	roles := "ADMIN"
+	if value, ok := os.LookupEnv("TWO_FACTOR_REQUIRED_ROLES"); ok {
+		roles = value
+	}
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			config.RequiredRoles = append(config.RequiredRoles, domainUser.Role(strings.ToUpper(role)))
+		}
+	}
+	return config
+}
+
+func (c TwoFactorConfig) isRequiredFor(role domainUser.Role) bool {
+	for _, required := range c.RequiredRoles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// issueTwoFactorChallenge answers a correct password with a short-lived token instead of the real tokens.
+// Users that must have a second factor but never enrolled get an enrollment token instead.
+func (s *AuthUseCase) issueTwoFactorChallenge(user *domainUser.User, clientIP string) (*domainUser.User, *AuthTokens, error) {
+	challengeType := security.TwoFactorChallenge
+	if !user.TwoFactorEnabled {
+		challengeType = security.TwoFactorEnrollment
+	}
+	challenge, err := s.JWTService.GenerateJWTToken(user.ID, challengeType)
+	if err != nil {
+		s.Logger.Error("Error generating two-factor challenge", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, nil, err
+	}
+	s.recordAttempt(&user.ID, user.Email, clientIP, domainLoginAttempt.ReasonTwoFactorRequired)
+	s.Logger.Info("Two-factor challenge issued", zap.Int("userID", user.ID), zap.String("type", challengeType))
+	return user, &AuthTokens{
+		ChallengeToken:              challenge.Token,
+		ChallengeType:               challengeType,
+		ExpirationChallengeDateTime: challenge.ExpirationTime,
+	}, nil
+}
+
+func (s *AuthUseCase) VerifyTwoFactor(challengeToken, code, clientIP string) (*domainUser.User, *AuthTokens, error) {
+	s.Logger.Info("Two-factor verification attempt", zap.String("ip", clientIP))
+	user, err := s.userFromChallenge(challengeToken, security.TwoFactorChallenge)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+
+	if err := s.secondFactorThrottled(user, now); err != nil {
+		reason := domainLoginAttempt.ReasonThrottled
+		if err.Type == domainErrors.AccountLocked {
+			reason = domainLoginAttempt.ReasonAccountLocked
+		}
+		s.Logger.Warn("Two-factor verification throttled", zap.Int("userID", user.ID), zap.String("reason", string(reason)))
+		s.recordAttempt(&user.ID, user.Email, clientIP, reason)
+		return nil, nil, err
+	}
+
+	if !s.checkSecondFactor(user, code) {
+		s.Logger.Warn("Two-factor verification failed: invalid code", zap.Int("userID", user.ID))
+		s.registerFailedAttempt(user, now)
+		s.recordAttempt(&user.ID, user.Email, clientIP, domainLoginAttempt.ReasonInvalidTwoFactorCode)
+		return nil, nil, newInvalidTwoFactorCodeError()
+	}
+
+	authTokens, err := s.generateAuthTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.resetLoginState(user)
+	s.recordAttempt(&user.ID, user.Email, clientIP, domainLoginAttempt.ReasonSuccess)
+
+	s.Logger.Info("Two-factor verification successful", zap.Int("userID", user.ID))
+	return user, authTokens, nil
+}
+
+func (s *AuthUseCase) EnrollTwoFactor(userID int) (*TwoFactorEnrollment, error) {
+	s.Logger.Info("Starting two-factor enrollment", zap.Int("userID", userID))
+	user, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enrollTwoFactor(user)
+}
+
+func (s *AuthUseCase) EnrollTwoFactorWithChallenge(enrollmentToken string) (*TwoFactorEnrollment, error) {
+	user, err := s.userFromChallenge(enrollmentToken, security.TwoFactorEnrollment)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Starting required two-factor enrollment", zap.Int("userID", user.ID))
+	return s.enrollTwoFactor(user)
+}
+
+func (s *AuthUseCase) ConfirmTwoFactor(userID int, code string) ([]string, error) {
+	s.Logger.Info("Confirming two-factor enrollment", zap.Int("userID", userID))
+	user, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.confirmTwoFactor(user, code)
+}
+
+func (s *AuthUseCase) ConfirmTwoFactorWithChallenge(enrollmentToken, code string) ([]string, error) {
+	user, err := s.userFromChallenge(enrollmentToken, security.TwoFactorEnrollment)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Confirming required two-factor enrollment", zap.Int("userID", user.ID))
+	return s.confirmTwoFactor(user, code)
+}
+
+func (s *AuthUseCase) DisableTwoFactor(userID int, code string) error {
+	s.Logger.Info("Disabling two-factor authentication", zap.Int("userID", userID))
+	user, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return domainErrors.NewAppError(errors.New("two-factor authentication is not enabled"), domainErrors.ValidationError)
+	}
+	if s.TwoFactorConfig.isRequiredFor(user.Role) {
+		s.Logger.Warn("Two-factor authentication is mandatory for role", zap.Int("userID", userID), zap.String("role", string(user.Role)))
+		return domainErrors.NewAppError(errors.New("two-factor authentication is required for this role"), domainErrors.NotAuthorized)
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		s.Logger.Warn("Two-factor disable rejected", zap.Error(err), zap.Int("userID", userID))
+		return err
+	}
+	if err := s.UserRepository.UpdateTwoFactor(user.ID, false, "", nil); err != nil {
+		return err
+	}
+	s.Logger.Info("Two-factor authentication disabled", zap.Int("userID", userID))
+	return nil
+}
+
+func (s *AuthUseCase) enrollTwoFactor(user *domainUser.User) (*TwoFactorEnrollment, error) {
+	if user.TwoFactorEnabled {
+		return nil, domainErrors.NewAppError(errors.New("two-factor authentication is already enabled"), domainErrors.ValidationError)
+	}
+	secret, err := s.TOTPService.GenerateSecret()
+	if err != nil {
+		s.Logger.Error("Error generating two-factor secret", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, err
+	}
+	encrypted, err := s.APIService.EncryptApiKey(secret)
+	if err != nil {
+		s.Logger.Error("Error encrypting two-factor secret", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, err
+	}
+	// the secret stays pending until a first code confirms the authenticator app was set up
+	if err := s.UserRepository.UpdateTwoFactor(user.ID, false, encrypted, nil); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: s.TOTPService.ProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+func (s *AuthUseCase) confirmTwoFactor(user *domainUser.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, domainErrors.NewAppError(errors.New("two-factor authentication is already enabled"), domainErrors.ValidationError)
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, domainErrors.NewAppError(errors.New("two-factor enrollment has not been started"), domainErrors.ValidationError)
+	}
+	// a pending enrollment has no recovery codes, so only the authenticator app can confirm it
+	if err := s.verifySecondFactor(user, code); err != nil {
+		s.Logger.Warn("Two-factor confirmation failed", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, err
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes(s.TwoFactorConfig.RecoveryCodeCount)
+	if err != nil {
+		s.Logger.Error("Error generating recovery codes", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, err
+	}
+	if err := s.UserRepository.UpdateTwoFactor(user.ID, true, user.TwoFactorSecret, hashes); err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Two-factor authentication enabled", zap.Int("userID", user.ID))
+	return recoveryCodes, nil
+}
+
+func (s *AuthUseCase) userFromChallenge(challengeToken string, challengeType string) (*domainUser.User, error) {
+	claims, err := s.JWTService.GetClaimsAndVerifyToken(challengeToken, challengeType)
+	if err != nil {
+		s.Logger.Warn("Invalid two-factor challenge token", zap.Error(err))
+		return nil, err
+	}
+	userID := int(claims["id"].(float64))
+	user, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		s.Logger.Error("Error getting user for two-factor challenge", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+	return user, nil
+}
+
+// secondFactorThrottled returns the error answering a code check while the account is locked or
+// backing off after failures, the same limits Login applies to passwords
+func (s *AuthUseCase) secondFactorThrottled(user *domainUser.User, now time.Time) *domainErrors.AppError {
+	if user.IsLocked(now) {
+		return newAccountLockedError(user.LockedUntil.Sub(now))
+	}
+	failedAttempts := currentFailedAttempts(user)
+	if wait := s.ThrottleConfig.retryAfter(int64(failedAttempts), user.LastFailedLoginAt, now); failedAttempts > 0 && wait > 0 {
+		return newTooManyRequestsError(wait)
+	}
+	return nil
+}
+
+// verifySecondFactor checks a code outside of a login, counting a wrong code as a failed attempt
+func (s *AuthUseCase) verifySecondFactor(user *domainUser.User, code string) error {
+	now := time.Now()
+	if err := s.secondFactorThrottled(user, now); err != nil {
+		return err
+	}
+	if !s.checkSecondFactor(user, code) {
+		s.registerFailedAttempt(user, now)
+		return newInvalidTwoFactorCodeError()
+	}
+	return nil
+}
+
+// checkSecondFactor accepts either a TOTP code of a time step later than the last accepted one, or
+// one of the unused recovery codes. A recovery code is consumed as soon as it is accepted.
+func (s *AuthUseCase) checkSecondFactor(user *domainUser.User, code string) bool {
+	if user.TwoFactorSecret == "" {
+		return false
+	}
+	secret, err := s.APIService.DecryptApiKey(user.TwoFactorSecret)
+	if err != nil {
+		s.Logger.Error("Error decrypting two-factor secret", zap.Error(err), zap.Int("userID", user.ID))
+		return false
+	}
+	if step, ok := s.TOTPService.ValidateCodeStep(secret, code); ok {
+		claimed, err := s.UserRepository.ClaimTwoFactorStep(user.ID, step)
+		if err != nil || !claimed {
+			s.Logger.Warn("Two-factor code rejected: already used", zap.Int("userID", user.ID), zap.Int64("step", step))
+			return false
+		}
+		return true
+	}
+
+	hashed := hashRecoveryCode(code)
+	for i, candidate := range user.TwoFactorRecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(hashed)) != 1 {
+			continue
+		}
+		// a concurrent login may have used the same code since the user was loaded
+		consumed, err := s.UserRepository.ConsumeRecoveryCode(user.ID, hashed)
+		if err != nil || !consumed {
+			s.Logger.Warn("Recovery code rejected: already used", zap.Int("userID", user.ID))
+			return false
+		}
+		user.TwoFactorRecoveryCodes = append(append([]string{}, user.TwoFactorRecoveryCodes[:i]...), user.TwoFactorRecoveryCodes[i+1:]...)
+		s.Logger.Info("Recovery code used", zap.Int("userID", user.ID), zap.Int("remaining", len(user.TwoFactorRecoveryCodes)))
+		return true
+	}
+	return false
+}
+
+// generateRecoveryCodes returns the plain codes to show once to the user and the hashes to store
+func generateRecoveryCodes(count int) ([]string, []string, error) {
+	codes := make([]string, count)
+	hashes := make([]string, count)
+	for i := range codes {
+		raw := make([]byte, 6)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func newInvalidTwoFactorCodeError() *domainErrors.AppError {
+	return domainErrors.NewAppError(errors.New("invalid two-factor code"), domainErrors.NotAuthenticated)
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func challengeVerifier(expectedType string, userID int) func(string, string) (jwt.MapClaims, error) {
+	return func(token string, tokenType string) (jwt.MapClaims, error) {
+		if tokenType != expectedType {
+			return nil, domainErrors.NewAppError(errors.New("invalid token type"), domainErrors.NotAuthenticated)
+		}
+		return jwt.MapClaims{"id": float64(userID), "type": tokenType}, nil
+	}
+}
+
+func TestAuthUseCase_Login_TwoFactorChallenge(t *testing.T) {
+	hashed, _ := HashPasswordForTest("mySecretPass")
+	tests := []struct {
+		name          string
+		user          domainUser.User
+		wantChallenge string
+	}{
+		{
+			name:          "Enrolled user gets a verification challenge",
+			user:          domainUser.User{ID: 10, HashPassword: hashed, Status: true, TwoFactorEnabled: true, TwoFactorSecret: "enc:JBSWY3DPEHPK3PXP"},
+			wantChallenge: security.TwoFactorChallenge,
+		},
+		{
+			name:          "Admin without second factor must enroll",
+			user:          domainUser.User{ID: 10, HashPassword: hashed, Status: true, Role: domainUser.RoleAdmin},
+			wantChallenge: security.TwoFactorEnrollment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := &mockUserService{
+				getByEmailFn: func(email string) (*domainUser.User, error) {
+					user := tt.user
+					return &user, nil
+				},
+			}
+			attemptRepo := &mockLoginAttemptRepository{}
+			uc := newThrottledUseCase(userRepo, attemptRepo, t)
+
+			_, tokens, err := uc.Login("test@example.com", "mySecretPass", "10.0.0.1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tokens.AccessToken != "" || tokens.RefreshToken != "" {
+				t.Error("expected no access or refresh token before the second factor")
+			}
+			if tokens.ChallengeToken == "" || tokens.ChallengeType != tt.wantChallenge {
+				t.Errorf("expected %s challenge, got %+v", tt.wantChallenge, tokens)
+			}
+			if len(attemptRepo.recorded) != 1 || attemptRepo.recorded[0].Reason != domainLoginAttempt.ReasonTwoFactorRequired {
+				t.Errorf("expected one two_factor_required attempt to be recorded, got %+v", attemptRepo.recorded)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_VerifyTwoFactor(t *testing.T) {
+	totp := security.NewTOTPService()
+	secret, _ := totp.GenerateSecret()
+	validCode, _ := totp.GenerateCode(secret, time.Now())
+	recoveryCodes, recoveryHashes, err := generateRecoveryCodes(2)
+	if err != nil {
+		t.Fatalf("failed to generate recovery codes: %v", err)
+	}
+
+	encrypted := "enc:" + secret
+	currentStep := time.Now().Unix() / 30
+
+	tests := []struct {
+		name              string
+		code              string
+		storedSecret      string
+		lastStep          int64
+		consumed          []string
+		wantErrType       domainErrors.ErrorType
+		wantFailureStored bool
+		wantConsumed      int
+	}{
+		{name: "Valid TOTP code", code: validCode, storedSecret: encrypted},
+		{name: "Valid recovery code is consumed", code: recoveryCodes[1], storedSecret: encrypted, wantConsumed: 1},
+		{name: "Recovery code used by a concurrent login counts as failure", code: recoveryCodes[1], storedSecret: encrypted, consumed: recoveryHashes[1:], wantErrType: domainErrors.NotAuthenticated, wantFailureStored: true, wantConsumed: 1},
+		{name: "Invalid code counts as failure", code: "000000x", storedSecret: encrypted, wantErrType: domainErrors.NotAuthenticated, wantFailureStored: true},
+		{name: "Replayed TOTP code counts as failure", code: validCode, storedSecret: encrypted, lastStep: currentStep, wantErrType: domainErrors.NotAuthenticated, wantFailureStored: true},
+		{name: "Secret that does not decrypt counts as failure", code: validCode, storedSecret: secret, wantErrType: domainErrors.NotAuthenticated, wantFailureStored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failureStored := false
+			userRepo := &mockUserService{
+				getByIDFn: func(id int) (*domainUser.User, error) {
+					return &domainUser.User{ID: id, TwoFactorEnabled: true, TwoFactorSecret: tt.storedSecret, TwoFactorRecoveryCodes: recoveryHashes}, nil
+				},
+				incrementFailedFn: func(id int, failedAt time.Time) (int, error) {
+					failureStored = true
+					return 1, nil
+				},
+				updateTwoFactorFn: func(id int, enabled bool, s string, codes []string) error {
+					t.Error("verifying a code must not rewrite the two-factor settings")
+					return nil
+				},
+				lastTwoFactorStep:    tt.lastStep,
+				consumedRecoveryCode: tt.consumed,
+			}
+			uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+			uc.JWTService.(*mockJWTService).verifyTokenFn = challengeVerifier(security.TwoFactorChallenge, 10)
+
+			_, tokens, err := uc.VerifyTwoFactor("challenge", tt.code, "10.0.0.1")
+			if tt.wantErrType != "" {
+				assertErrorType(t, err, tt.wantErrType)
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if tokens.AccessToken == "" {
+					t.Error("expected a non-empty AccessToken")
+				}
+			}
+			if failureStored != tt.wantFailureStored {
+				t.Errorf("expected failure stored = %v, got %v", tt.wantFailureStored, failureStored)
+			}
+			if len(userRepo.consumedRecoveryCode) != tt.wantConsumed {
+				t.Errorf("expected %d consumed recovery codes, got %v", tt.wantConsumed, userRepo.consumedRecoveryCode)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_VerifyTwoFactor_RejectsEnrollmentToken(t *testing.T) {
+	userRepo := &mockUserService{
+		getByIDFn: func(id int) (*domainUser.User, error) {
+			t.Fatal("user lookup must not happen for a wrong challenge type")
+			return nil, nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+	uc.JWTService.(*mockJWTService).verifyTokenFn = challengeVerifier(security.TwoFactorEnrollment, 10)
+
+	_, _, err := uc.VerifyTwoFactor("enrollment", "123456", "10.0.0.1")
+	assertErrorType(t, err, domainErrors.NotAuthenticated)
+}
+
+func TestAuthUseCase_EnrollAndConfirmTwoFactor(t *testing.T) {
+	stored := domainUser.User{ID: 10, Email: "admin@example.com", Role: domainUser.RoleAdmin}
+	userRepo := &mockUserService{
+		getByIDFn: func(id int) (*domainUser.User, error) {
+			user := stored
+			return &user, nil
+		},
+		updateTwoFactorFn: func(id int, enabled bool, secret string, codes []string) error {
+			stored.TwoFactorEnabled = enabled
+			stored.TwoFactorSecret = secret
+			stored.TwoFactorRecoveryCodes = codes
+			return nil
+		},
+	}
+	userRepo.incrementFailedFn = func(id int, failedAt time.Time) (int, error) {
+		stored.FailedLoginAttempts++
+		return stored.FailedLoginAttempts, nil
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+	uc.JWTService.(*mockJWTService).verifyTokenFn = challengeVerifier(security.TwoFactorEnrollment, 10)
+
+	enrollment, err := uc.EnrollTwoFactorWithChallenge("enrollment")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enrollment.Secret == "" || stored.TwoFactorSecret != "enc:"+enrollment.Secret || stored.TwoFactorEnabled {
+		t.Fatalf("expected a pending encrypted secret to be stored, got %+v", stored)
+	}
+
+	_, err = uc.ConfirmTwoFactorWithChallenge("enrollment", "000000")
+	assertErrorType(t, err, domainErrors.NotAuthenticated)
+	if stored.FailedLoginAttempts != 1 {
+		t.Errorf("expected the wrong code to count as a failed attempt, got %d", stored.FailedLoginAttempts)
+	}
+
+	code, _ := uc.TOTPService.GenerateCode(enrollment.Secret, time.Now())
+	recoveryCodes, err := uc.ConfirmTwoFactorWithChallenge("enrollment", code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stored.TwoFactorEnabled || len(recoveryCodes) != 3 || len(stored.TwoFactorRecoveryCodes) != 3 {
+		t.Fatalf("expected two-factor to be enabled with 3 recovery codes, got %+v", stored)
+	}
+	if stored.TwoFactorRecoveryCodes[0] == recoveryCodes[0] {
+		t.Error("recovery codes must be stored hashed")
+	}
+
+	_, err = uc.EnrollTwoFactor(10)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	err = uc.DisableTwoFactor(10, code)
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+}
+
+func TestAuthUseCase_DisableTwoFactor_Throttled(t *testing.T) {
+	totp := security.NewTOTPService()
+	secret, _ := totp.GenerateSecret()
+	code, _ := totp.GenerateCode(secret, time.Now())
+	lastFailure := time.Now()
+	disabled := false
+	userRepo := &mockUserService{
+		getByIDFn: func(id int) (*domainUser.User, error) {
+			return &domainUser.User{ID: id, TwoFactorEnabled: true, TwoFactorSecret: "enc:" + secret, FailedLoginAttempts: 2, LastFailedLoginAt: &lastFailure}, nil
+		},
+		updateTwoFactorFn: func(id int, enabled bool, s string, codes []string) error {
+			disabled = !enabled
+			return nil
+		},
+	}
+	uc := newThrottledUseCase(userRepo, &mockLoginAttemptRepository{}, t)
+	uc.ThrottleConfig.BackoffAfter = 2
+
+	err := uc.DisableTwoFactor(10, code)
+	assertErrorType(t, err, domainErrors.TooManyRequests)
+	if disabled {
+		t.Error("two-factor must not be disabled while the account is backing off")
+	}
+}
+
+func TestLoadTwoFactorConfig(t *testing.T) {
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "admin, subscriber")
+	config := loadTwoFactorConfig()
+	if !config.isRequiredFor(domainUser.RoleAdmin) || !config.isRequiredFor(domainUser.RoleSubscriber) {
+		t.Errorf("expected both roles to require two-factor, got %+v", config.RequiredRoles)
+	}
+
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "")
+	config = loadTwoFactorConfig()
+	if config.isRequiredFor(domainUser.RoleAdmin) {
+		t.Error("expected an empty list to disable enforcement")
+	}
+}
@@ -0,0 +1,134 @@
+package currency
+
+import (
+	"fmt"
+	"time"
+
+	exchangerDomain "github.com/gbrayhan/microservices-go/src/domain/exchanger"
+	"go.uber.org/zap"
+)
+
+// breakerConfig is when the circuit of an exchanger opens and how long it stays open before a
+// refresh probes the exchanger again
+type breakerConfig struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+func loadBreakerConfig() breakerConfig {
+	return breakerConfig{
+		FailureThreshold: getEnvAsIntOrDefault("EXCHANGER_CIRCUIT_FAILURE_THRESHOLD", 3),
+		Cooldown:         time.Duration(getEnvAsIntOrDefault("EXCHANGER_CIRCUIT_COOLDOWN_SECONDS", 300)) * time.Second,
+	}
+}
+
+// loadHealth returns the circuit state of every exchanger. A refresh goes ahead without it, as if
+// every circuit were closed.
+func (s *CurrencyUseCase) loadHealth() map[int]exchangerDomain.Health {
+	health := make(map[int]exchangerDomain.Health)
+	all, err := s.exchangeRepository.GetAllHealth()
+	if err != nil {
+		s.Logger.Warn("Error loading exchanger health", zap.Error(err))
+		return health
+	}
+	for _, h := range *all {
+		health[h.ExchangerID] = h
+	}
+	return health
+}
+
+// shouldFetch tells whether a refresh calls an exchanger. Exchangers turned off by hand are
+// skipped; an open circuit is skipped until its cooldown has passed, then probed half-open.
+func (s *CurrencyUseCase) shouldFetch(exchanger exchangerDomain.Exchanger, health *exchangerDomain.Health) bool {
+	if !exchanger.IsActive && !health.AutoDisabled {
+		return false
+	}
+	switch health.State {
+	case exchangerDomain.CircuitOpen:
+		if health.OpenedAt != nil && s.now().Sub(*health.OpenedAt) < s.breaker.Cooldown {
+			return false
+		}
+		health.State = exchangerDomain.CircuitHalfOpen
+		return true
+	case exchangerDomain.CircuitHalfOpen:
+		return true
+	default:
+		return exchanger.IsActive
+	}
+}
+
+// recordFetch moves the circuit of an exchanger after a fetch and stores it. A successful probe
+// closes the circuit and turns the exchanger back on; a failed one, or FailureThreshold failures
+// in a row, open it and turn the exchanger off.
+func (s *CurrencyUseCase) recordFetch(exchanger exchangerDomain.Exchanger, health exchangerDomain.Health, latency time.Duration, fetchErr error) {
+	at := s.now()
+	health.ExchangerID = exchanger.ID
+	fetch := exchangerDomain.Fetch{ExchangerID: exchanger.ID, Succeeded: fetchErr == nil, Latency: latency, At: at}
+	var change *exchangerDomain.StatusChange
+	if fetchErr == nil {
+		health.Successes++
+		health.ConsecutiveFailures = 0
+		health.LastSuccessAt = &at
+		if health.State != exchangerDomain.CircuitClosed && health.State != "" {
+			s.Logger.Info("Exchanger circuit closed", zap.String("exchanger", exchanger.Name))
+		}
+		health.State = exchangerDomain.CircuitClosed
+		health.OpenedAt = nil
+		if health.AutoDisabled {
+			health.AutoDisabled = false
+			change = &exchangerDomain.StatusChange{IsActive: true, Reason: "probe succeeded, circuit closed"}
+		}
+	} else {
+		fetch.Error = fetchErr.Error()
+		health.Failures++
+		health.ConsecutiveFailures++
+		health.LastError = fetch.Error
+		health.LastErrorAt = &at
+		if health.State == exchangerDomain.CircuitHalfOpen || health.ConsecutiveFailures >= s.breaker.FailureThreshold {
+			if health.State != exchangerDomain.CircuitOpen {
+				s.Logger.Warn("Exchanger circuit opened", zap.String("exchanger", exchanger.Name), zap.Int("consecutiveFailures", health.ConsecutiveFailures))
+			}
+			health.State = exchangerDomain.CircuitOpen
+			health.OpenedAt = &at
+			if exchanger.IsActive {
+				health.AutoDisabled = true
+				change = &exchangerDomain.StatusChange{IsActive: false, Reason: fmt.Sprintf("%d consecutive failures, last: %s", health.ConsecutiveFailures, fetch.Error)}
+			}
+		} else if health.State == "" {
+			health.State = exchangerDomain.CircuitClosed
+		}
+	}
+
+	// the refresh itself does not depend on the bookkeeping
+	if err := s.exchangeRepository.RecordFetch(fetch, health); err != nil {
+		s.Logger.Warn("Error recording exchanger fetch", zap.Error(err), zap.String("exchanger", exchanger.Name))
+	}
+	if change != nil {
+		change.Actor = exchangerDomain.StatusActorCircuitBreaker
+		change.CreatedAt = at
+		if err := s.exchangeRepository.SetActive(exchanger.ID, change.IsActive, *change); err != nil {
+			s.Logger.Warn("Error changing exchanger status", zap.Error(err), zap.String("exchanger", exchanger.Name))
+		}
+	}
+}
+
+// reserveCall counts a call to an exchanger against its quota before it is made, as providers bill
+// failed calls too. Without the count, the exchanger is skipped rather than risk an overage.
+func (s *CurrencyUseCase) reserveCall(exchanger exchangerDomain.Exchanger) bool {
+	usage, err := s.exchangeRepository.ReserveCall(exchanger, s.now())
+	if err != nil {
+		s.Logger.Warn("Skipping exchanger, its usage could not be counted", zap.Error(err), zap.String("exchanger", exchanger.Name))
+		return false
+	}
+	if usage == nil {
+		s.Logger.Info("Skipping exchanger, its quota is used up or it was called too recently",
+			zap.String("exchanger", exchanger.Name),
+			zap.Int("monthlyQuota", exchanger.MonthlyQuota),
+			zap.Duration("minInterval", exchanger.MinInterval))
+		return false
+	}
+	if exchanger.MonthlyQuota > 0 && usage.Calls == exchanger.MonthlyQuota {
+		s.Logger.Warn("Exchanger quota used up", zap.String("exchanger", exchanger.Name), zap.String("period", usage.Period), zap.Int("calls", usage.Calls))
+	}
+	return true
+}
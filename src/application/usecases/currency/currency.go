@@ -3,12 +3,19 @@ package currency
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	currencyDomain "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	exchangerDomain "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/exchanger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
@@ -20,62 +27,399 @@ type ICurrencyUseCase interface {
 	GetByID(id int) (*currencyDomain.Currency, error)
 	Delete(id int) error
 	UpdateExchanges() (any, error)
+	GetOHLC(code string, interval currencyDomain.Interval, from, to *time.Time) (*[]currencyDomain.OHLC, error)
+	GetStats(code string, from, to *time.Time) (*currencyDomain.RateStats, error)
+	Convert(from, to string, amount float64, at *time.Time) (*currencyDomain.Conversion, error)
+	ConvertBatch(items []currencyDomain.ConversionRequest) (*currencyDomain.BatchConversion, error)
+	SearchPaginated(filters domain.DataFilters) (*currencyDomain.SearchResultCurrency, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	StreamSearch(filters domain.DataFilters, fn func(*currencyDomain.Currency) error) error
+	StreamHistory(code string, from, to *time.Time, fn func(*currencyDomain.RatePoint) error) error
+	Import(rows []currencyDomain.ImportRow, dryRun bool) (*currencyDomain.ImportReport, error)
+}
+
+const (
+	// maxOHLCBuckets bounds the span of an OHLC request
+	maxOHLCBuckets = 1000
+	// defaultOHLCBuckets is the span returned when no start is given
+	defaultOHLCBuckets = 30
+	// defaultStatsPeriod is the period summarised when no start is given
+	defaultStatsPeriod = 24 * time.Hour
+	// defaultHistoryPeriod is the period exported when no start is given
+	defaultHistoryPeriod = 30 * 24 * time.Hour
+)
+
+// RateObserver is notified with the freshly aggregated rates after every refresh
+type RateObserver interface {
+	OnRatesUpdated(currencies []currencyDomain.Currency)
+}
+
+// CurrencyDeletedObserver can be implemented by a RateObserver that also wants to know about deleted currencies
+type CurrencyDeletedObserver interface {
+	OnCurrencyDeleted(currency currencyDomain.Currency)
+}
+
+// RefreshState is the stage a rate refresh has reached
+type RefreshState string
+
+const (
+	RefreshStarted   RefreshState = "started"
+	RefreshCompleted RefreshState = "completed"
+	RefreshFailed    RefreshState = "failed"
+)
+
+// RefreshStatus reports the progress of one rate refresh
+type RefreshStatus struct {
+	State      RefreshState
+	Currencies int
+	Error      string
+	At         time.Time
+}
+
+// RefreshStatusObserver can be implemented by a RateObserver that also wants to follow the refresh job
+type RefreshStatusObserver interface {
+	OnRefreshStatus(status RefreshStatus)
 }
 
 type CurrencyUseCase struct {
 	currencyRepository currency.CurrencyRepositoryInterface
 	exchangeRepository exchanger.ExchangerRepositoryInterface
 	apiService         security.IAPIService
+	providerClient     provider.IClient
+	observers          []RateObserver
 	Logger             *logger.Logger
+	now                func() time.Time
+	breaker            breakerConfig
+	// importMaxChangePercent bounds how far an imported rate may move from the current one; 0 disables the check
+	importMaxChangePercent int
 }
 
-func NewCurrencyUseCase(currencyRepository currency.CurrencyRepositoryInterface, exchangeRepository exchanger.ExchangerRepositoryInterface, apiService security.IAPIService, logger *logger.Logger) ICurrencyUseCase {
+func NewCurrencyUseCase(currencyRepository currency.CurrencyRepositoryInterface, exchangeRepository exchanger.ExchangerRepositoryInterface, apiService security.IAPIService, providerClient provider.IClient, logger *logger.Logger, observers ...RateObserver) ICurrencyUseCase {
 	return &CurrencyUseCase{
 		currencyRepository: currencyRepository,
 		exchangeRepository: exchangeRepository,
 		apiService:         apiService,
+		providerClient:     providerClient,
+		observers:          observers,
 		Logger:             logger,
+		now:                time.Now,
+		breaker:            loadBreakerConfig(),
+
+		importMaxChangePercent: getEnvAsIntOrDefault("CURRENCY_IMPORT_MAX_CHANGE_PERCENT", 50),
+	}
+}
+
+func (s *CurrencyUseCase) GetAll() (*[]currencyDomain.Currency, error) {
+	s.Logger.Info("Getting all users")
+	return s.currencyRepository.GetAll()
+}
+
+func (s *CurrencyUseCase) GetByID(id int) (*currencyDomain.Currency, error) {
+	s.Logger.Info("Getting user by ID", zap.Int("id", id))
+	return s.currencyRepository.GetByID(id)
+}
+
+func (s *CurrencyUseCase) Delete(id int) error {
+	s.Logger.Info("Deleting currency", zap.Int("id", id))
+	deleted, err := s.currencyRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.currencyRepository.Delete(id); err != nil {
+		return err
+	}
+	for _, observer := range s.observers {
+		if deletedObserver, ok := observer.(CurrencyDeletedObserver); ok {
+			deletedObserver.OnCurrencyDeleted(*deleted)
+		}
 	}
+	return nil
 }
 
-func GetProviderRates(ctx context.Context) (map[string]float64, error) {
-	req, _ := http.NewRequestWithContext(
-		ctx,
-		"GET",
-		"https://api.exchangerate-api.com/v4/latest/USD",
-		nil,
-	)
+func (s *CurrencyUseCase) SearchPaginated(filters domain.DataFilters) (*currencyDomain.SearchResultCurrency, error) {
+	s.Logger.Info("Searching currencies with pagination",
+		zap.Int("page", filters.Page),
+		zap.Int("pageSize", filters.PageSize))
+	return s.currencyRepository.SearchPaginated(filters)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (s *CurrencyUseCase) SearchByProperty(property string, searchText string) (*[]string, error) {
+	s.Logger.Info("Searching currencies by property",
+		zap.String("property", property),
+		zap.String("searchText", searchText))
+	return s.currencyRepository.SearchByProperty(property, searchText)
+}
+
+// StreamSearch calls fn with every currency matching filters, ignoring pagination
+func (s *CurrencyUseCase) StreamSearch(filters domain.DataFilters, fn func(*currencyDomain.Currency) error) error {
+	s.Logger.Info("Streaming currencies")
+	return s.currencyRepository.StreamSearch(filters, fn)
+}
+
+// StreamHistory calls fn with every rate of a currency recorded between from and to, oldest
+// first. to defaults to now and from to defaultHistoryPeriod before to.
+func (s *CurrencyUseCase) StreamHistory(code string, from, to *time.Time, fn func(*currencyDomain.RatePoint) error) error {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return err
+	}
+	start, end, err := s.resolvePeriod(from, to, defaultHistoryPeriod)
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("Streaming rate history", zap.String("code", code), zap.Time("from", start), zap.Time("to", end))
+	return s.currencyRepository.StreamRates(code, start, end, fn)
+}
+
+// GetOHLC returns the OHLC buckets of a currency between from and to. to defaults to now and
+// from to defaultOHLCBuckets intervals before to.
+func (s *CurrencyUseCase) GetOHLC(code string, interval currencyDomain.Interval, from, to *time.Time) (*[]currencyDomain.OHLC, error) {
+	if interval == "" {
+		interval = currencyDomain.IntervalHour
+	}
+	if !interval.IsValid() {
+		return nil, domainErrors.NewAppError(fmt.Errorf("interval must be %s or %s", currencyDomain.IntervalHour, currencyDomain.IntervalDay), domainErrors.ValidationError)
+	}
+	code, err := normalizeCode(code)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	start, end, err := s.resolvePeriod(from, to, defaultOHLCBuckets*interval.Duration())
+	if err != nil {
+		return nil, err
+	}
+	if end.Sub(start) > maxOHLCBuckets*interval.Duration() {
+		return nil, domainErrors.NewAppError(fmt.Errorf("the period spans more than %d buckets of %s", maxOHLCBuckets, interval), domainErrors.ValidationError)
+	}
+	s.Logger.Info("Getting OHLC", zap.String("code", code), zap.String("interval", string(interval)))
+	return s.currencyRepository.GetOHLC(code, interval, start, end)
+}
 
-	var payload struct {
-		Base  string             `json:"base"`
-		Rates map[string]float64 `json:"rates"`
+// GetStats summarises the rates of a currency between from and to. to defaults to now and from
+// to defaultStatsPeriod before to.
+func (s *CurrencyUseCase) GetStats(code string, from, to *time.Time) (*currencyDomain.RateStats, error) {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := s.resolvePeriod(from, to, defaultStatsPeriod)
+	if err != nil {
+		return nil, err
 	}
+	s.Logger.Info("Getting rate statistics", zap.String("code", code))
+	stats, err := s.currencyRepository.GetStats(code, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Samples == 0 {
+		return nil, domainErrors.NewAppError(fmt.Errorf("no rates recorded for %s in the period", code), domainErrors.NotFound)
+	}
+	if stats.First != 0 {
+		stats.ChangePercent = (stats.Last - stats.First) / stats.First * 100
+	}
+	return stats, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+// Convert converts amount of from into to. Without at it uses the stored current rates; with at
+// it uses, for each leg, the last rate recorded at or before that time and reports which one.
+func (s *CurrencyUseCase) Convert(from, to string, amount float64, at *time.Time) (*currencyDomain.Conversion, error) {
+	conversion, err := s.convert(s.newRateSnapshot(), currencyDomain.ConversionRequest{From: from, To: to, Amount: amount, At: at})
+	if err != nil {
 		return nil, err
 	}
-	return payload.Rates, nil
+	s.Logger.Info("Converted currency", zap.String("from", conversion.From.Code), zap.String("to", conversion.To.Code), zap.Bool("historical", at != nil))
+	return conversion, nil
 }
 
-func (s *CurrencyUseCase) GetAll() (*[]currencyDomain.Currency, error) {
-	s.Logger.Info("Getting all users")
-	return s.currencyRepository.GetAll()
+// ConvertBatch converts every item against one snapshot: current rates are loaded in a single
+// query and each historical rate once, so items sharing a currency agree. Invalid items get
+// their own error instead of failing the batch.
+func (s *CurrencyUseCase) ConvertBatch(items []currencyDomain.ConversionRequest) (*currencyDomain.BatchConversion, error) {
+	if len(items) == 0 {
+		return nil, domainErrors.NewAppError(errors.New("the batch has no items"), domainErrors.ValidationError)
+	}
+	if len(items) > currencyDomain.MaxBatchConversions {
+		return nil, domainErrors.NewAppError(fmt.Errorf("a batch may hold at most %d items", currencyDomain.MaxBatchConversions), domainErrors.ValidationError)
+	}
+
+	snapshot := s.newRateSnapshot()
+	codes := []string{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.At != nil {
+			continue
+		}
+		for _, code := range []string{item.From, item.To} {
+			if normalized, err := normalizeCode(code); err == nil && normalized != currencyDomain.RateBase && !seen[normalized] {
+				seen[normalized] = true
+				codes = append(codes, normalized)
+			}
+		}
+	}
+	if err := snapshot.preload(codes); err != nil {
+		return nil, err
+	}
+
+	batch := &currencyDomain.BatchConversion{SnapshotAt: snapshot.takenAt, Results: make([]currencyDomain.ConversionResult, len(items))}
+	failed := 0
+	for i, item := range items {
+		conversion, err := s.convert(snapshot, item)
+		batch.Results[i] = currencyDomain.ConversionResult{Conversion: conversion, Err: err}
+		if err != nil {
+			failed++
+		}
+	}
+	s.Logger.Info("Converted currency batch", zap.Int("items", len(items)), zap.Int("failed", failed))
+	return batch, nil
 }
 
-func (s *CurrencyUseCase) GetByID(id int) (*currencyDomain.Currency, error) {
-	s.Logger.Info("Getting user by ID", zap.Int("id", id))
-	return s.currencyRepository.GetByID(id)
+func (s *CurrencyUseCase) convert(snapshot *rateSnapshot, request currencyDomain.ConversionRequest) (*currencyDomain.Conversion, error) {
+	from, err := normalizeCode(request.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeCode(request.To)
+	if err != nil {
+		return nil, err
+	}
+	if request.Amount <= 0 {
+		return nil, domainErrors.NewAppError(errors.New("amount must be greater than zero"), domainErrors.ValidationError)
+	}
+	if request.At != nil && request.At.After(snapshot.takenAt) {
+		return nil, domainErrors.NewAppError(errors.New("at must not be in the future"), domainErrors.ValidationError)
+	}
+
+	fromLeg, err := snapshot.leg(from, request.At)
+	if err != nil {
+		return nil, err
+	}
+	toLeg, err := snapshot.leg(to, request.At)
+	if err != nil {
+		return nil, err
+	}
+	rate := toLeg.Rate / fromLeg.Rate
+	return &currencyDomain.Conversion{From: fromLeg, To: toLeg, Amount: request.Amount, Rate: rate, Result: request.Amount * rate, At: request.At}, nil
 }
 
-func (s *CurrencyUseCase) Delete(id int) error {
-	s.Logger.Info("Deleting currency", zap.Int("id", id))
-	return s.currencyRepository.Delete(id)
+// rateSnapshot resolves conversion legs and remembers each one, so every conversion made through
+// the same snapshot uses the same rates
+type rateSnapshot struct {
+	useCase *CurrencyUseCase
+	takenAt time.Time
+	legs    map[legKey]legLookup
+}
+
+// legKey identifies a rate lookup; at is the UnixNano of the requested time, 0 for current rates
+type legKey struct {
+	code       string
+	historical bool
+	at         int64
+}
+
+type legLookup struct {
+	leg currencyDomain.ConversionLeg
+	err error
+}
+
+func (s *CurrencyUseCase) newRateSnapshot() *rateSnapshot {
+	return &rateSnapshot{useCase: s, takenAt: s.now(), legs: make(map[legKey]legLookup)}
+}
+
+// preload loads the current rates of the given codes in a single query
+func (snapshot *rateSnapshot) preload(codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	currencies, err := snapshot.useCase.currencyRepository.GetByCodes(codes)
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		snapshot.legs[legKey{code: code}] = legLookup{err: noCurrentRate(code)}
+	}
+	for _, c := range *currencies {
+		if c.Rate > 0 {
+			updatedAt := c.UpdatedAt
+			snapshot.legs[legKey{code: c.Code}] = legLookup{leg: currencyDomain.ConversionLeg{Code: c.Code, Rate: c.Rate, RecordedAt: &updatedAt}}
+		}
+	}
+	return nil
+}
+
+func (snapshot *rateSnapshot) leg(code string, at *time.Time) (currencyDomain.ConversionLeg, error) {
+	if code == currencyDomain.RateBase {
+		return currencyDomain.ConversionLeg{Code: code, Rate: 1}, nil
+	}
+	key := legKey{code: code}
+	if at != nil {
+		key = legKey{code: code, historical: true, at: at.UnixNano()}
+	}
+	lookup, ok := snapshot.legs[key]
+	if !ok {
+		if at != nil {
+			lookup.leg, lookup.err = snapshot.useCase.historicalLeg(code, *at)
+		} else {
+			lookup.leg, lookup.err = snapshot.useCase.currentLeg(code)
+		}
+		snapshot.legs[key] = lookup
+	}
+	return lookup.leg, lookup.err
+}
+
+func (s *CurrencyUseCase) currentLeg(code string) (currencyDomain.ConversionLeg, error) {
+	currencies, err := s.currencyRepository.GetByCodes([]string{code})
+	if err != nil {
+		return currencyDomain.ConversionLeg{}, err
+	}
+	for _, c := range *currencies {
+		if c.Rate > 0 {
+			updatedAt := c.UpdatedAt
+			return currencyDomain.ConversionLeg{Code: code, Rate: c.Rate, RecordedAt: &updatedAt}, nil
+		}
+	}
+	return currencyDomain.ConversionLeg{}, noCurrentRate(code)
+}
+
+func noCurrentRate(code string) error {
+	return domainErrors.NewAppError(fmt.Errorf("no rate known for %s", code), domainErrors.NotFound)
+}
+
+func (s *CurrencyUseCase) historicalLeg(code string, at time.Time) (currencyDomain.ConversionLeg, error) {
+	point, err := s.currencyRepository.GetRateAt(code, at)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return currencyDomain.ConversionLeg{}, domainErrors.NewAppError(fmt.Errorf("no %s rate was recorded at or before %s", code, at.UTC().Format(time.RFC3339)), domainErrors.NotFound)
+		}
+		return currencyDomain.ConversionLeg{}, err
+	}
+	if point.Rate <= 0 {
+		return currencyDomain.ConversionLeg{}, domainErrors.NewAppError(fmt.Errorf("the %s rate recorded at %s is not usable", code, point.RecordedAt.UTC().Format(time.RFC3339)), domainErrors.NotFound)
+	}
+	return currencyDomain.ConversionLeg{Code: code, Rate: point.Rate, RecordedAt: &point.RecordedAt}, nil
+}
+
+func (s *CurrencyUseCase) resolvePeriod(from, to *time.Time, defaultSpan time.Duration) (time.Time, time.Time, error) {
+	end := s.now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultSpan)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, domainErrors.NewAppError(errors.New("from must be before to"), domainErrors.ValidationError)
+	}
+	return start, end, nil
+}
+
+func normalizeCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return "", domainErrors.NewAppError(fmt.Errorf("invalid currency code %q", code), domainErrors.ValidationError)
+	}
+	return code, nil
 }
 
 type NormalizedRate struct {
@@ -83,21 +427,33 @@ type NormalizedRate struct {
 	Base     string
 	Currency string
 	Rate     float64
+	Priority int
+	Weight   float64
 }
 
+// normalizeExchange keeps the rates of the currencies the exchanger covers
 func normalizeExchange(
-	provider string,
+	provider exchangerDomain.Exchanger,
 	base string,
 	raw map[string]float64,
 ) []NormalizedRate {
+	weight := provider.Weight
+	if weight <= 0 {
+		weight = exchangerDomain.DefaultWeight
+	}
 	rates := make([]NormalizedRate, 0, len(raw))
 
 	for currency, rate := range raw {
+		if !provider.Covers(currency) {
+			continue
+		}
 		rates = append(rates, NormalizedRate{
-			Provider: provider,
+			Provider: provider.Name,
 			Base:     base,
 			Currency: currency,
 			Rate:     rate,
+			Priority: provider.Priority,
+			Weight:   weight,
 		})
 	}
 
@@ -106,44 +462,108 @@ func normalizeExchange(
 
 func (s *CurrencyUseCase) UpdateExchanges() (any, error) {
 	s.Logger.Info("Updating Exchanges in service")
+	s.notifyRefreshStatus(RefreshStatus{State: RefreshStarted})
+	updated, err := s.refreshRates()
+	if err != nil {
+		s.notifyRefreshStatus(RefreshStatus{State: RefreshFailed, Error: err.Error()})
+		return nil, err
+	}
+
+	for _, observer := range s.observers {
+		observer.OnRatesUpdated(updated)
+	}
+	s.notifyRefreshStatus(RefreshStatus{State: RefreshCompleted, Currencies: len(updated)})
+
+	return nil, nil
+}
+
+func (s *CurrencyUseCase) notifyRefreshStatus(status RefreshStatus) {
+	status.At = s.now()
+	for _, observer := range s.observers {
+		if statusObserver, ok := observer.(RefreshStatusObserver); ok {
+			statusObserver.OnRefreshStatus(status)
+		}
+	}
+}
+
+// refreshRates fetches every exchanger the circuit breaker lets through, aggregates their rates and
+// stores the result. It fails when every exchanger it called failed or when a rate could not be
+// stored; the rates that were stored are still recorded in the history.
+func (s *CurrencyUseCase) refreshRates() ([]currencyDomain.Currency, error) {
 	exchangers, err := s.exchangeRepository.GetAll()
 	if err != nil {
 		return nil, err
 	}
+	health := s.loadHealth()
 
 	allRates := []NormalizedRate{}
-
+	var failures []error
 	for _, exchanger := range *exchangers {
-		//first unhash the api key
-		decodedApiKey, err := s.apiService.DecryptApiKey(exchanger.ApiKey)
-		if err != nil {
-			return nil, err
+		state := health[exchanger.ID]
+		if !s.shouldFetch(exchanger, &state) {
+			s.Logger.Info("Skipping exchanger", zap.String("exchanger", exchanger.Name), zap.Bool("active", exchanger.IsActive), zap.String("circuit", string(state.State)))
+			continue
+		}
+		if !s.reserveCall(exchanger) {
+			continue
 		}
-		//now i have to Fetch every exchanger and also unhash de api key to send
-		data, err := s.fetchExchangeData(exchanger.Url, decodedApiKey)
+		start := s.now()
+		data, err := s.fetchExchanger(exchanger)
+		s.recordFetch(exchanger, state, s.now().Sub(start), err)
 		if err != nil {
-			return nil, err
+			s.Logger.Warn("Error fetching exchanger", zap.Error(err), zap.String("exchanger", exchanger.Name))
+			failures = append(failures, fmt.Errorf("%s: %w", exchanger.Name, err))
+			continue
 		}
-		normalizedRate := normalizeExchange(exchanger.Name, "USD", data)
+		normalizedRate := normalizeExchange(exchanger, currencyDomain.RateBase, data)
 		allRates = append(allRates, normalizedRate...)
 	}
+	if len(failures) > 0 && len(allRates) == 0 {
+		return nil, errors.Join(failures...)
+	}
 
 	aggregated := aggregateRates(allRates)
 
-	//Create Currencies
+	updated := make([]currencyDomain.Currency, 0, len(aggregated))
+	var storeFailures []error
 	for _, rate := range aggregated {
-		s.Logger.Info("Creating currency", zap.String("currency", rate.Currency))
+		s.Logger.Info("Storing currency rate", zap.String("currency", rate.Currency))
 
-		currency := currencyDomain.Currency{
+		stored, err := s.currencyRepository.Upsert(&currencyDomain.Currency{
 			Rate:   rate.Rate,
 			Status: true,
 			Code:   rate.Currency,
 			Name:   rate.Name,
+		})
+		if err != nil {
+			s.Logger.Error("Error storing currency rate", zap.Error(err), zap.String("currency", rate.Currency))
+			storeFailures = append(storeFailures, fmt.Errorf("%s: %w", rate.Currency, err))
+			continue
 		}
-		s.currencyRepository.Create(&currency)
+		updated = append(updated, *stored)
 	}
 
-	return nil, nil
+	// a gap in the history is better than losing the refresh itself
+	if err := s.currencyRepository.RecordRates(updated, s.now()); err != nil {
+		s.Logger.Warn("Error recording rate history", zap.Error(err))
+	}
+	if len(storeFailures) > 0 {
+		return nil, errors.Join(storeFailures...)
+	}
+
+	return updated, nil
+}
+
+func (s *CurrencyUseCase) fetchExchanger(exchanger exchangerDomain.Exchanger) (map[string]float64, error) {
+	decodedApiKey, err := s.apiService.DecryptApiKey(exchanger.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+	// retries are calls too, so each one needs room in the quota, but they belong to the call that
+	// just passed the minimum interval and would never get through it again
+	retry := exchanger
+	retry.MinInterval = 0
+	return s.fetchExchangeData(exchanger.Url, decodedApiKey, func() bool { return s.reserveCall(retry) })
 }
 
 type AggregatedRate struct {
@@ -154,10 +574,15 @@ type AggregatedRate struct {
 	Sources  int
 }
 
+// aggregateRates averages the rates of each currency, weighted by provider, within the highest
+// priority tier that quotes it. Lower tiers are a fallback: they only count for the currencies no
+// higher provider returned, so their weight never mixes with a higher tier's.
 func aggregateRates(rates []NormalizedRate) map[string]AggregatedRate {
 	acc := make(map[string]struct {
 		sum      float64
+		weights  float64
 		count    int
+		priority int
 		base     string
 		currency string
 		name     string
@@ -166,9 +591,17 @@ func aggregateRates(rates []NormalizedRate) map[string]AggregatedRate {
 	for _, r := range rates {
 		key := r.Base + "_" + r.Currency
 
-		v := acc[key]
-		v.sum += r.Rate
+		v, seen := acc[key]
+		if seen && r.Priority < v.priority {
+			continue
+		}
+		if seen && r.Priority > v.priority {
+			v.sum, v.weights, v.count = 0, 0, 0
+		}
+		v.sum += r.Rate * r.Weight
+		v.weights += r.Weight
 		v.count++
+		v.priority = r.Priority
 		v.base = r.Base
 		v.currency = r.Currency
 		acc[key] = v
@@ -181,7 +614,7 @@ func aggregateRates(rates []NormalizedRate) map[string]AggregatedRate {
 			Base:     v.base,
 			Name:     v.name,
 			Currency: v.currency,
-			Rate:     v.sum / float64(v.count),
+			Rate:     v.sum / v.weights,
 			Sources:  v.count,
 		}
 	}
@@ -195,32 +628,26 @@ type ExchangeResponse struct {
 func (s *CurrencyUseCase) fetchExchangeData(
 	url string,
 	apiKey string,
+	allowRetry func() bool,
 ) (map[string]float64, error) {
-	req, err := http.NewRequestWithContext(
-		context.Background(),
-		http.MethodGet,
-		url+"?apikey="+apiKey,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+	body, err := s.providerClient.Get(context.Background(), url+"?apikey="+apiKey, allowRetry)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("provider error %d: %s", resp.StatusCode, body)
-	}
 
 	var payload ExchangeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+	if err := json.Unmarshal(body, &payload); err != nil {
 		return nil, err
 	}
 
 	return payload.Data, nil
 }
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -2,12 +2,20 @@ package currency
 
 import (
 	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	currencyDomain "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	exchangerDomain "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider/fakeprovider"
 	security "github.com/gbrayhan/microservices-go/src/infrastructure/security"
 )
 
@@ -19,14 +27,30 @@ type mockExchangerService struct {
 	createFn  func(u *exchangerDomain.Exchanger) (*exchangerDomain.Exchanger, error)
 	deleteFn  func(id int) error
 	updateFn  func(id int, m map[string]interface{}) (*exchangerDomain.Exchanger, error)
+	health    []exchangerDomain.Health
+	fetches   []exchangerDomain.Fetch
+	recorded  []exchangerDomain.Health
+	changes   []exchangerDomain.StatusChange
+	// quotas are the calls ReserveCall allows per exchanger, unlimited when missing
+	quotas   map[int]int
+	reserved map[int]int
+	lastCall map[int]time.Time
 }
 
 type mockUserService struct {
 	getAllFn  func() (*[]currencyDomain.Currency, error)
 	getByIDFn func(id int) (*currencyDomain.Currency, error)
 	createFn  func(u *currencyDomain.Currency) (*currencyDomain.Currency, error)
+	upsertFn  func(u *currencyDomain.Currency) (*currencyDomain.Currency, error)
 	deleteFn  func(id int) error
 	updateFn  func(id int, m map[string]interface{}) (*currencyDomain.Currency, error)
+	recorded  []currencyDomain.Currency
+	ohlcFn    func(code string, interval currencyDomain.Interval, from, to time.Time) (*[]currencyDomain.OHLC, error)
+	statsFn   func(code string, from, to time.Time) (*currencyDomain.RateStats, error)
+	byCodesFn func(codes []string) (*[]currencyDomain.Currency, error)
+	rateAtFn  func(code string, at time.Time) (*currencyDomain.RatePoint, error)
+	ratesFn   func(code string, from, to time.Time, fn func(*currencyDomain.RatePoint) error) error
+	importFn  func(changes []currencyDomain.ImportChange, recordedAt time.Time) (*[]currencyDomain.Currency, error)
 }
 
 func (m *mockExchangerService) GetAll() (*[]exchangerDomain.Exchanger, error) {
@@ -44,6 +68,46 @@ func (m *mockExchangerService) Delete(id int) error {
 func (m *mockExchangerService) Update(id int, userMap map[string]interface{}) (*exchangerDomain.Exchanger, error) {
 	return m.updateFn(id, userMap)
 }
+func (m *mockExchangerService) SearchPaginated(filters domain.DataFilters) (*exchangerDomain.SearchResultExchanger, error) {
+	return nil, nil
+}
+func (m *mockExchangerService) SearchByProperty(property string, searchText string) (*[]string, error) {
+	return nil, nil
+}
+func (m *mockExchangerService) GetAllHealth() (*[]exchangerDomain.Health, error) {
+	return &m.health, nil
+}
+func (m *mockExchangerService) GetHealth(exchangerID int, since time.Time) (*exchangerDomain.Health, error) {
+	return nil, nil
+}
+func (m *mockExchangerService) RecordFetch(fetch exchangerDomain.Fetch, health exchangerDomain.Health) error {
+	m.fetches = append(m.fetches, fetch)
+	m.recorded = append(m.recorded, health)
+	return nil
+}
+func (m *mockExchangerService) SetActive(exchangerID int, active bool, change exchangerDomain.StatusChange) error {
+	change.ExchangerID = exchangerID
+	m.changes = append(m.changes, change)
+	return nil
+}
+func (m *mockExchangerService) ReserveCall(exchanger exchangerDomain.Exchanger, at time.Time) (*exchangerDomain.Usage, error) {
+	if quota, limited := m.quotas[exchanger.ID]; limited && m.reserved[exchanger.ID] >= quota {
+		return nil, nil
+	}
+	if last, called := m.lastCall[exchanger.ID]; called && last.After(at.Add(-exchanger.MinInterval)) {
+		return nil, nil
+	}
+	if m.reserved == nil {
+		m.reserved = make(map[int]int)
+		m.lastCall = make(map[int]time.Time)
+	}
+	m.reserved[exchanger.ID]++
+	m.lastCall[exchanger.ID] = at
+	return &exchangerDomain.Usage{ExchangerID: exchanger.ID, Period: exchangerDomain.UsagePeriod(at), Calls: m.reserved[exchanger.ID], LastCallAt: &at}, nil
+}
+func (m *mockExchangerService) GetUsage(exchangerID int, limit int) (*[]exchangerDomain.Usage, error) {
+	return &[]exchangerDomain.Usage{}, nil
+}
 func (m *mockAPIService) EncryptApiKey(value string) (string, error) {
 	return "encrypted-api-key", nil
 }
@@ -64,12 +128,52 @@ func (m *mockUserService) GetByID(id int) (*currencyDomain.Currency, error) {
 func (m *mockUserService) Create(newUser *currencyDomain.Currency) (*currencyDomain.Currency, error) {
 	return m.createFn(newUser)
 }
+func (m *mockUserService) Upsert(currency *currencyDomain.Currency) (*currencyDomain.Currency, error) {
+	return m.upsertFn(currency)
+}
 func (m *mockUserService) Delete(id int) error {
 	return m.deleteFn(id)
 }
+func (m *mockUserService) GetByCodes(codes []string) (*[]currencyDomain.Currency, error) {
+	return m.byCodesFn(codes)
+}
+func (m *mockUserService) GetRateAt(code string, at time.Time) (*currencyDomain.RatePoint, error) {
+	return m.rateAtFn(code, at)
+}
 func (m *mockUserService) Update(id int, userMap map[string]interface{}) (*currencyDomain.Currency, error) {
 	return m.updateFn(id, userMap)
 }
+func (m *mockUserService) RecordRates(currencies []currencyDomain.Currency, recordedAt time.Time) error {
+	m.recorded = append(m.recorded, currencies...)
+	return nil
+}
+func (m *mockUserService) GetOHLC(code string, interval currencyDomain.Interval, from, to time.Time) (*[]currencyDomain.OHLC, error) {
+	return m.ohlcFn(code, interval, from, to)
+}
+func (m *mockUserService) GetStats(code string, from, to time.Time) (*currencyDomain.RateStats, error) {
+	return m.statsFn(code, from, to)
+}
+func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*currencyDomain.SearchResultCurrency, error) {
+	return &currencyDomain.SearchResultCurrency{Data: &[]currencyDomain.Currency{}, Page: filters.Page, PageSize: filters.PageSize}, nil
+}
+func (m *mockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
+	return &[]string{searchText}, nil
+}
+func (m *mockUserService) StreamSearch(filters domain.DataFilters, fn func(*currencyDomain.Currency) error) error {
+	return nil
+}
+func (m *mockUserService) StreamRates(code string, from, to time.Time, fn func(*currencyDomain.RatePoint) error) error {
+	return m.ratesFn(code, from, to, fn)
+}
+
+func (m *mockUserService) ApplyImport(changes []currencyDomain.ImportChange, recordedAt time.Time) (*[]currencyDomain.Currency, error) {
+	return m.importFn(changes, recordedAt)
+}
+
+// newProviderClient calls providers once, so that failing test servers fail fast
+func newProviderClient() provider.IClient {
+	return provider.NewClientWithHTTPClient(&http.Client{Timeout: time.Second}, provider.ClientConfig{MaxResponseBytes: 1 << 20})
+}
 
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
@@ -85,7 +189,7 @@ func TestUserUseCase(t *testing.T) {
 	mockRepoExchanger := &mockExchangerService{}
 	logger := setupLogger(t)
 	apiService := &mockAPIService{}
-	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, apiService, logger)
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, apiService, newProviderClient(), logger)
 
 	t.Run("Test GetAll", func(t *testing.T) {
 		mockRepo.getAllFn = func() (*[]currencyDomain.Currency, error) {
@@ -138,13 +242,716 @@ func TestUserUseCase(t *testing.T) {
 	})
 }
 
+type recordingObserver struct {
+	currencies []currencyDomain.Currency
+	deleted    []currencyDomain.Currency
+	statuses   []RefreshStatus
+}
+
+func (o *recordingObserver) OnRefreshStatus(status RefreshStatus) {
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *recordingObserver) OnCurrencyDeleted(currency currencyDomain.Currency) {
+	o.deleted = append(o.deleted, currency)
+}
+
+func (o *recordingObserver) OnRatesUpdated(currencies []currencyDomain.Currency) {
+	o.currencies = currencies
+}
+
+func TestUpdateExchanges_NotifiesObservers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"EUR":0.9}}`))
+	}))
+	defer server.Close()
+
+	mockRepo := &mockUserService{
+		upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil },
+	}
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "a", Url: server.URL, IsActive: true}, {ID: 2, Name: "b", Url: server.URL, IsActive: true}}, nil
+		},
+	}
+	observer := &recordingObserver{}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t), observer)
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(observer.currencies) != 1 {
+		t.Fatalf("expected 1 currency, got %d", len(observer.currencies))
+	}
+	if observer.currencies[0].Code != "EUR" || observer.currencies[0].Rate != 0.9 {
+		t.Errorf("unexpected currency %+v", observer.currencies[0])
+	}
+	if len(mockRepo.recorded) != 1 || mockRepo.recorded[0].Code != "EUR" {
+		t.Errorf("expected the refreshed rates to be recorded, got %+v", mockRepo.recorded)
+	}
+	if len(observer.statuses) != 2 || observer.statuses[0].State != RefreshStarted || observer.statuses[1].State != RefreshCompleted {
+		t.Fatalf("expected started then completed, got %+v", observer.statuses)
+	}
+	if observer.statuses[1].Currencies != 1 {
+		t.Errorf("expected 1 refreshed currency, got %d", observer.statuses[1].Currencies)
+	}
+}
+
+func rateServers(t *testing.T) (good, bad, forbidden *httptest.Server) {
+	good = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"EUR":0.9}}`))
+	}))
+	bad = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	forbidden = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("skipped exchanger was called: %s", r.URL)
+	}))
+	t.Cleanup(func() { good.Close(); bad.Close(); forbidden.Close() })
+	return good, bad, forbidden
+}
+
+func TestUpdateExchanges_OpensCircuit(t *testing.T) {
+	t.Setenv("EXCHANGER_CIRCUIT_FAILURE_THRESHOLD", "2")
+	good, bad, forbidden := rateServers(t)
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{
+				{ID: 1, Name: "good", Url: good.URL, IsActive: true},
+				{ID: 2, Name: "bad", Url: bad.URL, IsActive: true},
+				{ID: 3, Name: "off", Url: forbidden.URL},
+			}, nil
+		},
+		health: []exchangerDomain.Health{{ExchangerID: 2, State: exchangerDomain.CircuitClosed, ConsecutiveFailures: 1}},
+	}
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("a failing exchanger must not fail the refresh: %v", err)
+	}
+	if len(mockRepoExchanger.recorded) != 2 {
+		t.Fatalf("expected 2 fetches, got %+v", mockRepoExchanger.fetches)
+	}
+	if health := mockRepoExchanger.recorded[1]; health.State != exchangerDomain.CircuitOpen || !health.AutoDisabled || health.LastError == "" {
+		t.Errorf("expected the circuit of bad to open, got %+v", health)
+	}
+	if len(mockRepoExchanger.changes) != 1 || mockRepoExchanger.changes[0].ExchangerID != 2 || mockRepoExchanger.changes[0].IsActive ||
+		mockRepoExchanger.changes[0].Actor != exchangerDomain.StatusActorCircuitBreaker {
+		t.Errorf("expected bad to be turned off, got %+v", mockRepoExchanger.changes)
+	}
+}
+
+func TestUpdateExchanges_ProbesOpenCircuit(t *testing.T) {
+	good, _, forbidden := rateServers(t)
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	recent, expired := now.Add(-time.Minute), now.Add(-time.Hour)
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{
+				{ID: 1, Name: "cooling", Url: forbidden.URL},
+				{ID: 2, Name: "recovered", Url: good.URL},
+			}, nil
+		},
+		health: []exchangerDomain.Health{
+			{ExchangerID: 1, State: exchangerDomain.CircuitOpen, OpenedAt: &recent, AutoDisabled: true},
+			{ExchangerID: 2, State: exchangerDomain.CircuitOpen, OpenedAt: &expired, AutoDisabled: true, ConsecutiveFailures: 3},
+		},
+	}
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t))
+	useCase.(*CurrencyUseCase).now = func() time.Time { return now }
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepoExchanger.recorded) != 1 {
+		t.Fatalf("expected only the expired circuit to be probed, got %+v", mockRepoExchanger.fetches)
+	}
+	if health := mockRepoExchanger.recorded[0]; health.State != exchangerDomain.CircuitClosed || health.AutoDisabled || health.ConsecutiveFailures != 0 {
+		t.Errorf("expected the circuit to close, got %+v", health)
+	}
+	if len(mockRepoExchanger.changes) != 1 || !mockRepoExchanger.changes[0].IsActive {
+		t.Errorf("expected recovered to be turned back on, got %+v", mockRepoExchanger.changes)
+	}
+}
+
+func TestUpdateExchanges_FailsWhenEveryExchangerFails(t *testing.T) {
+	_, bad, _ := rateServers(t)
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "bad", Url: bad.URL, IsActive: true}}, nil
+		},
+	}
+	useCase := NewCurrencyUseCase(&mockUserService{}, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(mockRepoExchanger.recorded) != 1 || mockRepoExchanger.recorded[0].Failures != 1 || mockRepoExchanger.recorded[0].State != exchangerDomain.CircuitClosed {
+		t.Errorf("expected one failure below the threshold, got %+v", mockRepoExchanger.recorded)
+	}
+}
+
+func TestUpdateExchanges_SkipsExchangersOverQuota(t *testing.T) {
+	good, _, forbidden := rateServers(t)
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{
+				{ID: 1, Name: "good", Url: good.URL, IsActive: true},
+				{ID: 2, Name: "exhausted", Url: forbidden.URL, IsActive: true, MonthlyQuota: 100},
+			}, nil
+		},
+		quotas: map[int]int{2: 0},
+	}
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepoExchanger.fetches) != 1 || mockRepoExchanger.fetches[0].ExchangerID != 1 {
+		t.Errorf("expected only good to be fetched, got %+v", mockRepoExchanger.fetches)
+	}
+}
+
+func TestUpdateExchanges_RetriesWithinQuota(t *testing.T) {
+	calls := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"EUR":0.9}}`))
+	}))
+	defer flaky.Close()
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "flaky", Url: flaky.URL, IsActive: true}}, nil
+		},
+		quotas: map[int]int{1: 2},
+	}
+	client := provider.NewClientWithHTTPClient(&http.Client{Timeout: time.Second}, provider.ClientConfig{MaxRetries: 5, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	useCase := NewCurrencyUseCase(&mockUserService{}, mockRepoExchanger, &mockAPIService{}, client, setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err == nil {
+		t.Fatal("expected the refresh to fail once the quota stops the retries")
+	}
+	if calls != 2 || mockRepoExchanger.reserved[1] != 2 {
+		t.Errorf("expected every call to be counted against the quota, got %d calls and %d reserved", calls, mockRepoExchanger.reserved[1])
+	}
+
+	mockRepoExchanger.quotas, mockRepoExchanger.reserved = nil, nil
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase = NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, client, setupLogger(t))
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("expected the retry to succeed: %v", err)
+	}
+	if len(mockRepo.recorded) != 1 || mockRepo.recorded[0].Code != "EUR" {
+		t.Errorf("unexpected recorded rates %+v", mockRepo.recorded)
+	}
+}
+
+func TestUpdateExchanges_RetriesWithinMinInterval(t *testing.T) {
+	calls := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"EUR":0.9}}`))
+	}))
+	defer flaky.Close()
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "flaky", Url: flaky.URL, IsActive: true, MinInterval: time.Hour}}, nil
+		},
+	}
+	client := provider.NewClientWithHTTPClient(&http.Client{Timeout: time.Second}, provider.ClientConfig{MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, client, setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("expected the retry to succeed: %v", err)
+	}
+	if calls != 2 || mockRepoExchanger.reserved[1] != 2 {
+		t.Errorf("expected the retry to be counted and made, got %d calls and %d reserved", calls, mockRepoExchanger.reserved[1])
+	}
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the next refresh to respect the minimum interval, got %d calls", calls)
+	}
+}
+
+func TestUpdateExchanges_FakeProvider(t *testing.T) {
+	fake := fakeprovider.NewServer(fakeprovider.Config{Rates: map[string]float64{"XTS": 2.5}, APIKey: "decrypted-api-key", DriftPercent: 1})
+	defer fake.Close()
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "fake", Url: fake.URL(fakeprovider.FormatData), IsActive: true}}, nil
+		},
+	}
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) { return u, nil }}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	if _, err := useCase.UpdateExchanges(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepo.recorded) != 1 || mockRepo.recorded[0].Rate != fake.Rates()["XTS"] {
+		t.Errorf("expected the drifted rate %v to be recorded, got %+v", fake.Rates()["XTS"], mockRepo.recorded)
+	}
+
+	fake.Update(func(config *fakeprovider.Config) { config.MalformedRate = 1 })
+	if _, err := useCase.UpdateExchanges(); err == nil {
+		t.Fatal("expected a malformed answer to fail the refresh")
+	}
+	if health := mockRepoExchanger.recorded[len(mockRepoExchanger.recorded)-1]; health.ConsecutiveFailures != 1 || health.LastError == "" {
+		t.Errorf("expected the malformed answer to be recorded, got %+v", health)
+	}
+}
+
+func TestUpdateExchanges_FailsWhenARateIsNotStored(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"EUR":0.9,"GBP":0.8}}`))
+	}))
+	defer server.Close()
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) {
+			return &[]exchangerDomain.Exchanger{{ID: 1, Name: "a", Url: server.URL, IsActive: true}}, nil
+		},
+	}
+	mockRepo := &mockUserService{upsertFn: func(u *currencyDomain.Currency) (*currencyDomain.Currency, error) {
+		if u.Code == "GBP" {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+		}
+		return u, nil
+	}}
+	observer := &recordingObserver{}
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t), observer)
+
+	if _, err := useCase.UpdateExchanges(); err == nil {
+		t.Fatal("expected a rate that was not stored to fail the refresh")
+	}
+	if len(mockRepo.recorded) != 1 || mockRepo.recorded[0].Code != "EUR" {
+		t.Errorf("expected only the stored rate to be recorded, got %+v", mockRepo.recorded)
+	}
+	if len(observer.statuses) != 2 || observer.statuses[1].State != RefreshFailed {
+		t.Errorf("expected a failed status, got %+v", observer.statuses)
+	}
+}
+
+func TestUpdateExchanges_ReportsFailedRefresh(t *testing.T) {
+	mockRepoExchanger := &mockExchangerService{
+		getAllFn: func() (*[]exchangerDomain.Exchanger, error) { return nil, errors.New("db down") },
+	}
+	observer := &recordingObserver{}
+	useCase := NewCurrencyUseCase(&mockUserService{}, mockRepoExchanger, &mockAPIService{}, newProviderClient(), setupLogger(t), observer)
+
+	if _, err := useCase.UpdateExchanges(); err == nil {
+		t.Fatal("expected error")
+	}
+	if observer.currencies != nil {
+		t.Error("rates must not be published when the refresh fails")
+	}
+	if len(observer.statuses) != 2 || observer.statuses[1].State != RefreshFailed || observer.statuses[1].Error != "db down" {
+		t.Errorf("expected a failed status, got %+v", observer.statuses)
+	}
+}
+
+func importCatalog() (*[]currencyDomain.Currency, error) {
+	return &[]currencyDomain.Currency{{Code: "EUR", Name: "Euro", Rate: 0.9}, {Code: "GBP", Name: "Pound", Rate: 0.8}}, nil
+}
+
+func TestImport_Applies(t *testing.T) {
+	var applied []currencyDomain.ImportChange
+	mockRepo := &mockUserService{
+		getAllFn: importCatalog,
+		importFn: func(changes []currencyDomain.ImportChange, recordedAt time.Time) (*[]currencyDomain.Currency, error) {
+			applied = changes
+			return &[]currencyDomain.Currency{{Code: "EUR", Rate: 0.92}}, nil
+		},
+	}
+	observer := &recordingObserver{}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t), observer)
+
+	report, err := useCase.Import([]currencyDomain.ImportRow{
+		{Line: 2, Code: "eur", Rate: 0.92},
+		{Line: 3, Code: "GBP", Rate: 0.8},
+		{Line: 4, Code: "CHF", Name: "Swiss franc", Rate: 0.88},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Applied || report.Updated != 1 || report.Unchanged != 1 || report.Created != 1 || report.Failed != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(applied) != 3 || applied[0].Code != "EUR" || *applied[0].OldRate != 0.9 || applied[2].Action != currencyDomain.ImportCreate {
+		t.Errorf("unexpected changes %+v", applied)
+	}
+	if len(observer.currencies) != 1 {
+		t.Errorf("expected the imported rates to be published, got %+v", observer.currencies)
+	}
+}
+
+func TestImport_RejectsInvalidRows(t *testing.T) {
+	mockRepo := &mockUserService{getAllFn: importCatalog}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	report, err := useCase.Import([]currencyDomain.ImportRow{
+		{Line: 2, Code: "EUR", Rate: 0.91},
+		{Line: 3, Code: "EUR", Rate: 0.92},
+		{Line: 4, Code: "GBP", Rate: 8},
+		{Line: 5, Code: "CHF", Rate: 0.88},
+		{Line: 6, Code: "JPY", Name: "Yen", Rate: -1},
+		{Line: 7, Code: "USD", Rate: 1},
+		{Line: 8, Code: "E1", Rate: 1},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Applied || report.Updated != 1 || report.Failed != 6 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	for _, change := range report.Changes[1:] {
+		if change.Err == nil {
+			t.Errorf("expected line %d to be rejected", change.Line)
+		}
+	}
+
+	if _, err := useCase.Import(nil, false); err == nil {
+		t.Error("expected an empty import to be rejected")
+	}
+}
+
+func TestImport_DryRun(t *testing.T) {
+	mockRepo := &mockUserService{getAllFn: importCatalog}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	report, err := useCase.Import([]currencyDomain.ImportRow{{Line: 2, Code: "EUR", Name: "Euro area", Rate: 0.9}}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.DryRun || report.Applied || report.Updated != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestImport_ChangeLimit(t *testing.T) {
+	t.Setenv("CURRENCY_IMPORT_MAX_CHANGE_PERCENT", "0")
+	mockRepo := &mockUserService{getAllFn: importCatalog}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	report, err := useCase.Import([]currencyDomain.ImportRow{{Line: 2, Code: "GBP", Rate: 8}}, true)
+	if err != nil || report.Failed != 0 {
+		t.Errorf("expected the limit to be disabled, got %+v %v", report, err)
+	}
+}
+
+func TestDelete_NotifiesObservers(t *testing.T) {
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*currencyDomain.Currency, error) {
+			if id == 404 {
+				return nil, errors.New("not found")
+			}
+			return &currencyDomain.Currency{ID: id, Code: "EUR"}, nil
+		},
+		deleteFn: func(id int) error { return nil },
+	}
+	observer := &recordingObserver{}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t), observer)
+
+	if err := useCase.Delete(404); err == nil {
+		t.Error("expected error for a missing currency")
+	}
+	if err := useCase.Delete(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(observer.deleted) != 1 || observer.deleted[0].Code != "EUR" {
+		t.Errorf("expected EUR to be reported as deleted, got %+v", observer.deleted)
+	}
+}
+
 func TestNewUserUseCase(t *testing.T) {
 	mockRepo := &mockUserService{}
 	mockRepoExchanger := &mockExchangerService{}
 	loggerInstance := setupLogger(t)
 	apiService := &security.APIService{}
-	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, apiService, loggerInstance)
+	useCase := NewCurrencyUseCase(mockRepo, mockRepoExchanger, apiService, newProviderClient(), loggerInstance)
 	if reflect.TypeOf(useCase).String() != "*currency.CurrencyUseCase" {
 		t.Error("expected *currency.CurrencyUseCase type")
 	}
 }
+
+func TestGetOHLC(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	var gotFrom, gotTo time.Time
+	var gotInterval currencyDomain.Interval
+	mockRepo := &mockUserService{
+		ohlcFn: func(code string, interval currencyDomain.Interval, from, to time.Time) (*[]currencyDomain.OHLC, error) {
+			gotInterval, gotFrom, gotTo = interval, from, to
+			return &[]currencyDomain.OHLC{{Start: from, Open: 1, High: 2, Low: 0.5, Close: 1.5, Samples: 3}}, nil
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t)).(*CurrencyUseCase)
+	useCase.now = func() time.Time { return now }
+
+	buckets, err := useCase.GetOHLC("eur", "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*buckets) != 1 || gotInterval != currencyDomain.IntervalHour {
+		t.Errorf("expected hourly buckets, got %v %+v", gotInterval, buckets)
+	}
+	if !gotTo.Equal(now) || !gotFrom.Equal(now.Add(-defaultOHLCBuckets*time.Hour)) {
+		t.Errorf("unexpected default period %v - %v", gotFrom, gotTo)
+	}
+
+	from := now.Add(-(maxOHLCBuckets + 1) * 24 * time.Hour)
+	invalid := []struct {
+		code     string
+		interval currencyDomain.Interval
+		from     *time.Time
+	}{
+		{"EUR", "5m", nil},
+		{"EURO", currencyDomain.IntervalDay, nil},
+		{"EUR", currencyDomain.IntervalDay, &from},
+		{"EUR", currencyDomain.IntervalDay, &now},
+	}
+	for _, tc := range invalid {
+		_, err := useCase.GetOHLC(tc.code, tc.interval, tc.from, &now)
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %+v, got %v", tc, err)
+		}
+	}
+}
+
+func TestStreamHistory(t *testing.T) {
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	var gotCode string
+	var gotFrom, gotTo time.Time
+	mockRepo := &mockUserService{
+		ratesFn: func(code string, from, to time.Time, fn func(*currencyDomain.RatePoint) error) error {
+			gotCode, gotFrom, gotTo = code, from, to
+			return fn(&currencyDomain.RatePoint{Code: code, Rate: 0.9, RecordedAt: from})
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t)).(*CurrencyUseCase)
+	useCase.now = func() time.Time { return now }
+
+	var streamed []currencyDomain.RatePoint
+	err := useCase.StreamHistory("eur", nil, nil, func(point *currencyDomain.RatePoint) error {
+		streamed = append(streamed, *point)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotCode != "EUR" || !gotTo.Equal(now) || !gotFrom.Equal(now.Add(-defaultHistoryPeriod)) || len(streamed) != 1 {
+		t.Errorf("unexpected stream %s %v - %v: %+v", gotCode, gotFrom, gotTo, streamed)
+	}
+
+	err = useCase.StreamHistory("EURO", nil, nil, func(*currencyDomain.RatePoint) error { return nil })
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	samples := 3
+	mockRepo := &mockUserService{
+		statsFn: func(code string, from, to time.Time) (*currencyDomain.RateStats, error) {
+			return &currencyDomain.RateStats{Code: code, Samples: samples, First: 0.8, Last: 0.9}, nil
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	stats, err := useCase.GetStats("GBP", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.ChangePercent < 12.49 || stats.ChangePercent > 12.51 {
+		t.Errorf("expected a 12.5%% change, got %v", stats.ChangePercent)
+	}
+
+	samples = 0
+	_, err = useCase.GetStats("GBP", nil, nil)
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound {
+		t.Errorf("expected not found without samples, got %v", err)
+	}
+}
+
+func TestConvert_CurrentRates(t *testing.T) {
+	mockRepo := &mockUserService{
+		byCodesFn: func(codes []string) (*[]currencyDomain.Currency, error) {
+			if codes[0] == "EUR" {
+				return &[]currencyDomain.Currency{{Code: "EUR", Rate: 0.8}}, nil
+			}
+			return &[]currencyDomain.Currency{}, nil
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	conversion, err := useCase.Convert("eur", "USD", 100, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conversion.Rate != 1.25 || conversion.Result != 125 {
+		t.Errorf("expected 100 EUR to be 125 USD, got %+v", conversion)
+	}
+	if conversion.From.RecordedAt == nil || conversion.To.RecordedAt != nil {
+		t.Errorf("expected a snapshot only for the non-base leg, got %+v", conversion)
+	}
+
+	_, err = useCase.Convert("EUR", "JPY", 100, nil)
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound {
+		t.Errorf("expected not found for an unknown currency, got %v", err)
+	}
+	_, err = useCase.Convert("EUR", "USD", 0, nil)
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for a zero amount, got %v", err)
+	}
+}
+
+func TestConvert_HistoricalRates(t *testing.T) {
+	at := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+	eurAt := at.Add(-2 * time.Hour)
+	gbpAt := at.Add(-time.Hour)
+	mockRepo := &mockUserService{
+		rateAtFn: func(code string, gotAt time.Time) (*currencyDomain.RatePoint, error) {
+			if !gotAt.Equal(at) {
+				t.Errorf("expected lookups at %v, got %v", at, gotAt)
+			}
+			switch code {
+			case "EUR":
+				return &currencyDomain.RatePoint{Code: code, Rate: 0.8, RecordedAt: eurAt}, nil
+			case "GBP":
+				return &currencyDomain.RatePoint{Code: code, Rate: 0.6, RecordedAt: gbpAt}, nil
+			}
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	conversion, err := useCase.Convert("EUR", "GBP", 100, &at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conversion.Result < 74.99 || conversion.Result > 75.01 {
+		t.Errorf("expected 100 EUR to be 75 GBP, got %v", conversion.Result)
+	}
+	if !conversion.From.RecordedAt.Equal(eurAt) || !conversion.To.RecordedAt.Equal(gbpAt) || conversion.At != &at {
+		t.Errorf("expected the snapshots used to be reported, got %+v", conversion)
+	}
+
+	_, err = useCase.Convert("EUR", "JPY", 100, &at)
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound || appErr.Err.Error() != "no JPY rate was recorded at or before 2026-03-31T23:59:59Z" {
+		t.Errorf("expected a clear not found error, got %v", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	_, err = useCase.Convert("EUR", "GBP", 100, &future)
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for a future time, got %v", err)
+	}
+}
+
+func TestConvertBatch(t *testing.T) {
+	queries := 0
+	at := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	historical := 0
+	mockRepo := &mockUserService{
+		byCodesFn: func(codes []string) (*[]currencyDomain.Currency, error) {
+			queries++
+			if len(codes) != 3 {
+				t.Errorf("expected each current code to be loaded once, got %v", codes)
+			}
+			return &[]currencyDomain.Currency{{Code: "EUR", Rate: 0.8}, {Code: "GBP", Rate: 0.5}}, nil
+		},
+		rateAtFn: func(code string, gotAt time.Time) (*currencyDomain.RatePoint, error) {
+			historical++
+			return &currencyDomain.RatePoint{Code: code, Rate: 0.4, RecordedAt: gotAt}, nil
+		},
+	}
+	useCase := NewCurrencyUseCase(mockRepo, &mockExchangerService{}, &mockAPIService{}, newProviderClient(), setupLogger(t))
+
+	batch, err := useCase.ConvertBatch([]currencyDomain.ConversionRequest{
+		{From: "EUR", To: "USD", Amount: 100},
+		{From: "gbp", To: "EUR", Amount: 10},
+		{From: "EUR", To: "JPY", Amount: 10},
+		{From: "EUR", To: "USD", Amount: -1},
+		{From: "EUR", To: "USD", Amount: 100, At: &at},
+		{From: "USD", To: "EUR", Amount: 1, At: &at},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queries != 1 || historical != 1 {
+		t.Errorf("expected one current query and one historical lookup, got %d and %d", queries, historical)
+	}
+	results := batch.Results
+	if len(results) != 6 {
+		t.Fatalf("expected a result per item, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Conversion.Result != 125 {
+		t.Errorf("unexpected first result %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].Conversion.Result != 16 {
+		t.Errorf("unexpected second result %+v", results[1])
+	}
+	var appErr *domainErrors.AppError
+	if !errors.As(results[2].Err, &appErr) || appErr.Type != domainErrors.NotFound {
+		t.Errorf("expected not found for JPY, got %v", results[2].Err)
+	}
+	if !errors.As(results[3].Err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for a negative amount, got %v", results[3].Err)
+	}
+	if results[4].Err != nil || results[4].Conversion.Result != 250 || results[5].Conversion.Result != 0.4 {
+		t.Errorf("unexpected historical results %+v %+v", results[4], results[5])
+	}
+
+	_, err = useCase.ConvertBatch(nil)
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for an empty batch, got %v", err)
+	}
+	_, err = useCase.ConvertBatch(make([]currencyDomain.ConversionRequest, currencyDomain.MaxBatchConversions+1))
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+		t.Errorf("expected a validation error for an oversized batch, got %v", err)
+	}
+}
+
+func TestNormalizeExchange_Coverage(t *testing.T) {
+	provider := exchangerDomain.Exchanger{Name: "p", Priority: 1, AllowedCodes: []string{"EUR", "GBP"}, DeniedCodes: []string{"GBP"}}
+	rates := normalizeExchange(provider, currencyDomain.RateBase, map[string]float64{"EUR": 0.9, "GBP": 0.8, "JPY": 150})
+	if len(rates) != 1 || rates[0].Currency != "EUR" || rates[0].Weight != exchangerDomain.DefaultWeight || rates[0].Priority != 1 {
+		t.Errorf("expected only EUR with the default weight, got %+v", rates)
+	}
+}
+
+func TestAggregateRates_PriorityAndWeight(t *testing.T) {
+	aggregated := aggregateRates([]NormalizedRate{
+		{Provider: "a", Base: "USD", Currency: "EUR", Rate: 0.5, Priority: 0, Weight: 1},
+		{Provider: "b", Base: "USD", Currency: "EUR", Rate: 0.9, Priority: 1, Weight: 3},
+		{Provider: "c", Base: "USD", Currency: "EUR", Rate: 0.8, Priority: 1, Weight: 1},
+		{Provider: "d", Base: "USD", Currency: "EUR", Rate: 0.1, Priority: 0, Weight: 5},
+		{Provider: "a", Base: "USD", Currency: "JPY", Rate: 150, Priority: 0, Weight: 1},
+		{Provider: "d", Base: "USD", Currency: "JPY", Rate: 160, Priority: 0, Weight: 3},
+	})
+	eur := aggregated["USD_EUR"]
+	if math.Abs(eur.Rate-0.875) > 1e-9 || eur.Sources != 2 {
+		t.Errorf("expected the weighted mean of the priority 1 providers, got %+v", eur)
+	}
+	if jpy := aggregated["USD_JPY"]; math.Abs(jpy.Rate-157.5) > 1e-9 || jpy.Sources != 2 {
+		t.Errorf("expected the weighted priority 0 providers to stand in for JPY, got %+v", jpy)
+	}
+}
@@ -0,0 +1,119 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	currencyDomain "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"go.uber.org/zap"
+)
+
+// Import checks rows against the currency catalog and, unless dryRun is set or a row is rejected,
+// applies them in one transaction. Every applied rate is recorded in the history as a manual
+// import, and the rate observers are told as after a refresh.
+func (s *CurrencyUseCase) Import(rows []currencyDomain.ImportRow, dryRun bool) (*currencyDomain.ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, domainErrors.NewAppError(errors.New("the import has no rows"), domainErrors.ValidationError)
+	}
+	if len(rows) > currencyDomain.MaxImportRows {
+		return nil, domainErrors.NewAppError(fmt.Errorf("an import is limited to %d rows", currencyDomain.MaxImportRows), domainErrors.ValidationError)
+	}
+	catalog, err := s.currencyRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]currencyDomain.Currency, len(*catalog))
+	for _, c := range *catalog {
+		known[c.Code] = c
+	}
+
+	report := &currencyDomain.ImportReport{DryRun: dryRun, Source: currencyDomain.RateSourceManualImport, Changes: make([]currencyDomain.ImportChange, len(rows))}
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		change := s.planImportRow(row, known, seen)
+		report.Changes[i] = change
+		switch {
+		case change.Err != nil:
+			report.Failed++
+		case change.Action == currencyDomain.ImportCreate:
+			report.Created++
+		case change.Action == currencyDomain.ImportUpdate:
+			report.Updated++
+		default:
+			report.Unchanged++
+		}
+	}
+	s.Logger.Info("Planned currency import",
+		zap.Bool("dryRun", dryRun),
+		zap.Int("created", report.Created),
+		zap.Int("updated", report.Updated),
+		zap.Int("failed", report.Failed))
+	if dryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	imported, err := s.currencyRepository.ApplyImport(report.Changes, s.now())
+	if err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	for _, observer := range s.observers {
+		observer.OnRatesUpdated(*imported)
+	}
+	return report, nil
+}
+
+// planImportRow decides what a row does to the catalog. A rate may not move further from the
+// current one than the configured percentage, which catches misplaced decimals and inverted quotes.
+func (s *CurrencyUseCase) planImportRow(row currencyDomain.ImportRow, known map[string]currencyDomain.Currency, seen map[string]int) currencyDomain.ImportChange {
+	change := currencyDomain.ImportChange{Line: row.Line, Code: row.Code, Name: row.Name, Rate: row.Rate}
+	code, err := normalizeCode(row.Code)
+	if err != nil {
+		change.Err = err
+		return change
+	}
+	change.Code = code
+	if line, ok := seen[code]; ok {
+		change.Err = importError("%s is already imported on line %d", code, line)
+		return change
+	}
+	seen[code] = row.Line
+	if code == currencyDomain.RateBase {
+		change.Err = importError("%s is the rate base and always has a rate of 1", currencyDomain.RateBase)
+		return change
+	}
+	if math.IsNaN(row.Rate) || math.IsInf(row.Rate, 0) || row.Rate <= 0 {
+		change.Err = importError("the rate of %s must be a positive number", code)
+		return change
+	}
+
+	current, ok := known[code]
+	if !ok {
+		if row.Name == "" {
+			change.Err = importError("%s is not in the catalog; give it a name to add it", code)
+			return change
+		}
+		change.Action = currencyDomain.ImportCreate
+		return change
+	}
+	oldRate := current.Rate
+	change.OldRate = &oldRate
+	if s.importMaxChangePercent > 0 && oldRate > 0 {
+		if moved := math.Abs(row.Rate-oldRate) / oldRate * 100; moved > float64(s.importMaxChangePercent) {
+			change.Err = importError("the rate of %s moves %.1f%% from %g, more than the allowed %d%%", code, moved, oldRate, s.importMaxChangePercent)
+			return change
+		}
+	}
+	if row.Rate == oldRate && (row.Name == "" || row.Name == current.Name) {
+		change.Action = currencyDomain.ImportUnchanged
+		return change
+	}
+	change.Action = currencyDomain.ImportUpdate
+	return change
+}
+
+func importError(format string, args ...any) error {
+	return domainErrors.NewAppError(fmt.Errorf(format, args...), domainErrors.ValidationError)
+}
@@ -1,6 +1,17 @@
 package exchanger
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	exchangerDomain "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/exchanger"
@@ -8,18 +19,30 @@ import (
 	"go.uber.org/zap"
 )
 
+// usageHistoryPeriods is how many months of usage GetUsage returns
+const usageHistoryPeriods = 12
+
 type IExchangerUseCase interface {
 	GetAll() (*[]exchangerDomain.Exchanger, error)
 	GetByID(id int) (*exchangerDomain.Exchanger, error)
 	Create(newUser *exchangerDomain.Exchanger) (*exchangerDomain.Exchanger, error)
 	Delete(id int) error
 	Update(id int, userMap map[string]interface{}) (*exchangerDomain.Exchanger, error)
+	SearchPaginated(filters domain.DataFilters) (*exchangerDomain.SearchResultExchanger, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetHealth(id int) (*exchangerDomain.Health, error)
+	GetUsage(id int) (*exchangerDomain.UsageReport, error)
 }
 
 type ExchangerUseCase struct {
 	exchangerRepository exchanger.ExchangerRepositoryInterface
 	apiService          security.IAPIService
 	Logger              *logger.Logger
+	now                 func() time.Time
+	// healthWindow is how far back the latency percentiles of GetHealth look
+	healthWindow time.Duration
+	// quotaWarnings are the percentages of a monthly quota GetUsage warns at, in ascending order
+	quotaWarnings []int
 }
 
 func NewExchangerUseCase(exchangerRepository exchanger.ExchangerRepositoryInterface, apiService security.IAPIService, logger *logger.Logger) IExchangerUseCase {
@@ -27,6 +50,9 @@ func NewExchangerUseCase(exchangerRepository exchanger.ExchangerRepositoryInterf
 		exchangerRepository: exchangerRepository,
 		apiService:          apiService,
 		Logger:              logger,
+		now:                 time.Now,
+		healthWindow:        time.Duration(getEnvAsIntOrDefault("EXCHANGER_HEALTH_WINDOW_HOURS", 24)) * time.Hour,
+		quotaWarnings:       getEnvAsPercentsOrDefault("EXCHANGER_QUOTA_WARNING_PERCENTS", []int{80, 95}),
 	}
 }
 
@@ -42,8 +68,23 @@ func (s *ExchangerUseCase) GetByID(id int) (*exchangerDomain.Exchanger, error) {
 
 func (s *ExchangerUseCase) Create(newExchanger *exchangerDomain.Exchanger) (*exchangerDomain.Exchanger, error) {
 	s.Logger.Info("Creating new user", zap.String("Name", newExchanger.Name))
-	//Encrypt  the apiKey
+	if newExchanger.Weight == 0 {
+		newExchanger.Weight = exchangerDomain.DefaultWeight
+	}
+	if err := validateWeighting(newExchanger.Priority, newExchanger.Weight); err != nil {
+		return nil, err
+	}
+	if err := validateLimits(newExchanger.MonthlyQuota, newExchanger.MinInterval); err != nil {
+		return nil, err
+	}
 	var err error
+	if newExchanger.AllowedCodes, err = normalizeCodes("allowedCodes", newExchanger.AllowedCodes); err != nil {
+		return nil, err
+	}
+	if newExchanger.DeniedCodes, err = normalizeCodes("deniedCodes", newExchanger.DeniedCodes); err != nil {
+		return nil, err
+	}
+	//Encrypt  the apiKey
 	newExchanger.ApiKey, err = s.apiService.EncryptApiKey(newExchanger.ApiKey)
 	if err != nil {
 		return nil, err
@@ -56,6 +97,8 @@ func (s *ExchangerUseCase) Delete(id int) error {
 	return s.exchangerRepository.Delete(id)
 }
 
+// Update changes an exchanger. Turning it on or off is added to its audit trail, and turning it
+// on closes its circuit.
 func (s *ExchangerUseCase) Update(id int, userMap map[string]interface{}) (*exchangerDomain.Exchanger, error) {
 	s.Logger.Info("Updating user", zap.Int("id", id))
 	//Encrypt  the apiKey
@@ -66,5 +109,226 @@ func (s *ExchangerUseCase) Update(id int, userMap map[string]interface{}) (*exch
 			return nil, err
 		}
 	}
-	return s.exchangerRepository.Update(id, userMap)
+	if err := normalizeUpdate(userMap); err != nil {
+		return nil, err
+	}
+	active, toggled := userMap["isActive"].(bool)
+	if !toggled {
+		return s.exchangerRepository.Update(id, userMap)
+	}
+
+	current, err := s.exchangerRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	delete(userMap, "isActive")
+	if len(userMap) > 0 {
+		if _, err := s.exchangerRepository.Update(id, userMap); err != nil {
+			return nil, err
+		}
+	}
+	if current.IsActive != active {
+		change := exchangerDomain.StatusChange{IsActive: active, Actor: exchangerDomain.StatusActorManual, Reason: "updated through the API", CreatedAt: s.now()}
+		if err := s.exchangerRepository.SetActive(id, active, change); err != nil {
+			return nil, err
+		}
+	}
+	return s.exchangerRepository.GetByID(id)
+}
+
+func (s *ExchangerUseCase) SearchPaginated(filters domain.DataFilters) (*exchangerDomain.SearchResultExchanger, error) {
+	s.Logger.Info("Searching exchangers with pagination",
+		zap.Int("page", filters.Page),
+		zap.Int("pageSize", filters.PageSize))
+	return s.exchangerRepository.SearchPaginated(filters)
+}
+
+func (s *ExchangerUseCase) SearchByProperty(property string, searchText string) (*[]string, error) {
+	s.Logger.Info("Searching exchangers by property",
+		zap.String("property", property),
+		zap.String("searchText", searchText))
+	return s.exchangerRepository.SearchByProperty(property, searchText)
+}
+
+// GetHealth returns how an exchanger has been answering refreshes and its latest status changes
+func (s *ExchangerUseCase) GetHealth(id int) (*exchangerDomain.Health, error) {
+	s.Logger.Info("Getting exchanger health", zap.Int("id", id))
+	if _, err := s.exchangerRepository.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.exchangerRepository.GetHealth(id, s.now().Add(-s.healthWindow))
+}
+
+// GetUsage returns the calls refreshes made to an exchanger this month against its quota, with a
+// warning for each configured share of the quota it has reached
+func (s *ExchangerUseCase) GetUsage(id int) (*exchangerDomain.UsageReport, error) {
+	s.Logger.Info("Getting exchanger usage", zap.Int("id", id))
+	current, err := s.exchangerRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.exchangerRepository.GetUsage(id, usageHistoryPeriods)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	report := &exchangerDomain.UsageReport{
+		ExchangerID:  id,
+		Period:       exchangerDomain.UsagePeriod(now),
+		MonthlyQuota: current.MonthlyQuota,
+		MinInterval:  current.MinInterval,
+		Warnings:     []string{},
+		History:      *history,
+	}
+	if len(report.History) > 0 {
+		latest := report.History[0]
+		report.LastCallAt = latest.LastCallAt
+		if latest.Period == report.Period {
+			report.Calls = latest.Calls
+		}
+	}
+	if report.LastCallAt != nil && current.MinInterval > 0 {
+		next := report.LastCallAt.Add(current.MinInterval)
+		if next.After(now) {
+			report.NextCallAt = &next
+		}
+	}
+	if current.MonthlyQuota > 0 {
+		if report.Calls >= current.MonthlyQuota {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("monthly quota of %d calls used up, refreshes skip this exchanger until next month", current.MonthlyQuota))
+		} else {
+			for i := len(s.quotaWarnings) - 1; i >= 0; i-- {
+				if report.Calls*100 >= current.MonthlyQuota*s.quotaWarnings[i] {
+					report.Warnings = append(report.Warnings, fmt.Sprintf("%d%% of the monthly quota of %d calls used", s.quotaWarnings[i], current.MonthlyQuota))
+					break
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// normalizeUpdate checks the priority, weight, limits and currency lists of an update and converts
+// them from their JSON types
+func normalizeUpdate(userMap map[string]interface{}) error {
+	if value, ok := userMap["priority"]; ok {
+		priority, ok := value.(float64)
+		if !ok || priority != math.Trunc(priority) {
+			return invalid(errors.New("priority must be a whole number"))
+		}
+		if err := validateWeighting(int(priority), exchangerDomain.DefaultWeight); err != nil {
+			return err
+		}
+		userMap["priority"] = int(priority)
+	}
+	if value, ok := userMap["weight"]; ok {
+		weight, ok := value.(float64)
+		if !ok {
+			return invalid(errors.New("weight must be a number"))
+		}
+		if err := validateWeighting(0, weight); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{"monthlyQuota", "minInterval"} {
+		value, ok := userMap[key]
+		if !ok {
+			continue
+		}
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) || number < 0 {
+			return invalid(fmt.Errorf("%s must be a whole number of at least 0", key))
+		}
+		userMap[key] = int(number)
+	}
+	for _, key := range []string{"allowedCodes", "deniedCodes"} {
+		value, ok := userMap[key]
+		if !ok {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return invalid(fmt.Errorf("%s must be a list of currency codes", key))
+		}
+		codes := make([]string, len(items))
+		for i, item := range items {
+			if codes[i], ok = item.(string); !ok {
+				return invalid(fmt.Errorf("%s must be a list of currency codes", key))
+			}
+		}
+		normalized, err := normalizeCodes(key, codes)
+		if err != nil {
+			return err
+		}
+		userMap[key] = normalized
+	}
+	return nil
+}
+
+func validateWeighting(priority int, weight float64) error {
+	if priority < 0 {
+		return invalid(errors.New("priority cannot be negative"))
+	}
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		return invalid(errors.New("weight must be a positive number"))
+	}
+	return nil
+}
+
+func validateLimits(monthlyQuota int, minInterval time.Duration) error {
+	if monthlyQuota < 0 {
+		return invalid(errors.New("monthlyQuota cannot be negative"))
+	}
+	if minInterval < 0 {
+		return invalid(errors.New("minInterval cannot be negative"))
+	}
+	return nil
+}
+
+func normalizeCodes(field string, codes []string) ([]string, error) {
+	normalized := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+			return nil, invalid(fmt.Errorf("%s holds an invalid currency code %q", field, code))
+		}
+		if !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized, nil
+}
+
+func invalid(err error) error {
+	return domainErrors.NewAppError(err, domainErrors.ValidationError)
+}
+
+// getEnvAsPercentsOrDefault reads a comma separated list of percentages between 1 and 100,
+// falling back to defaultValue when any of them is not one
+func getEnvAsPercentsOrDefault(key string, defaultValue []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var percents []int
+	for _, item := range strings.Split(value, ",") {
+		percent, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || percent < 1 || percent > 100 {
+			return defaultValue
+		}
+		percents = append(percents, percent)
+	}
+	sort.Ints(percents)
+	return percents
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
 }
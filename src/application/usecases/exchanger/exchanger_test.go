@@ -4,7 +4,9 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	exchangerDomain "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	security "github.com/gbrayhan/microservices-go/src/infrastructure/security"
@@ -18,6 +20,9 @@ type mockUserService struct {
 	createFn  func(u *exchangerDomain.Exchanger) (*exchangerDomain.Exchanger, error)
 	deleteFn  func(id int) error
 	updateFn  func(id int, m map[string]interface{}) (*exchangerDomain.Exchanger, error)
+	healthFn  func(id int, since time.Time) (*exchangerDomain.Health, error)
+	usage     []exchangerDomain.Usage
+	changes   []exchangerDomain.StatusChange
 }
 
 func (m *mockAPIService) EncryptApiKey(value string) (string, error) {
@@ -46,6 +51,32 @@ func (m *mockUserService) Delete(id int) error {
 func (m *mockUserService) Update(id int, userMap map[string]interface{}) (*exchangerDomain.Exchanger, error) {
 	return m.updateFn(id, userMap)
 }
+func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*exchangerDomain.SearchResultExchanger, error) {
+	return &exchangerDomain.SearchResultExchanger{Data: &[]exchangerDomain.Exchanger{}, Page: filters.Page, PageSize: filters.PageSize}, nil
+}
+func (m *mockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
+	return &[]string{searchText}, nil
+}
+
+func (m *mockUserService) GetAllHealth() (*[]exchangerDomain.Health, error) {
+	return &[]exchangerDomain.Health{}, nil
+}
+func (m *mockUserService) GetHealth(exchangerID int, since time.Time) (*exchangerDomain.Health, error) {
+	return m.healthFn(exchangerID, since)
+}
+func (m *mockUserService) RecordFetch(fetch exchangerDomain.Fetch, health exchangerDomain.Health) error {
+	return nil
+}
+func (m *mockUserService) SetActive(exchangerID int, active bool, change exchangerDomain.StatusChange) error {
+	m.changes = append(m.changes, change)
+	return nil
+}
+func (m *mockUserService) ReserveCall(exchanger exchangerDomain.Exchanger, at time.Time) (*exchangerDomain.Usage, error) {
+	return nil, nil
+}
+func (m *mockUserService) GetUsage(exchangerID int, limit int) (*[]exchangerDomain.Usage, error) {
+	return &m.usage, nil
+}
 
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
@@ -159,3 +190,171 @@ func TestNewUserUseCase(t *testing.T) {
 		t.Error("expected *exchanger.ExchangerUseCase type")
 	}
 }
+
+func TestUpdate_AuditsStatusChanges(t *testing.T) {
+	active := false
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*exchangerDomain.Exchanger, error) {
+			return &exchangerDomain.Exchanger{ID: id, Name: "Provider", IsActive: active}, nil
+		},
+		updateFn: func(id int, m map[string]interface{}) (*exchangerDomain.Exchanger, error) {
+			if _, ok := m["isActive"]; ok {
+				t.Error("isActive must go through SetActive")
+			}
+			return &exchangerDomain.Exchanger{ID: id}, nil
+		},
+	}
+	useCase := NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+
+	if _, err := useCase.Update(1, map[string]interface{}{"isActive": true, "name": "Provider"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepo.changes) != 1 || !mockRepo.changes[0].IsActive || mockRepo.changes[0].Actor != exchangerDomain.StatusActorManual {
+		t.Errorf("expected a manual activation, got %+v", mockRepo.changes)
+	}
+
+	active = true
+	if _, err := useCase.Update(1, map[string]interface{}{"isActive": true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepo.changes) != 1 {
+		t.Errorf("an unchanged status must not be audited, got %+v", mockRepo.changes)
+	}
+}
+
+func TestGetHealth(t *testing.T) {
+	now := time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC)
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*exchangerDomain.Exchanger, error) {
+			if id != 1 {
+				return nil, errors.New("not found")
+			}
+			return &exchangerDomain.Exchanger{ID: id}, nil
+		},
+		healthFn: func(id int, since time.Time) (*exchangerDomain.Health, error) {
+			if !since.Equal(now.Add(-24 * time.Hour)) {
+				t.Errorf("unexpected window start %v", since)
+			}
+			return &exchangerDomain.Health{ExchangerID: id, State: exchangerDomain.CircuitClosed}, nil
+		},
+	}
+	useCase := NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+	useCase.(*ExchangerUseCase).now = func() time.Time { return now }
+
+	if _, err := useCase.GetHealth(2); err == nil {
+		t.Error("expected an unknown exchanger to fail")
+	}
+	health, err := useCase.GetHealth(1)
+	if err != nil || health.State != exchangerDomain.CircuitClosed {
+		t.Errorf("unexpected health %+v %v", health, err)
+	}
+}
+
+func TestCreate_NormalizesCoverage(t *testing.T) {
+	mockRepo := &mockUserService{
+		createFn: func(e *exchangerDomain.Exchanger) (*exchangerDomain.Exchanger, error) { return e, nil },
+	}
+	useCase := NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+
+	created, err := useCase.Create(&exchangerDomain.Exchanger{Name: "Provider", AllowedCodes: []string{" eur", "GBP", "EUR"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Weight != exchangerDomain.DefaultWeight || !reflect.DeepEqual(created.AllowedCodes, []string{"EUR", "GBP"}) {
+		t.Errorf("unexpected exchanger %+v", created)
+	}
+
+	for _, invalid := range []exchangerDomain.Exchanger{
+		{Priority: -1},
+		{Weight: -2},
+		{DeniedCodes: []string{"EURO"}},
+	} {
+		if _, err := useCase.Create(&invalid); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
+
+func TestUpdate_NormalizesCoverage(t *testing.T) {
+	var updated map[string]interface{}
+	mockRepo := &mockUserService{
+		updateFn: func(id int, m map[string]interface{}) (*exchangerDomain.Exchanger, error) {
+			updated = m
+			return &exchangerDomain.Exchanger{ID: id}, nil
+		},
+	}
+	useCase := NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+
+	_, err := useCase.Update(1, map[string]interface{}{"priority": 2.0, "weight": 0.5, "deniedCodes": []interface{}{"ars"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated["priority"] != 2 || !reflect.DeepEqual(updated["deniedCodes"], []string{"ARS"}) {
+		t.Errorf("unexpected update %+v", updated)
+	}
+
+	_, err = useCase.Update(1, map[string]interface{}{"monthlyQuota": 1000.0, "minInterval": 60.0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated["monthlyQuota"] != 1000 || updated["minInterval"] != 60 {
+		t.Errorf("unexpected update %+v", updated)
+	}
+
+	for _, invalid := range []map[string]interface{}{
+		{"priority": 1.5},
+		{"monthlyQuota": -1.0},
+		{"minInterval": 1.5},
+		{"weight": 0.0},
+		{"allowedCodes": "EUR"},
+		{"allowedCodes": []interface{}{"EUR", 3.0}},
+	} {
+		if _, err := useCase.Update(1, invalid); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	now := time.Date(2026, 4, 20, 12, 0, 0, 0, time.UTC)
+	lastCall := now.Add(-10 * time.Minute)
+	quota := 1000
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*exchangerDomain.Exchanger, error) {
+			return &exchangerDomain.Exchanger{ID: id, MonthlyQuota: quota, MinInterval: time.Hour}, nil
+		},
+		usage: []exchangerDomain.Usage{
+			{ExchangerID: 1, Period: "2026-04", Calls: 850, LastCallAt: &lastCall},
+			{ExchangerID: 1, Period: "2026-03", Calls: 1000},
+		},
+	}
+	useCase := NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+	useCase.(*ExchangerUseCase).now = func() time.Time { return now }
+
+	report, err := useCase.GetUsage(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Period != "2026-04" || report.Calls != 850 || len(report.History) != 2 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.NextCallAt == nil || !report.NextCallAt.Equal(lastCall.Add(time.Hour)) {
+		t.Errorf("expected the next call after the minimum interval, got %v", report.NextCallAt)
+	}
+	if !reflect.DeepEqual(report.Warnings, []string{"80% of the monthly quota of 1000 calls used"}) {
+		t.Errorf("unexpected warnings %v", report.Warnings)
+	}
+
+	quota = 850
+	if report, _ = useCase.GetUsage(1); len(report.Warnings) != 1 || report.Warnings[0] != "monthly quota of 850 calls used up, refreshes skip this exchanger until next month" {
+		t.Errorf("expected the quota to be used up, got %v", report.Warnings)
+	}
+
+	t.Setenv("EXCHANGER_QUOTA_WARNING_PERCENTS", "50")
+	quota = 2000
+	useCase = NewExchangerUseCase(mockRepo, &mockAPIService{}, setupLogger(t))
+	useCase.(*ExchangerUseCase).now = func() time.Time { return now.AddDate(0, 1, 0) }
+	if report, _ = useCase.GetUsage(1); report.Calls != 0 || report.NextCallAt != nil || len(report.Warnings) != 0 {
+		t.Errorf("expected a fresh period, got %+v", report)
+	}
+}
@@ -0,0 +1,175 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/oauthclient"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"go.uber.org/zap"
+)
+
+const (
+	clientIDLength     = 12
+	clientSecretLength = 32
+)
+
+type IOAuthUseCase interface {
+	CreateClient(adminID int, name string, scopes []string, audiences []string) (*domainOAuthClient.Client, string, error)
+	GetClients(adminID int) (*[]domainOAuthClient.Client, error)
+	DeleteClient(adminID int, id int) error
+	IssueClientToken(clientID string, clientSecret string, requestedScopes []string) (*security.AppToken, []string, error)
+}
+
+type OAuthUseCase struct {
+	OAuthClientRepository oauthclient.OAuthClientRepositoryInterface
+	UserRepository        user.UserRepositoryInterface
+	APIService            security.IAPIService
+	JWTService            security.IJWTService
+	Logger                *logger.Logger
+}
+
+func NewOAuthUseCase(
+	oauthClientRepository oauthclient.OAuthClientRepositoryInterface,
+	userRepository user.UserRepositoryInterface,
+	apiService security.IAPIService,
+	jwtService security.IJWTService,
+	loggerInstance *logger.Logger,
+) IOAuthUseCase {
+	return &OAuthUseCase{
+		OAuthClientRepository: oauthClientRepository,
+		UserRepository:        userRepository,
+		APIService:            apiService,
+		JWTService:            jwtService,
+		Logger:                loggerInstance,
+	}
+}
+
+// CreateClient registers a new client and returns it together with its plain secret, which is never stored
+func (s *OAuthUseCase) CreateClient(adminID int, name string, scopes []string, audiences []string) (*domainOAuthClient.Client, string, error) {
+	s.Logger.Info("Creating OAuth client", zap.Int("adminID", adminID), zap.String("name", name))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, "", err
+	}
+	if len(scopes) == 0 {
+		return nil, "", domainErrors.NewAppError(errors.New("at least one scope is required"), domainErrors.ValidationError)
+	}
+	for _, requested := range scopes {
+		if !scope.IsValid(requested) {
+			return nil, "", domainErrors.NewAppError(fmt.Errorf("unknown scope %q", requested), domainErrors.ValidationError)
+		}
+	}
+	for _, audience := range audiences {
+		if strings.TrimSpace(audience) == "" || strings.Contains(audience, ",") {
+			return nil, "", domainErrors.NewAppError(fmt.Errorf("invalid audience %q", audience), domainErrors.ValidationError)
+		}
+	}
+
+	clientKey, err := s.APIService.GenerateApiKey(clientIDLength)
+	if err != nil {
+		s.Logger.Error("Error generating OAuth client id", zap.Error(err))
+		return nil, "", err
+	}
+	secret, err := s.APIService.GenerateApiKey(clientSecretLength)
+	if err != nil {
+		s.Logger.Error("Error generating OAuth client secret", zap.Error(err))
+		return nil, "", err
+	}
+	secret = strings.TrimRight(secret, "=")
+
+	client, err := s.OAuthClientRepository.Create(&domainOAuthClient.Client{
+		ClientID:   domainOAuthClient.ClientIDPrefix + strings.TrimRight(clientKey, "="),
+		Name:       name,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+		Audiences:  audiences,
+		CreatedBy:  adminID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	s.Logger.Info("OAuth client created", zap.Int("id", client.ID), zap.String("clientID", client.ClientID))
+	return client, secret, nil
+}
+
+func (s *OAuthUseCase) GetClients(adminID int) (*[]domainOAuthClient.Client, error) {
+	s.Logger.Info("Getting OAuth clients", zap.Int("adminID", adminID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	return s.OAuthClientRepository.GetAll()
+}
+
+func (s *OAuthUseCase) DeleteClient(adminID int, id int) error {
+	s.Logger.Info("Deleting OAuth client", zap.Int("adminID", adminID), zap.Int("id", id))
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+	return s.OAuthClientRepository.Delete(id)
+}
+
+// IssueClientToken implements the client_credentials grant. When no scope is requested the
+// token carries every scope granted to the client; otherwise only the requested ones, which
+// must all have been granted.
+func (s *OAuthUseCase) IssueClientToken(clientID string, clientSecret string, requestedScopes []string) (*security.AppToken, []string, error) {
+	s.Logger.Info("Issuing OAuth client token", zap.String("clientID", clientID))
+	client, err := s.OAuthClientRepository.GetByClientID(clientID)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return nil, nil, domainErrors.NewAppError(errors.New("invalid client credentials"), domainErrors.NotAuthenticated)
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(clientSecret)), []byte(client.SecretHash)) != 1 {
+		s.Logger.Warn("OAuth client authentication failed", zap.String("clientID", clientID))
+		return nil, nil, domainErrors.NewAppError(errors.New("invalid client credentials"), domainErrors.NotAuthenticated)
+	}
+
+	granted := client.Scopes
+	if len(requestedScopes) > 0 {
+		for _, requested := range requestedScopes {
+			if !scope.Contains(client.Scopes, requested) {
+				s.Logger.Warn("OAuth client requested a scope it was not granted", zap.String("clientID", clientID), zap.String("scope", requested))
+				return nil, nil, domainErrors.NewAppError(fmt.Errorf("scope %q is not granted to this client", requested), domainErrors.ValidationError)
+			}
+		}
+		granted = requestedScopes
+	}
+
+	token, err := s.JWTService.GenerateClientToken(client.ClientID, granted, client.Audiences)
+	if err != nil {
+		s.Logger.Error("Error generating OAuth client token", zap.Error(err), zap.String("clientID", clientID))
+		return nil, nil, err
+	}
+	s.Logger.Info("OAuth client token issued", zap.String("clientID", clientID), zap.Strings("scopes", granted))
+	return token, granted, nil
+}
+
+func (s *OAuthUseCase) requireAdmin(userID int) error {
+	actor, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != domainUser.RoleAdmin {
+		s.Logger.Warn("Admin operation rejected", zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return nil
+}
+
+// hashSecret uses a plain SHA-256 for the same reason as API tokens: secrets are long random strings
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,186 @@
+package oauth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type mockOAuthClientRepository struct {
+	clients map[string]*domainOAuthClient.Client
+	created *domainOAuthClient.Client
+}
+
+func (m *mockOAuthClientRepository) Create(client *domainOAuthClient.Client) (*domainOAuthClient.Client, error) {
+	m.created = client
+	saved := *client
+	saved.ID = 1
+	return &saved, nil
+}
+func (m *mockOAuthClientRepository) GetByClientID(clientID string) (*domainOAuthClient.Client, error) {
+	if client, ok := m.clients[clientID]; ok {
+		return client, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+func (m *mockOAuthClientRepository) GetAll() (*[]domainOAuthClient.Client, error) {
+	return &[]domainOAuthClient.Client{}, nil
+}
+func (m *mockOAuthClientRepository) Delete(id int) error {
+	return nil
+}
+
+type mockUserRepository struct {
+	user.UserRepositoryInterface
+	users map[int]*domainUser.User
+}
+
+func (m *mockUserRepository) GetByID(id int) (*domainUser.User, error) {
+	if u, ok := m.users[id]; ok {
+		return u, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type mockJWTService struct {
+	clientID  string
+	scopes    []string
+	audiences []string
+}
+
+func (m *mockJWTService) GenerateJWTToken(userID int, tokenType string) (*security.AppToken, error) {
+	return nil, errors.New("not implemented")
+}
+func (m *mockJWTService) GenerateClientToken(clientID string, scopes []string, audiences []string) (*security.AppToken, error) {
+	m.clientID = clientID
+	m.scopes = scopes
+	m.audiences = audiences
+	return &security.AppToken{Token: "signed", TokenType: security.Access, ExpirationTime: time.Now().Add(time.Hour)}, nil
+}
+func (m *mockJWTService) JWKS() security.JSONWebKeySet {
+	return security.JSONWebKeySet{}
+}
+func (m *mockJWTService) GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error) {
+	return nil, errors.New("not implemented")
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, clientRepo *mockOAuthClientRepository, jwtService *mockJWTService) IOAuthUseCase {
+	userRepo := &mockUserRepository{users: map[int]*domainUser.User{
+		1: {ID: 1, Role: domainUser.RoleAdmin},
+		2: {ID: 2, Role: "USER"},
+	}}
+	return NewOAuthUseCase(clientRepo, userRepo, security.NewAPIService(), jwtService, setupLogger(t))
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected AppError of type %s, got %v", expected, err)
+	}
+	if appErr.Type != expected {
+		t.Errorf("expected error type %s, got %s", expected, appErr.Type)
+	}
+}
+
+func TestOAuthUseCase_CreateClient(t *testing.T) {
+	clientRepo := &mockOAuthClientRepository{}
+	uc := newTestUseCase(t, clientRepo, &mockJWTService{})
+
+	client, secret, err := uc.CreateClient(1, "billing", []string{"currency:read"}, []string{"billing-api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(client.ClientID, domainOAuthClient.ClientIDPrefix) {
+		t.Errorf("expected client id with prefix %q, got %q", domainOAuthClient.ClientIDPrefix, client.ClientID)
+	}
+	if secret == "" || clientRepo.created.SecretHash != hashSecret(secret) {
+		t.Error("expected only the secret hash to be stored")
+	}
+	if strings.Join(clientRepo.created.Audiences, ",") != "billing-api" {
+		t.Errorf("expected audiences to be stored, got %v", clientRepo.created.Audiences)
+	}
+	if client.CreatedBy != 1 {
+		t.Errorf("expected client created by admin 1, got %d", client.CreatedBy)
+	}
+}
+
+func TestOAuthUseCase_CreateClient_Rejected(t *testing.T) {
+	uc := newTestUseCase(t, &mockOAuthClientRepository{}, &mockJWTService{})
+
+	_, _, err := uc.CreateClient(2, "billing", []string{"currency:read"}, nil)
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+
+	_, _, err = uc.CreateClient(1, "billing", []string{"currency:admin"}, nil)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	_, _, err = uc.CreateClient(1, "billing", nil, nil)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	_, _, err = uc.CreateClient(1, "billing", []string{"currency:read"}, []string{"a,b"})
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestOAuthUseCase_IssueClientToken(t *testing.T) {
+	clientRepo := &mockOAuthClientRepository{clients: map[string]*domainOAuthClient.Client{
+		"cli_test": {ID: 1, ClientID: "cli_test", SecretHash: hashSecret("s3cret"), Scopes: []string{"currency:read", "exchanger:read"}, Audiences: []string{"billing-api"}},
+	}}
+
+	tests := []struct {
+		name       string
+		clientID   string
+		secret     string
+		requested  []string
+		wantScopes []string
+		wantErr    domainErrors.ErrorType
+	}{
+		{name: "all granted scopes", clientID: "cli_test", secret: "s3cret", wantScopes: []string{"currency:read", "exchanger:read"}},
+		{name: "narrowed scopes", clientID: "cli_test", secret: "s3cret", requested: []string{"currency:read"}, wantScopes: []string{"currency:read"}},
+		{name: "scope not granted", clientID: "cli_test", secret: "s3cret", requested: []string{"currency:write"}, wantErr: domainErrors.ValidationError},
+		{name: "wrong secret", clientID: "cli_test", secret: "wrong", wantErr: domainErrors.NotAuthenticated},
+		{name: "unknown client", clientID: "cli_missing", secret: "s3cret", wantErr: domainErrors.NotAuthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtService := &mockJWTService{}
+			uc := newTestUseCase(t, clientRepo, jwtService)
+			token, scopes, err := uc.IssueClientToken(tt.clientID, tt.secret, tt.requested)
+			if tt.wantErr != "" {
+				assertErrorType(t, err, tt.wantErr)
+				if jwtService.clientID != "" {
+					t.Error("expected no token to be issued")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.Token != "signed" || jwtService.clientID != tt.clientID {
+				t.Errorf("expected token issued for %s", tt.clientID)
+			}
+			if strings.Join(jwtService.audiences, ",") != "billing-api" {
+				t.Errorf("expected token issued for the client audiences, got %v", jwtService.audiences)
+			}
+			if strings.Join(scopes, " ") != strings.Join(tt.wantScopes, " ") || strings.Join(jwtService.scopes, " ") != strings.Join(tt.wantScopes, " ") {
+				t.Errorf("expected scopes %v, got %v", tt.wantScopes, scopes)
+			}
+		})
+	}
+}
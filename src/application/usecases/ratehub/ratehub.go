@@ -0,0 +1,338 @@
+package ratehub
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	useCaseCurrency "github.com/gbrayhan/microservices-go/src/application/usecases/currency"
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// Message types sent to clients
+const (
+	TypeSubscribed   = "subscribed"
+	TypeUnsubscribed = "unsubscribed"
+	TypeTick         = "tick"
+	TypeAlert        = "alert"
+	TypeRefresh      = "refresh"
+	TypeError        = "error"
+	TypePong         = "pong"
+)
+
+type IRateHubUseCase interface {
+	OnRatesUpdated(currencies []domainCurrency.Currency)
+	OnRefreshStatus(status useCaseCurrency.RefreshStatus)
+	Notify(event *domainAlert.Event) error
+	Connect(userID int) (*Client, error)
+	Disconnect(client *Client)
+	Subscribe(client *Client, pairs []string) error
+	Unsubscribe(client *Client, pairs []string) error
+	Send(client *Client, message any)
+}
+
+// SubscriptionMessage acknowledges a subscription change with the pairs the client now follows
+type SubscriptionMessage struct {
+	Type  string   `json:"type"`
+	Pairs []string `json:"pairs"`
+}
+
+// TickMessage carries the current rate of a subscribed pair
+type TickMessage struct {
+	Type      string    `json:"type"`
+	Pair      string    `json:"pair"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AlertMessage carries an alert that triggered for the connected user
+type AlertMessage struct {
+	Type          string    `json:"type"`
+	EventID       int       `json:"eventId"`
+	RuleID        int       `json:"ruleId"`
+	Pair          string    `json:"pair"`
+	Condition     string    `json:"condition"`
+	Threshold     float64   `json:"threshold"`
+	Rate          float64   `json:"rate"`
+	ReferenceRate float64   `json:"referenceRate,omitempty"`
+	TriggeredAt   time.Time `json:"triggeredAt"`
+}
+
+// RefreshMessage reports the progress of the rate refresh job
+type RefreshMessage struct {
+	Type       string    `json:"type"`
+	State      string    `json:"state"`
+	Currencies int       `json:"currencies,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// PongMessage answers a client ping
+type PongMessage struct {
+	Type string `json:"type"`
+}
+
+// ErrorMessage reports a request the hub could not honour; the connection stays open
+type ErrorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Client is one connection to the hub. Messages is closed when the client is disconnected;
+// Dropped then tells whether the hub did it because the client stopped keeping up.
+type Client struct {
+	UserID   int
+	Messages <-chan any
+	messages chan any
+	pairs    map[domainWatchlist.Pair]bool
+	dropped  atomic.Bool
+}
+
+// Dropped reports whether the hub disconnected the client for falling behind
+func (c *Client) Dropped() bool {
+	return c.dropped.Load()
+}
+
+// RateHubConfig holds the limits of the rate hub
+type RateHubConfig struct {
+	MaxClients       int
+	MaxSubscriptions int
+	BufferSize       int
+}
+
+// RateHubUseCase fans rate ticks, alert notifications and refresh job status out to connected clients
+type RateHubUseCase struct {
+	Config    RateHubConfig
+	Logger    *logger.Logger
+	mu        sync.Mutex
+	rates     map[string]float64
+	updatedAt time.Time
+	clients   map[*Client]struct{}
+}
+
+func NewRateHubUseCase(loggerInstance *logger.Logger) IRateHubUseCase {
+	return &RateHubUseCase{
+		Config:  loadRateHubConfig(),
+		Logger:  loggerInstance,
+		rates:   make(map[string]float64),
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+// loadRateHubConfig loads rate hub configuration from environment variables
+func loadRateHubConfig() RateHubConfig {
+	return RateHubConfig{
+		MaxClients:       getEnvAsIntOrDefault("RATE_HUB_MAX_CLIENTS", 1000),
+		MaxSubscriptions: getEnvAsIntOrDefault("RATE_HUB_MAX_SUBSCRIPTIONS", 50),
+		BufferSize:       getEnvAsIntOrDefault("RATE_HUB_BUFFER_SIZE", 64),
+	}
+}
+
+// Connect registers a client for the given user
+func (s *RateHubUseCase) Connect(userID int) (*Client, error) {
+	messages := make(chan any, s.Config.BufferSize)
+	client := &Client{UserID: userID, Messages: messages, messages: messages, pairs: make(map[domainWatchlist.Pair]bool)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Config.MaxClients > 0 && len(s.clients) >= s.Config.MaxClients {
+		s.Logger.Warn("Rate hub client rejected", zap.Int("clients", len(s.clients)))
+		return nil, domainErrors.NewAppError(fmt.Errorf("the rate hub is limited to %d clients", s.Config.MaxClients), domainErrors.TooManyRequests)
+	}
+	s.clients[client] = struct{}{}
+	s.Logger.Info("Rate hub client connected", zap.Int("userID", userID), zap.Int("clients", len(s.clients)))
+	return client, nil
+}
+
+func (s *RateHubUseCase) Disconnect(client *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(client)
+}
+
+// Subscribe adds pairs to the client, sends the current rate of each new pair when one is known
+// and acknowledges with the full subscription. Nothing changes when a pair is invalid or the
+// subscription would exceed the per-connection limit.
+func (s *RateHubUseCase) Subscribe(client *Client, pairs []string) error {
+	parsed, err := parsePairs(pairs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := make([]domainWatchlist.Pair, 0, len(parsed))
+	for _, pair := range parsed {
+		if !client.pairs[pair] {
+			added = append(added, pair)
+		}
+	}
+	if s.Config.MaxSubscriptions > 0 && len(client.pairs)+len(added) > s.Config.MaxSubscriptions {
+		return domainErrors.NewAppError(fmt.Errorf("a connection may subscribe to at most %d pairs", s.Config.MaxSubscriptions), domainErrors.ValidationError)
+	}
+	for _, pair := range added {
+		client.pairs[pair] = true
+	}
+	if !s.deliver(client, SubscriptionMessage{Type: TypeSubscribed, Pairs: client.pairNames()}) {
+		return nil
+	}
+	for _, pair := range added {
+		if rate, ok := pair.CrossRate(s.rates); ok {
+			if !s.deliver(client, TickMessage{Type: TypeTick, Pair: pair.String(), Rate: rate, UpdatedAt: s.updatedAt}) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes pairs from the client and acknowledges with what is left
+func (s *RateHubUseCase) Unsubscribe(client *Client, pairs []string) error {
+	parsed, err := parsePairs(pairs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pair := range parsed {
+		delete(client.pairs, pair)
+	}
+	s.deliver(client, SubscriptionMessage{Type: TypeUnsubscribed, Pairs: client.pairNames()})
+	return nil
+}
+
+// Send queues a message for a single client, such as a reply to one of its requests
+func (s *RateHubUseCase) Send(client *Client, message any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliver(client, message)
+}
+
+// OnRatesUpdated keeps the refreshed rates and sends a tick for every subscribed pair
+func (s *RateHubUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range currencies {
+		s.rates[c.Code] = c.Rate
+	}
+	s.updatedAt = time.Now()
+
+	for client := range s.clients {
+		for pair := range client.pairs {
+			rate, ok := pair.CrossRate(s.rates)
+			if !ok {
+				continue
+			}
+			if !s.deliver(client, TickMessage{Type: TypeTick, Pair: pair.String(), Rate: rate, UpdatedAt: s.updatedAt}) {
+				break
+			}
+		}
+	}
+}
+
+// OnRefreshStatus tells every client how the rate refresh job is doing
+func (s *RateHubUseCase) OnRefreshStatus(status useCaseCurrency.RefreshStatus) {
+	message := RefreshMessage{Type: TypeRefresh, State: string(status.State), Currencies: status.Currencies, Error: status.Error, At: status.At}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		s.deliver(client, message)
+	}
+}
+
+// Notify sends a triggered alert to every connection of its owner. It never fails, so it can sit
+// alongside the configured alert notifier.
+func (s *RateHubUseCase) Notify(event *domainAlert.Event) error {
+	message := AlertMessage{
+		Type:          TypeAlert,
+		EventID:       event.ID,
+		RuleID:        event.RuleID,
+		Pair:          event.Pair.String(),
+		Condition:     string(event.Condition),
+		Threshold:     event.Threshold,
+		Rate:          event.Rate,
+		ReferenceRate: event.ReferenceRate,
+		TriggeredAt:   event.TriggeredAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		if client.UserID == event.UserID {
+			s.deliver(client, message)
+		}
+	}
+	return nil
+}
+
+// deliver queues a message without blocking and drops the client when its buffer is full, so one
+// slow connection cannot hold up the others. It must be called with the lock held and reports
+// whether the client is still connected.
+func (s *RateHubUseCase) deliver(client *Client, message any) bool {
+	if _, ok := s.clients[client]; !ok {
+		return false
+	}
+	select {
+	case client.messages <- message:
+		return true
+	default:
+		s.Logger.Warn("Dropping slow rate hub client", zap.Int("userID", client.UserID))
+		client.dropped.Store(true)
+		s.remove(client)
+		return false
+	}
+}
+
+// remove must be called with the lock held
+func (s *RateHubUseCase) remove(client *Client) {
+	if _, ok := s.clients[client]; !ok {
+		return
+	}
+	delete(s.clients, client)
+	close(client.messages)
+}
+
+// pairNames must be called with the hub lock held
+func (c *Client) pairNames() []string {
+	names := make([]string, 0, len(c.pairs))
+	for pair := range c.pairs {
+		names = append(names, pair.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parsePairs(pairs []string) ([]domainWatchlist.Pair, error) {
+	if len(pairs) == 0 {
+		return nil, domainErrors.NewAppError(fmt.Errorf("at least one pair is required"), domainErrors.ValidationError)
+	}
+	parsed := make([]domainWatchlist.Pair, 0, len(pairs))
+	for _, raw := range pairs {
+		pair, err := domainWatchlist.ParsePair(raw)
+		if err != nil {
+			return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		parsed = append(parsed, pair)
+	}
+	return parsed, nil
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
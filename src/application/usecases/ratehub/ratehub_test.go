@@ -0,0 +1,179 @@
+package ratehub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	useCaseCurrency "github.com/gbrayhan/microservices-go/src/application/usecases/currency"
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+)
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, config RateHubConfig) *RateHubUseCase {
+	return &RateHubUseCase{
+		Config:  config,
+		Logger:  setupLogger(t),
+		rates:   make(map[string]float64),
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+func refresh(uc *RateHubUseCase, eur, gbp float64) {
+	uc.OnRatesUpdated([]domainCurrency.Currency{{Code: "EUR", Rate: eur}, {Code: "GBP", Rate: gbp}})
+}
+
+func next(t *testing.T, client *Client) any {
+	t.Helper()
+	select {
+	case message := <-client.Messages:
+		return message
+	default:
+		t.Fatal("expected a queued message")
+		return nil
+	}
+}
+
+func assertAppErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != expected {
+		t.Fatalf("expected %s error, got %v", expected, err)
+	}
+}
+
+func TestRateHub_SubscribeSendsSnapshotAndTicks(t *testing.T) {
+	uc := newTestUseCase(t, RateHubConfig{MaxSubscriptions: 5, BufferSize: 8})
+	refresh(uc, 0.8, 0.5)
+
+	client, err := uc.Connect(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := uc.Subscribe(client, []string{"eur/usd", "JPY/USD"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ack := next(t, client).(SubscriptionMessage)
+	if ack.Type != TypeSubscribed || len(ack.Pairs) != 2 || ack.Pairs[0] != "EUR/USD" {
+		t.Errorf("unexpected acknowledgement %+v", ack)
+	}
+	tick := next(t, client).(TickMessage)
+	if tick.Pair != "EUR/USD" || tick.Rate != 1.25 {
+		t.Errorf("expected the current EUR/USD rate, got %+v", tick)
+	}
+	if len(client.Messages) != 0 {
+		t.Error("expected no tick for a pair without a known rate")
+	}
+
+	refresh(uc, 0.5, 0.5)
+	tick = next(t, client).(TickMessage)
+	if tick.Pair != "EUR/USD" || tick.Rate != 2 {
+		t.Errorf("expected the refreshed EUR/USD rate, got %+v", tick)
+	}
+
+	if err := uc.Unsubscribe(client, []string{"EUR/USD"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ack = next(t, client).(SubscriptionMessage)
+	if ack.Type != TypeUnsubscribed || len(ack.Pairs) != 1 || ack.Pairs[0] != "JPY/USD" {
+		t.Errorf("unexpected acknowledgement %+v", ack)
+	}
+	refresh(uc, 0.4, 0.5)
+	if len(client.Messages) != 0 {
+		t.Error("expected no tick after unsubscribing")
+	}
+}
+
+func TestRateHub_SubscribeValidatesAndLimits(t *testing.T) {
+	uc := newTestUseCase(t, RateHubConfig{MaxSubscriptions: 2, BufferSize: 8})
+	client, _ := uc.Connect(1)
+
+	assertAppErrorType(t, uc.Subscribe(client, nil), domainErrors.ValidationError)
+	assertAppErrorType(t, uc.Subscribe(client, []string{"EURUSD"}), domainErrors.ValidationError)
+	assertAppErrorType(t, uc.Subscribe(client, []string{"EUR/USD", "GBP/USD", "JPY/USD"}), domainErrors.ValidationError)
+	if len(client.pairs) != 0 {
+		t.Errorf("a rejected subscription must not change anything, got %v", client.pairs)
+	}
+
+	if err := uc.Subscribe(client, []string{"EUR/USD", "GBP/USD"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := uc.Subscribe(client, []string{"EUR/USD"}); err != nil {
+		t.Errorf("re-subscribing to a followed pair must not count against the limit: %v", err)
+	}
+}
+
+func TestRateHub_ConnectLimitsClients(t *testing.T) {
+	uc := newTestUseCase(t, RateHubConfig{MaxClients: 1, BufferSize: 1})
+	client, err := uc.Connect(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = uc.Connect(2)
+	assertAppErrorType(t, err, domainErrors.TooManyRequests)
+
+	uc.Disconnect(client)
+	if _, ok := <-client.Messages; ok {
+		t.Error("expected the messages channel to be closed")
+	}
+	if client.Dropped() {
+		t.Error("a client that disconnected itself was not dropped")
+	}
+	if _, err := uc.Connect(2); err != nil {
+		t.Errorf("expected a free slot after disconnecting: %v", err)
+	}
+}
+
+func TestRateHub_DropsSlowClients(t *testing.T) {
+	uc := newTestUseCase(t, RateHubConfig{BufferSize: 1})
+	slow, _ := uc.Connect(1)
+	fast, _ := uc.Connect(2)
+
+	uc.OnRefreshStatus(useCaseCurrency.RefreshStatus{State: useCaseCurrency.RefreshStarted, At: time.Now()})
+	<-fast.Messages
+	uc.OnRefreshStatus(useCaseCurrency.RefreshStatus{State: useCaseCurrency.RefreshCompleted, Currencies: 2, At: time.Now()})
+
+	if !slow.Dropped() {
+		t.Fatal("expected the slow client to be dropped")
+	}
+	message := <-slow.Messages
+	if status := message.(RefreshMessage); status.State != "started" {
+		t.Errorf("expected the queued started status, got %+v", status)
+	}
+	if _, ok := <-slow.Messages; ok {
+		t.Error("expected the slow client's channel to be closed")
+	}
+	status := next(t, fast).(RefreshMessage)
+	if status.Type != TypeRefresh || status.State != "completed" || status.Currencies != 2 {
+		t.Errorf("unexpected refresh status %+v", status)
+	}
+}
+
+func TestRateHub_NotifySendsAlertsToTheOwner(t *testing.T) {
+	uc := newTestUseCase(t, RateHubConfig{BufferSize: 4})
+	owner, _ := uc.Connect(3)
+	other, _ := uc.Connect(4)
+
+	err := uc.Notify(&domainAlert.Event{ID: 9, RuleID: 2, UserID: 3, Pair: domainWatchlist.Pair{Base: "EUR", Quote: "USD"}, Condition: domainAlert.ConditionAbove, Threshold: 1.1, Rate: 1.2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alert := next(t, owner).(AlertMessage)
+	if alert.Type != TypeAlert || alert.EventID != 9 || alert.Pair != "EUR/USD" || alert.Condition != "above" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+	if len(other.Messages) != 0 {
+		t.Error("alerts must only reach their owner")
+	}
+}
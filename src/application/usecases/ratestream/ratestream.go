@@ -0,0 +1,183 @@
+package ratestream
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+type IRateStreamUseCase interface {
+	OnRatesUpdated(currencies []domainCurrency.Currency)
+	Subscribe(codes []string, lastEventID uint64) (*Subscription, []RateEvent, error)
+	Unsubscribe(subscription *Subscription)
+}
+
+// RateUpdate is one currency of a rate event
+type RateUpdate struct {
+	Code string  `json:"code"`
+	Rate float64 `json:"rate"`
+}
+
+// RateEvent is the result of one refresh. IDs increase by one per refresh so clients can resume.
+type RateEvent struct {
+	ID        uint64       `json:"id"`
+	Base      string       `json:"base"`
+	Rates     []RateUpdate `json:"rates"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// Subscription receives the events that pass its currency filter. Events is closed when the
+// subscriber falls too far behind; it should reconnect with the last event id it received.
+type Subscription struct {
+	Events <-chan RateEvent
+	events chan RateEvent
+	codes  map[string]bool
+}
+
+// RateStreamConfig holds the limits of the live rate stream
+type RateStreamConfig struct {
+	HistorySize int
+	MaxClients  int
+	BufferSize  int
+}
+
+// RateStreamUseCase fans refreshed rates out to live subscribers and keeps a short history for resuming
+type RateStreamUseCase struct {
+	Config        RateStreamConfig
+	Logger        *logger.Logger
+	mu            sync.Mutex
+	lastID        uint64
+	history       []RateEvent
+	subscriptions map[*Subscription]struct{}
+}
+
+func NewRateStreamUseCase(loggerInstance *logger.Logger) IRateStreamUseCase {
+	return &RateStreamUseCase{
+		Config:        loadRateStreamConfig(),
+		Logger:        loggerInstance,
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// loadRateStreamConfig loads rate stream configuration from environment variables
+func loadRateStreamConfig() RateStreamConfig {
+	return RateStreamConfig{
+		HistorySize: getEnvAsIntOrDefault("RATE_STREAM_HISTORY_SIZE", 50),
+		MaxClients:  getEnvAsIntOrDefault("RATE_STREAM_MAX_CLIENTS", 1000),
+		BufferSize:  getEnvAsIntOrDefault("RATE_STREAM_BUFFER_SIZE", 16),
+	}
+}
+
+// OnRatesUpdated records the refresh as a new event and pushes it to every subscriber
+func (s *RateStreamUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {
+	rates := make([]RateUpdate, len(currencies))
+	for i, c := range currencies {
+		rates[i] = RateUpdate{Code: c.Code, Rate: c.Rate}
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Code < rates[j].Code })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	event := RateEvent{ID: s.lastID, Base: domainCurrency.RateBase, Rates: rates, UpdatedAt: time.Now()}
+	s.history = append(s.history, event)
+	if len(s.history) > s.Config.HistorySize {
+		s.history = s.history[len(s.history)-s.Config.HistorySize:]
+	}
+
+	for subscription := range s.subscriptions {
+		filtered, ok := subscription.filter(event)
+		if !ok {
+			continue
+		}
+		select {
+		case subscription.events <- filtered:
+		default:
+			s.Logger.Warn("Dropping slow rate stream subscriber", zap.Uint64("eventID", event.ID))
+			s.remove(subscription)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the given currency codes (all when empty) and returns the
+// events after lastEventID still held in history, so a reconnecting client misses nothing.
+func (s *RateStreamUseCase) Subscribe(codes []string, lastEventID uint64) (*Subscription, []RateEvent, error) {
+	filter := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" {
+			filter[code] = true
+		}
+	}
+	events := make(chan RateEvent, s.Config.BufferSize)
+	subscription := &Subscription{Events: events, events: events, codes: filter}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Config.MaxClients > 0 && len(s.subscriptions) >= s.Config.MaxClients {
+		s.Logger.Warn("Rate stream subscriber rejected", zap.Int("clients", len(s.subscriptions)))
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("the rate stream is limited to %d clients", s.Config.MaxClients), domainErrors.TooManyRequests)
+	}
+	backlog := []RateEvent{}
+	if lastEventID > 0 {
+		for _, event := range s.history {
+			if event.ID <= lastEventID {
+				continue
+			}
+			if filtered, ok := subscription.filter(event); ok {
+				backlog = append(backlog, filtered)
+			}
+		}
+	}
+	s.subscriptions[subscription] = struct{}{}
+	s.Logger.Info("Rate stream subscriber connected", zap.Int("clients", len(s.subscriptions)), zap.Int("backlog", len(backlog)))
+	return subscription, backlog, nil
+}
+
+func (s *RateStreamUseCase) Unsubscribe(subscription *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(subscription)
+}
+
+// remove must be called with the lock held
+func (s *RateStreamUseCase) remove(subscription *Subscription) {
+	if _, ok := s.subscriptions[subscription]; !ok {
+		return
+	}
+	delete(s.subscriptions, subscription)
+	close(subscription.events)
+}
+
+// filter keeps the rates the subscription asked for and reports whether any are left
+func (sub *Subscription) filter(event RateEvent) (RateEvent, bool) {
+	if len(sub.codes) == 0 {
+		return event, true
+	}
+	rates := make([]RateUpdate, 0, len(sub.codes))
+	for _, rate := range event.Rates {
+		if sub.codes[rate.Code] {
+			rates = append(rates, rate)
+		}
+	}
+	event.Rates = rates
+	return event, len(rates) > 0
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
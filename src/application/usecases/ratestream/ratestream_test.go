@@ -0,0 +1,114 @@
+package ratestream
+
+import (
+	"errors"
+	"testing"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+)
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, config RateStreamConfig) *RateStreamUseCase {
+	return &RateStreamUseCase{
+		Config:        config,
+		Logger:        setupLogger(t),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+func refresh(uc *RateStreamUseCase, eur, gbp float64) {
+	uc.OnRatesUpdated([]domainCurrency.Currency{{Code: "GBP", Rate: gbp}, {Code: "EUR", Rate: eur}})
+}
+
+func TestRateStream_PushesFilteredEvents(t *testing.T) {
+	uc := newTestUseCase(t, RateStreamConfig{HistorySize: 5, BufferSize: 4})
+
+	all, _, err := uc.Subscribe(nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eurOnly, _, _ := uc.Subscribe([]string{"eur"}, 0)
+	jpyOnly, _, _ := uc.Subscribe([]string{"JPY"}, 0)
+
+	refresh(uc, 0.9, 0.8)
+
+	event := <-all.Events
+	if event.ID != 1 || len(event.Rates) != 2 || event.Rates[0].Code != "EUR" {
+		t.Errorf("expected every rate sorted by code, got %+v", event)
+	}
+	event = <-eurOnly.Events
+	if len(event.Rates) != 1 || event.Rates[0].Code != "EUR" {
+		t.Errorf("expected only EUR, got %+v", event)
+	}
+	if len(jpyOnly.Events) != 0 {
+		t.Error("expected no event for a filter matching nothing")
+	}
+}
+
+func TestRateStream_ResumesFromHistory(t *testing.T) {
+	uc := newTestUseCase(t, RateStreamConfig{HistorySize: 2, BufferSize: 4})
+	refresh(uc, 0.90, 0.80)
+	refresh(uc, 0.91, 0.81)
+	refresh(uc, 0.92, 0.82)
+
+	_, backlog, err := uc.Subscribe([]string{"GBP"}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backlog) != 1 || backlog[0].ID != 3 || backlog[0].Rates[0].Rate != 0.82 {
+		t.Errorf("expected event 3 only, got %+v", backlog)
+	}
+
+	_, backlog, _ = uc.Subscribe(nil, 0)
+	if len(backlog) != 0 {
+		t.Errorf("expected no backlog without a last event id, got %+v", backlog)
+	}
+
+	_, backlog, _ = uc.Subscribe(nil, 1)
+	if len(backlog) != 2 || backlog[0].ID != 2 {
+		t.Errorf("expected the events still held in history, got %+v", backlog)
+	}
+}
+
+func TestRateStream_DropsSlowSubscribers(t *testing.T) {
+	uc := newTestUseCase(t, RateStreamConfig{HistorySize: 5, BufferSize: 1})
+	subscription, _, _ := uc.Subscribe(nil, 0)
+
+	refresh(uc, 0.90, 0.80)
+	refresh(uc, 0.91, 0.81)
+
+	<-subscription.Events
+	if _, open := <-subscription.Events; open {
+		t.Error("expected the events channel to be closed")
+	}
+	uc.Unsubscribe(subscription)
+	if len(uc.subscriptions) != 0 {
+		t.Error("expected the subscriber to be removed")
+	}
+}
+
+func TestRateStream_MaxClients(t *testing.T) {
+	uc := newTestUseCase(t, RateStreamConfig{HistorySize: 5, BufferSize: 1, MaxClients: 1})
+	first, _, err := uc.Subscribe(nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, err = uc.Subscribe(nil, 0)
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.TooManyRequests {
+		t.Errorf("expected TooManyRequests, got %v", err)
+	}
+	uc.Unsubscribe(first)
+	if _, _, err := uc.Subscribe(nil, 0); err != nil {
+		t.Errorf("expected a free slot after unsubscribing, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package user
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
@@ -10,17 +13,27 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// IUserUseCase methods take the id of the acting user (0 for anonymous callers). Admins may act
+// on any account; everyone else may only read and modify their own.
 type IUserUseCase interface {
-	GetAll() (*[]userDomain.User, error)
-	GetByID(id int) (*userDomain.User, error)
+	GetAll(actorID int) (*[]userDomain.User, error)
+	GetByID(actorID int, id int) (*userDomain.User, error)
+	GetPublicProfile(id int) (*userDomain.User, error)
 	GetByEmail(email string) (*userDomain.User, error)
-	Create(newUser *userDomain.User) (*userDomain.User, error)
-	Delete(id int) error
-	Update(id int, userMap map[string]interface{}) (*userDomain.User, error)
-	SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	Create(actorID int, newUser *userDomain.User) (*userDomain.User, error)
+	Delete(actorID int, id int) error
+	Update(actorID int, id int, userMap map[string]interface{}) (*userDomain.User, error)
+	ChangePassword(id int, currentPassword string, newPassword string) error
+	SearchPaginated(actorID int, filters domain.DataFilters) (*userDomain.SearchResultUser, error)
+	SearchByProperty(actorID int, property string, searchText string) (*[]string, error)
 }
 
+// MinPasswordLength is the shortest password accepted on a password change
+const MinPasswordLength = 8
+
+// adminOnlyFields may only be changed by admins, even on their own account
+var adminOnlyFields = []string{"role", "status"}
+
 type UserUseCase struct {
 	userRepository user.UserRepositoryInterface
 	Logger         *logger.Logger
@@ -33,23 +46,47 @@ func NewUserUseCase(userRepository user.UserRepositoryInterface, logger *logger.
 	}
 }
 
-func (s *UserUseCase) GetAll() (*[]userDomain.User, error) {
+func (s *UserUseCase) GetAll(actorID int) (*[]userDomain.User, error) {
 	s.Logger.Info("Getting all users")
+	if err := s.requireAdmin(actorID); err != nil {
+		return nil, err
+	}
 	return s.userRepository.GetAll()
 }
 
-func (s *UserUseCase) GetByID(id int) (*userDomain.User, error) {
+func (s *UserUseCase) GetByID(actorID int, id int) (*userDomain.User, error) {
 	s.Logger.Info("Getting user by ID", zap.Int("id", id))
+	if _, err := s.authorize(actorID, id); err != nil {
+		return nil, err
+	}
 	return s.userRepository.GetByID(id)
 }
 
+// GetPublicProfile returns only the fields of a user that anyone may read
+func (s *UserUseCase) GetPublicProfile(id int) (*userDomain.User, error) {
+	s.Logger.Info("Getting public user profile", zap.Int("id", id))
+	found, err := s.userRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return found.PublicProfile(), nil
+}
+
 func (s *UserUseCase) GetByEmail(email string) (*userDomain.User, error) {
 	s.Logger.Info("Getting user by email", zap.String("email", email))
 	return s.userRepository.GetByEmail(email)
 }
 
-func (s *UserUseCase) Create(newUser *userDomain.User) (*userDomain.User, error) {
+func (s *UserUseCase) Create(actorID int, newUser *userDomain.User) (*userDomain.User, error) {
 	s.Logger.Info("Creating new user", zap.String("email", newUser.Email))
+	if newUser.Role == "" {
+		newUser.Role = userDomain.RoleSubscriber
+	}
+	if newUser.Role != userDomain.RoleSubscriber {
+		if err := s.requireAdmin(actorID); err != nil {
+			return nil, err
+		}
+	}
 	existingEmail, err := s.userRepository.GetByEmail(newUser.Email)
 	if err != nil {
 		return nil, err
@@ -79,26 +116,113 @@ func (s *UserUseCase) Create(newUser *userDomain.User) (*userDomain.User, error)
 	return s.userRepository.Create(newUser)
 }
 
-func (s *UserUseCase) Delete(id int) error {
+func (s *UserUseCase) Delete(actorID int, id int) error {
 	s.Logger.Info("Deleting user", zap.Int("id", id))
+	if _, err := s.authorize(actorID, id); err != nil {
+		return err
+	}
 	return s.userRepository.Delete(id)
 }
 
-func (s *UserUseCase) Update(id int, userMap map[string]interface{}) (*userDomain.User, error) {
+func (s *UserUseCase) Update(actorID int, id int, userMap map[string]interface{}) (*userDomain.User, error) {
 	s.Logger.Info("Updating user", zap.Int("id", id))
+	isAdmin, err := s.authorize(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		for _, field := range adminOnlyFields {
+			if _, ok := userMap[field]; ok {
+				s.Logger.Warn("Rejected self-update of admin-only field", zap.Int("id", id), zap.String("field", field))
+				return nil, domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+			}
+		}
+	}
 	return s.userRepository.Update(id, userMap)
 }
 
-func (s *UserUseCase) SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
+// ChangePassword replaces the password of a user after checking the current one
+func (s *UserUseCase) ChangePassword(id int, currentPassword string, newPassword string) error {
+	s.Logger.Info("Changing user password", zap.Int("id", id))
+	if len(newPassword) < MinPasswordLength {
+		return domainErrors.NewAppError(fmt.Errorf("new password must be at least %d characters", MinPasswordLength), domainErrors.ValidationError)
+	}
+	if newPassword == currentPassword {
+		return domainErrors.NewAppError(errors.New("new password must differ from the current one"), domainErrors.ValidationError)
+	}
+
+	existing, err := s.userRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(existing.HashPassword), []byte(currentPassword)) != nil {
+		s.Logger.Warn("Rejected password change with wrong current password", zap.Int("id", id))
+		return domainErrors.NewAppError(errors.New("current password is incorrect"), domainErrors.ValidationError)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		s.Logger.Error("Error hashing password", zap.Error(err))
+		return err
+	}
+	return s.userRepository.UpdatePassword(id, string(hash))
+}
+
+func (s *UserUseCase) SearchPaginated(actorID int, filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
 	s.Logger.Info("Searching users with pagination",
 		zap.Int("page", filters.Page),
 		zap.Int("pageSize", filters.PageSize))
+	if err := s.requireAdmin(actorID); err != nil {
+		return nil, err
+	}
 	return s.userRepository.SearchPaginated(filters)
 }
 
-func (s *UserUseCase) SearchByProperty(property string, searchText string) (*[]string, error) {
+func (s *UserUseCase) SearchByProperty(actorID int, property string, searchText string) (*[]string, error) {
 	s.Logger.Info("Searching users by property",
 		zap.String("property", property),
 		zap.String("searchText", searchText))
+	if err := s.requireAdmin(actorID); err != nil {
+		return nil, err
+	}
 	return s.userRepository.SearchByProperty(property, searchText)
 }
+
+// authorize lets admins act on any user and everyone else only on themselves.
+// It reports whether the actor is an admin.
+func (s *UserUseCase) authorize(actorID int, targetID int) (bool, error) {
+	actor, err := s.getActor(actorID)
+	if err != nil {
+		return false, err
+	}
+	isAdmin := actor.Role == userDomain.RoleAdmin
+	if !isAdmin && actorID != targetID {
+		s.Logger.Warn("User operation rejected", zap.Int("actorID", actorID), zap.Int("targetID", targetID))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return isAdmin, nil
+}
+
+func (s *UserUseCase) requireAdmin(actorID int) error {
+	actor, err := s.getActor(actorID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != userDomain.RoleAdmin {
+		s.Logger.Warn("Admin operation rejected", zap.Int("actorID", actorID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return nil
+}
+
+// getActor loads the acting user; anonymous callers and unknown ids are not authorized
+func (s *UserUseCase) getActor(actorID int) (*userDomain.User, error) {
+	if actorID == 0 {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	actor, err := s.userRepository.GetByID(actorID)
+	if err != nil || actor == nil {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return actor, nil
+}
@@ -4,10 +4,13 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type mockUserService struct {
@@ -18,6 +21,7 @@ type mockUserService struct {
 	deleteFn        func(id int) error
 	updateFn        func(id int, m map[string]interface{}) (*userDomain.User, error)
 	getByUsernameFn func(username string) (*userDomain.User, error)
+	updatePassFn    func(id int, hashPassword string) error
 }
 
 func (m *mockUserService) GetAll() (*[]userDomain.User, error) {
@@ -42,6 +46,27 @@ func (m *mockUserService) Delete(id int) error {
 func (m *mockUserService) Update(id int, userMap map[string]interface{}) (*userDomain.User, error) {
 	return m.updateFn(id, userMap)
 }
+func (m *mockUserService) UpdateLoginState(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+	return nil
+}
+func (m *mockUserService) IncrementFailedLogins(id int, failedAt time.Time) (int, error) {
+	return 0, nil
+}
+func (m *mockUserService) LockAccount(id int, lockedUntil time.Time) error {
+	return nil
+}
+func (m *mockUserService) UpdateTwoFactor(id int, enabled bool, secret string, recoveryCodes []string) error {
+	return nil
+}
+func (m *mockUserService) ClaimTwoFactorStep(id int, step int64) (bool, error) {
+	return true, nil
+}
+func (m *mockUserService) ConsumeRecoveryCode(id int, hash string) (bool, error) {
+	return true, nil
+}
+func (m *mockUserService) UpdatePassword(id int, hashPassword string) error {
+	return m.updatePassFn(id, hashPassword)
+}
 func (m *mockUserService) SearchPaginated(filters domain.DataFilters) (*userDomain.SearchResultUser, error) {
 	return nil, nil
 }
@@ -57,9 +82,23 @@ func setupLogger(t *testing.T) *logger.Logger {
 	return loggerInstance
 }
 
+const adminID = 1
+
+func adminAwareGetByID(users map[int]*userDomain.User) func(id int) (*userDomain.User, error) {
+	return func(id int) (*userDomain.User, error) {
+		if id == adminID {
+			return &userDomain.User{ID: adminID, Role: userDomain.RoleAdmin}, nil
+		}
+		if u, ok := users[id]; ok {
+			return u, nil
+		}
+		return nil, errors.New("not found")
+	}
+}
+
 func TestUserUseCase(t *testing.T) {
 
-	mockRepo := &mockUserService{}
+	mockRepo := &mockUserService{getByIDFn: adminAwareGetByID(nil)}
 	logger := setupLogger(t)
 	useCase := NewUserUseCase(mockRepo, logger)
 
@@ -67,7 +106,7 @@ func TestUserUseCase(t *testing.T) {
 		mockRepo.getAllFn = func() (*[]userDomain.User, error) {
 			return &[]userDomain.User{{ID: 1}}, nil
 		}
-		us, err := useCase.GetAll()
+		us, err := useCase.GetAll(adminID)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -77,17 +116,12 @@ func TestUserUseCase(t *testing.T) {
 	})
 
 	t.Run("Test GetByID", func(t *testing.T) {
-		mockRepo.getByIDFn = func(id int) (*userDomain.User, error) {
-			if id == 999 {
-				return nil, errors.New("not found")
-			}
-			return &userDomain.User{ID: id}, nil
-		}
-		_, err := useCase.GetByID(999)
+		mockRepo.getByIDFn = adminAwareGetByID(map[int]*userDomain.User{10: {ID: 10}})
+		_, err := useCase.GetByID(adminID, 999)
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
-		u, err := useCase.GetByID(10)
+		u, err := useCase.GetByID(adminID, 10)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -139,7 +173,7 @@ func TestUserUseCase(t *testing.T) {
 			return newU, nil
 		}
 
-		created, err := useCase.Create(&userDomain.User{
+		created, err := useCase.Create(0, &userDomain.User{
 			Email:    "test222@mail.com",
 			Password: "abc",
 		})
@@ -153,7 +187,7 @@ func TestUserUseCase(t *testing.T) {
 	})
 
 	t.Run("Test Create (Error empty email)", func(t *testing.T) {
-		_, err := useCase.Create(&userDomain.User{Email: "", Password: "abc"})
+		_, err := useCase.Create(0, &userDomain.User{Email: "", Password: "abc"})
 		if err == nil {
 			t.Error("expected error on create user with empty email")
 		}
@@ -166,11 +200,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return errors.New("cannot delete")
 		}
-		err := useCase.Delete(999)
+		err := useCase.Delete(adminID, 999)
 		if err == nil {
 			t.Error("expected error for cannot delete")
 		}
-		err = useCase.Delete(101)
+		err = useCase.Delete(adminID, 101)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -183,11 +217,11 @@ func TestUserUseCase(t *testing.T) {
 			}
 			return &userDomain.User{ID: id, UserName: "Updated"}, nil
 		}
-		_, err := useCase.Update(999, map[string]interface{}{"userName": "any"})
+		_, err := useCase.Update(adminID, 999, map[string]interface{}{"userName": "any"})
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
-		updated, err := useCase.Update(1001, map[string]interface{}{"userName": "whatever"})
+		updated, err := useCase.Update(adminID, 1001, map[string]interface{}{"userName": "whatever"})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -197,6 +231,179 @@ func TestUserUseCase(t *testing.T) {
 	})
 }
 
+func TestUserUseCase_ChangePassword(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	var storedHash string
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*userDomain.User, error) {
+			return &userDomain.User{ID: id, HashPassword: string(hash)}, nil
+		},
+		updatePassFn: func(id int, hashPassword string) error {
+			storedHash = hashPassword
+			return nil
+		},
+	}
+	useCase := NewUserUseCase(mockRepo, setupLogger(t))
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		wantErr         bool
+	}{
+		{name: "changes password", currentPassword: "old-password", newPassword: "new-password"},
+		{name: "wrong current password", currentPassword: "guess", newPassword: "new-password", wantErr: true},
+		{name: "too short", currentPassword: "old-password", newPassword: "short", wantErr: true},
+		{name: "unchanged", currentPassword: "old-password", newPassword: "old-password", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storedHash = ""
+			err := useCase.ChangePassword(1, tt.currentPassword, tt.newPassword)
+			if tt.wantErr {
+				var appErr *domainErrors.AppError
+				if !errors.As(err, &appErr) || appErr.Type != domainErrors.ValidationError {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+				if storedHash != "" {
+					t.Error("expected password to be left unchanged")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(tt.newPassword)) != nil {
+				t.Error("expected the new password to be stored hashed")
+			}
+		})
+	}
+}
+
+func TestUserUseCase_Authorization(t *testing.T) {
+	const ownerID, otherID = 7, 8
+	users := map[int]*userDomain.User{
+		ownerID: {ID: ownerID, Email: "owner@example.com", Role: userDomain.RoleSubscriber},
+		otherID: {ID: otherID, Email: "other@example.com", Role: userDomain.RoleSubscriber},
+	}
+	mockRepo := &mockUserService{
+		getByIDFn: adminAwareGetByID(users),
+		getAllFn: func() (*[]userDomain.User, error) {
+			return &[]userDomain.User{}, nil
+		},
+		updateFn: func(id int, m map[string]interface{}) (*userDomain.User, error) {
+			return users[id], nil
+		},
+		deleteFn: func(id int) error {
+			return nil
+		},
+		getByEmailFn: func(email string) (*userDomain.User, error) {
+			return nil, nil
+		},
+		createFn: func(u *userDomain.User) (*userDomain.User, error) {
+			return u, nil
+		},
+	}
+	useCase := NewUserUseCase(mockRepo, setupLogger(t))
+
+	tests := []struct {
+		name       string
+		call       func() error
+		authorized bool
+	}{
+		{name: "user reads themselves", authorized: true, call: func() error {
+			_, err := useCase.GetByID(ownerID, ownerID)
+			return err
+		}},
+		{name: "user reads someone else", call: func() error {
+			_, err := useCase.GetByID(ownerID, otherID)
+			return err
+		}},
+		{name: "anonymous reads a user", call: func() error {
+			_, err := useCase.GetByID(0, ownerID)
+			return err
+		}},
+		{name: "admin reads anyone", authorized: true, call: func() error {
+			_, err := useCase.GetByID(adminID, otherID)
+			return err
+		}},
+		{name: "user updates themselves", authorized: true, call: func() error {
+			_, err := useCase.Update(ownerID, ownerID, map[string]interface{}{"firstName": "Jane"})
+			return err
+		}},
+		{name: "user updates someone else", call: func() error {
+			_, err := useCase.Update(ownerID, otherID, map[string]interface{}{"firstName": "Jane"})
+			return err
+		}},
+		{name: "user promotes themselves", call: func() error {
+			_, err := useCase.Update(ownerID, ownerID, map[string]interface{}{"role": "ADMIN"})
+			return err
+		}},
+		{name: "admin changes a role", authorized: true, call: func() error {
+			_, err := useCase.Update(adminID, otherID, map[string]interface{}{"role": "ADMIN"})
+			return err
+		}},
+		{name: "user deletes themselves", authorized: true, call: func() error {
+			return useCase.Delete(ownerID, ownerID)
+		}},
+		{name: "user deletes someone else", call: func() error {
+			return useCase.Delete(ownerID, otherID)
+		}},
+		{name: "user lists users", call: func() error {
+			_, err := useCase.GetAll(ownerID)
+			return err
+		}},
+		{name: "user searches users", call: func() error {
+			_, err := useCase.SearchByProperty(ownerID, "email", "example")
+			return err
+		}},
+		{name: "anonymous registers a subscriber", authorized: true, call: func() error {
+			_, err := useCase.Create(0, &userDomain.User{Email: "new@example.com", Password: "password"})
+			return err
+		}},
+		{name: "anonymous registers an admin", call: func() error {
+			_, err := useCase.Create(0, &userDomain.User{Email: "new@example.com", Password: "password", Role: userDomain.RoleAdmin})
+			return err
+		}},
+		{name: "admin creates an admin", authorized: true, call: func() error {
+			_, err := useCase.Create(adminID, &userDomain.User{Email: "new@example.com", Password: "password", Role: userDomain.RoleAdmin})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if tt.authorized {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var appErr *domainErrors.AppError
+			if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotAuthorized {
+				t.Fatalf("expected NotAuthorized, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUserUseCase_GetPublicProfile(t *testing.T) {
+	mockRepo := &mockUserService{
+		getByIDFn: func(id int) (*userDomain.User, error) {
+			return &userDomain.User{ID: id, UserName: "jane", Email: "jane@example.com", Role: userDomain.RoleAdmin, HashPassword: "hash"}, nil
+		},
+	}
+	useCase := NewUserUseCase(mockRepo, setupLogger(t))
+
+	profile, err := useCase.GetPublicProfile(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.UserName != "jane" || profile.Email != "" || profile.Role != "" || profile.HashPassword != "" {
+		t.Errorf("expected only public fields, got %+v", profile)
+	}
+}
+
 func TestNewUserUseCase(t *testing.T) {
 	mockRepo := &mockUserService{}
 	loggerInstance := setupLogger(t)
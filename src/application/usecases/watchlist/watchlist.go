@@ -0,0 +1,220 @@
+package watchlist
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/watchlist"
+	"go.uber.org/zap"
+)
+
+const maxNameLength = 100
+
+type IWatchlistUseCase interface {
+	Create(userID int, name string, pairs []string) (*domainWatchlist.Watchlist, error)
+	GetByUser(userID int) (*[]domainWatchlist.Watchlist, error)
+	GetByID(userID int, id int) (*domainWatchlist.Watchlist, error)
+	Update(userID int, id int, name string, pairs []string) (*domainWatchlist.Watchlist, error)
+	Delete(userID int, id int) error
+	GetRates(userID int, id int) (*domainWatchlist.Watchlist, []domainWatchlist.PairRate, error)
+}
+
+// WatchlistConfig holds the limits applied to watchlists
+type WatchlistConfig struct {
+	MaxListsPerUser int
+	MaxPairsPerList int
+}
+
+type WatchlistUseCase struct {
+	WatchlistRepository watchlist.WatchlistRepositoryInterface
+	CurrencyRepository  currency.CurrencyRepositoryInterface
+	Config              WatchlistConfig
+	Logger              *logger.Logger
+}
+
+func NewWatchlistUseCase(watchlistRepository watchlist.WatchlistRepositoryInterface, currencyRepository currency.CurrencyRepositoryInterface, loggerInstance *logger.Logger) IWatchlistUseCase {
+	return &WatchlistUseCase{
+		WatchlistRepository: watchlistRepository,
+		CurrencyRepository:  currencyRepository,
+		Config:              loadWatchlistConfig(),
+		Logger:              loggerInstance,
+	}
+}
+
+// loadWatchlistConfig loads watchlist configuration from environment variables
+func loadWatchlistConfig() WatchlistConfig {
+	return WatchlistConfig{
+		MaxListsPerUser: getEnvAsIntOrDefault("WATCHLIST_MAX_PER_USER", 20),
+		MaxPairsPerList: getEnvAsIntOrDefault("WATCHLIST_MAX_PAIRS", 50),
+	}
+}
+
+func (s *WatchlistUseCase) Create(userID int, name string, pairs []string) (*domainWatchlist.Watchlist, error) {
+	s.Logger.Info("Creating watchlist", zap.Int("userID", userID), zap.String("name", name))
+	name, parsed, err := s.validate(name, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.WatchlistRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Config.MaxListsPerUser > 0 && len(*existing) >= s.Config.MaxListsPerUser {
+		return nil, domainErrors.NewAppError(fmt.Errorf("a user cannot have more than %d watchlists", s.Config.MaxListsPerUser), domainErrors.ValidationError)
+	}
+	if hasName(*existing, name, 0) {
+		return nil, domainErrors.NewResourceAlreadyExists("watchlist name")
+	}
+
+	created, err := s.WatchlistRepository.Create(&domainWatchlist.Watchlist{UserID: userID, Name: name, Pairs: parsed})
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Watchlist created", zap.Int("userID", userID), zap.Int("watchlistID", created.ID))
+	return created, nil
+}
+
+func (s *WatchlistUseCase) GetByUser(userID int) (*[]domainWatchlist.Watchlist, error) {
+	s.Logger.Info("Getting watchlists", zap.Int("userID", userID))
+	return s.WatchlistRepository.GetByUserID(userID)
+}
+
+func (s *WatchlistUseCase) GetByID(userID int, id int) (*domainWatchlist.Watchlist, error) {
+	s.Logger.Info("Getting watchlist", zap.Int("userID", userID), zap.Int("watchlistID", id))
+	return s.WatchlistRepository.GetByID(userID, id)
+}
+
+// Update replaces the name and pairs of a watchlist
+func (s *WatchlistUseCase) Update(userID int, id int, name string, pairs []string) (*domainWatchlist.Watchlist, error) {
+	s.Logger.Info("Updating watchlist", zap.Int("userID", userID), zap.Int("watchlistID", id))
+	name, parsed, err := s.validate(name, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.WatchlistRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if hasName(*existing, name, id) {
+		return nil, domainErrors.NewResourceAlreadyExists("watchlist name")
+	}
+	return s.WatchlistRepository.Update(&domainWatchlist.Watchlist{ID: id, UserID: userID, Name: name, Pairs: parsed})
+}
+
+func (s *WatchlistUseCase) Delete(userID int, id int) error {
+	s.Logger.Info("Deleting watchlist", zap.Int("userID", userID), zap.Int("watchlistID", id))
+	return s.WatchlistRepository.Delete(userID, id)
+}
+
+// GetRates prices every pair of a watchlist from the stored currency rates in a single lookup
+func (s *WatchlistUseCase) GetRates(userID int, id int) (*domainWatchlist.Watchlist, []domainWatchlist.PairRate, error) {
+	s.Logger.Info("Getting watchlist rates", zap.Int("userID", userID), zap.Int("watchlistID", id))
+	list, err := s.WatchlistRepository.GetByID(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list.Pairs) == 0 {
+		return list, []domainWatchlist.PairRate{}, nil
+	}
+
+	codeSet := make(map[string]bool)
+	for _, pair := range list.Pairs {
+		codeSet[pair.Base] = true
+		codeSet[pair.Quote] = true
+	}
+	codes := make([]string, 0, len(codeSet))
+	for code := range codeSet {
+		codes = append(codes, code)
+	}
+	currencies, err := s.CurrencyRepository.GetByCodes(codes)
+	if err != nil {
+		return nil, nil, err
+	}
+	byCode := make(map[string]domainCurrency.Currency, len(*currencies))
+	for _, c := range *currencies {
+		byCode[c.Code] = c
+	}
+
+	rates := make([]domainWatchlist.PairRate, len(list.Pairs))
+	for i, pair := range list.Pairs {
+		rates[i] = pairRate(pair, byCode)
+	}
+	return list, rates, nil
+}
+
+// pairRate prices a pair and dates it by the older of its two stored rates
+func pairRate(pair domainWatchlist.Pair, byCode map[string]domainCurrency.Currency) domainWatchlist.PairRate {
+	result := domainWatchlist.PairRate{Pair: pair}
+	rates := make(map[string]float64, 2)
+	for _, code := range []string{pair.Base, pair.Quote} {
+		if c, ok := byCode[code]; ok {
+			rates[code] = c.Rate
+		}
+	}
+	rate, ok := pair.CrossRate(rates)
+	if !ok {
+		return result
+	}
+	result.Rate = rate
+	result.Available = true
+	for _, code := range []string{pair.Base, pair.Quote} {
+		updatedAt := byCode[code].UpdatedAt
+		if !updatedAt.IsZero() && (result.UpdatedAt.IsZero() || updatedAt.Before(result.UpdatedAt)) {
+			result.UpdatedAt = updatedAt
+		}
+	}
+	return result
+}
+
+// validate normalizes the name and parses the pairs, dropping duplicates
+func (s *WatchlistUseCase) validate(name string, pairs []string) (string, []domainWatchlist.Pair, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", nil, domainErrors.NewAppError(fmt.Errorf("name must be between 1 and %d characters", maxNameLength), domainErrors.ValidationError)
+	}
+	if s.Config.MaxPairsPerList > 0 && len(pairs) > s.Config.MaxPairsPerList {
+		return "", nil, domainErrors.NewAppError(fmt.Errorf("a watchlist cannot hold more than %d pairs", s.Config.MaxPairsPerList), domainErrors.ValidationError)
+	}
+
+	parsed := make([]domainWatchlist.Pair, 0, len(pairs))
+	seen := make(map[domainWatchlist.Pair]bool)
+	for _, raw := range pairs {
+		pair, err := domainWatchlist.ParsePair(raw)
+		if err != nil {
+			return "", nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			parsed = append(parsed, pair)
+		}
+	}
+	return name, parsed, nil
+}
+
+// hasName reports whether another watchlist than exceptID already uses the name
+func hasName(lists []domainWatchlist.Watchlist, name string, exceptID int) bool {
+	for _, l := range lists {
+		if l.ID != exceptID && strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,186 @@
+package watchlist
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
+)
+
+type mockWatchlistRepository struct {
+	lists   []domainWatchlist.Watchlist
+	created *domainWatchlist.Watchlist
+	updated *domainWatchlist.Watchlist
+}
+
+func (m *mockWatchlistRepository) Create(w *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error) {
+	m.created = w
+	saved := *w
+	saved.ID = 1
+	return &saved, nil
+}
+func (m *mockWatchlistRepository) GetByID(userID int, id int) (*domainWatchlist.Watchlist, error) {
+	for _, l := range m.lists {
+		if l.ID == id && l.UserID == userID {
+			return &l, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+func (m *mockWatchlistRepository) GetByUserID(userID int) (*[]domainWatchlist.Watchlist, error) {
+	var lists []domainWatchlist.Watchlist
+	for _, l := range m.lists {
+		if l.UserID == userID {
+			lists = append(lists, l)
+		}
+	}
+	return &lists, nil
+}
+func (m *mockWatchlistRepository) Update(w *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error) {
+	m.updated = w
+	return w, nil
+}
+func (m *mockWatchlistRepository) Delete(userID int, id int) error {
+	return nil
+}
+
+type mockCurrencyRepository struct {
+	currency.CurrencyRepositoryInterface
+	currencies []domainCurrency.Currency
+	requested  []string
+}
+
+func (m *mockCurrencyRepository) GetByCodes(codes []string) (*[]domainCurrency.Currency, error) {
+	m.requested = codes
+	return &m.currencies, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, watchlistRepo *mockWatchlistRepository, currencyRepo *mockCurrencyRepository) *WatchlistUseCase {
+	return &WatchlistUseCase{
+		WatchlistRepository: watchlistRepo,
+		CurrencyRepository:  currencyRepo,
+		Config:              WatchlistConfig{MaxListsPerUser: 2, MaxPairsPerList: 3},
+		Logger:              setupLogger(t),
+	}
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected AppError of type %s, got %v", expected, err)
+	}
+	if appErr.Type != expected {
+		t.Errorf("expected error type %s, got %s", expected, appErr.Type)
+	}
+}
+
+func TestWatchlistUseCase_Create(t *testing.T) {
+	repo := &mockWatchlistRepository{}
+	uc := newTestUseCase(t, repo, &mockCurrencyRepository{})
+
+	created, err := uc.Create(7, "  majors ", []string{"eur/usd", "GBP/USD", "EUR/USD"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.UserID != 7 || created.Name != "majors" {
+		t.Errorf("unexpected watchlist %+v", created)
+	}
+	if len(repo.created.Pairs) != 2 {
+		t.Errorf("expected duplicate pairs to be dropped, got %v", repo.created.Pairs)
+	}
+}
+
+func TestWatchlistUseCase_Create_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		listName string
+		pairs    []string
+		existing []domainWatchlist.Watchlist
+		expected domainErrors.ErrorType
+	}{
+		{name: "empty name", listName: " ", expected: domainErrors.ValidationError},
+		{name: "invalid pair", listName: "majors", pairs: []string{"EURUSD"}, expected: domainErrors.ValidationError},
+		{name: "too many pairs", listName: "majors", pairs: []string{"EUR/USD", "GBP/USD", "USD/JPY", "USD/CHF"}, expected: domainErrors.ValidationError},
+		{name: "duplicate name", listName: "Majors", existing: []domainWatchlist.Watchlist{{ID: 1, UserID: 7, Name: "majors"}}, expected: domainErrors.ResourceAlreadyExists},
+		{name: "too many lists", listName: "third", existing: []domainWatchlist.Watchlist{{ID: 1, UserID: 7, Name: "a"}, {ID: 2, UserID: 7, Name: "b"}}, expected: domainErrors.ValidationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestUseCase(t, &mockWatchlistRepository{lists: tt.existing}, &mockCurrencyRepository{})
+			_, err := uc.Create(7, tt.listName, tt.pairs)
+			assertErrorType(t, err, tt.expected)
+		})
+	}
+}
+
+func TestWatchlistUseCase_Update(t *testing.T) {
+	repo := &mockWatchlistRepository{lists: []domainWatchlist.Watchlist{
+		{ID: 1, UserID: 7, Name: "majors"},
+		{ID: 2, UserID: 7, Name: "crosses"},
+	}}
+	uc := newTestUseCase(t, repo, &mockCurrencyRepository{})
+
+	if _, err := uc.Update(7, 1, "majors", []string{"EUR/USD"}); err != nil {
+		t.Fatalf("expected a list to keep its own name, got %v", err)
+	}
+	if repo.updated.ID != 1 || repo.updated.UserID != 7 {
+		t.Errorf("unexpected update %+v", repo.updated)
+	}
+
+	_, err := uc.Update(7, 1, "crosses", nil)
+	assertErrorType(t, err, domainErrors.ResourceAlreadyExists)
+}
+
+func TestWatchlistUseCase_GetRates(t *testing.T) {
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	repo := &mockWatchlistRepository{lists: []domainWatchlist.Watchlist{{
+		ID:     1,
+		UserID: 7,
+		Pairs: []domainWatchlist.Pair{
+			{Base: "EUR", Quote: "GBP"},
+			{Base: "USD", Quote: "EUR"},
+			{Base: "EUR", Quote: "XXX"},
+		},
+	}}}
+	currencyRepo := &mockCurrencyRepository{currencies: []domainCurrency.Currency{
+		{Code: "EUR", Rate: 0.9, UpdatedAt: newer},
+		{Code: "GBP", Rate: 0.75, UpdatedAt: older},
+	}}
+	uc := newTestUseCase(t, repo, currencyRepo)
+
+	_, rates, err := uc.GetRates(7, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(currencyRepo.requested) != 4 {
+		t.Errorf("expected each currency to be looked up once, got %v", currencyRepo.requested)
+	}
+	if !rates[0].Available || math.Abs(rates[0].Rate-0.75/0.9) > 1e-9 || !rates[0].UpdatedAt.Equal(older) {
+		t.Errorf("unexpected cross rate %+v", rates[0])
+	}
+	if !rates[1].Available || rates[1].Rate != 0.9 {
+		t.Errorf("expected USD to be priced at 1 when not stored, got %+v", rates[1])
+	}
+	if rates[2].Available {
+		t.Errorf("expected pair with an unknown currency to be unavailable, got %+v", rates[2])
+	}
+
+	_, _, err = uc.GetRates(8, 1)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
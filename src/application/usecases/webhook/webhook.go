@@ -0,0 +1,470 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	webhookSender "github.com/gbrayhan/microservices-go/src/infrastructure/webhook"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	secretLength    = 32
+	minSecretLength = 16
+)
+
+type IWebhookUseCase interface {
+	Create(adminID int, targetURL string, secret string, eventTypes []string, currencies []string) (*domainWebhook.Subscription, string, error)
+	GetAll(adminID int) (*[]domainWebhook.Subscription, error)
+	GetByID(adminID int, id int) (*domainWebhook.Subscription, error)
+	Update(adminID int, id int, targetURL string, eventTypes []string, currencies []string, active bool) (*domainWebhook.Subscription, error)
+	Delete(adminID int, id int) error
+	GetDeliveries(adminID int, subscriptionID int) (*[]domainWebhook.Delivery, error)
+	RetryDelivery(adminID int, deliveryID int) (*domainWebhook.Delivery, error)
+	OnRatesUpdated(currencies []domainCurrency.Currency)
+	OnCurrencyDeleted(currency domainCurrency.Currency)
+	ProcessDue()
+	Run(ctx context.Context)
+}
+
+// WebhookConfig holds the delivery policy of webhooks
+type WebhookConfig struct {
+	MaxAttempts      int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	PollInterval     time.Duration
+	ClaimTimeout     time.Duration
+	BatchSize        int
+	DeliveryLogLimit int
+}
+
+type WebhookUseCase struct {
+	WebhookRepository webhook.WebhookRepositoryInterface
+	UserRepository    user.UserRepositoryInterface
+	APIService        security.IAPIService
+	Sender            webhookSender.ISender
+	Config            WebhookConfig
+	Logger            *logger.Logger
+	now               func() time.Time
+	wake              chan struct{}
+}
+
+func NewWebhookUseCase(
+	webhookRepository webhook.WebhookRepositoryInterface,
+	userRepository user.UserRepositoryInterface,
+	apiService security.IAPIService,
+	sender webhookSender.ISender,
+	loggerInstance *logger.Logger,
+) IWebhookUseCase {
+	return &WebhookUseCase{
+		WebhookRepository: webhookRepository,
+		UserRepository:    userRepository,
+		APIService:        apiService,
+		Sender:            sender,
+		Config:            loadWebhookConfig(),
+		Logger:            loggerInstance,
+		now:               time.Now,
+		wake:              make(chan struct{}, 1),
+	}
+}
+
+// loadWebhookConfig loads webhook configuration from environment variables
+func loadWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts:      getEnvAsIntOrDefault("WEBHOOK_MAX_ATTEMPTS", 6),
+		BackoffBase:      time.Duration(getEnvAsIntOrDefault("WEBHOOK_BACKOFF_BASE_SECONDS", 30)) * time.Second,
+		BackoffMax:       time.Duration(getEnvAsIntOrDefault("WEBHOOK_BACKOFF_MAX_SECONDS", 3600)) * time.Second,
+		PollInterval:     time.Duration(getEnvAsIntOrDefault("WEBHOOK_POLL_INTERVAL_SECONDS", 10)) * time.Second,
+		ClaimTimeout:     time.Duration(getEnvAsIntOrDefault("WEBHOOK_CLAIM_TIMEOUT_SECONDS", 600)) * time.Second,
+		BatchSize:        getEnvAsIntOrDefault("WEBHOOK_BATCH_SIZE", 50),
+		DeliveryLogLimit: getEnvAsIntOrDefault("WEBHOOK_DELIVERY_LOG_LIMIT", 100),
+	}
+}
+
+// Create registers a subscription and returns it together with its plain signing secret, which is
+// only shown once. A secret is generated when none is given.
+func (s *WebhookUseCase) Create(adminID int, targetURL string, secret string, eventTypes []string, currencies []string) (*domainWebhook.Subscription, string, error) {
+	s.Logger.Info("Creating webhook subscription", zap.Int("adminID", adminID), zap.String("url", targetURL))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, "", err
+	}
+	targetURL, eventTypes, currencies, err := validate(targetURL, eventTypes, currencies)
+	if err != nil {
+		return nil, "", err
+	}
+	if secret == "" {
+		if secret, err = s.APIService.GenerateApiKey(secretLength); err != nil {
+			s.Logger.Error("Error generating webhook secret", zap.Error(err))
+			return nil, "", domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	} else if len(secret) < minSecretLength {
+		return nil, "", domainErrors.NewAppError(fmt.Errorf("secret must be at least %d characters", minSecretLength), domainErrors.ValidationError)
+	}
+	encrypted, err := s.APIService.EncryptApiKey(secret)
+	if err != nil {
+		s.Logger.Error("Error encrypting webhook secret", zap.Error(err))
+		return nil, "", domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	created, err := s.WebhookRepository.CreateSubscription(&domainWebhook.Subscription{
+		URL:        targetURL,
+		Secret:     encrypted,
+		EventTypes: eventTypes,
+		Currencies: currencies,
+		Active:     true,
+		CreatedBy:  adminID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	s.Logger.Info("Webhook subscription created", zap.Int("id", created.ID))
+	return created, secret, nil
+}
+
+func (s *WebhookUseCase) GetAll(adminID int) (*[]domainWebhook.Subscription, error) {
+	s.Logger.Info("Getting webhook subscriptions", zap.Int("adminID", adminID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	return s.WebhookRepository.GetSubscriptions()
+}
+
+func (s *WebhookUseCase) GetByID(adminID int, id int) (*domainWebhook.Subscription, error) {
+	s.Logger.Info("Getting webhook subscription", zap.Int("adminID", adminID), zap.Int("id", id))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	return s.WebhookRepository.GetSubscriptionByID(id)
+}
+
+// Update replaces the URL, filters and active flag of a subscription. The secret cannot be changed;
+// rotate it by creating a new subscription.
+func (s *WebhookUseCase) Update(adminID int, id int, targetURL string, eventTypes []string, currencies []string, active bool) (*domainWebhook.Subscription, error) {
+	s.Logger.Info("Updating webhook subscription", zap.Int("adminID", adminID), zap.Int("id", id))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	targetURL, eventTypes, currencies, err := validate(targetURL, eventTypes, currencies)
+	if err != nil {
+		return nil, err
+	}
+	return s.WebhookRepository.UpdateSubscription(&domainWebhook.Subscription{
+		ID:         id,
+		URL:        targetURL,
+		EventTypes: eventTypes,
+		Currencies: currencies,
+		Active:     active,
+	})
+}
+
+func (s *WebhookUseCase) Delete(adminID int, id int) error {
+	s.Logger.Info("Deleting webhook subscription", zap.Int("adminID", adminID), zap.Int("id", id))
+	if err := s.requireAdmin(adminID); err != nil {
+		return err
+	}
+	return s.WebhookRepository.DeleteSubscription(id)
+}
+
+// GetDeliveries returns the most recent deliveries of a subscription, newest first
+func (s *WebhookUseCase) GetDeliveries(adminID int, subscriptionID int) (*[]domainWebhook.Delivery, error) {
+	s.Logger.Info("Getting webhook deliveries", zap.Int("adminID", adminID), zap.Int("subscriptionID", subscriptionID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	if _, err := s.WebhookRepository.GetSubscriptionByID(subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.WebhookRepository.GetDeliveriesBySubscriptionID(subscriptionID, s.Config.DeliveryLogLimit)
+}
+
+// RetryDelivery moves a dead delivery back to pending with a fresh set of attempts
+func (s *WebhookUseCase) RetryDelivery(adminID int, deliveryID int) (*domainWebhook.Delivery, error) {
+	s.Logger.Info("Retrying webhook delivery", zap.Int("adminID", adminID), zap.Int("deliveryID", deliveryID))
+	if err := s.requireAdmin(adminID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.WebhookRepository.GetDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != domainWebhook.DeliveryDead {
+		return nil, domainErrors.NewAppError(errors.New("only dead deliveries can be retried"), domainErrors.ValidationError)
+	}
+	now := s.now()
+	delivery.Status = domainWebhook.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	if err := s.WebhookRepository.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	s.notifyWorker()
+	return delivery, nil
+}
+
+// RateUpdate is one currency of a rates.updated payload
+type RateUpdate struct {
+	Code string  `json:"code"`
+	Rate float64 `json:"rate"`
+}
+
+type ratesUpdatedData struct {
+	Base  string       `json:"base"`
+	Rates []RateUpdate `json:"rates"`
+}
+
+type currencyDeletedData struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Envelope is the JSON body of every webhook
+type Envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// OnRatesUpdated queues a rates.updated delivery for every interested subscription, each
+// holding only the currencies that pass its filter
+func (s *WebhookUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {
+	sorted := make([]domainCurrency.Currency, len(currencies))
+	copy(sorted, currencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Code < sorted[j].Code })
+
+	s.publish(domainWebhook.EventRatesUpdated, func(subscription *domainWebhook.Subscription) (any, bool) {
+		rates := make([]RateUpdate, 0, len(sorted))
+		for _, c := range sorted {
+			if subscription.WantsCurrency(c.Code) {
+				rates = append(rates, RateUpdate{Code: c.Code, Rate: c.Rate})
+			}
+		}
+		return ratesUpdatedData{Base: domainCurrency.RateBase, Rates: rates}, len(rates) > 0
+	})
+}
+
+// OnCurrencyDeleted queues a currency.deleted delivery for every interested subscription
+func (s *WebhookUseCase) OnCurrencyDeleted(currency domainCurrency.Currency) {
+	s.publish(domainWebhook.EventCurrencyDeleted, func(subscription *domainWebhook.Subscription) (any, bool) {
+		return currencyDeletedData{ID: currency.ID, Code: currency.Code, Name: currency.Name}, subscription.WantsCurrency(currency.Code)
+	})
+}
+
+// publish stores one pending delivery per subscription that wants the event and wakes the worker.
+// data builds the payload of a subscription and reports whether anything is left to send.
+func (s *WebhookUseCase) publish(eventType string, data func(subscription *domainWebhook.Subscription) (any, bool)) {
+	subscriptions, err := s.WebhookRepository.GetActiveSubscriptions()
+	if err != nil {
+		s.Logger.Error("Error loading webhook subscriptions", zap.Error(err), zap.String("event", eventType))
+		return
+	}
+
+	now := s.now()
+	eventID := uuid.NewString()
+	queued := 0
+	for i := range *subscriptions {
+		subscription := &(*subscriptions)[i]
+		if !subscription.Wants(eventType) {
+			continue
+		}
+		payloadData, ok := data(subscription)
+		if !ok {
+			continue
+		}
+		payload, err := json.Marshal(Envelope{ID: eventID, Type: eventType, CreatedAt: now, Data: payloadData})
+		if err != nil {
+			s.Logger.Error("Error encoding webhook payload", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+			continue
+		}
+		_, err = s.WebhookRepository.CreateDelivery(&domainWebhook.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         domainWebhook.DeliveryPending,
+			NextAttemptAt:  &now,
+		})
+		if err != nil {
+			s.Logger.Error("Error queueing webhook delivery", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		s.Logger.Info("Webhook deliveries queued", zap.String("event", eventType), zap.Int("count", queued))
+		s.notifyWorker()
+	}
+}
+
+// Run delivers due webhooks until ctx is done, waking up on every publish and every poll interval
+func (s *WebhookUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.ProcessDue()
+	}
+}
+
+func (s *WebhookUseCase) notifyWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessDue attempts every due delivery once. Deliveries are claimed first, so instances running
+// side by side never send the same one. Failed deliveries are rescheduled with an exponential
+// back-off until they run out of attempts and become dead letters.
+func (s *WebhookUseCase) ProcessDue() {
+	now := s.now()
+	deliveries, err := s.WebhookRepository.ClaimDueDeliveries(now, now.Add(s.Config.ClaimTimeout), s.Config.BatchSize)
+	if err != nil {
+		s.Logger.Error("Error loading due webhook deliveries", zap.Error(err))
+		return
+	}
+	subscriptions := make(map[int]*domainWebhook.Subscription)
+	for i := range *deliveries {
+		delivery := &(*deliveries)[i]
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.WebhookRepository.GetSubscriptionByID(delivery.SubscriptionID)
+			if err != nil && !isNotFound(err) {
+				s.Logger.Error("Error loading webhook subscription", zap.Error(err), zap.Int("subscriptionID", delivery.SubscriptionID))
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		s.attempt(delivery, subscription)
+		if err := s.WebhookRepository.UpdateDelivery(delivery); err != nil {
+			s.Logger.Error("Error saving webhook delivery", zap.Error(err), zap.Int("deliveryID", delivery.ID))
+		}
+	}
+}
+
+// attempt sends a delivery once and records the outcome on it
+func (s *WebhookUseCase) attempt(delivery *domainWebhook.Delivery, subscription *domainWebhook.Subscription) {
+	if subscription == nil || !subscription.Active {
+		delivery.Status = domainWebhook.DeliveryDead
+		delivery.LastError = "subscription deleted or disabled"
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+	secret, err := s.APIService.DecryptApiKey(subscription.Secret)
+	if err == nil {
+		delivery.ResponseStatus, err = s.Sender.Send(subscription.URL, secret, delivery.EventType, delivery.ID, []byte(delivery.Payload))
+		if err == nil && (delivery.ResponseStatus < 200 || delivery.ResponseStatus >= 300) {
+			err = fmt.Errorf("endpoint responded with status %d", delivery.ResponseStatus)
+		}
+	}
+
+	now := s.now()
+	if err == nil {
+		delivery.Status = domainWebhook.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.Config.MaxAttempts {
+		s.Logger.Warn("Webhook delivery moved to dead letter", zap.Int("deliveryID", delivery.ID), zap.Int("attempts", delivery.Attempts), zap.Error(err))
+		delivery.Status = domainWebhook.DeliveryDead
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(s.backoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+	s.Logger.Info("Webhook delivery failed, retrying later", zap.Int("deliveryID", delivery.ID), zap.Int("attempts", delivery.Attempts), zap.Time("nextAttemptAt", next), zap.Error(err))
+}
+
+// backoff doubles the wait after every failed attempt, capped at BackoffMax
+func (s *WebhookUseCase) backoff(attempts int) time.Duration {
+	wait := s.Config.BackoffBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= s.Config.BackoffMax {
+			return s.Config.BackoffMax
+		}
+	}
+	return wait
+}
+
+func (s *WebhookUseCase) requireAdmin(userID int) error {
+	actor, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != domainUser.RoleAdmin {
+		s.Logger.Warn("Admin operation rejected", zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized)
+	}
+	return nil
+}
+
+// validate checks the target URL and event types and normalizes the currency filter
+func validate(targetURL string, eventTypes []string, currencies []string) (string, []string, []string, error) {
+	targetURL = strings.TrimSpace(targetURL)
+	parsed, err := url.Parse(targetURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", nil, nil, domainErrors.NewAppError(errors.New("url must be an absolute http or https URL"), domainErrors.ValidationError)
+	}
+	if len(eventTypes) == 0 {
+		return "", nil, nil, domainErrors.NewAppError(errors.New("at least one event type is required"), domainErrors.ValidationError)
+	}
+	for _, eventType := range eventTypes {
+		if !domainWebhook.IsValidEventType(eventType) {
+			return "", nil, nil, domainErrors.NewAppError(fmt.Errorf("unknown event type %q", eventType), domainErrors.ValidationError)
+		}
+	}
+	normalized := make([]string, 0, len(currencies))
+	for _, code := range currencies {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" || strings.Contains(code, ",") {
+			return "", nil, nil, domainErrors.NewAppError(fmt.Errorf("invalid currency code %q", code), domainErrors.ValidationError)
+		}
+		normalized = append(normalized, code)
+	}
+	return targetURL, eventTypes, normalized, nil
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,357 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+)
+
+type mockWebhookRepository struct {
+	subscriptions map[int]*domainWebhook.Subscription
+	deliveries    []domainWebhook.Delivery
+	updated       []domainWebhook.Delivery
+}
+
+func (m *mockWebhookRepository) CreateSubscription(s *domainWebhook.Subscription) (*domainWebhook.Subscription, error) {
+	saved := *s
+	saved.ID = len(m.subscriptions) + 1
+	m.subscriptions[saved.ID] = &saved
+	return &saved, nil
+}
+func (m *mockWebhookRepository) GetSubscriptions() (*[]domainWebhook.Subscription, error) {
+	var subscriptions []domainWebhook.Subscription
+	for _, s := range m.subscriptions {
+		subscriptions = append(subscriptions, *s)
+	}
+	return &subscriptions, nil
+}
+func (m *mockWebhookRepository) GetActiveSubscriptions() (*[]domainWebhook.Subscription, error) {
+	var subscriptions []domainWebhook.Subscription
+	for _, s := range m.subscriptions {
+		if s.Active {
+			subscriptions = append(subscriptions, *s)
+		}
+	}
+	return &subscriptions, nil
+}
+func (m *mockWebhookRepository) GetSubscriptionByID(id int) (*domainWebhook.Subscription, error) {
+	if s, ok := m.subscriptions[id]; ok {
+		return s, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+func (m *mockWebhookRepository) UpdateSubscription(s *domainWebhook.Subscription) (*domainWebhook.Subscription, error) {
+	return s, nil
+}
+func (m *mockWebhookRepository) DeleteSubscription(id int) error {
+	delete(m.subscriptions, id)
+	return nil
+}
+func (m *mockWebhookRepository) CreateDelivery(d *domainWebhook.Delivery) (*domainWebhook.Delivery, error) {
+	saved := *d
+	saved.ID = len(m.deliveries) + 1
+	m.deliveries = append(m.deliveries, saved)
+	return &saved, nil
+}
+func (m *mockWebhookRepository) GetDeliveryByID(id int) (*domainWebhook.Delivery, error) {
+	for _, d := range m.deliveries {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+func (m *mockWebhookRepository) GetDeliveriesBySubscriptionID(subscriptionID int, limit int) (*[]domainWebhook.Delivery, error) {
+	return &m.deliveries, nil
+}
+func (m *mockWebhookRepository) ClaimDueDeliveries(now time.Time, claimedUntil time.Time, limit int) (*[]domainWebhook.Delivery, error) {
+	var due []domainWebhook.Delivery
+	for i, d := range m.deliveries {
+		if d.Status == domainWebhook.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			m.deliveries[i].NextAttemptAt = &claimedUntil
+			due = append(due, m.deliveries[i])
+		}
+	}
+	return &due, nil
+}
+func (m *mockWebhookRepository) UpdateDelivery(d *domainWebhook.Delivery) error {
+	m.updated = append(m.updated, *d)
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == d.ID {
+			m.deliveries[i] = *d
+		}
+	}
+	return nil
+}
+
+type mockUserRepository struct {
+	user.UserRepositoryInterface
+	users map[int]*domainUser.User
+}
+
+func (m *mockUserRepository) GetByID(id int) (*domainUser.User, error) {
+	if u, ok := m.users[id]; ok {
+		return u, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockAPIService stores secrets with a visible prefix instead of encrypting them
+type mockAPIService struct{}
+
+func (m *mockAPIService) GenerateApiKey(length int) (string, error) {
+	return "generated-secret-0123456789", nil
+}
+func (m *mockAPIService) EncryptApiKey(value string) (string, error) {
+	return "enc:" + value, nil
+}
+func (m *mockAPIService) DecryptApiKey(value string) (string, error) {
+	return value[len("enc:"):], nil
+}
+
+type sentRequest struct {
+	url     string
+	secret  string
+	event   string
+	payload string
+}
+
+type mockSender struct {
+	status int
+	err    error
+	sent   []sentRequest
+}
+
+func (m *mockSender) Send(url string, secret string, eventType string, deliveryID int, payload []byte) (int, error) {
+	m.sent = append(m.sent, sentRequest{url: url, secret: secret, event: eventType, payload: string(payload)})
+	return m.status, m.err
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func newTestUseCase(t *testing.T, repo *mockWebhookRepository, sender *mockSender, now *time.Time) *WebhookUseCase {
+	return &WebhookUseCase{
+		WebhookRepository: repo,
+		UserRepository: &mockUserRepository{users: map[int]*domainUser.User{
+			1: {ID: 1, Role: domainUser.RoleAdmin},
+			2: {ID: 2, Role: domainUser.RoleSubscriber},
+		}},
+		APIService: &mockAPIService{},
+		Sender:     sender,
+		Config: WebhookConfig{
+			MaxAttempts:      3,
+			BackoffBase:      time.Minute,
+			BackoffMax:       90 * time.Second,
+			ClaimTimeout:     10 * time.Minute,
+			BatchSize:        10,
+			DeliveryLogLimit: 10,
+		},
+		Logger: setupLogger(t),
+		now:    func() time.Time { return *now },
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != expected {
+		t.Errorf("expected %s error, got %v", expected, err)
+	}
+}
+
+func TestWebhookUseCase_Create(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{}}
+	uc := newTestUseCase(t, repo, &mockSender{}, &now)
+
+	subscription, secret, err := uc.Create(1, "https://example.com/hook", "", []string{domainWebhook.EventRatesUpdated}, []string{" eur "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret != "generated-secret-0123456789" || subscription.Secret != "enc:"+secret {
+		t.Errorf("expected the generated secret to be returned and stored encrypted, got %q / %q", secret, subscription.Secret)
+	}
+	if !subscription.Active || len(subscription.Currencies) != 1 || subscription.Currencies[0] != "EUR" {
+		t.Errorf("unexpected subscription %+v", subscription)
+	}
+
+	_, _, err = uc.Create(2, "https://example.com/hook", "", []string{domainWebhook.EventRatesUpdated}, nil)
+	assertErrorType(t, err, domainErrors.NotAuthorized)
+
+	_, _, err = uc.Create(1, "ftp://example.com", "", []string{domainWebhook.EventRatesUpdated}, nil)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	_, _, err = uc.Create(1, "https://example.com/hook", "", []string{"rates.deleted"}, nil)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	_, _, err = uc.Create(1, "https://example.com/hook", "short", []string{domainWebhook.EventRatesUpdated}, nil)
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestWebhookUseCase_OnRatesUpdated(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{
+		1: {ID: 1, URL: "https://a.example", Secret: "enc:a", Active: true, EventTypes: []string{domainWebhook.EventRatesUpdated}},
+		2: {ID: 2, URL: "https://b.example", Secret: "enc:b", Active: true, EventTypes: []string{domainWebhook.EventRatesUpdated}, Currencies: []string{"GBP"}},
+		3: {ID: 3, URL: "https://c.example", Secret: "enc:c", Active: true, EventTypes: []string{domainWebhook.EventRatesUpdated}, Currencies: []string{"JPY"}},
+		4: {ID: 4, URL: "https://d.example", Secret: "enc:d", Active: true, EventTypes: []string{domainWebhook.EventCurrencyDeleted}},
+	}}
+	uc := newTestUseCase(t, repo, &mockSender{}, &now)
+
+	uc.OnRatesUpdated([]domainCurrency.Currency{{Code: "GBP", Rate: 0.8}, {Code: "EUR", Rate: 0.9}})
+
+	if len(repo.deliveries) != 2 {
+		t.Fatalf("expected deliveries for subscriptions 1 and 2, got %+v", repo.deliveries)
+	}
+	for _, delivery := range repo.deliveries {
+		var envelope struct {
+			Type string           `json:"type"`
+			Data ratesUpdatedData `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(delivery.Payload), &envelope); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		if envelope.Type != domainWebhook.EventRatesUpdated || delivery.Status != domainWebhook.DeliveryPending {
+			t.Errorf("unexpected delivery %+v", delivery)
+		}
+		switch delivery.SubscriptionID {
+		case 1:
+			if len(envelope.Data.Rates) != 2 || envelope.Data.Rates[0].Code != "EUR" {
+				t.Errorf("expected every rate sorted by code, got %+v", envelope.Data.Rates)
+			}
+		case 2:
+			if len(envelope.Data.Rates) != 1 || envelope.Data.Rates[0].Code != "GBP" {
+				t.Errorf("expected only GBP, got %+v", envelope.Data.Rates)
+			}
+		default:
+			t.Errorf("unexpected delivery for subscription %d", delivery.SubscriptionID)
+		}
+	}
+	if repo.deliveries[0].EventID == "" || repo.deliveries[0].EventID != repo.deliveries[1].EventID {
+		t.Error("expected both deliveries to share the event id")
+	}
+}
+
+func TestWebhookUseCase_OnCurrencyDeleted(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{
+		1: {ID: 1, URL: "https://a.example", Secret: "enc:a", Active: true, EventTypes: []string{domainWebhook.EventCurrencyDeleted}},
+		2: {ID: 2, URL: "https://b.example", Secret: "enc:b", Active: false, EventTypes: []string{domainWebhook.EventCurrencyDeleted}},
+	}}
+	uc := newTestUseCase(t, repo, &mockSender{}, &now)
+
+	uc.OnCurrencyDeleted(domainCurrency.Currency{ID: 5, Code: "EUR"})
+	if len(repo.deliveries) != 1 || repo.deliveries[0].EventType != domainWebhook.EventCurrencyDeleted {
+		t.Errorf("expected one currency.deleted delivery, got %+v", repo.deliveries)
+	}
+}
+
+func TestWebhookUseCase_ProcessDue_RetriesThenDeadLetters(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{
+		1: {ID: 1, URL: "https://a.example", Secret: "enc:secret", Active: true, EventTypes: []string{domainWebhook.EventRatesUpdated}},
+	}}
+	sender := &mockSender{status: http.StatusInternalServerError}
+	uc := newTestUseCase(t, repo, sender, &now)
+	repo.deliveries = []domainWebhook.Delivery{{ID: 1, SubscriptionID: 1, EventType: domainWebhook.EventRatesUpdated, Payload: "{}", Status: domainWebhook.DeliveryPending, NextAttemptAt: &now}}
+
+	uc.ProcessDue()
+	delivery := repo.deliveries[0]
+	if len(sender.sent) != 1 || sender.sent[0].secret != "secret" {
+		t.Fatalf("expected one attempt signed with the decrypted secret, got %+v", sender.sent)
+	}
+	if delivery.Status != domainWebhook.DeliveryPending || delivery.Attempts != 1 || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a retry in one minute, got %+v", delivery)
+	}
+
+	uc.ProcessDue()
+	if len(sender.sent) != 1 {
+		t.Fatal("expected no attempt before the back-off elapsed")
+	}
+
+	now = now.Add(time.Minute)
+	uc.ProcessDue()
+	delivery = repo.deliveries[0]
+	if delivery.Attempts != 2 || !delivery.NextAttemptAt.Equal(now.Add(90*time.Second)) {
+		t.Fatalf("expected the back-off to double and be capped, got %+v", delivery)
+	}
+
+	now = now.Add(90 * time.Second)
+	uc.ProcessDue()
+	delivery = repo.deliveries[0]
+	if delivery.Status != domainWebhook.DeliveryDead || delivery.NextAttemptAt != nil || delivery.LastError == "" {
+		t.Fatalf("expected the delivery to become a dead letter, got %+v", delivery)
+	}
+
+	retried, err := uc.RetryDelivery(1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retried.Status != domainWebhook.DeliveryPending || retried.Attempts != 0 {
+		t.Errorf("expected the dead letter to be requeued, got %+v", retried)
+	}
+
+	sender.status = http.StatusOK
+	uc.ProcessDue()
+	delivery = repo.deliveries[0]
+	if delivery.Status != domainWebhook.DeliveryDelivered || delivery.DeliveredAt == nil || delivery.ResponseStatus != http.StatusOK {
+		t.Errorf("expected the delivery to succeed, got %+v", delivery)
+	}
+
+	_, err = uc.RetryDelivery(1, 1)
+	assertErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestWebhookUseCase_ProcessDue_SkipsClaimedDeliveries(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{
+		1: {ID: 1, URL: "https://a.example", Secret: "enc:secret", Active: true, EventTypes: []string{domainWebhook.EventRatesUpdated}},
+	}}
+	repo.deliveries = []domainWebhook.Delivery{{ID: 1, SubscriptionID: 1, EventType: domainWebhook.EventRatesUpdated, Payload: "{}", Status: domainWebhook.DeliveryPending, NextAttemptAt: &now}}
+	sender := &mockSender{status: http.StatusOK}
+	other := newTestUseCase(t, repo, &mockSender{}, &now)
+	uc := newTestUseCase(t, repo, sender, &now)
+
+	if claimed, _ := other.WebhookRepository.ClaimDueDeliveries(now, now.Add(other.Config.ClaimTimeout), 10); len(*claimed) != 1 {
+		t.Fatalf("expected the other instance to claim the delivery, got %+v", *claimed)
+	}
+	uc.ProcessDue()
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected a delivery claimed by another instance not to be sent, got %+v", sender.sent)
+	}
+
+	now = now.Add(10 * time.Minute)
+	uc.ProcessDue()
+	if len(sender.sent) != 1 || repo.deliveries[0].Status != domainWebhook.DeliveryDelivered {
+		t.Errorf("expected the delivery to be sent once its claim expired, got %+v", repo.deliveries[0])
+	}
+}
+
+func TestWebhookUseCase_ProcessDue_DeletedSubscription(t *testing.T) {
+	now := time.Now()
+	repo := &mockWebhookRepository{subscriptions: map[int]*domainWebhook.Subscription{}}
+	sender := &mockSender{status: http.StatusOK}
+	uc := newTestUseCase(t, repo, sender, &now)
+	repo.deliveries = []domainWebhook.Delivery{{ID: 1, SubscriptionID: 9, Status: domainWebhook.DeliveryPending, NextAttemptAt: &now}}
+
+	uc.ProcessDue()
+	if len(sender.sent) != 0 || repo.deliveries[0].Status != domainWebhook.DeliveryDead {
+		t.Errorf("expected the delivery to be dead without an attempt, got %+v", repo.deliveries[0])
+	}
+}
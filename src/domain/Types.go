@@ -19,6 +19,16 @@ func (sd SortDirection) IsValid() bool {
 	return sd == SortAsc || sd == SortDesc
 }
 
+// PaginationMode selects how a search pages through its results. Offset pages are numbered and
+// counted; cursor pages follow one another from an opaque cursor, which stays fast and stable on
+// large tables.
+type PaginationMode string
+
+const (
+	PaginationOffset PaginationMode = "offset"
+	PaginationCursor PaginationMode = "cursor"
+)
+
 type DataFilters struct {
 	LikeFilters      map[string][]string `json:"likeFilters"`
 	Matches          map[string][]string `json:"matches"`
@@ -27,4 +37,6 @@ type DataFilters struct {
 	SortDirection    SortDirection       `json:"sortDirection"`
 	Page             int                 `json:"page"`
 	PageSize         int                 `json:"pageSize"`
+	Pagination       PaginationMode      `json:"pagination,omitempty"`
+	Cursor           string              `json:"cursor,omitempty"`
 }
@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"time"
+
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+)
+
+type Condition string
+
+const (
+	// ConditionAbove triggers when the rate rises above the threshold
+	ConditionAbove Condition = "above"
+	// ConditionBelow triggers when the rate falls below the threshold
+	ConditionBelow Condition = "below"
+	// ConditionChangePercent triggers when the rate moves by at least threshold percent,
+	// in either direction, from the reference rate taken at the start of the current day window
+	ConditionChangePercent Condition = "change_percent"
+)
+
+// ChangeWindow is how long a reference rate is kept before ConditionChangePercent re-bases
+const ChangeWindow = 24 * time.Hour
+
+// IsValid reports whether the condition is known
+func (c Condition) IsValid() bool {
+	switch c {
+	case ConditionAbove, ConditionBelow, ConditionChangePercent:
+		return true
+	}
+	return false
+}
+
+type Rule struct {
+	ID              int
+	UserID          int
+	Pair            domainWatchlist.Pair
+	Condition       Condition
+	Threshold       float64
+	Cooldown        time.Duration
+	ReferenceRate   float64
+	ReferenceAt     *time.Time
+	LastTriggeredAt *time.Time
+	CreatedAt       time.Time
+}
+
+// InCooldown reports whether the rule triggered too recently to trigger again
+func (r *Rule) InCooldown(now time.Time) bool {
+	return r.LastTriggeredAt != nil && now.Before(r.LastTriggeredAt.Add(r.Cooldown))
+}
+
+// Event records a rule that triggered
+type Event struct {
+	ID            int
+	RuleID        int
+	UserID        int
+	Pair          domainWatchlist.Pair
+	Condition     Condition
+	Threshold     float64
+	Rate          float64
+	ReferenceRate float64
+	TriggeredAt   time.Time
+}
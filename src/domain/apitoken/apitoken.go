@@ -0,0 +1,25 @@
+package apitoken
+
+import (
+	"time"
+)
+
+// TokenPrefix marks personal API tokens so they can be told apart from JWTs
+const TokenPrefix = "pat_"
+
+type APIToken struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// IsExpired reports whether the token can no longer be used at the given moment
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
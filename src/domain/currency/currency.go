@@ -2,8 +2,13 @@ package currency
 
 import (
 	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
 )
 
+// RateBase is the currency every stored rate is quoted against; it may not be stored itself
+const RateBase = "USD"
+
 type Currency struct {
 	ID        int
 	Name      string
@@ -14,9 +19,175 @@ type Currency struct {
 	UpdatedAt time.Time
 }
 
+type SearchResultCurrency struct {
+	Data       *[]Currency
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+	NextCursor string
+	PrevCursor string
+}
+
+// Interval is the width of an OHLC bucket
+type Interval string
+
+const (
+	IntervalHour Interval = "1h"
+	IntervalDay  Interval = "1d"
+)
+
+// IsValid reports whether the interval is supported
+func (i Interval) IsValid() bool {
+	return i == IntervalHour || i == IntervalDay
+}
+
+// Duration is the length of one bucket of the interval
+func (i Interval) Duration() time.Duration {
+	if i == IntervalDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// OHLC summarises the rates recorded during one bucket, starting at Start (UTC)
+type OHLC struct {
+	Start   time.Time
+	Open    float64
+	High    float64
+	Low     float64
+	Close   float64
+	Samples int
+}
+
+// RateStats summarises the rates of a currency recorded in [From, To). Volatility is the sample
+// standard deviation of the log returns between consecutive recorded rates.
+type RateStats struct {
+	Code          string
+	From          time.Time
+	To            time.Time
+	Samples       int
+	Min           float64
+	Max           float64
+	Mean          float64
+	Volatility    float64
+	First         float64
+	Last          float64
+	ChangePercent float64
+}
+
+// RatePoint is the rate of a currency as recorded at a point in time
+type RatePoint struct {
+	Code       string
+	Rate       float64
+	RecordedAt time.Time
+	Source     string
+}
+
+// Sources of the recorded rates
+const (
+	RateSourceProvider     = "provider"
+	RateSourceManualImport = "manual-import"
+)
+
+// ConversionLeg is the rate used for one side of a conversion. RecordedAt is nil for the rate
+// base, whose rate is always 1.
+type ConversionLeg struct {
+	Code       string
+	Rate       float64
+	RecordedAt *time.Time
+}
+
+// Conversion is Amount of From converted to To. At is the time asked for, nil for current rates.
+type Conversion struct {
+	From   ConversionLeg
+	To     ConversionLeg
+	Amount float64
+	Rate   float64
+	Result float64
+	At     *time.Time
+}
+
+// MaxBatchConversions bounds the items of one batch conversion
+const MaxBatchConversions = 1000
+
+// ConversionRequest asks for Amount of From in To, at the current rates when At is nil
+type ConversionRequest struct {
+	From   string
+	To     string
+	Amount float64
+	At     *time.Time
+}
+
+// ConversionResult is the outcome of one item of a batch: a conversion or the reason it failed
+type ConversionResult struct {
+	Conversion *Conversion
+	Err        error
+}
+
+// BatchConversion holds the results of a batch in request order. SnapshotAt is when the current
+// rates shared by every item were read.
+type BatchConversion struct {
+	SnapshotAt time.Time
+	Results    []ConversionResult
+}
+
+// MaxImportRows bounds the rows of one import
+const MaxImportRows = 5000
+
+// ImportRow is one rate of an import file. Name is only required for a code missing from the
+// catalog, which adds the currency.
+type ImportRow struct {
+	Line int
+	Code string
+	Name string
+	Rate float64
+}
+
+type ImportAction string
+
+const (
+	ImportCreate    ImportAction = "create"
+	ImportUpdate    ImportAction = "update"
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+// ImportChange is what an import does to one currency, or why its row was rejected
+type ImportChange struct {
+	Line    int
+	Code    string
+	Action  ImportAction
+	Name    string
+	OldRate *float64
+	Rate    float64
+	Err     error
+}
+
+// ImportReport describes an import row by row. Nothing is applied on a dry run or when any row
+// was rejected.
+type ImportReport struct {
+	DryRun    bool
+	Applied   bool
+	Source    string
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    int
+	Changes   []ImportChange
+}
+
 type ICurrencyService interface {
 	GetAll() (*[]Currency, error)
 	GetByID(id int) (*Currency, error)
 	Delete(id int) error
 	UpdateExchanges() (any, error)
+	GetOHLC(code string, interval Interval, from, to *time.Time) (*[]OHLC, error)
+	GetStats(code string, from, to *time.Time) (*RateStats, error)
+	Convert(from, to string, amount float64, at *time.Time) (*Conversion, error)
+	ConvertBatch(items []ConversionRequest) (*BatchConversion, error)
+	SearchPaginated(filters domain.DataFilters) (*SearchResultCurrency, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	StreamSearch(filters domain.DataFilters, fn func(*Currency) error) error
+	StreamHistory(code string, from, to *time.Time, fn func(*RatePoint) error) error
+	Import(rows []ImportRow, dryRun bool) (*ImportReport, error)
 }
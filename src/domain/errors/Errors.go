@@ -30,6 +30,12 @@ const (
 	NotAuthorized             ErrorType    = "NotAuthorized"
 	notAuthorizedErrorMessage ErrorMessage = "not authorized"
 
+	TooManyRequests             ErrorType    = "TooManyRequests"
+	tooManyRequestsErrorMessage ErrorMessage = "too many requests, try again later"
+
+	AccountLocked             ErrorType    = "AccountLocked"
+	accountLockedErrorMessage ErrorMessage = "account is temporarily locked"
+
 	UnknownError        ErrorType    = "UnknownError"
 	unknownErrorMessage ErrorMessage = "something went wrong"
 )
@@ -84,6 +90,12 @@ func NewAppErrorWithType(errType ErrorType) *AppError {
 	case NotAuthorized:
 		err = errors.New(string(notAuthorizedErrorMessage))
 		message = string(notAuthorizedErrorMessage)
+	case TooManyRequests:
+		err = errors.New(string(tooManyRequestsErrorMessage))
+		message = string(tooManyRequestsErrorMessage)
+	case AccountLocked:
+		err = errors.New(string(accountLockedErrorMessage))
+		message = string(accountLockedErrorMessage)
 	case TokenGeneratorError:
 		err = errors.New(string(tokenGeneratorErrorMessage))
 	default:
@@ -102,6 +114,10 @@ func (appErr *AppError) Error() string {
 	return appErr.Err.Error()
 }
 
+func (appErr *AppError) Unwrap() error {
+	return appErr.Err
+}
+
 // AppErrorToHTTP maps an AppError to an HTTP status code and message
 func AppErrorToHTTP(appErr *AppError) (int, string) {
 	switch appErr.Type {
@@ -118,6 +134,10 @@ func AppErrorToHTTP(appErr *AppError) (int, string) {
 		return http.StatusUnauthorized, appErr.Error()
 	case NotAuthorized:
 		return http.StatusForbidden, appErr.Error()
+	case TooManyRequests:
+		return http.StatusTooManyRequests, appErr.Error()
+	case AccountLocked:
+		return http.StatusLocked, appErr.Error()
 	default:
 		return http.StatusInternalServerError, "Internal Server Error"
 	}
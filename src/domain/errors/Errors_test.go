@@ -139,6 +139,22 @@ func TestAppErrorToHTTP_NotAuthorized(t *testing.T) {
 	assert.Equal(t, "not authorized", message)
 }
 
+func TestAppErrorToHTTP_TooManyRequests(t *testing.T) {
+	appError := NewAppErrorWithType(TooManyRequests)
+	statusCode, message := AppErrorToHTTP(appError)
+
+	assert.Equal(t, http.StatusTooManyRequests, statusCode)
+	assert.Equal(t, "too many requests, try again later", message)
+}
+
+func TestAppErrorToHTTP_AccountLocked(t *testing.T) {
+	appError := NewAppErrorWithType(AccountLocked)
+	statusCode, message := AppErrorToHTTP(appError)
+
+	assert.Equal(t, http.StatusLocked, statusCode)
+	assert.Equal(t, "account is temporarily locked", message)
+}
+
 func TestAppErrorToHTTP_UnknownError(t *testing.T) {
 	appError := NewAppErrorWithType(UnknownError)
 	statusCode, message := AppErrorToHTTP(appError)
@@ -180,5 +196,7 @@ func TestErrorTypeConstants(t *testing.T) {
 	assert.Equal(t, ErrorType("NotAuthenticated"), NotAuthenticated)
 	assert.Equal(t, ErrorType("NotAuthorized"), NotAuthorized)
 	assert.Equal(t, ErrorType("TokenGeneratorError"), TokenGeneratorError)
+	assert.Equal(t, ErrorType("TooManyRequests"), TooManyRequests)
+	assert.Equal(t, ErrorType("AccountLocked"), AccountLocked)
 	assert.Equal(t, ErrorType("UnknownError"), UnknownError)
 }
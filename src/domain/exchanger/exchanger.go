@@ -1,17 +1,148 @@
 package exchanger
 
 import (
+	"strings"
 	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
 )
 
+// DefaultWeight is the weight of an exchanger created without one
+const DefaultWeight = 1.0
+
+// Exchanger is a rate provider. For each currency, refreshes average the rates of the exchangers
+// with the highest Priority that quote it, weighted by Weight; AllowedCodes, when set, and
+// DeniedCodes restrict the currencies an exchanger is used for. MonthlyQuota and MinInterval,
+// when set, bound how often refreshes call it.
 type Exchanger struct {
-	ID        int
-	Name      string
-	ApiKey    string
-	Url       string
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           int
+	Name         string
+	ApiKey       string
+	Url          string
+	IsActive     bool
+	Priority     int
+	Weight       float64
+	AllowedCodes []string
+	DeniedCodes  []string
+	MonthlyQuota int
+	MinInterval  time.Duration
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Covers reports whether the exchanger is used for a currency
+func (e *Exchanger) Covers(code string) bool {
+	for _, denied := range e.DeniedCodes {
+		if strings.EqualFold(denied, code) {
+			return false
+		}
+	}
+	if len(e.AllowedCodes) == 0 {
+		return true
+	}
+	for _, allowed := range e.AllowedCodes {
+		if strings.EqualFold(allowed, code) {
+			return true
+		}
+	}
+	return false
+}
+
+type CircuitState string
+
+const (
+	// CircuitClosed lets refreshes call the exchanger
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen skips the exchanger until its cooldown has passed
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single refresh probe the exchanger
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// Actors of an exchanger status change
+const (
+	StatusActorCircuitBreaker = "circuit-breaker"
+	StatusActorManual         = "manual"
+)
+
+// Health is how an exchanger has been answering refreshes. Latencies are percentiles over the
+// recent fetches, failed ones included.
+type Health struct {
+	ExchangerID         int
+	State               CircuitState
+	Successes           int64
+	Failures            int64
+	ConsecutiveFailures int
+	LastError           string
+	LastErrorAt         *time.Time
+	LastSuccessAt       *time.Time
+	OpenedAt            *time.Time
+	// AutoDisabled is set while the circuit breaker, not a person, has deactivated the exchanger
+	AutoDisabled  bool
+	Samples       int
+	LatencyP50    time.Duration
+	LatencyP95    time.Duration
+	LatencyP99    time.Duration
+	StatusChanges []StatusChange
+}
+
+// Fetch is one call a refresh made to an exchanger
+type Fetch struct {
+	ExchangerID int
+	Succeeded   bool
+	Latency     time.Duration
+	Error       string
+	At          time.Time
+}
+
+// StatusChange is an entry of the audit trail of IsActive
+type StatusChange struct {
+	ID          int
+	ExchangerID int
+	IsActive    bool
+	Actor       string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+// UsagePeriodLayout formats the calendar month (UTC) calls are counted in
+const UsagePeriodLayout = "2006-01"
+
+// UsagePeriod returns the period a call made at t is counted in
+func UsagePeriod(t time.Time) string {
+	return t.UTC().Format(UsagePeriodLayout)
+}
+
+// Usage is the number of calls refreshes made to an exchanger in a period
+type Usage struct {
+	ExchangerID int
+	Period      string
+	Calls       int
+	LastCallAt  *time.Time
+}
+
+// UsageReport is the quota consumption of an exchanger in the current period, with the previous
+// periods in History, newest first
+type UsageReport struct {
+	ExchangerID  int
+	Period       string
+	Calls        int
+	MonthlyQuota int
+	MinInterval  time.Duration
+	LastCallAt   *time.Time
+	NextCallAt   *time.Time
+	Warnings     []string
+	History      []Usage
+}
+
+type SearchResultExchanger struct {
+	Data       *[]Exchanger
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+	NextCursor string
+	PrevCursor string
 }
 
 type IExchangerService interface {
@@ -20,4 +151,8 @@ type IExchangerService interface {
 	Create(newUser *Exchanger) (*Exchanger, error)
 	Delete(id int) error
 	Update(id int, userMap map[string]interface{}) (*Exchanger, error)
+	SearchPaginated(filters domain.DataFilters) (*SearchResultExchanger, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetHealth(id int) (*Health, error)
+	GetUsage(id int) (*UsageReport, error)
 }
@@ -68,3 +68,19 @@ func TestUser_ZeroValues(t *testing.T) {
 		t.Errorf("Expected UpdatedAt to be zero, got %v", user.UpdatedAt)
 	}
 }
+
+func TestExchanger_Covers(t *testing.T) {
+	e := Exchanger{}
+	if !e.Covers("EUR") {
+		t.Error("expected no lists to cover every currency")
+	}
+	e.AllowedCodes = []string{"EUR", "GBP"}
+	e.DeniedCodes = []string{"GBP"}
+	if !e.Covers("eur") || e.Covers("GBP") || e.Covers("JPY") {
+		t.Error("expected only EUR to be covered")
+	}
+	e.AllowedCodes = nil
+	if !e.Covers("JPY") || e.Covers("GBP") {
+		t.Error("expected every currency but GBP to be covered")
+	}
+}
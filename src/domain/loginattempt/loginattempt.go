@@ -0,0 +1,41 @@
+package loginattempt
+
+import (
+	"time"
+)
+
+type Reason string
+
+const (
+	ReasonSuccess              Reason = "success"
+	ReasonInvalidCredentials   Reason = "invalid_credentials"
+	ReasonAccountLocked        Reason = "account_locked"
+	ReasonAccountDisabled      Reason = "account_disabled"
+	ReasonThrottled            Reason = "throttled"
+	ReasonTwoFactorRequired    Reason = "two_factor_required"
+	ReasonInvalidTwoFactorCode Reason = "invalid_two_factor_code"
+)
+
+type LoginAttempt struct {
+	ID        int
+	UserID    *int
+	Email     string
+	IP        string
+	Success   bool
+	Reason    Reason
+	CreatedAt time.Time
+}
+
+// Filter narrows the login attempts returned for review
+type Filter struct {
+	UserID int
+	Email  string
+	IP     string
+	Limit  int
+}
+
+// FailureStats summarises the failed attempts of a client within a window
+type FailureStats struct {
+	Count         int64
+	LastFailureAt *time.Time
+}
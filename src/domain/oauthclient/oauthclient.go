@@ -0,0 +1,25 @@
+package oauthclient
+
+import (
+	"time"
+)
+
+// GrantClientCredentials is the only OAuth2 grant type supported for clients
+const GrantClientCredentials = "client_credentials"
+
+// ClientIDPrefix marks OAuth2 client ids
+const ClientIDPrefix = "cli_"
+
+// Client is a machine client of the client_credentials grant. Audiences, when set, replace
+// the default aud claim of the tokens issued to it.
+type Client struct {
+	ID         int
+	ClientID   string
+	Name       string
+	SecretHash string
+	Scopes     []string
+	Audiences  []string
+	CreatedBy  int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
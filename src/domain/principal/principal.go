@@ -0,0 +1,25 @@
+package principal
+
+import (
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+)
+
+// Principal is the caller resolved by the auth middleware. Interactive users and personal API
+// tokens carry a UserID and Role; OAuth2 clients carry a ClientID instead.
+// Scopes is nil for interactive user sessions, which are not scope-restricted.
+type Principal struct {
+	UserID   int
+	ClientID string
+	Role     domainUser.Role
+	Scopes   []string
+}
+
+// IsUser reports whether the principal acts on behalf of a user
+func (p *Principal) IsUser() bool {
+	return p != nil && p.UserID != 0
+}
+
+// IsAdmin reports whether the principal is a user holding the admin role
+func (p *Principal) IsAdmin() bool {
+	return p.IsUser() && p.Role == domainUser.RoleAdmin
+}
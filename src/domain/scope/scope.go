@@ -0,0 +1,53 @@
+package scope
+
+// Scopes restrict what machine clients may do. Interactive user sessions are not scope-restricted.
+const (
+	CurrencyRead   = "currency:read"
+	CurrencyWrite  = "currency:write"
+	ExchangerRead  = "exchanger:read"
+	ExchangerWrite = "exchanger:write"
+	UserRead       = "user:read"
+	UserWrite      = "user:write"
+	WatchlistRead  = "watchlist:read"
+	WatchlistWrite = "watchlist:write"
+	AlertRead      = "alert:read"
+	AlertWrite     = "alert:write"
+	WebhookRead    = "webhook:read"
+	WebhookWrite   = "webhook:write"
+)
+
+// All lists every scope that can be granted
+var All = []string{
+	CurrencyRead,
+	CurrencyWrite,
+	ExchangerRead,
+	ExchangerWrite,
+	UserRead,
+	UserWrite,
+	WatchlistRead,
+	WatchlistWrite,
+	AlertRead,
+	AlertWrite,
+	WebhookRead,
+	WebhookWrite,
+}
+
+// IsValid reports whether the scope is known
+func IsValid(s string) bool {
+	for _, known := range All {
+		if known == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether granted includes the required scope
+func Contains(granted []string, required string) bool {
+	for _, s := range granted {
+		if s == required {
+			return true
+		}
+	}
+	return false
+}
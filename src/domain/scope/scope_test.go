@@ -0,0 +1,27 @@
+package scope
+
+import "testing"
+
+func TestIsValid(t *testing.T) {
+	for _, s := range All {
+		if !IsValid(s) {
+			t.Errorf("expected %s to be valid", s)
+		}
+	}
+	if IsValid("currency:admin") {
+		t.Error("expected unknown scope to be invalid")
+	}
+}
+
+func TestContains(t *testing.T) {
+	granted := []string{CurrencyRead, ExchangerRead}
+	if !Contains(granted, CurrencyRead) {
+		t.Error("expected currency:read to be granted")
+	}
+	if Contains(granted, CurrencyWrite) {
+		t.Error("expected currency:write not to be granted")
+	}
+	if Contains(nil, CurrencyRead) {
+		t.Error("expected nothing to be granted from an empty list")
+	}
+}
@@ -9,24 +9,46 @@ import (
 type Role string
 
 const (
-	RoleAdmin Role = "ADMIN"
+	RoleAdmin      Role = "ADMIN"
 	RoleSubscriber Role = "SUBSCRIBER"
 )
 
 type User struct {
-	ID           int
-	UserName     string
-	Email        string
-	FirstName    string
-	LastName     string
-	Status       bool
-	HashPassword string
-	Password     string
-	CreatedAt    time.Time
-	Role         Role
-	UpdatedAt    time.Time
+	ID                  int
+	UserName            string
+	Email               string
+	FirstName           string
+	LastName            string
+	Status              bool
+	HashPassword        string
+	Password            string
+	CreatedAt           time.Time
+	Role                Role
+	UpdatedAt           time.Time
+	FailedLoginAttempts int
+	LastFailedLoginAt   *time.Time
+	LockedUntil         *time.Time
+	TwoFactorEnabled    bool
+	// TwoFactorSecret is the TOTP secret encrypted with the API key cipher
+	TwoFactorSecret string
+	// TwoFactorRecoveryCodes holds the hashes of the unused recovery codes
+	TwoFactorRecoveryCodes []string
 }
 
+// IsLocked reports whether the account is locked at the given moment
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// PublicProfile returns a copy holding only the fields anyone may read
+func (u *User) PublicProfile() *User {
+	return &User{
+		ID:        u.ID,
+		UserName:  u.UserName,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+	}
+}
 
 type SearchResultUser struct {
 	Data       *[]User
@@ -34,14 +56,19 @@ type SearchResultUser struct {
 	Page       int
 	PageSize   int
 	TotalPages int
+	NextCursor string
+	PrevCursor string
 }
 
+// IUserService methods take the id of the acting user, 0 for anonymous callers
 type IUserService interface {
-	GetAll() (*[]User, error)
-	GetByID(id int) (*User, error)
-	Create(newUser *User) (*User, error)
-	Delete(id int) error
-	Update(id int, userMap map[string]interface{}) (*User, error)
-	SearchPaginated(filters domain.DataFilters) (*SearchResultUser, error)
-	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAll(actorID int) (*[]User, error)
+	GetByID(actorID int, id int) (*User, error)
+	GetPublicProfile(id int) (*User, error)
+	Create(actorID int, newUser *User) (*User, error)
+	Delete(actorID int, id int) error
+	Update(actorID int, id int, userMap map[string]interface{}) (*User, error)
+	ChangePassword(id int, currentPassword string, newPassword string) error
+	SearchPaginated(actorID int, filters domain.DataFilters) (*SearchResultUser, error)
+	SearchByProperty(actorID int, property string, searchText string) (*[]string, error)
 }
@@ -112,3 +112,24 @@ func TestUser_ZeroValues(t *testing.T) {
 		t.Errorf("Expected UpdatedAt to be zero, got %v", user.UpdatedAt)
 	}
 }
+
+func TestUser_IsLocked(t *testing.T) {
+	now := time.Now()
+	user := User{}
+
+	if user.IsLocked(now) {
+		t.Error("Expected user without LockedUntil to be unlocked")
+	}
+
+	future := now.Add(time.Minute)
+	user.LockedUntil = &future
+	if !user.IsLocked(now) {
+		t.Error("Expected user to be locked until a future time")
+	}
+
+	past := now.Add(-time.Minute)
+	user.LockedUntil = &past
+	if user.IsLocked(now) {
+		t.Error("Expected lock to expire once LockedUntil has passed")
+	}
+}
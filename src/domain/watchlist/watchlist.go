@@ -0,0 +1,80 @@
+package watchlist
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain/currency"
+)
+
+// Pair is a currency pair such as EUR/USD: one unit of Base priced in Quote
+type Pair struct {
+	Base  string
+	Quote string
+}
+
+// ParsePair reads a pair written as "BASE/QUOTE"
+func ParsePair(s string) (Pair, error) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(s)), "/")
+	if len(parts) != 2 || !isCurrencyCode(parts[0]) || !isCurrencyCode(parts[1]) {
+		return Pair{}, fmt.Errorf("invalid currency pair %q, expected BASE/QUOTE", s)
+	}
+	if parts[0] == parts[1] {
+		return Pair{}, fmt.Errorf("currency pair %q must use two different currencies", s)
+	}
+	return Pair{Base: parts[0], Quote: parts[1]}, nil
+}
+
+func (p Pair) String() string {
+	return p.Base + "/" + p.Quote
+}
+
+// CrossRate prices the pair from rates quoted against currency.RateBase. It reports false when either
+// currency has no usable rate.
+func (p Pair) CrossRate(rates map[string]float64) (float64, bool) {
+	base, baseOK := baseRate(p.Base, rates)
+	quote, quoteOK := baseRate(p.Quote, rates)
+	if !baseOK || !quoteOK {
+		return 0, false
+	}
+	return quote / base, true
+}
+
+func baseRate(code string, rates map[string]float64) (float64, bool) {
+	rate, ok := rates[code]
+	if !ok && code == currency.RateBase {
+		return 1, true
+	}
+	return rate, ok && rate > 0
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+type Watchlist struct {
+	ID        int
+	UserID    int
+	Name      string
+	Pairs     []Pair
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PairRate is the current rate of a pair computed from the stored currency rates.
+// Available is false when either currency has no stored rate.
+type PairRate struct {
+	Pair      Pair
+	Rate      float64
+	Available bool
+	UpdatedAt time.Time
+}
@@ -0,0 +1,37 @@
+package watchlist
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePair(t *testing.T) {
+	pair, err := ParsePair(" eur/usd ")
+	if err != nil || pair != (Pair{Base: "EUR", Quote: "USD"}) {
+		t.Errorf("unexpected pair %+v, err %v", pair, err)
+	}
+	if pair.String() != "EUR/USD" {
+		t.Errorf("expected EUR/USD, got %s", pair.String())
+	}
+	for _, invalid := range []string{"EURUSD", "EUR/US", "EUR/EUR", "E1R/USD", "EUR/USD/GBP"} {
+		if _, err := ParsePair(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPair_CrossRate(t *testing.T) {
+	rates := map[string]float64{"EUR": 0.9, "GBP": 0.75, "BAD": 0}
+	if rate, ok := (Pair{Base: "EUR", Quote: "GBP"}).CrossRate(rates); !ok || math.Abs(rate-0.75/0.9) > 1e-9 {
+		t.Errorf("unexpected EUR/GBP rate %v", rate)
+	}
+	if rate, ok := (Pair{Base: "EUR", Quote: "USD"}).CrossRate(rates); !ok || math.Abs(rate-1/0.9) > 1e-9 {
+		t.Errorf("expected USD to be priced at 1 when not stored, got %v", rate)
+	}
+	if _, ok := (Pair{Base: "EUR", Quote: "BAD"}).CrossRate(rates); ok {
+		t.Error("expected a zero rate to be unusable")
+	}
+	if _, ok := (Pair{Base: "EUR", Quote: "JPY"}).CrossRate(rates); ok {
+		t.Error("expected an unknown currency to be unusable")
+	}
+}
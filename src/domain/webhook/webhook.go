@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// EventRatesUpdated is published after every exchange refresh with the refreshed rates
+	EventRatesUpdated = "rates.updated"
+	// EventCurrencyDeleted is published when a currency is removed
+	EventCurrencyDeleted = "currency.deleted"
+)
+
+// EventTypes lists every event a subscription can receive
+var EventTypes = []string{
+	EventRatesUpdated,
+	EventCurrencyDeleted,
+}
+
+// IsValidEventType reports whether the event type is known
+func IsValidEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription is a downstream endpoint pushed the events it subscribed to. Secret holds the
+// encrypted signing secret; Currencies, when set, restricts the payloads to those currency codes.
+type Subscription struct {
+	ID         int
+	URL        string
+	Secret     string
+	EventTypes []string
+	Currencies []string
+	Active     bool
+	CreatedBy  int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Wants reports whether the subscription is active and subscribed to the event type
+func (s *Subscription) Wants(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WantsCurrency reports whether the currency passes the subscription filter
+func (s *Subscription) WantsCurrency(code string) bool {
+	if len(s.Currencies) == 0 {
+		return true
+	}
+	for _, c := range s.Currencies {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first attempt or for a retry
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered was acknowledged with a 2xx response
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead exhausted its attempts and is no longer retried
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is one event payload sent, or to be sent, to one subscription
+type Delivery struct {
+	ID             int
+	SubscriptionID int
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         DeliveryStatus
+	Attempts       int
+	ResponseStatus int
+	LastError      string
+	NextAttemptAt  *time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
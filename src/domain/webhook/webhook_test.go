@@ -0,0 +1,39 @@
+package webhook
+
+import "testing"
+
+func TestIsValidEventType(t *testing.T) {
+	for _, eventType := range EventTypes {
+		if !IsValidEventType(eventType) {
+			t.Errorf("expected %s to be valid", eventType)
+		}
+	}
+	if IsValidEventType("rates.deleted") {
+		t.Error("expected unknown event type to be invalid")
+	}
+}
+
+func TestSubscription_Wants(t *testing.T) {
+	s := Subscription{Active: true, EventTypes: []string{EventRatesUpdated}}
+	if !s.Wants(EventRatesUpdated) {
+		t.Error("expected subscription to want rates.updated")
+	}
+	if s.Wants(EventCurrencyDeleted) {
+		t.Error("expected subscription not to want currency.deleted")
+	}
+	s.Active = false
+	if s.Wants(EventRatesUpdated) {
+		t.Error("expected an inactive subscription to want nothing")
+	}
+}
+
+func TestSubscription_WantsCurrency(t *testing.T) {
+	s := Subscription{}
+	if !s.WantsCurrency("EUR") {
+		t.Error("expected no filter to accept every currency")
+	}
+	s.Currencies = []string{"EUR", "GBP"}
+	if !s.WantsCurrency("eur") || s.WantsCurrency("JPY") {
+		t.Error("expected the filter to accept EUR and reject JPY")
+	}
+}
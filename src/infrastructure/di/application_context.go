@@ -3,36 +3,80 @@ package di
 import (
 	"sync"
 
+	alertUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/alert"
+	apiTokenUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/apitoken"
 	authUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/auth"
 	currencyUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/currency"
 	exchangerUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/exchanger"
+	oauthUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/oauth"
+	rateHubUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/ratehub"
+	rateStreamUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/ratestream"
 	userUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/user"
+	watchlistUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/watchlist"
+	webhookUseCase "github.com/gbrayhan/microservices-go/src/application/usecases/webhook"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/notifier"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/alert"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/apitoken"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/exchanger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/loginattempt"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/oauthclient"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/watchlist"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
+	alertController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/alert"
+	apiTokenController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/apitoken"
 	authController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/auth"
 	currencyController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/currency"
 	exchangerController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/exchanger"
+	oauthController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/oauth"
+	streamController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stream"
 	userController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/user"
+	watchlistController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/watchlist"
+	webhookController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/webhook"
+	wellKnownController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/wellknown"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	webhookSender "github.com/gbrayhan/microservices-go/src/infrastructure/webhook"
 	"gorm.io/gorm"
 )
 
 // ApplicationContext holds all application dependencies and services
 type ApplicationContext struct {
-	DB                  *gorm.DB
-	Logger              *logger.Logger
-	AuthController      authController.IAuthController
-	UserController      userController.IUserController
-	CurrencyController  currencyController.ICurrencyController
-	ExchangerController exchangerController.IExchangerController
-	JWTService          security.IJWTService
-	UserRepository      user.UserRepositoryInterface
-	AuthUseCase         authUseCase.IAuthUseCase
-	UserUseCase         userUseCase.IUserUseCase
-	CurrencyUseCase     currencyUseCase.ICurrencyUseCase
+	DB                     *gorm.DB
+	Logger                 *logger.Logger
+	AuthController         authController.IAuthController
+	UserController         userController.IUserController
+	APITokenController     apiTokenController.IAPITokenController
+	OAuthController        oauthController.IOAuthController
+	WellKnownController    wellKnownController.IWellKnownController
+	CurrencyController     currencyController.ICurrencyController
+	ExchangerController    exchangerController.IExchangerController
+	WatchlistController    watchlistController.IWatchlistController
+	AlertController        alertController.IAlertController
+	WebhookController      webhookController.IWebhookController
+	StreamController       streamController.IStreamController
+	WebSocketController    streamController.IWebSocketController
+	JWTService             security.IJWTService
+	UserRepository         user.UserRepositoryInterface
+	LoginAttemptRepository loginattempt.LoginAttemptRepositoryInterface
+	APITokenRepository     apitoken.APITokenRepositoryInterface
+	OAuthClientRepository  oauthclient.OAuthClientRepositoryInterface
+	WatchlistRepository    watchlist.WatchlistRepositoryInterface
+	AlertRepository        alert.AlertRepositoryInterface
+	WebhookRepository      webhook.WebhookRepositoryInterface
+	AuthUseCase            authUseCase.IAuthUseCase
+	APITokenUseCase        apiTokenUseCase.IAPITokenUseCase
+	OAuthUseCase           oauthUseCase.IOAuthUseCase
+	UserUseCase            userUseCase.IUserUseCase
+	CurrencyUseCase        currencyUseCase.ICurrencyUseCase
+	WatchlistUseCase       watchlistUseCase.IWatchlistUseCase
+	AlertUseCase           alertUseCase.IAlertUseCase
+	WebhookUseCase         webhookUseCase.IWebhookUseCase
+	RateStreamUseCase      rateStreamUseCase.IRateStreamUseCase
+	RateHubUseCase         rateHubUseCase.IRateHubUseCase
 }
 
 var (
@@ -56,49 +100,104 @@ func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, erro
 	}
 
 	// Initialize JWT service (manages its own configuration)
-	jwtService := security.NewJWTService()
+	jwtService, err := security.NewJWTService()
+	if err != nil {
+		return nil, err
+	}
 	apiService := security.NewAPIService()
+	totpService := security.NewTOTPService()
+	alertNotifier, err := notifier.NewNotifier(loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	providerClient, err := provider.NewClient()
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize repositories with logger
 	userRepo := user.NewUserRepository(db, loggerInstance)
 	currencyRepo := currency.NewCurrencyRepository(db, loggerInstance)
 	exchangerRepo := exchanger.NewExchangerRepository(db, loggerInstance)
+	loginAttemptRepo := loginattempt.NewLoginAttemptRepository(db, loggerInstance)
+	apiTokenRepo := apitoken.NewAPITokenRepository(db, loggerInstance)
+	oauthClientRepo := oauthclient.NewOAuthClientRepository(db, loggerInstance)
+	watchlistRepo := watchlist.NewWatchlistRepository(db, loggerInstance)
+	alertRepo := alert.NewAlertRepository(db, loggerInstance)
+	webhookRepo := webhook.NewWebhookRepository(db, loggerInstance)
 
 	// Initialize use cases with logger
-	authUC := authUseCase.NewAuthUseCase(userRepo, jwtService, loggerInstance)
+	authUC := authUseCase.NewAuthUseCase(userRepo, loginAttemptRepo, jwtService, totpService, apiService, loggerInstance)
 	userUC := userUseCase.NewUserUseCase(userRepo, loggerInstance)
+	apiTokenUC := apiTokenUseCase.NewAPITokenUseCase(apiTokenRepo, userRepo, apiService, loggerInstance)
+	oauthUC := oauthUseCase.NewOAuthUseCase(oauthClientRepo, userRepo, apiService, jwtService, loggerInstance)
 	exchangerUC := exchangerUseCase.NewExchangerUseCase(exchangerRepo, apiService, loggerInstance)
-	currencyUC := currencyUseCase.NewCurrencyUseCase(currencyRepo, exchangerRepo, apiService, loggerInstance)
+	rateHubUC := rateHubUseCase.NewRateHubUseCase(loggerInstance)
+	alertUC := alertUseCase.NewAlertUseCase(alertRepo, notifier.NewMultiNotifier(alertNotifier, rateHubUC), loggerInstance)
+	webhookUC := webhookUseCase.NewWebhookUseCase(webhookRepo, userRepo, apiService, webhookSender.NewSender(), loggerInstance)
+	rateStreamUC := rateStreamUseCase.NewRateStreamUseCase(loggerInstance)
+	currencyUC := currencyUseCase.NewCurrencyUseCase(currencyRepo, exchangerRepo, apiService, providerClient, loggerInstance, alertUC, webhookUC, rateStreamUC, rateHubUC)
+	watchlistUC := watchlistUseCase.NewWatchlistUseCase(watchlistRepo, currencyRepo, loggerInstance)
 
 	// Initialize controllers with logger
 	authController := authController.NewAuthController(authUC, loggerInstance)
 	userController := userController.NewUserController(userUC, loggerInstance)
+	apiTokenController := apiTokenController.NewAPITokenController(apiTokenUC, loggerInstance)
+	oauthController := oauthController.NewOAuthController(oauthUC, loggerInstance)
+	wellKnownController := wellKnownController.NewWellKnownController(jwtService, loggerInstance)
 	currencyController := currencyController.NewCurrencyController(currencyUC, loggerInstance)
 	exchangerController := exchangerController.NewExchangerController(exchangerUC, loggerInstance)
+	watchlistController := watchlistController.NewWatchlistController(watchlistUC, loggerInstance)
+	alertController := alertController.NewAlertController(alertUC, loggerInstance)
+	webhookController := webhookController.NewWebhookController(webhookUC, loggerInstance)
+	webSocketController := streamController.NewWebSocketController(rateHubUC, loggerInstance)
+	streamController := streamController.NewStreamController(rateStreamUC, loggerInstance)
 
 	return &ApplicationContext{
-		DB:                  db,
-		Logger:              loggerInstance,
-		AuthController:      authController,
-		UserController:      userController,
-		CurrencyController:  currencyController,
-		ExchangerController: exchangerController,
-		JWTService:          jwtService,
-		UserRepository:      userRepo,
-		AuthUseCase:         authUC,
-		UserUseCase:         userUC,
-		CurrencyUseCase:     currencyUC,
+		DB:                     db,
+		Logger:                 loggerInstance,
+		AuthController:         authController,
+		UserController:         userController,
+		APITokenController:     apiTokenController,
+		OAuthController:        oauthController,
+		WellKnownController:    wellKnownController,
+		CurrencyController:     currencyController,
+		ExchangerController:    exchangerController,
+		WatchlistController:    watchlistController,
+		AlertController:        alertController,
+		WebhookController:      webhookController,
+		StreamController:       streamController,
+		WebSocketController:    webSocketController,
+		JWTService:             jwtService,
+		UserRepository:         userRepo,
+		LoginAttemptRepository: loginAttemptRepo,
+		APITokenRepository:     apiTokenRepo,
+		OAuthClientRepository:  oauthClientRepo,
+		WatchlistRepository:    watchlistRepo,
+		AlertRepository:        alertRepo,
+		WebhookRepository:      webhookRepo,
+		AuthUseCase:            authUC,
+		APITokenUseCase:        apiTokenUC,
+		OAuthUseCase:           oauthUC,
+		UserUseCase:            userUC,
+		CurrencyUseCase:        currencyUC,
+		WatchlistUseCase:       watchlistUC,
+		AlertUseCase:           alertUC,
+		WebhookUseCase:         webhookUC,
+		RateStreamUseCase:      rateStreamUC,
+		RateHubUseCase:         rateHubUC,
 	}, nil
 }
 
 // NewTestApplicationContext creates an application context for testing with mocked dependencies
 func NewTestApplicationContext(
 	mockUserRepo user.UserRepositoryInterface,
+	mockLoginAttemptRepository loginattempt.LoginAttemptRepositoryInterface,
 	mockJWTService security.IJWTService,
 	loggerInstance *logger.Logger,
 ) *ApplicationContext {
 	// Initialize use cases with mocked repositories and logger
-	authUC := authUseCase.NewAuthUseCase(mockUserRepo, mockJWTService, loggerInstance)
+	authUC := authUseCase.NewAuthUseCase(mockUserRepo, mockLoginAttemptRepository, mockJWTService, security.NewTOTPService(), security.NewAPIService(), loggerInstance)
 	userUC := userUseCase.NewUserUseCase(mockUserRepo, loggerInstance)
 
 	// Initialize controllers with logger
@@ -106,12 +205,13 @@ func NewTestApplicationContext(
 	userController := userController.NewUserController(userUC, loggerInstance)
 
 	return &ApplicationContext{
-		Logger:         loggerInstance,
-		AuthController: authController,
-		UserController: userController,
-		JWTService:     mockJWTService,
-		UserRepository: mockUserRepo,
-		AuthUseCase:    authUC,
-		UserUseCase:    userUC,
+		Logger:                 loggerInstance,
+		AuthController:         authController,
+		UserController:         userController,
+		JWTService:             mockJWTService,
+		UserRepository:         mockUserRepo,
+		LoginAttemptRepository: mockLoginAttemptRepository,
+		AuthUseCase:            authUC,
+		UserUseCase:            userUC,
 	}
 }
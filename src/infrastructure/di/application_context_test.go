@@ -3,8 +3,10 @@ package di
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
@@ -62,6 +64,60 @@ func (m *MockUserRepository) SearchByProperty(property string, searchText string
 	return args.Get(0).(*[]string), args.Error(1)
 }
 
+func (m *MockUserRepository) UpdateLoginState(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+	args := m.Called(id, failedAttempts, lastFailedLoginAt, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IncrementFailedLogins(id int, failedAt time.Time) (int, error) {
+	args := m.Called(id, failedAt)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) LockAccount(id int, lockedUntil time.Time) error {
+	args := m.Called(id, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateTwoFactor(id int, enabled bool, secret string, recoveryCodes []string) error {
+	args := m.Called(id, enabled, secret, recoveryCodes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ClaimTwoFactorStep(id int, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeRecoveryCode(id int, hash string) (bool, error) {
+	args := m.Called(id, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(id int, hashPassword string) error {
+	args := m.Called(id, hashPassword)
+	return args.Error(0)
+}
+
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Create(attempt *domainLoginAttempt.LoginAttempt) (*domainLoginAttempt.LoginAttempt, error) {
+	args := m.Called(attempt)
+	return args.Get(0).(*domainLoginAttempt.LoginAttempt), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) GetFailureStatsByIP(ip string, since time.Time) (*domainLoginAttempt.FailureStats, error) {
+	args := m.Called(ip, since)
+	return args.Get(0).(*domainLoginAttempt.FailureStats), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) List(filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error) {
+	args := m.Called(filter)
+	return args.Get(0).(*[]domainLoginAttempt.LoginAttempt), args.Error(1)
+}
+
 type MockMedicineRepository struct {
 	mock.Mock
 }
@@ -85,6 +141,16 @@ func (m *MockJWTService) GenerateJWTToken(userID int, tokenType string) (*securi
 	return args.Get(0).(*security.AppToken), args.Error(1)
 }
 
+func (m *MockJWTService) GenerateClientToken(clientID string, scopes []string, audiences []string) (*security.AppToken, error) {
+	args := m.Called(clientID, scopes, audiences)
+	return args.Get(0).(*security.AppToken), args.Error(1)
+}
+
+func (m *MockJWTService) JWKS() security.JSONWebKeySet {
+	args := m.Called()
+	return args.Get(0).(security.JSONWebKeySet)
+}
+
 func (m *MockJWTService) GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error) {
 	args := m.Called(tokenString, tokenType)
 	return args.Get(0).(jwt.MapClaims), args.Error(1)
@@ -100,13 +166,15 @@ func setupLogger(t *testing.T) *logger.Logger {
 
 func TestNewTestApplicationContext(t *testing.T) {
 	mockUserRepo := &MockUserRepository{}
+	mockLoginAttemptRepo := &MockLoginAttemptRepository{}
 	mockJWTService := &MockJWTService{}
 	logger := setupLogger(t)
 
-	appContext := NewTestApplicationContext(mockUserRepo, mockJWTService, logger)
+	appContext := NewTestApplicationContext(mockUserRepo, mockLoginAttemptRepo, mockJWTService, logger)
 
 	assert.NotNil(t, appContext)
 	assert.Equal(t, mockUserRepo, appContext.UserRepository)
+	assert.Equal(t, mockLoginAttemptRepo, appContext.LoginAttemptRepository)
 	assert.Equal(t, mockJWTService, appContext.JWTService)
 
 	// Test that controllers are created
@@ -134,10 +202,11 @@ func TestSetupDependencies(t *testing.T) {
 
 func TestApplicationContextStructure(t *testing.T) {
 	mockUserRepo := &MockUserRepository{}
+	mockLoginAttemptRepo := &MockLoginAttemptRepository{}
 	mockJWTService := &MockJWTService{}
 	logger := setupLogger(t)
 
-	appContext := NewTestApplicationContext(mockUserRepo, mockJWTService, logger)
+	appContext := NewTestApplicationContext(mockUserRepo, mockLoginAttemptRepo, mockJWTService, logger)
 
 	// Test that all fields are properly set
 	assert.NotNil(t, appContext.AuthController)
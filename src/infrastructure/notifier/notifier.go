@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	KindLog     = "log"
+	KindWebhook = "webhook"
+	KindMemory  = "memory"
+)
+
+// INotifier delivers triggered alert events to their owner
+type INotifier interface {
+	Notify(event *domainAlert.Event) error
+}
+
+// NotifierConfig selects and configures the notifier
+type NotifierConfig struct {
+	Kind           string
+	WebhookURL     string
+	WebhookTimeout time.Duration
+}
+
+// NewNotifier builds the notifier selected by ALERT_NOTIFIER
+func NewNotifier(loggerInstance *logger.Logger) (INotifier, error) {
+	return NewNotifierWithConfig(loadNotifierConfig(), loggerInstance)
+}
+
+func NewNotifierWithConfig(config NotifierConfig, loggerInstance *logger.Logger) (INotifier, error) {
+	switch config.Kind {
+	case "", KindLog:
+		return NewLogNotifier(loggerInstance), nil
+	case KindWebhook:
+		if config.WebhookURL == "" {
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL is required when ALERT_NOTIFIER is %q", KindWebhook)
+		}
+		return NewWebhookNotifier(config.WebhookURL, config.WebhookTimeout), nil
+	case KindMemory:
+		return NewMemoryNotifier(), nil
+	}
+	return nil, fmt.Errorf("unknown alert notifier %q", config.Kind)
+}
+
+// loadNotifierConfig loads notifier configuration from environment variables
+func loadNotifierConfig() NotifierConfig {
+	timeoutSeconds := 5
+	if value, err := strconv.Atoi(os.Getenv("ALERT_WEBHOOK_TIMEOUT_SECONDS")); err == nil && value > 0 {
+		timeoutSeconds = value
+	}
+	return NotifierConfig{
+		Kind:           os.Getenv("ALERT_NOTIFIER"),
+		WebhookURL:     os.Getenv("ALERT_WEBHOOK_URL"),
+		WebhookTimeout: time.Duration(timeoutSeconds) * time.Second,
+	}
+}
+
+// LogNotifier writes triggered events to the application log
+type LogNotifier struct {
+	Logger *logger.Logger
+}
+
+func NewLogNotifier(loggerInstance *logger.Logger) *LogNotifier {
+	return &LogNotifier{Logger: loggerInstance}
+}
+
+func (n *LogNotifier) Notify(event *domainAlert.Event) error {
+	n.Logger.Info("Alert triggered",
+		zap.Int("ruleID", event.RuleID),
+		zap.Int("userID", event.UserID),
+		zap.String("pair", event.Pair.String()),
+		zap.String("condition", string(event.Condition)),
+		zap.Float64("threshold", event.Threshold),
+		zap.Float64("rate", event.Rate))
+	return nil
+}
+
+// WebhookPayload is the JSON body posted for every triggered event
+type WebhookPayload struct {
+	EventID       int       `json:"eventId"`
+	RuleID        int       `json:"ruleId"`
+	UserID        int       `json:"userId"`
+	Pair          string    `json:"pair"`
+	Condition     string    `json:"condition"`
+	Threshold     float64   `json:"threshold"`
+	Rate          float64   `json:"rate"`
+	ReferenceRate float64   `json:"referenceRate,omitempty"`
+	TriggeredAt   time.Time `json:"triggeredAt"`
+}
+
+// WebhookNotifier posts triggered events as JSON to a fixed URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Notify(event *domainAlert.Event) error {
+	body, err := json.Marshal(WebhookPayload{
+		EventID:       event.ID,
+		RuleID:        event.RuleID,
+		UserID:        event.UserID,
+		Pair:          event.Pair.String(),
+		Condition:     string(event.Condition),
+		Threshold:     event.Threshold,
+		Rate:          event.Rate,
+		ReferenceRate: event.ReferenceRate,
+		TriggeredAt:   event.TriggeredAt,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MemoryNotifier keeps triggered events in memory; a local stand-in for development and tests
+type MemoryNotifier struct {
+	mu     sync.Mutex
+	events []domainAlert.Event
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Notify(event *domainAlert.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, *event)
+	return nil
+}
+
+// Events returns a copy of the events received so far
+func (n *MemoryNotifier) Events() []domainAlert.Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	events := make([]domainAlert.Event, len(n.events))
+	copy(events, n.events)
+	return events
+}
+
+// MultiNotifier hands every event to each of its notifiers, so one failing does not starve the others
+type MultiNotifier struct {
+	Notifiers []INotifier
+}
+
+func NewMultiNotifier(notifiers ...INotifier) *MultiNotifier {
+	return &MultiNotifier{Notifiers: notifiers}
+}
+
+func (n *MultiNotifier) Notify(event *domainAlert.Event) error {
+	var errs []error
+	for _, notifier := range n.Notifiers {
+		if err := notifier.Notify(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() *domainAlert.Event {
+	return &domainAlert.Event{
+		ID:          7,
+		RuleID:      2,
+		UserID:      3,
+		Pair:        domainWatchlist.Pair{Base: "EUR", Quote: "USD"},
+		Condition:   domainAlert.ConditionAbove,
+		Threshold:   1.12,
+		Rate:        1.13,
+		TriggeredAt: time.Now(),
+	}
+}
+
+func TestNewNotifierWithConfig(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	n, err := NewNotifierWithConfig(NotifierConfig{}, loggerInstance)
+	require.NoError(t, err)
+	assert.IsType(t, &LogNotifier{}, n)
+
+	n, err = NewNotifierWithConfig(NotifierConfig{Kind: KindMemory}, loggerInstance)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryNotifier{}, n)
+
+	_, err = NewNotifierWithConfig(NotifierConfig{Kind: KindWebhook}, loggerInstance)
+	assert.Error(t, err)
+
+	_, err = NewNotifierWithConfig(NotifierConfig{Kind: "sms"}, loggerInstance)
+	assert.Error(t, err)
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, time.Second)
+	require.NoError(t, n.Notify(testEvent()))
+	assert.Equal(t, "EUR/USD", received.Pair)
+	assert.Equal(t, 1.13, received.Rate)
+	assert.Equal(t, 2, received.RuleID)
+}
+
+func TestWebhookNotifier_NonSuccessStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, time.Second)
+	assert.Error(t, n.Notify(testEvent()))
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	n := NewMemoryNotifier()
+	require.NoError(t, n.Notify(testEvent()))
+	events := n.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, 7, events[0].ID)
+}
+
+func TestMultiNotifier_NotifiesAllAndJoinsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	memory := NewMemoryNotifier()
+	n := NewMultiNotifier(NewWebhookNotifier(server.URL, time.Second), memory)
+	assert.Error(t, n.Notify(testEvent()))
+	assert.Len(t, memory.Events(), 1)
+}
@@ -0,0 +1,246 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize bounds the part of an error response kept in the error message
+const maxErrorBodySize = 512
+
+// ErrResponseTooLarge is returned for a response over ClientConfig.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("provider response too large")
+
+// IClient fetches rates from providers. allowRetry, when not nil, is asked before every retry and
+// stops them by returning false, so that callers can count retries against a quota.
+type IClient interface {
+	Get(ctx context.Context, url string, allowRetry func() bool) ([]byte, error)
+}
+
+// ClientConfig configures provider calls. Timeout applies to each attempt. Failed attempts are
+// retried up to MaxRetries times, waiting an exponential back-off from BaseBackoff up to MaxBackoff
+// with jitter. ProxyURL, when empty, falls back to the HTTP_PROXY and HTTPS_PROXY variables;
+// CABundle is a PEM file of certificates trusted besides the system ones.
+type ClientConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	MaxResponseBytes int64
+	ProxyURL         string
+	CABundle         string
+}
+
+// StatusError is a provider answer other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("provider error %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable tells whether the provider may answer differently later: on server errors and when
+// it is rate limiting
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
+
+type Client struct {
+	HTTP   *http.Client
+	Config ClientConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient builds a client configured by the PROVIDER_HTTP_* variables
+func NewClient() (IClient, error) {
+	return NewClientWithConfig(loadClientConfig())
+}
+
+// NewClientWithConfig builds the transport of the client, failing on an invalid proxy URL or CA
+// bundle
+func NewClientWithConfig(config ClientConfig) (IClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("PROVIDER_HTTP_PROXY %q is not a valid URL", config.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if config.CABundle != "" {
+		pem, err := os.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading PROVIDER_HTTP_CA_BUNDLE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("PROVIDER_HTTP_CA_BUNDLE %q holds no PEM certificate", config.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return NewClientWithHTTPClient(&http.Client{Transport: transport, Timeout: config.Timeout}, config), nil
+}
+
+// NewClientWithHTTPClient builds a client on an existing HTTP client, whose transport and timeout
+// are used as they are
+func NewClientWithHTTPClient(client *http.Client, config ClientConfig) IClient {
+	return &Client{HTTP: client, Config: config, sleep: sleep}
+}
+
+// loadClientConfig loads the client configuration from environment variables
+func loadClientConfig() ClientConfig {
+	return ClientConfig{
+		Timeout:          time.Duration(getEnvAsIntOrDefault("PROVIDER_HTTP_TIMEOUT_SECONDS", 10)) * time.Second,
+		MaxRetries:       getEnvAsIntOrDefault("PROVIDER_HTTP_MAX_RETRIES", 3),
+		BaseBackoff:      time.Duration(getEnvAsIntOrDefault("PROVIDER_HTTP_BACKOFF_MS", 500)) * time.Millisecond,
+		MaxBackoff:       time.Duration(getEnvAsIntOrDefault("PROVIDER_HTTP_MAX_BACKOFF_SECONDS", 30)) * time.Second,
+		MaxResponseBytes: int64(getEnvAsIntOrDefault("PROVIDER_HTTP_MAX_RESPONSE_BYTES", 1<<20)),
+		ProxyURL:         os.Getenv("PROVIDER_HTTP_PROXY"),
+		CABundle:         os.Getenv("PROVIDER_HTTP_CA_BUNDLE"),
+	}
+}
+
+// Get returns the body of a 200 OK answer. Transport errors, server errors and 429 are retried;
+// a Retry-After header replaces the back-off, and one asking for longer than MaxBackoff ends the
+// retries.
+func (c *Client) Get(ctx context.Context, url string, allowRetry func() bool) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.get(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		wait, retryable := c.backoff(attempt, err)
+		if !retryable || attempt >= c.Config.MaxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+		if allowRetry != nil && !allowRetry() {
+			return nil, err
+		}
+		if sleepErr := c.sleep(ctx, wait); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, redact(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, redact(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	limit := c.Config.MaxResponseBytes
+	if limit <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, redact(err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, limit)
+	}
+	return body, nil
+}
+
+// backoff returns how long to wait before retrying after err, and whether to retry at all. The
+// back-off doubles with every attempt and is drawn from its upper half, so that instances failing
+// together do not retry together.
+func (c *Client) backoff(attempt int, err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if !statusErr.Retryable() {
+			return 0, false
+		}
+		if statusErr.RetryAfter > 0 {
+			return statusErr.RetryAfter, statusErr.RetryAfter <= c.Config.MaxBackoff
+		}
+	} else if errors.Is(err, ErrResponseTooLarge) || errors.Is(err, context.Canceled) {
+		return 0, false
+	}
+
+	wait := c.Config.BaseBackoff << min(attempt, 30)
+	if wait <= 0 || wait > c.Config.MaxBackoff {
+		wait = c.Config.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0, true
+	}
+	half := wait / 2
+	return half + rand.N(wait-half+1), true
+}
+
+// retryAfter reads a Retry-After header, given either in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// redact drops the query of the URL in a request error, as providers take their API key there
+func redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if base, _, found := strings.Cut(urlErr.URL, "?"); found {
+			urlErr.URL = base
+		}
+	}
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = ClientConfig{
+	Timeout:          time.Second,
+	MaxRetries:       3,
+	BaseBackoff:      100 * time.Millisecond,
+	MaxBackoff:       2 * time.Second,
+	MaxResponseBytes: 64,
+}
+
+// newTestClient returns a client that records its back-offs instead of sleeping
+func newTestClient(config ClientConfig) (*Client, *[]time.Duration) {
+	var waits []time.Duration
+	client := NewClientWithHTTPClient(&http.Client{Timeout: config.Timeout}, config).(*Client)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return client, &waits
+}
+
+// flakyServer answers the given statuses in turn, then 200 with body
+func flakyServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1))
+		if call <= len(statuses) {
+			if statuses[call-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(statuses[call-1])
+			_, _ = w.Write([]byte("try later"))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	server, calls := flakyServer(t, `{"data":{}}`, http.StatusBadGateway, http.StatusServiceUnavailable)
+	client, waits := newTestClient(testConfig)
+
+	body, err := client.Get(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{}}`, string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	require.Len(t, *waits, 2)
+	assert.True(t, (*waits)[0] >= 50*time.Millisecond && (*waits)[0] <= 100*time.Millisecond, "first back-off %v", (*waits)[0])
+	assert.True(t, (*waits)[1] >= 100*time.Millisecond && (*waits)[1] <= 200*time.Millisecond, "second back-off %v", (*waits)[1])
+}
+
+func TestClient_HonorsRetryAfter(t *testing.T) {
+	server, calls := flakyServer(t, `{}`, http.StatusTooManyRequests)
+	client, waits := newTestClient(testConfig)
+
+	_, err := client.Get(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Equal(t, []time.Duration{time.Second}, *waits)
+
+	server, calls = flakyServer(t, `{}`, http.StatusTooManyRequests)
+	config := testConfig
+	config.MaxBackoff = 500 * time.Millisecond
+	client, _ = newTestClient(config)
+	_, err = client.Get(context.Background(), server.URL, nil)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "a Retry-After past the longest back-off ends the retries")
+}
+
+func TestClient_GivesUp(t *testing.T) {
+	server, calls := flakyServer(t, `{}`, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	client, _ := newTestClient(testConfig)
+	_, err := client.Get(context.Background(), server.URL, nil)
+	assert.EqualError(t, err, "provider error 500: try later")
+	assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+
+	server, calls = flakyServer(t, `{}`, http.StatusUnauthorized)
+	_, err = client.Get(context.Background(), server.URL, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "client errors are not retried")
+
+	server, calls = flakyServer(t, `{}`, http.StatusBadGateway)
+	_, err = client.Get(context.Background(), server.URL, func() bool { return false })
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "a refused retry is not made")
+}
+
+func TestClient_LimitsResponseSize(t *testing.T) {
+	server, calls := flakyServer(t, strings.Repeat("x", 65))
+	client, _ := newTestClient(testConfig)
+
+	_, err := client.Get(context.Background(), server.URL, nil)
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestClient_RedactsAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	config := testConfig
+	config.MaxRetries = 0
+	client, _ := newTestClient(config)
+
+	_, err := client.Get(context.Background(), server.URL+"?apikey=secret", nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestNewClientWithConfig_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, certificate, 0o600))
+
+	untrusted, err := NewClientWithConfig(ClientConfig{Timeout: time.Second})
+	require.NoError(t, err)
+	_, err = untrusted.Get(context.Background(), server.URL, nil)
+	var certErr *tls.CertificateVerificationError
+	assert.True(t, errors.As(err, &certErr), "expected a certificate error, got %v", err)
+
+	config := ClientConfig{Timeout: time.Second, CABundle: bundle}
+	trusted, err := NewClientWithConfig(config)
+	require.NoError(t, err)
+	body, err := trusted.Get(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(body))
+
+	_, err = NewClientWithConfig(ClientConfig{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+	_, err = NewClientWithConfig(ClientConfig{ProxyURL: "not a url"})
+	assert.Error(t, err)
+}
+
+func TestNewClientWithConfig_Proxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		assert.Equal(t, "rates.example.com", r.Host)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClientWithConfig(ClientConfig{Timeout: time.Second, ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	_, err = client.Get(context.Background(), "http://rates.example.com/latest", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, retryAfter("5", now))
+	assert.Equal(t, 90*time.Second, retryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), retryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), retryAfter("soon", now))
+}
@@ -0,0 +1,214 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the payload shape of a rate provider, chosen by the first segment of the request path
+type Format string
+
+const (
+	// FormatData is {"data": {"EUR": 0.92}}, the shape refreshes read from exchangers
+	FormatData Format = "data"
+	// FormatRates is {"base": "USD", "rates": {"EUR": 0.92}}, the shape of exchangerate-api
+	FormatRates Format = "rates"
+)
+
+// Config is what the fake answers. A failed request answers ErrorStatus (503 when 0), a malformed
+// one cuts its JSON short. Each request moves every rate by up to DriftPercent, as a random walk.
+// With an APIKey, requests without it as the apikey query parameter answer 401. A zero Seed seeds
+// the random source from the clock.
+type Config struct {
+	Base          string
+	Rates         map[string]float64
+	Latency       time.Duration
+	ErrorRate     float64
+	ErrorStatus   int
+	MalformedRate float64
+	DriftPercent  float64
+	APIKey        string
+	Seed          uint64
+}
+
+// DefaultConfig quotes a few major currencies against USD, answering every request
+func DefaultConfig() Config {
+	return Config{
+		Base:  "USD",
+		Rates: map[string]float64{"EUR": 0.92, "GBP": 0.79, "JPY": 151.3, "MXN": 17.1, "CAD": 1.36},
+	}
+}
+
+// Provider serves rates in every Format
+type Provider struct {
+	mu     sync.Mutex
+	config Config
+	rates  map[string]float64
+	random *rand.Rand
+	calls  int
+}
+
+func New(config Config) *Provider {
+	p := &Provider{}
+	p.Configure(config)
+	return p
+}
+
+// Configure replaces the config and the current rates, and resets the call count
+func (p *Provider) Configure(config Config) {
+	seed := config.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	if config.Base == "" {
+		config.Base = "USD"
+	}
+	if config.ErrorStatus == 0 {
+		config.ErrorStatus = http.StatusServiceUnavailable
+	}
+	config.Rates = maps.Clone(config.Rates)
+	if config.Rates == nil {
+		config.Rates = make(map[string]float64)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+	p.rates = maps.Clone(config.Rates)
+	p.random = rand.New(rand.NewPCG(seed, seed))
+	p.calls = 0
+}
+
+// Update changes the config in place. Currencies whose configured rate is unchanged keep their
+// drifted rate.
+func (p *Provider) Update(change func(config *Config)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	configured := maps.Clone(p.config.Rates)
+	change(&p.config)
+	if p.config.Rates == nil {
+		p.config.Rates = make(map[string]float64)
+	}
+	for code, rate := range p.config.Rates {
+		if previous, quoted := configured[code]; !quoted || previous != rate {
+			p.rates[code] = rate
+		}
+	}
+	for code := range p.rates {
+		if _, quoted := p.config.Rates[code]; !quoted {
+			delete(p.rates, code)
+		}
+	}
+	if p.config.ErrorStatus == 0 {
+		p.config.ErrorStatus = http.StatusServiceUnavailable
+	}
+}
+
+// Rates returns the rates the last successful request was answered with
+func (p *Provider) Rates() map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.rates)
+}
+
+// Calls returns how many requests the provider has received
+func (p *Provider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := Format(strings.Split(strings.Trim(r.URL.Path, "/"), "/")[0])
+	if format == "" {
+		format = FormatData
+	}
+	if format != FormatData && format != FormatRates {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusNotFound)
+		return
+	}
+
+	p.mu.Lock()
+	p.calls++
+	config := p.config
+	if config.APIKey != "" && r.URL.Query().Get("apikey") != config.APIKey {
+		p.mu.Unlock()
+		http.Error(w, `{"message":"invalid api key"}`, http.StatusUnauthorized)
+		return
+	}
+	failed := p.random.Float64() < config.ErrorRate
+	malformed := !failed && p.random.Float64() < config.MalformedRate
+	if !failed && config.DriftPercent > 0 {
+		for code, rate := range p.rates {
+			p.rates[code] = rate * (1 + (p.random.Float64()*2-1)*config.DriftPercent/100)
+		}
+	}
+	rates := maps.Clone(p.rates)
+	p.mu.Unlock()
+
+	if config.Latency > 0 {
+		select {
+		case <-time.After(config.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failed {
+		http.Error(w, `{"message":"provider unavailable"}`, config.ErrorStatus)
+		return
+	}
+
+	var payload any = map[string]any{"data": rates}
+	if format == FormatRates {
+		payload = map[string]any{"base": config.Base, "rates": rates}
+	}
+	body, _ := json.Marshal(payload)
+	if malformed {
+		body = body[:len(body)/2]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// Server runs a Provider in process, for tests
+type Server struct {
+	*Provider
+	server *httptest.Server
+}
+
+// NewServer serves a provider on a free loopback port
+func NewServer(config Config) *Server {
+	provider := New(config)
+	return &Server{Provider: provider, server: httptest.NewServer(provider)}
+}
+
+// NewServerOn serves a provider on addr, for callers outside the process such as an API running
+// in a container
+func NewServerOn(addr string, config Config) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	provider := New(config)
+	server := httptest.NewUnstartedServer(provider)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	return &Server{Provider: provider, server: server}, nil
+}
+
+// URL returns the address of a format, to register as the URL of an exchanger
+func (s *Server) URL(format Format) string {
+	return s.server.URL + "/" + string(format)
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
@@ -0,0 +1,107 @@
+package fakeprovider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/infrastructure/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string) (int, []byte) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+func TestServer_Formats(t *testing.T) {
+	server := NewServer(Config{Rates: map[string]float64{"EUR": 0.9}})
+	defer server.Close()
+
+	status, body := get(t, server.URL(FormatData))
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"data":{"EUR":0.9}}`, string(body))
+
+	status, body = get(t, server.URL(FormatRates))
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"base":"USD","rates":{"EUR":0.9}}`, string(body))
+
+	status, _ = get(t, server.URL("xml"))
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, 2, server.Calls(), "unknown formats are not counted")
+}
+
+func TestServer_APIKey(t *testing.T) {
+	server := NewServer(Config{Rates: map[string]float64{"EUR": 0.9}, APIKey: "key"})
+	defer server.Close()
+
+	status, _ := get(t, server.URL(FormatData)+"?apikey=other")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = get(t, server.URL(FormatData)+"?apikey=key")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestServer_Failures(t *testing.T) {
+	server := NewServer(Config{Rates: map[string]float64{"EUR": 0.9}, ErrorRate: 1})
+	defer server.Close()
+
+	status, _ := get(t, server.URL(FormatData))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	server.Update(func(config *Config) { config.ErrorRate, config.ErrorStatus = 1, http.StatusTooManyRequests })
+	status, _ = get(t, server.URL(FormatData))
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	server.Update(func(config *Config) { config.ErrorRate, config.MalformedRate = 0, 1 })
+	status, body := get(t, server.URL(FormatData))
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, json.Valid(body), "expected malformed JSON, got %s", body)
+}
+
+func TestServer_Drift(t *testing.T) {
+	server := NewServer(Config{Rates: map[string]float64{"EUR": 1}, DriftPercent: 2, Seed: 7})
+	defer server.Close()
+
+	previous := 1.0
+	for i := 0; i < 5; i++ {
+		_, body := get(t, server.URL(FormatData))
+		var payload struct {
+			Data map[string]float64 `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		rate := payload.Data["EUR"]
+		assert.NotEqual(t, previous, rate)
+		assert.InEpsilon(t, previous, rate, 0.02)
+		assert.Equal(t, rate, server.Rates()["EUR"])
+		previous = rate
+	}
+
+	server.Update(func(config *Config) { config.Rates["EUR"] = 2; config.DriftPercent = 0 })
+	assert.Equal(t, 2.0, server.Rates()["EUR"])
+}
+
+func TestServer_Latency(t *testing.T) {
+	server := NewServer(Config{Rates: map[string]float64{"EUR": 0.9}, Latency: 200 * time.Millisecond})
+	defer server.Close()
+
+	client := provider.NewClientWithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}, provider.ClientConfig{})
+	_, err := client.Get(context.Background(), server.URL(FormatData), nil)
+	assert.Error(t, err, "expected the client to time out")
+}
+
+func TestNewServerOn(t *testing.T) {
+	server, err := NewServerOn("127.0.0.1:0", DefaultConfig())
+	require.NoError(t, err)
+	defer server.Close()
+
+	status, _ := get(t, server.URL(FormatRates))
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,215 @@
+package alert
+
+import (
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Rule struct {
+	ID              int        `gorm:"primaryKey"`
+	UserID          int        `gorm:"column:user_id;index"`
+	Pair            string     `gorm:"column:pair"`
+	Condition       string     `gorm:"column:condition"`
+	Threshold       float64    `gorm:"column:threshold"`
+	CooldownSeconds int64      `gorm:"column:cooldown_seconds"`
+	ReferenceRate   float64    `gorm:"column:reference_rate"`
+	ReferenceAt     *time.Time `gorm:"column:reference_at"`
+	LastTriggeredAt *time.Time `gorm:"column:last_triggered_at"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime:mili"`
+}
+
+func (Rule) TableName() string {
+	return "alert_rules"
+}
+
+type Event struct {
+	ID            int       `gorm:"primaryKey"`
+	RuleID        int       `gorm:"column:rule_id;index"`
+	UserID        int       `gorm:"column:user_id;index"`
+	Pair          string    `gorm:"column:pair"`
+	Condition     string    `gorm:"column:condition"`
+	Threshold     float64   `gorm:"column:threshold"`
+	Rate          float64   `gorm:"column:rate"`
+	ReferenceRate float64   `gorm:"column:reference_rate"`
+	TriggeredAt   time.Time `gorm:"column:triggered_at;index"`
+}
+
+func (Event) TableName() string {
+	return "alert_events"
+}
+
+// AlertRepositoryInterface defines the interface for alert rule and event repository operations
+type AlertRepositoryInterface interface {
+	CreateRule(rule *domainAlert.Rule) (*domainAlert.Rule, error)
+	GetRulesByUserID(userID int) (*[]domainAlert.Rule, error)
+	GetAllRules() (*[]domainAlert.Rule, error)
+	UpdateRuleState(rule *domainAlert.Rule) error
+	DeleteRule(userID int, id int) error
+	CreateEvent(event *domainAlert.Event) (*domainAlert.Event, error)
+	GetEventsByUserID(userID int, limit int) (*[]domainAlert.Event, error)
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewAlertRepository(db *gorm.DB, loggerInstance *logger.Logger) AlertRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) CreateRule(rule *domainAlert.Rule) (*domainAlert.Rule, error) {
+	r.Logger.Info("Creating new alert rule", zap.Int("userID", rule.UserID), zap.String("pair", rule.Pair.String()))
+	ruleRepository := fromDomainRuleMapper(rule)
+	if err := r.DB.Create(ruleRepository).Error; err != nil {
+		r.Logger.Error("Error creating alert rule", zap.Error(err), zap.Int("userID", rule.UserID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully created alert rule", zap.Int("id", ruleRepository.ID))
+	return ruleRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetRulesByUserID(userID int) (*[]domainAlert.Rule, error) {
+	var rules []Rule
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&rules).Error; err != nil {
+		r.Logger.Error("Error getting alert rules by user", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainRuleMapper(&rules), nil
+}
+
+func (r *Repository) GetAllRules() (*[]domainAlert.Rule, error) {
+	var rules []Rule
+	if err := r.DB.Find(&rules).Error; err != nil {
+		r.Logger.Error("Error getting alert rules", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainRuleMapper(&rules), nil
+}
+
+// UpdateRuleState stores the evaluation state of a rule: its reference rate and last trigger
+func (r *Repository) UpdateRuleState(rule *domainAlert.Rule) error {
+	err := r.DB.Model(&Rule{ID: rule.ID}).
+		Select("reference_rate", "reference_at", "last_triggered_at").
+		Updates(map[string]interface{}{
+			"reference_rate":    rule.ReferenceRate,
+			"reference_at":      rule.ReferenceAt,
+			"last_triggered_at": rule.LastTriggeredAt,
+		}).Error
+	if err != nil {
+		r.Logger.Error("Error updating alert rule state", zap.Error(err), zap.Int("id", rule.ID))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteRule(userID int, id int) error {
+	tx := r.DB.Where("user_id = ?", userID).Delete(&Rule{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting alert rule", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Alert rule not found for deletion", zap.Int("id", id), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted alert rule", zap.Int("id", id))
+	return nil
+}
+
+func (r *Repository) CreateEvent(event *domainAlert.Event) (*domainAlert.Event, error) {
+	eventRepository := fromDomainEventMapper(event)
+	if err := r.DB.Create(eventRepository).Error; err != nil {
+		r.Logger.Error("Error creating alert event", zap.Error(err), zap.Int("ruleID", event.RuleID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return eventRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetEventsByUserID(userID int, limit int) (*[]domainAlert.Event, error) {
+	var events []Event
+	if err := r.DB.Where("user_id = ?", userID).Order("triggered_at desc").Limit(limit).Find(&events).Error; err != nil {
+		r.Logger.Error("Error getting alert events by user", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	eventsDomain := make([]domainAlert.Event, len(events))
+	for i, event := range events {
+		eventsDomain[i] = *event.toDomainMapper()
+	}
+	return &eventsDomain, nil
+}
+
+// Mappers
+func (r *Rule) toDomainMapper() *domainAlert.Rule {
+	pair, _ := domainWatchlist.ParsePair(r.Pair)
+	return &domainAlert.Rule{
+		ID:              r.ID,
+		UserID:          r.UserID,
+		Pair:            pair,
+		Condition:       domainAlert.Condition(r.Condition),
+		Threshold:       r.Threshold,
+		Cooldown:        time.Duration(r.CooldownSeconds) * time.Second,
+		ReferenceRate:   r.ReferenceRate,
+		ReferenceAt:     r.ReferenceAt,
+		LastTriggeredAt: r.LastTriggeredAt,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+func fromDomainRuleMapper(r *domainAlert.Rule) *Rule {
+	return &Rule{
+		ID:              r.ID,
+		UserID:          r.UserID,
+		Pair:            r.Pair.String(),
+		Condition:       string(r.Condition),
+		Threshold:       r.Threshold,
+		CooldownSeconds: int64(r.Cooldown / time.Second),
+		ReferenceRate:   r.ReferenceRate,
+		ReferenceAt:     r.ReferenceAt,
+		LastTriggeredAt: r.LastTriggeredAt,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+func arrayToDomainRuleMapper(rules *[]Rule) *[]domainAlert.Rule {
+	rulesDomain := make([]domainAlert.Rule, len(*rules))
+	for i, rule := range *rules {
+		rulesDomain[i] = *rule.toDomainMapper()
+	}
+	return &rulesDomain
+}
+
+func (e *Event) toDomainMapper() *domainAlert.Event {
+	pair, _ := domainWatchlist.ParsePair(e.Pair)
+	return &domainAlert.Event{
+		ID:            e.ID,
+		RuleID:        e.RuleID,
+		UserID:        e.UserID,
+		Pair:          pair,
+		Condition:     domainAlert.Condition(e.Condition),
+		Threshold:     e.Threshold,
+		Rate:          e.Rate,
+		ReferenceRate: e.ReferenceRate,
+		TriggeredAt:   e.TriggeredAt,
+	}
+}
+
+func fromDomainEventMapper(e *domainAlert.Event) *Event {
+	return &Event{
+		ID:            e.ID,
+		RuleID:        e.RuleID,
+		UserID:        e.UserID,
+		Pair:          e.Pair.String(),
+		Condition:     string(e.Condition),
+		Threshold:     e.Threshold,
+		Rate:          e.Rate,
+		ReferenceRate: e.ReferenceRate,
+		TriggeredAt:   e.TriggeredAt,
+	}
+}
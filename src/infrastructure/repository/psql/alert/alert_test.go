@@ -0,0 +1,154 @@
+package alert
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestTableNames(t *testing.T) {
+	assert.Equal(t, "alert_rules", Rule{}.TableName())
+	assert.Equal(t, "alert_events", Event{}.TableName())
+}
+
+func TestRepository_CreateRule(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAlertRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "alert_rules"`)).
+		WithArgs(3, "EUR/USD", "above", 1.12, int64(3600), float64(0), nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	rule, err := repo.CreateRule(&domainAlert.Rule{
+		UserID:    3,
+		Pair:      domainWatchlist.Pair{Base: "EUR", Quote: "USD"},
+		Condition: domainAlert.ConditionAbove,
+		Threshold: 1.12,
+		Cooldown:  time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rule.ID)
+	assert.Equal(t, time.Hour, rule.Cooldown)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "alert_rules"`)).WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+	_, err = repo.CreateRule(&domainAlert.Rule{UserID: 3})
+	assertErrorType(t, err, domainErrors.RepositoryError)
+}
+
+func TestRepository_GetAllRules(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAlertRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "pair", "condition", "threshold", "cooldown_seconds"}).
+		AddRow(1, 3, "EUR/USD", "change_percent", 2.0, 600)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "alert_rules"`)).WillReturnRows(rows)
+	rules, err := repo.GetAllRules()
+	require.NoError(t, err)
+	require.Len(t, *rules, 1)
+	rule := (*rules)[0]
+	assert.Equal(t, domainWatchlist.Pair{Base: "EUR", Quote: "USD"}, rule.Pair)
+	assert.Equal(t, domainAlert.ConditionChangePercent, rule.Condition)
+	assert.Equal(t, 10*time.Minute, rule.Cooldown)
+}
+
+func TestRepository_UpdateRuleState(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAlertRepository(db, setupLogger(t))
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "alert_rules" SET "last_triggered_at"=$1,"reference_at"=$2,"reference_rate"=$3 WHERE "id" = $4`)).
+		WithArgs(&now, &now, 1.1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err := repo.UpdateRuleState(&domainAlert.Rule{ID: 5, ReferenceRate: 1.1, ReferenceAt: &now, LastTriggeredAt: &now})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_DeleteRule(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAlertRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "alert_rules" WHERE user_id = $1 AND "alert_rules"."id" = $2`)).
+		WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assertErrorType(t, repo.DeleteRule(4, 2), domainErrors.NotFound)
+}
+
+func TestRepository_Events(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAlertRepository(db, setupLogger(t))
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "alert_events"`)).
+		WithArgs(1, 3, "EUR/USD", "above", 1.12, 1.13, float64(0), now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectCommit()
+	event, err := repo.CreateEvent(&domainAlert.Event{
+		RuleID:      1,
+		UserID:      3,
+		Pair:        domainWatchlist.Pair{Base: "EUR", Quote: "USD"},
+		Condition:   domainAlert.ConditionAbove,
+		Threshold:   1.12,
+		Rate:        1.13,
+		TriggeredAt: now,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 9, event.ID)
+
+	rows := sqlmock.NewRows([]string{"id", "rule_id", "user_id", "pair", "condition", "rate", "triggered_at"}).
+		AddRow(9, 1, 3, "EUR/USD", "above", 1.13, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "alert_events" WHERE user_id = $1 ORDER BY triggered_at desc LIMIT $2`)).
+		WithArgs(3, 50).WillReturnRows(rows)
+	events, err := repo.GetEventsByUserID(3, 50)
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+	assert.Equal(t, 1.13, (*events)[0].Rate)
+}
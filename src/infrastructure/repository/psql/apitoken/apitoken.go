@@ -0,0 +1,145 @@
+package apitoken
+
+import (
+	"strings"
+	"time"
+
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type APIToken struct {
+	ID         int        `gorm:"primaryKey"`
+	UserID     int        `gorm:"column:user_id;index"`
+	Name       string     `gorm:"column:name"`
+	Prefix     string     `gorm:"column:prefix"`
+	TokenHash  string     `gorm:"column:token_hash;unique"`
+	Scopes     string     `gorm:"column:scopes"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime:mili"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// APITokenRepositoryInterface defines the interface for API token repository operations
+type APITokenRepositoryInterface interface {
+	Create(token *domainAPIToken.APIToken) (*domainAPIToken.APIToken, error)
+	GetByHash(tokenHash string) (*domainAPIToken.APIToken, error)
+	GetByUserID(userID int) (*[]domainAPIToken.APIToken, error)
+	UpdateLastUsed(id int, lastUsedAt time.Time) error
+	Delete(userID int, id int) error
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewAPITokenRepository(db *gorm.DB, loggerInstance *logger.Logger) APITokenRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) Create(token *domainAPIToken.APIToken) (*domainAPIToken.APIToken, error) {
+	r.Logger.Info("Creating new API token", zap.Int("userID", token.UserID), zap.String("name", token.Name))
+	tokenRepository := fromDomainMapper(token)
+	if err := r.DB.Create(tokenRepository).Error; err != nil {
+		r.Logger.Error("Error creating API token", zap.Error(err), zap.Int("userID", token.UserID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully created API token", zap.Int("id", tokenRepository.ID))
+	return tokenRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByHash(tokenHash string) (*domainAPIToken.APIToken, error) {
+	var token APIToken
+	err := r.DB.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("API token not found")
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting API token by hash", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return token.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByUserID(userID int) (*[]domainAPIToken.APIToken, error) {
+	var tokens []APIToken
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error; err != nil {
+		r.Logger.Error("Error getting API tokens by user", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved API tokens", zap.Int("userID", userID), zap.Int("count", len(tokens)))
+	return arrayToDomainMapper(&tokens), nil
+}
+
+func (r *Repository) UpdateLastUsed(id int, lastUsedAt time.Time) error {
+	err := r.DB.Model(&APIToken{ID: id}).Update("last_used_at", lastUsedAt).Error
+	if err != nil {
+		r.Logger.Error("Error updating API token last use", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return nil
+}
+
+func (r *Repository) Delete(userID int, id int) error {
+	tx := r.DB.Where("user_id = ?", userID).Delete(&APIToken{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting API token", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("API token not found for deletion", zap.Int("id", id), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted API token", zap.Int("id", id))
+	return nil
+}
+
+// Mappers
+func (t *APIToken) toDomainMapper() *domainAPIToken.APIToken {
+	var scopes []string
+	if t.Scopes != "" {
+		scopes = strings.Split(t.Scopes, ",")
+	}
+	return &domainAPIToken.APIToken{
+		ID:         t.ID,
+		UserID:     t.UserID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		TokenHash:  t.TokenHash,
+		Scopes:     scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func fromDomainMapper(t *domainAPIToken.APIToken) *APIToken {
+	return &APIToken{
+		ID:         t.ID,
+		UserID:     t.UserID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		TokenHash:  t.TokenHash,
+		Scopes:     strings.Join(t.Scopes, ","),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func arrayToDomainMapper(tokens *[]APIToken) *[]domainAPIToken.APIToken {
+	tokensDomain := make([]domainAPIToken.APIToken, len(*tokens))
+	for i, token := range *tokens {
+		tokensDomain[i] = *token.toDomainMapper()
+	}
+	return &tokensDomain
+}
@@ -0,0 +1,111 @@
+package apitoken
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "api_tokens", APIToken{}.TableName())
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAPITokenRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "api_tokens"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	token, err := repo.Create(&domainAPIToken.APIToken{
+		UserID:    3,
+		Name:      "ci",
+		TokenHash: "hash",
+		Scopes:    []string{"currency:read", "exchanger:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, token.ID)
+	assert.Equal(t, []string{"currency:read", "exchanger:read"}, token.Scopes)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "api_tokens"`)).WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+	_, err = repo.Create(&domainAPIToken.APIToken{UserID: 3})
+	assert.Error(t, err)
+}
+
+func TestRepository_GetByHash(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAPITokenRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "token_hash", "scopes", "expires_at"}).
+		AddRow(2, 3, "ci", "pat_abcdefgh", "hash", "currency:read,currency:write", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_tokens" WHERE token_hash = $1`)).
+		WithArgs("hash", 1).WillReturnRows(rows)
+	token, err := repo.GetByHash("hash")
+	require.NoError(t, err)
+	assert.Equal(t, 3, token.UserID)
+	assert.Equal(t, []string{"currency:read", "currency:write"}, token.Scopes)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_tokens" WHERE token_hash = $1`)).
+		WithArgs("missing", 1).WillReturnError(gorm.ErrRecordNotFound)
+	_, err = repo.GetByHash("missing")
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewAPITokenRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "api_tokens"`)).
+		WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Delete(3, 2))
+
+	// Tokens owned by another user are reported as missing
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "api_tokens"`)).
+		WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err := repo.Delete(4, 2)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
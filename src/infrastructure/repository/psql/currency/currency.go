@@ -2,13 +2,18 @@ package currency
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/search"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Currency struct {
@@ -25,6 +30,52 @@ func (Currency) TableName() string {
 	return "currencies"
 }
 
+// CurrencyRate is one rate recorded by a refresh or an import; together they form the rate history
+type CurrencyRate struct {
+	ID         int       `gorm:"primaryKey"`
+	Code       string    `gorm:"column:code;index:idx_currency_rates_code_recorded_at,priority:1"`
+	Rate       float64   `gorm:"column:rate"`
+	RecordedAt time.Time `gorm:"column:recorded_at;index:idx_currency_rates_code_recorded_at,priority:2"`
+	Source     string    `gorm:"column:source;size:32;not null;default:provider"`
+}
+
+func (CurrencyRate) TableName() string {
+	return "currency_rates"
+}
+
+// ohlcQuery buckets the history in SQL; open and close are the first and last rate of each bucket
+const ohlcQuery = `SELECT date_trunc(?, recorded_at AT TIME ZONE 'UTC') AS start,
+	(array_agg(rate ORDER BY recorded_at ASC))[1] AS open,
+	MAX(rate) AS high,
+	MIN(rate) AS low,
+	(array_agg(rate ORDER BY recorded_at DESC))[1] AS close,
+	COUNT(*) AS samples
+FROM currency_rates
+WHERE code = ? AND recorded_at >= ? AND recorded_at < ?
+GROUP BY start
+ORDER BY start`
+
+// statsQuery computes the summary in SQL, with volatility taken over consecutive log returns
+const statsQuery = `WITH history AS (
+	SELECT rate, recorded_at, LN(rate / LAG(rate) OVER (ORDER BY recorded_at)) AS log_return
+	FROM currency_rates
+	WHERE code = ? AND recorded_at >= ? AND recorded_at < ? AND rate > 0
+)
+SELECT COUNT(*) AS samples,
+	COALESCE(MIN(rate), 0) AS min,
+	COALESCE(MAX(rate), 0) AS max,
+	COALESCE(AVG(rate), 0) AS mean,
+	COALESCE(STDDEV_SAMP(log_return), 0) AS volatility,
+	COALESCE((array_agg(rate ORDER BY recorded_at ASC))[1], 0) AS first,
+	COALESCE((array_agg(rate ORDER BY recorded_at DESC))[1], 0) AS last
+FROM history`
+
+// intervalUnits maps intervals to date_trunc units
+var intervalUnits = map[domainCurrency.Interval]string{
+	domainCurrency.IntervalHour: "hour",
+	domainCurrency.IntervalDay:  "day",
+}
+
 var ColumnsUserMapping = map[string]string{
 	"id":        "id",
 	"name":      "currency_name",
@@ -39,9 +90,20 @@ var ColumnsUserMapping = map[string]string{
 type CurrencyRepositoryInterface interface {
 	GetAll() (*[]domainCurrency.Currency, error)
 	Create(currencyDomain *domainCurrency.Currency) (*domainCurrency.Currency, error)
+	Upsert(currencyDomain *domainCurrency.Currency) (*domainCurrency.Currency, error)
 	GetByID(id int) (*domainCurrency.Currency, error)
+	GetByCodes(codes []string) (*[]domainCurrency.Currency, error)
 	Update(id int, currencyMap map[string]interface{}) (*domainCurrency.Currency, error)
 	Delete(id int) error
+	SearchPaginated(filters domain.DataFilters) (*domainCurrency.SearchResultCurrency, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	StreamSearch(filters domain.DataFilters, fn func(*domainCurrency.Currency) error) error
+	RecordRates(currencies []domainCurrency.Currency, recordedAt time.Time) error
+	GetOHLC(code string, interval domainCurrency.Interval, from, to time.Time) (*[]domainCurrency.OHLC, error)
+	GetStats(code string, from, to time.Time) (*domainCurrency.RateStats, error)
+	GetRateAt(code string, at time.Time) (*domainCurrency.RatePoint, error)
+	StreamRates(code string, from, to time.Time, fn func(*domainCurrency.RatePoint) error) error
+	ApplyImport(changes []domainCurrency.ImportChange, recordedAt time.Time) (*[]domainCurrency.Currency, error)
 }
 
 type Repository struct {
@@ -88,6 +150,21 @@ func (r *Repository) Create(currencyDomain *domainCurrency.Currency) (*domainCur
 	return userRepository.toDomainMapper(), err
 }
 
+// Upsert creates the currency or, when its code is already stored, updates the stored rate
+func (r *Repository) Upsert(currencyDomain *domainCurrency.Currency) (*domainCurrency.Currency, error) {
+	currency := fromDomainMapper(currencyDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}, clause.Returning{}).Create(currency).Error
+	if err != nil {
+		r.Logger.Error("Error upserting currency", zap.Error(err), zap.String("code", currencyDomain.Code))
+		return nil, domainErrors.NewAppError(err, domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully upserted currency", zap.String("code", currency.Code), zap.Int("id", currency.ID))
+	return currency.toDomainMapper(), nil
+}
+
 func (r *Repository) GetByID(id int) (*domainCurrency.Currency, error) {
 	var user Currency
 	err := r.DB.Where("id = ?", id).First(&user).Error
@@ -105,6 +182,17 @@ func (r *Repository) GetByID(id int) (*domainCurrency.Currency, error) {
 	return user.toDomainMapper(), nil
 }
 
+// GetByCodes returns the stored currencies among the given codes; unknown codes are skipped
+func (r *Repository) GetByCodes(codes []string) (*[]domainCurrency.Currency, error) {
+	var currencies []Currency
+	if err := r.DB.Where("code IN ?", codes).Find(&currencies).Error; err != nil {
+		r.Logger.Error("Error getting currencies by code", zap.Error(err), zap.Strings("codes", codes))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved currencies by code", zap.Int("count", len(currencies)))
+	return arrayToDomainMapper(&currencies), nil
+}
+
 func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainCurrency.Currency, error) {
 	var userObj Currency
 	userObj.ID = id
@@ -159,7 +247,209 @@ func (r *Repository) Delete(id int) error {
 	return nil
 }
 
+// RecordRates appends the given rates to the rate history
+func (r *Repository) RecordRates(currencies []domainCurrency.Currency, recordedAt time.Time) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+	rates := make([]CurrencyRate, len(currencies))
+	for i, c := range currencies {
+		rates[i] = CurrencyRate{Code: c.Code, Rate: c.Rate, RecordedAt: recordedAt, Source: domainCurrency.RateSourceProvider}
+	}
+	if err := r.DB.Create(&rates).Error; err != nil {
+		r.Logger.Error("Error recording rate history", zap.Error(err), zap.Int("count", len(rates)))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully recorded rate history", zap.Int("count", len(rates)))
+	return nil
+}
+
+// GetOHLC returns one OHLC bucket per interval in [from, to) that has recorded rates
+func (r *Repository) GetOHLC(code string, interval domainCurrency.Interval, from, to time.Time) (*[]domainCurrency.OHLC, error) {
+	unit, ok := intervalUnits[interval]
+	if !ok {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+	var buckets []domainCurrency.OHLC
+	if err := r.DB.Raw(ohlcQuery, unit, code, from, to).Scan(&buckets).Error; err != nil {
+		r.Logger.Error("Error getting OHLC", zap.Error(err), zap.String("code", code))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	if buckets == nil {
+		buckets = []domainCurrency.OHLC{}
+	}
+	r.Logger.Info("Successfully retrieved OHLC", zap.String("code", code), zap.Int("buckets", len(buckets)))
+	return &buckets, nil
+}
+
+// GetStats summarises the rates recorded in [from, to); Samples is 0 when there are none
+func (r *Repository) GetStats(code string, from, to time.Time) (*domainCurrency.RateStats, error) {
+	var stats domainCurrency.RateStats
+	if err := r.DB.Raw(statsQuery, code, from, to).Scan(&stats).Error; err != nil {
+		r.Logger.Error("Error getting rate statistics", zap.Error(err), zap.String("code", code))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	stats.Code, stats.From, stats.To = code, from, to
+	r.Logger.Info("Successfully retrieved rate statistics", zap.String("code", code), zap.Int("samples", stats.Samples))
+	return &stats, nil
+}
+
+// GetRateAt returns the last rate of a currency recorded at or before at
+func (r *Repository) GetRateAt(code string, at time.Time) (*domainCurrency.RatePoint, error) {
+	var rate CurrencyRate
+	err := r.DB.Where("code = ? AND recorded_at <= ?", code, at).Order("recorded_at DESC").First(&rate).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("No rate recorded yet", zap.String("code", code), zap.Time("at", at))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting rate at time", zap.Error(err), zap.String("code", code))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return rate.toDomainMapper(), nil
+}
+
+// StreamRates calls fn with every rate of code recorded between from and to, oldest first, reading
+// them one at a time
+func (r *Repository) StreamRates(code string, from, to time.Time, fn func(*domainCurrency.RatePoint) error) error {
+	rows, err := r.DB.Model(&CurrencyRate{}).
+		Where("code = ? AND recorded_at >= ? AND recorded_at <= ?", code, from, to).
+		Order("recorded_at").
+		Rows()
+	if err != nil {
+		r.Logger.Error("Error streaming rates", zap.Error(err), zap.String("code", code))
+		return domainErrors.NewAppError(err, domainErrors.RepositoryError)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rate CurrencyRate
+		if err := r.DB.ScanRows(rows, &rate); err != nil {
+			r.Logger.Error("Error reading streamed rate", zap.Error(err), zap.String("code", code))
+			return domainErrors.NewAppError(err, domainErrors.RepositoryError)
+		}
+		if err := fn(rate.toDomainMapper()); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.Logger.Error("Error streaming rates", zap.Error(err), zap.String("code", code))
+		return domainErrors.NewAppError(err, domainErrors.RepositoryError)
+	}
+	return nil
+}
+
+// ApplyImport creates and updates the currencies of an import and records every imported rate,
+// all in one transaction. It returns the imported currencies as stored.
+func (r *Repository) ApplyImport(changes []domainCurrency.ImportChange, recordedAt time.Time) (*[]domainCurrency.Currency, error) {
+	var imported []Currency
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		codes := make([]string, 0, len(changes))
+		rates := make([]CurrencyRate, 0, len(changes))
+		for _, change := range changes {
+			switch change.Action {
+			case domainCurrency.ImportCreate:
+				currency := Currency{Name: change.Name, Code: change.Code, Rate: change.Rate, Status: true}
+				if err := tx.Create(&currency).Error; err != nil {
+					return err
+				}
+			case domainCurrency.ImportUpdate:
+				updates := map[string]interface{}{"rate": change.Rate}
+				if change.Name != "" {
+					updates["currency_name"] = change.Name
+				}
+				result := tx.Model(&Currency{}).Where("code = ?", change.Code).Updates(updates)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return fmt.Errorf("currency %s was deleted during the import", change.Code)
+				}
+			}
+			codes = append(codes, change.Code)
+			rates = append(rates, CurrencyRate{Code: change.Code, Rate: change.Rate, RecordedAt: recordedAt, Source: domainCurrency.RateSourceManualImport})
+		}
+		if len(rates) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rates).Error; err != nil {
+			return err
+		}
+		return tx.Where("code IN ?", codes).Find(&imported).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error applying import", zap.Error(err), zap.Int("changes", len(changes)))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully applied import", zap.Int("currencies", len(imported)))
+	return arrayToDomainMapper(&imported), nil
+}
+
+func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainCurrency.SearchResultCurrency, error) {
+	page, err := search.Paginate[Currency](r.DB.Model(&Currency{}), ColumnsUserMapping, filters)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidCursor) {
+			r.Logger.Warn("Invalid search cursor")
+			return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		r.Logger.Error("Error searching currencies", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	result := &domainCurrency.SearchResultCurrency{
+		Data:       arrayToDomainMapper(&page.Rows),
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+
+	r.Logger.Info("Successfully searched currencies",
+		zap.Int64("total", page.Total),
+		zap.Int("page", page.Page),
+		zap.Int("pageSize", page.PageSize))
+
+	return result, nil
+}
+
+func (r *Repository) SearchByProperty(property string, searchText string) (*[]string, error) {
+	coincidences, err := search.Distinct(r.DB.Model(&Currency{}), ColumnsUserMapping, property, searchText)
+	if err != nil {
+		if errors.Is(err, search.ErrUnknownProperty) {
+			r.Logger.Warn("Invalid property for search", zap.String("property", property))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+		}
+		r.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(coincidences)))
+
+	return &coincidences, nil
+}
+
+// StreamSearch calls fn with every currency matching filters, reading them one at a time
+func (r *Repository) StreamSearch(filters domain.DataFilters, fn func(*domainCurrency.Currency) error) error {
+	var callbackErr error
+	err := search.Each[Currency](r.DB.Model(&Currency{}), ColumnsUserMapping, filters, func(row *Currency) error {
+		callbackErr = fn(row.toDomainMapper())
+		return callbackErr
+	})
+	if err != nil && err != callbackErr {
+		r.Logger.Error("Error streaming currencies", zap.Error(err))
+		return domainErrors.NewAppError(err, domainErrors.RepositoryError)
+	}
+	return err
+}
+
 // Mappers
+func (r *CurrencyRate) toDomainMapper() *domainCurrency.RatePoint {
+	return &domainCurrency.RatePoint{Code: r.Code, Rate: r.Rate, RecordedAt: r.RecordedAt, Source: r.Source}
+}
+
 func (u *Currency) toDomainMapper() *domainCurrency.Currency {
 	return &domainCurrency.Currency{
 		ID:        u.ID,
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/domain"
 	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -124,6 +126,21 @@ func TestRepository_GetByID(t *testing.T) {
 	assert.Equal(t, 0, currency.ID) // Should be zero value
 }
 
+func TestRepository_GetByCodes(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewCurrencyRepository(db, logger)
+	rows := sqlmock.NewRows([]string{"id", "currency_name", "code", "rate", "status", "created_at", "updated_at"}).
+		AddRow(2, "EUR Euro", "EUR", 0.92, true, time.Now(), time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "currencies" WHERE code IN ($1,$2)`)).
+		WithArgs("EUR", "XXX").WillReturnRows(rows)
+	currencies, err := repo.GetByCodes([]string{"EUR", "XXX"})
+	assert.NoError(t, err)
+	assert.Len(t, *currencies, 1)
+	assert.Equal(t, 0.92, (*currencies)[0].Rate)
+}
+
 func TestRepository_Create(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -145,6 +162,30 @@ func TestRepository_Create(t *testing.T) {
 	assert.Equal(t, "USD Dollar", result.Name)
 }
 
+func TestRepository_Upsert(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "currencies"`) + `.*` +
+		regexp.QuoteMeta(`ON CONFLICT ("code") DO UPDATE SET "rate"="excluded"."rate","updated_at"="excluded"."updated_at" RETURNING *`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "rate"}).AddRow(7, "EUR", 0.93))
+	mock.ExpectCommit()
+	result, err := repo.Upsert(&domainCurrency.Currency{Code: "EUR", Rate: 0.93, Status: true})
+	require.NoError(t, err)
+	assert.Equal(t, 7, result.ID)
+	assert.Equal(t, 0.93, result.Rate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "currencies"`)).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	_, err = repo.Upsert(&domainCurrency.Currency{Code: "EUR", Rate: 0.93})
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.RepositoryError, appErr.Type)
+}
+
 func TestRepository_Delete(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -193,3 +234,167 @@ func TestRepository_Delete(t *testing.T) {
 // TestRepository_Update_WithMultipleFields
 //
 // If you want me to refactor these as well, let me know and I'll do them one by one.
+
+func TestRepository_RecordRates(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	at := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "currency_rates" ("code","rate","recorded_at","source") VALUES ($1,$2,$3,$4),($5,$6,$7,$8) RETURNING "id"`)).
+		WithArgs("EUR", 0.9, at, "provider", "GBP", 0.8, at, "provider").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+	err := repo.RecordRates([]domainCurrency.Currency{{Code: "EUR", Rate: 0.9}, {Code: "GBP", Rate: 0.8}}, at)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, repo.RecordRates(nil, at))
+}
+
+func TestRepository_GetOHLC(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+	rows := sqlmock.NewRows([]string{"start", "open", "high", "low", "close", "samples"}).
+		AddRow(from, 0.9, 0.95, 0.88, 0.91, 24).
+		AddRow(from.Add(24*time.Hour), 0.91, 0.93, 0.9, 0.92, 24)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT date_trunc($1, recorded_at AT TIME ZONE 'UTC') AS start`)).
+		WithArgs("day", "EUR", from, to).WillReturnRows(rows)
+	buckets, err := repo.GetOHLC("EUR", domainCurrency.IntervalDay, from, to)
+	require.NoError(t, err)
+	require.Len(t, *buckets, 2)
+	assert.Equal(t, 0.95, (*buckets)[0].High)
+	assert.Equal(t, 0.92, (*buckets)[1].Close)
+	assert.Equal(t, 24, (*buckets)[1].Samples)
+}
+
+func TestRepository_GetStats(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	rows := sqlmock.NewRows([]string{"samples", "min", "max", "mean", "volatility", "first", "last"}).
+		AddRow(3, 0.8, 0.9, 0.85, 0.05, 0.8, 0.9)
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH history AS`)).
+		WithArgs("EUR", from, to).WillReturnRows(rows)
+	stats, err := repo.GetStats("EUR", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", stats.Code)
+	assert.Equal(t, 3, stats.Samples)
+	assert.Equal(t, 0.05, stats.Volatility)
+	assert.Equal(t, 0.9, stats.Last)
+	assert.Equal(t, to, stats.To)
+}
+
+func TestRepository_GetRateAt(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	at := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+	recordedAt := at.Add(-time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "currency_rates" WHERE code = $1 AND recorded_at <= $2 ORDER BY recorded_at DESC,"currency_rates"."id" LIMIT $3`)).
+		WithArgs("EUR", at, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "rate", "recorded_at"}).AddRow(7, "EUR", 0.92, recordedAt))
+	point, err := repo.GetRateAt("EUR", at)
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, point.Rate)
+	assert.Equal(t, recordedAt, point.RecordedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "currency_rates"`)).
+		WithArgs("JPY", at, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "rate", "recorded_at"}))
+	_, err = repo.GetRateAt("JPY", at)
+	assert.Error(t, err)
+}
+
+func TestRepository_SearchPaginated(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "currencies" WHERE currency_name ILIKE $1`)).
+		WithArgs("%euro%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "currencies" WHERE currency_name ILIKE $1 ORDER BY code asc LIMIT $2`)).
+		WithArgs("%euro%", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency_name", "code", "rate"}).AddRow(1, "Euro", "EUR", 0.9))
+
+	result, err := repo.SearchPaginated(domain.DataFilters{
+		LikeFilters:   map[string][]string{"name": {"euro"}},
+		SortBy:        []string{"code"},
+		SortDirection: domain.SortAsc,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, "EUR", (*result.Data)[0].Code)
+}
+
+func TestRepository_SearchByProperty(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "code" FROM "currencies" WHERE code ILIKE $1 LIMIT $2`)).
+		WithArgs("%E%", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("EUR"))
+
+	values, err := repo.SearchByProperty("code", "E")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EUR"}, *values)
+
+	_, err = repo.SearchByProperty("unknown", "E")
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
+
+func TestRepository_ApplyImport(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+	at := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	changes := []domainCurrency.ImportChange{
+		{Code: "CHF", Name: "Swiss Franc", Rate: 0.88, Action: domainCurrency.ImportCreate},
+		{Code: "EUR", Rate: 0.91, Action: domainCurrency.ImportUpdate},
+		{Code: "GBP", Rate: 0.79, Action: domainCurrency.ImportUnchanged},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "currencies"`)).
+		WithArgs("Swiss Franc", 0.88, "CHF", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "currencies" SET "rate"=$1,"updated_at"=$2 WHERE code = $3`)).
+		WithArgs(0.91, sqlmock.AnyArg(), "EUR").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "currency_rates" ("code","rate","recorded_at","source") VALUES ($1,$2,$3,$4),($5,$6,$7,$8),($9,$10,$11,$12) RETURNING "id"`)).
+		WithArgs("CHF", 0.88, at, "manual-import", "EUR", 0.91, at, "manual-import", "GBP", 0.79, at, "manual-import").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "currencies" WHERE code IN ($1,$2,$3)`)).
+		WithArgs("CHF", "EUR", "GBP").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency_name", "code", "rate"}).AddRow(9, "Swiss Franc", "CHF", 0.88).AddRow(1, "Euro", "EUR", 0.91).AddRow(2, "Pound", "GBP", 0.79))
+	mock.ExpectCommit()
+
+	imported, err := repo.ApplyImport(changes, at)
+	require.NoError(t, err)
+	assert.Len(t, *imported, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ApplyImportRollsBack(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewCurrencyRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "currencies"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.ApplyImport([]domainCurrency.ImportChange{{Code: "EUR", Rate: 0.91, Action: domainCurrency.ImportUpdate}}, time.Now())
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,23 +2,35 @@ package exchanger
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/search"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// Exchanger stores AllowedCodes and DeniedCodes as comma separated currency codes and MinInterval
+// in seconds
 type Exchanger struct {
-	ID        int       `gorm:"primaryKey"`
-	Name      string    `gorm:"column:name;"`
-	Url       string    `gorm:"column:url;"`
-	ApiKey    string    `gorm:"column:api_key;unique"`
-	IsActive  bool      `gorm:"column:is_active"`
-	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+	ID           int       `gorm:"primaryKey"`
+	Name         string    `gorm:"column:name;"`
+	Url          string    `gorm:"column:url;"`
+	ApiKey       string    `gorm:"column:api_key;unique"`
+	IsActive     bool      `gorm:"column:is_active"`
+	Priority     int       `gorm:"column:priority;not null;default:0"`
+	Weight       float64   `gorm:"column:weight;not null;default:1"`
+	AllowedCodes string    `gorm:"column:allowed_codes"`
+	DeniedCodes  string    `gorm:"column:denied_codes"`
+	MonthlyQuota int       `gorm:"column:monthly_quota;not null;default:0"`
+	MinInterval  int       `gorm:"column:min_interval_seconds;not null;default:0"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:mili"`
 }
 
 func (Exchanger) TableName() string {
@@ -26,11 +38,30 @@ func (Exchanger) TableName() string {
 }
 
 var ColumnsUserMapping = map[string]string{
+	"id":           "id",
+	"userName":     "name",
+	"apiKey":       "api_key",
+	"isActive":     "is_active",
+	"url":          "url",
+	"priority":     "priority",
+	"weight":       "weight",
+	"allowedCodes": "allowed_codes",
+	"deniedCodes":  "denied_codes",
+	"monthlyQuota": "monthly_quota",
+	"minInterval":  "min_interval_seconds",
+	"createdAt":    "created_at",
+	"updatedAt":    "updated_at",
+}
+
+// SearchColumns are the fields exchangers can be searched, filtered and sorted by; the encrypted
+// API key is left out
+var SearchColumns = search.ColumnMapping{
 	"id":        "id",
-	"userName":  "name",
-	"apiKey":    "api_key",
-	"isActive":  "is_active",
+	"name":      "name",
 	"url":       "url",
+	"isActive":  "is_active",
+	"priority":  "priority",
+	"weight":    "weight",
 	"createdAt": "created_at",
 	"updatedAt": "updated_at",
 }
@@ -42,6 +73,14 @@ type ExchangerRepositoryInterface interface {
 	GetByID(id int) (*domainExchanger.Exchanger, error)
 	Update(id int, userMap map[string]interface{}) (*domainExchanger.Exchanger, error)
 	Delete(id int) error
+	SearchPaginated(filters domain.DataFilters) (*domainExchanger.SearchResultExchanger, error)
+	SearchByProperty(property string, searchText string) (*[]string, error)
+	GetAllHealth() (*[]domainExchanger.Health, error)
+	GetHealth(exchangerID int, since time.Time) (*domainExchanger.Health, error)
+	RecordFetch(fetch domainExchanger.Fetch, health domainExchanger.Health) error
+	SetActive(exchangerID int, active bool, change domainExchanger.StatusChange) error
+	ReserveCall(exchanger domainExchanger.Exchanger, at time.Time) (*domainExchanger.Usage, error)
+	GetUsage(exchangerID int, limit int) (*[]domainExchanger.Usage, error)
 }
 
 type Repository struct {
@@ -130,6 +169,9 @@ func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainExch
 	// Map JSON field names to DB column names
 	updateData := make(map[string]interface{})
 	for k, v := range userMap {
+		if codes, ok := v.([]string); ok {
+			v = strings.Join(codes, ",")
+		}
 		if column, ok := ColumnsUserMapping[k]; ok {
 			updateData[column] = v
 		} else {
@@ -138,7 +180,7 @@ func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainExch
 	}
 
 	err := r.DB.Model(&userObj).
-		Select("name", "is_active", "api_key", "url").
+		Select("name", "is_active", "api_key", "url", "priority", "weight", "allowed_codes", "denied_codes", "monthly_quota", "min_interval_seconds").
 		Updates(updateData).Error
 	if err != nil {
 		r.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", id))
@@ -177,28 +219,87 @@ func (r *Repository) Delete(id int) error {
 	return nil
 }
 
+func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainExchanger.SearchResultExchanger, error) {
+	page, err := search.Paginate[Exchanger](r.DB.Model(&Exchanger{}), SearchColumns, filters)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidCursor) {
+			r.Logger.Warn("Invalid search cursor")
+			return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		r.Logger.Error("Error searching exchangers", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	result := &domainExchanger.SearchResultExchanger{
+		Data:       arrayToDomainMapper(&page.Rows),
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+
+	r.Logger.Info("Successfully searched exchangers",
+		zap.Int64("total", page.Total),
+		zap.Int("page", page.Page),
+		zap.Int("pageSize", page.PageSize))
+
+	return result, nil
+}
+
+func (r *Repository) SearchByProperty(property string, searchText string) (*[]string, error) {
+	coincidences, err := search.Distinct(r.DB.Model(&Exchanger{}), SearchColumns, property, searchText)
+	if err != nil {
+		if errors.Is(err, search.ErrUnknownProperty) {
+			r.Logger.Warn("Invalid property for search", zap.String("property", property))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+		}
+		r.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(coincidences)))
+
+	return &coincidences, nil
+}
+
 // Mappers
 func (u *Exchanger) toDomainMapper() *domainExchanger.Exchanger {
 	return &domainExchanger.Exchanger{
-		ID:        u.ID,
-		Name:      u.Name,
-		Url:       u.Url,
-		IsActive:  u.IsActive,
-		ApiKey:    u.ApiKey,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:           u.ID,
+		Name:         u.Name,
+		Url:          u.Url,
+		IsActive:     u.IsActive,
+		ApiKey:       u.ApiKey,
+		Priority:     u.Priority,
+		Weight:       u.Weight,
+		AllowedCodes: splitList(u.AllowedCodes),
+		DeniedCodes:  splitList(u.DeniedCodes),
+		MonthlyQuota: u.MonthlyQuota,
+		MinInterval:  time.Duration(u.MinInterval) * time.Second,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
 	}
 }
 
 func fromDomainMapper(u *domainExchanger.Exchanger) *Exchanger {
 	return &Exchanger{
-		ID:        u.ID,
-		Name:      u.Name,
-		Url:       u.Url,
-		IsActive:  u.IsActive,
-		ApiKey:    u.ApiKey,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:           u.ID,
+		Name:         u.Name,
+		Url:          u.Url,
+		IsActive:     u.IsActive,
+		ApiKey:       u.ApiKey,
+		Priority:     u.Priority,
+		Weight:       u.Weight,
+		AllowedCodes: strings.Join(u.AllowedCodes, ","),
+		DeniedCodes:  strings.Join(u.DeniedCodes, ","),
+		MonthlyQuota: u.MonthlyQuota,
+		MinInterval:  int(u.MinInterval / time.Second),
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
 	}
 }
 
@@ -209,3 +310,10 @@ func arrayToDomainMapper(users *[]Exchanger) *[]domainExchanger.Exchanger {
 	}
 	return &usersDomain
 }
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/stretchr/testify/assert"
@@ -48,36 +49,46 @@ func TestNewExchangerRepository(t *testing.T) {
 
 func TestToDomainMapper(t *testing.T) {
 	u := &Exchanger{
-		ID:        1,
-		Name:      "apiexchange",
-		ApiKey:    "dsdsd11232d",
-		IsActive:  true,
-		Url:       "https://api.exchange.com",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          1,
+		Name:        "apiexchange",
+		ApiKey:      "dsdsd11232d",
+		IsActive:    true,
+		Url:         "https://api.exchange.com",
+		Priority:    2,
+		Weight:      1.5,
+		DeniedCodes: "ARS,VES",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 	d := u.toDomainMapper()
 	assert.Equal(t, u.Name, d.Name)
 	assert.Equal(t, u.ApiKey, d.ApiKey)
 	assert.Equal(t, u.IsActive, d.IsActive)
 	assert.Equal(t, u.Url, d.Url)
+	assert.Equal(t, 2, d.Priority)
+	assert.Equal(t, 1.5, d.Weight)
+	assert.Equal(t, []string{}, d.AllowedCodes)
+	assert.Equal(t, []string{"ARS", "VES"}, d.DeniedCodes)
 }
 
 func TestFromDomainMapper(t *testing.T) {
 	d := &domainExchanger.Exchanger{
-		ID:        1,
-		Name:      "apiexchange",
-		IsActive:  true,
-		ApiKey:    "dsdsd11232d",
-		Url:       "https://api.exchange.com",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:           1,
+		Name:         "apiexchange",
+		IsActive:     true,
+		ApiKey:       "dsdsd11232d",
+		Url:          "https://api.exchange.com",
+		AllowedCodes: []string{"EUR", "GBP"},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 	u := fromDomainMapper(d)
 	assert.Equal(t, d.Name, u.Name)
 	assert.Equal(t, d.ApiKey, u.ApiKey)
 	assert.Equal(t, d.IsActive, u.IsActive)
 	assert.Equal(t, d.Url, u.Url)
+	assert.Equal(t, "EUR,GBP", u.AllowedCodes)
+	assert.Equal(t, "", u.DeniedCodes)
 }
 
 func TestArrayToDomainMapper(t *testing.T) {
@@ -145,6 +156,25 @@ func TestRepository_Create(t *testing.T) {
 	assert.Equal(t, "apiexchange", user.Name)
 }
 
+func TestRepository_UpdateJoinsCodeLists(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "exchangers" SET "allowed_codes"=$1,"weight"=$2,"updated_at"=$3 WHERE "id" = $4`)).
+		WithArgs("EUR,GBP", 2.5, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "exchangers" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "allowed_codes", "weight"}).AddRow(3, "EUR,GBP", 2.5))
+
+	updated, err := repo.Update(3, map[string]interface{}{"allowedCodes": []string{"EUR", "GBP"}, "weight": 2.5})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EUR", "GBP"}, updated.AllowedCodes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepository_Delete(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -171,3 +201,23 @@ func TestRepository_Delete(t *testing.T) {
 // TestRepository_Update_WithMultipleFields
 
 // If you want me to refactor these as well, let me know and I'll do them one by one.
+
+func TestRepository_SearchByPropertyHidesApiKey(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "name" FROM "exchangers" WHERE name ILIKE $1 LIMIT $2`)).
+		WithArgs("%api%", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("apiexchange"))
+
+	values, err := repo.SearchByProperty("name", "api")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apiexchange"}, *values)
+
+	_, err = repo.SearchByProperty("apiKey", "d")
+	appErr, ok := err.(*domainErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
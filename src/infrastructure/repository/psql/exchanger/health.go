@@ -0,0 +1,234 @@
+package exchanger
+
+import (
+	"errors"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fetchRetention is how long fetches are kept for the latency percentiles
+const fetchRetention = 7 * 24 * time.Hour
+
+// statusChangeLimit bounds the audit trail returned with the health of an exchanger
+const statusChangeLimit = 20
+
+type Health struct {
+	ExchangerID         int        `gorm:"primaryKey;autoIncrement:false"`
+	State               string     `gorm:"column:state;size:16;not null;default:closed"`
+	Successes           int64      `gorm:"column:successes"`
+	Failures            int64      `gorm:"column:failures"`
+	ConsecutiveFailures int        `gorm:"column:consecutive_failures"`
+	LastError           string     `gorm:"column:last_error"`
+	LastErrorAt         *time.Time `gorm:"column:last_error_at"`
+	LastSuccessAt       *time.Time `gorm:"column:last_success_at"`
+	OpenedAt            *time.Time `gorm:"column:opened_at"`
+	AutoDisabled        bool       `gorm:"column:auto_disabled"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (Health) TableName() string {
+	return "exchanger_health"
+}
+
+type Fetch struct {
+	ID          int       `gorm:"primaryKey"`
+	ExchangerID int       `gorm:"column:exchanger_id;index:idx_exchanger_fetches_exchanger_at,priority:1"`
+	Succeeded   bool      `gorm:"column:succeeded"`
+	LatencyMs   int64     `gorm:"column:latency_ms"`
+	Error       string    `gorm:"column:error"`
+	FetchedAt   time.Time `gorm:"column:fetched_at;index:idx_exchanger_fetches_exchanger_at,priority:2"`
+}
+
+func (Fetch) TableName() string {
+	return "exchanger_fetches"
+}
+
+type StatusChange struct {
+	ID          int       `gorm:"primaryKey"`
+	ExchangerID int       `gorm:"column:exchanger_id;index"`
+	IsActive    bool      `gorm:"column:is_active"`
+	Actor       string    `gorm:"column:actor;size:32"`
+	Reason      string    `gorm:"column:reason"`
+	CreatedAt   time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (StatusChange) TableName() string {
+	return "exchanger_status_changes"
+}
+
+// GetAllHealth returns the circuit state of every exchanger that has been fetched
+func (r *Repository) GetAllHealth() (*[]domainExchanger.Health, error) {
+	var rows []Health
+	if err := r.DB.Find(&rows).Error; err != nil {
+		r.Logger.Error("Error getting exchanger health", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	health := make([]domainExchanger.Health, len(rows))
+	for i, row := range rows {
+		health[i] = *row.toDomainMapper()
+	}
+	return &health, nil
+}
+
+// GetHealth returns the health of an exchanger with the latency percentiles of its fetches since
+// since and its latest status changes. An exchanger never fetched has a closed circuit.
+func (r *Repository) GetHealth(exchangerID int, since time.Time) (*domainExchanger.Health, error) {
+	var row Health
+	if err := r.DB.Where("exchanger_id = ?", exchangerID).First(&row).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Logger.Error("Error getting exchanger health", zap.Error(err), zap.Int("id", exchangerID))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+		}
+		row = Health{ExchangerID: exchangerID, State: string(domainExchanger.CircuitClosed)}
+	}
+	health := row.toDomainMapper()
+
+	var latency struct {
+		Samples int
+		P50     *float64
+		P95     *float64
+		P99     *float64
+	}
+	err := r.DB.Model(&Fetch{}).
+		Select("count(*) AS samples, "+
+			"percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms) AS p50, "+
+			"percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms) AS p95, "+
+			"percentile_cont(0.99) WITHIN GROUP (ORDER BY latency_ms) AS p99").
+		Where("exchanger_id = ? AND fetched_at >= ?", exchangerID, since).
+		Scan(&latency).Error
+	if err != nil {
+		r.Logger.Error("Error getting exchanger latency", zap.Error(err), zap.Int("id", exchangerID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	health.Samples = latency.Samples
+	health.LatencyP50 = milliseconds(latency.P50)
+	health.LatencyP95 = milliseconds(latency.P95)
+	health.LatencyP99 = milliseconds(latency.P99)
+
+	var changes []StatusChange
+	if err := r.DB.Where("exchanger_id = ?", exchangerID).Order("created_at desc, id desc").Limit(statusChangeLimit).Find(&changes).Error; err != nil {
+		r.Logger.Error("Error getting exchanger status changes", zap.Error(err), zap.Int("id", exchangerID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	health.StatusChanges = make([]domainExchanger.StatusChange, len(changes))
+	for i, change := range changes {
+		health.StatusChanges[i] = *change.toDomainMapper()
+	}
+	return health, nil
+}
+
+// RecordFetch stores a fetch with the health it leaves the exchanger in, and drops the fetches
+// past the retention
+func (r *Repository) RecordFetch(fetch domainExchanger.Fetch, health domainExchanger.Health) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		row := Fetch{
+			ExchangerID: fetch.ExchangerID,
+			Succeeded:   fetch.Succeeded,
+			LatencyMs:   fetch.Latency.Milliseconds(),
+			Error:       fetch.Error,
+			FetchedAt:   fetch.At,
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(fromDomainHealthMapper(&health)).Error; err != nil {
+			return err
+		}
+		return tx.Where("exchanger_id = ? AND fetched_at < ?", fetch.ExchangerID, fetch.At.Add(-fetchRetention)).Delete(&Fetch{}).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error recording exchanger fetch", zap.Error(err), zap.Int("id", fetch.ExchangerID))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return nil
+}
+
+// SetActive turns an exchanger on or off and adds the change to its audit trail. The circuit
+// breaker marks the exchangers it turns off so that it only turns those back on; turning an
+// exchanger on by hand also closes its circuit.
+func (r *Repository) SetActive(exchangerID int, active bool, change domainExchanger.StatusChange) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Exchanger{ID: exchangerID}).Update("is_active", active)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		row := StatusChange{ExchangerID: exchangerID, IsActive: active, Actor: change.Actor, Reason: change.Reason, CreatedAt: change.CreatedAt}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"auto_disabled": change.Actor == domainExchanger.StatusActorCircuitBreaker && !active}
+		if active && change.Actor != domainExchanger.StatusActorCircuitBreaker {
+			updates["state"] = string(domainExchanger.CircuitClosed)
+			updates["consecutive_failures"] = 0
+			updates["opened_at"] = nil
+		}
+		return tx.Model(&Health{}).Where("exchanger_id = ?", exchangerID).Updates(updates).Error
+	})
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			r.Logger.Warn("Exchanger not found to change its status", zap.Int("id", exchangerID))
+			return err
+		}
+		r.Logger.Error("Error changing exchanger status", zap.Error(err), zap.Int("id", exchangerID))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Exchanger status changed", zap.Int("id", exchangerID), zap.Bool("active", active), zap.String("actor", change.Actor))
+	return nil
+}
+
+func milliseconds(value *float64) time.Duration {
+	if value == nil {
+		return 0
+	}
+	return time.Duration(*value * float64(time.Millisecond))
+}
+
+func (h *Health) toDomainMapper() *domainExchanger.Health {
+	return &domainExchanger.Health{
+		ExchangerID:         h.ExchangerID,
+		State:               domainExchanger.CircuitState(h.State),
+		Successes:           h.Successes,
+		Failures:            h.Failures,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		LastError:           h.LastError,
+		LastErrorAt:         h.LastErrorAt,
+		LastSuccessAt:       h.LastSuccessAt,
+		OpenedAt:            h.OpenedAt,
+		AutoDisabled:        h.AutoDisabled,
+	}
+}
+
+func fromDomainHealthMapper(h *domainExchanger.Health) *Health {
+	return &Health{
+		ExchangerID:         h.ExchangerID,
+		State:               string(h.State),
+		Successes:           h.Successes,
+		Failures:            h.Failures,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		LastError:           h.LastError,
+		LastErrorAt:         h.LastErrorAt,
+		LastSuccessAt:       h.LastSuccessAt,
+		OpenedAt:            h.OpenedAt,
+		AutoDisabled:        h.AutoDisabled,
+	}
+}
+
+func (c *StatusChange) toDomainMapper() *domainExchanger.StatusChange {
+	return &domainExchanger.StatusChange{
+		ID:          c.ID,
+		ExchangerID: c.ExchangerID,
+		IsActive:    c.IsActive,
+		Actor:       c.Actor,
+		Reason:      c.Reason,
+		CreatedAt:   c.CreatedAt,
+	}
+}
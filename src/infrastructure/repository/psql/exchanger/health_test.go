@@ -0,0 +1,93 @@
+package exchanger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_GetHealth(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+	since := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "exchanger_health" WHERE exchanger_id = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exchanger_id"}))
+	mock.ExpectQuery(`SELECT count\(\*\) AS samples, percentile_cont\(0.5\) .* FROM "exchanger_fetches" WHERE exchanger_id = \$1 AND fetched_at >= \$2`).
+		WithArgs(7, since).
+		WillReturnRows(sqlmock.NewRows([]string{"samples", "p50", "p95", "p99"}).AddRow(3, 120.0, 480.5, 496.1))
+	mock.ExpectQuery(`SELECT \* FROM "exchanger_status_changes" WHERE exchanger_id = \$1 ORDER BY created_at desc, id desc LIMIT \$2`).
+		WithArgs(7, statusChangeLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exchanger_id", "is_active", "actor", "reason"}).
+			AddRow(2, 7, false, domainExchanger.StatusActorCircuitBreaker, "3 consecutive failures"))
+
+	health, err := repo.GetHealth(7, since)
+	require.NoError(t, err)
+	assert.Equal(t, domainExchanger.CircuitClosed, health.State)
+	assert.Equal(t, 3, health.Samples)
+	assert.Equal(t, 120*time.Millisecond, health.LatencyP50)
+	assert.Equal(t, 480500*time.Microsecond, health.LatencyP95)
+	require.Len(t, health.StatusChanges, 1)
+	assert.False(t, health.StatusChanges[0].IsActive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RecordFetch(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+	at := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "exchanger_fetches"`).
+		WithArgs(7, false, int64(250), "timeout", at).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`INSERT INTO "exchanger_health" .* ON CONFLICT \("exchanger_id"\) DO UPDATE SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "exchanger_fetches" WHERE exchanger_id = \$1 AND fetched_at < \$2`).
+		WithArgs(7, at.Add(-fetchRetention)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.RecordFetch(
+		domainExchanger.Fetch{ExchangerID: 7, Latency: 250 * time.Millisecond, Error: "timeout", At: at},
+		domainExchanger.Health{ExchangerID: 7, State: domainExchanger.CircuitOpen, Failures: 3, ConsecutiveFailures: 3},
+	)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_SetActive(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "exchangers" SET "is_active"=\$1,"updated_at"=\$2 WHERE "id" = \$3`).
+		WithArgs(true, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "exchanger_status_changes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "exchanger_health" SET .*"auto_disabled"=\$1.*"state"=.* WHERE exchanger_id = `).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SetActive(7, true, domainExchanger.StatusChange{Actor: domainExchanger.StatusActorManual, Reason: "updated through the API"})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "exchangers"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = repo.SetActive(8, false, domainExchanger.StatusChange{Actor: domainExchanger.StatusActorCircuitBreaker})
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package exchanger
+
+import (
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
+	"go.uber.org/zap"
+)
+
+type Usage struct {
+	ExchangerID int        `gorm:"primaryKey;autoIncrement:false"`
+	Period      string     `gorm:"primaryKey;size:7"`
+	Calls       int        `gorm:"column:calls;not null;default:0"`
+	LastCallAt  *time.Time `gorm:"column:last_call_at"`
+}
+
+func (Usage) TableName() string {
+	return "exchanger_usage"
+}
+
+// reserveCallQuery counts a call in its period unless that would exceed the quota (0 means
+// unlimited) or the last call, whatever its period, is more recent than the minimum interval.
+// Both checks and the count happen in one statement, so instances refreshing at the same time
+// cannot go over the quota together.
+const reserveCallQuery = `INSERT INTO exchanger_usage (exchanger_id, period, calls, last_call_at)
+SELECT @id, @period, 1, @at
+WHERE NOT EXISTS (SELECT 1 FROM exchanger_usage WHERE exchanger_id = @id AND last_call_at > @notBefore)
+ON CONFLICT (exchanger_id, period) DO UPDATE SET calls = exchanger_usage.calls + 1, last_call_at = EXCLUDED.last_call_at
+WHERE @quota = 0 OR exchanger_usage.calls < @quota
+RETURNING exchanger_id, period, calls, last_call_at`
+
+// ReserveCall counts a call to an exchanger made at at. It returns nil when the exchanger has used
+// up its monthly quota or was called less than its minimum interval ago.
+func (r *Repository) ReserveCall(exchanger domainExchanger.Exchanger, at time.Time) (*domainExchanger.Usage, error) {
+	var rows []Usage
+	err := r.DB.Raw(reserveCallQuery, map[string]interface{}{
+		"id":        exchanger.ID,
+		"period":    domainExchanger.UsagePeriod(at),
+		"at":        at,
+		"notBefore": at.Add(-exchanger.MinInterval),
+		"quota":     exchanger.MonthlyQuota,
+	}).Scan(&rows).Error
+	if err != nil {
+		r.Logger.Error("Error reserving exchanger call", zap.Error(err), zap.Int("id", exchanger.ID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0].toDomainMapper(), nil
+}
+
+// GetUsage returns the latest limit periods an exchanger was called in, newest first
+func (r *Repository) GetUsage(exchangerID int, limit int) (*[]domainExchanger.Usage, error) {
+	var rows []Usage
+	if err := r.DB.Where("exchanger_id = ?", exchangerID).Order("period desc").Limit(limit).Find(&rows).Error; err != nil {
+		r.Logger.Error("Error getting exchanger usage", zap.Error(err), zap.Int("id", exchangerID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	usage := make([]domainExchanger.Usage, len(rows))
+	for i, row := range rows {
+		usage[i] = *row.toDomainMapper()
+	}
+	return &usage, nil
+}
+
+func (u *Usage) toDomainMapper() *domainExchanger.Usage {
+	return &domainExchanger.Usage{
+		ExchangerID: u.ExchangerID,
+		Period:      u.Period,
+		Calls:       u.Calls,
+		LastCallAt:  u.LastCallAt,
+	}
+}
@@ -0,0 +1,50 @@
+package exchanger
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ReserveCall(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+	at := time.Date(2026, 4, 30, 23, 59, 0, 0, time.UTC)
+	exchanger := domainExchanger.Exchanger{ID: 7, MonthlyQuota: 1000, MinInterval: time.Minute}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO exchanger_usage (exchanger_id, period, calls, last_call_at)`)).
+		WithArgs(7, "2026-04", at, 7, at.Add(-time.Minute), 1000, 1000).
+		WillReturnRows(sqlmock.NewRows([]string{"exchanger_id", "period", "calls", "last_call_at"}).AddRow(7, "2026-04", 12, at))
+	usage, err := repo.ReserveCall(exchanger, at)
+	require.NoError(t, err)
+	require.NotNil(t, usage)
+	assert.Equal(t, 12, usage.Calls)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO exchanger_usage`)).
+		WillReturnRows(sqlmock.NewRows([]string{"exchanger_id", "period", "calls", "last_call_at"}))
+	usage, err = repo.ReserveCall(exchanger, at)
+	require.NoError(t, err)
+	assert.Nil(t, usage, "a refused call returns no usage")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetUsage(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewExchangerRepository(db, setupLogger(t))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "exchanger_usage" WHERE exchanger_id = $1 ORDER BY period desc LIMIT $2`)).
+		WithArgs(7, 12).
+		WillReturnRows(sqlmock.NewRows([]string{"exchanger_id", "period", "calls"}).AddRow(7, "2026-04", 12).AddRow(7, "2026-03", 980))
+	usage, err := repo.GetUsage(7, 12)
+	require.NoError(t, err)
+	assert.Len(t, *usage, 2)
+	assert.Equal(t, "2026-03", (*usage)[1].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package loginattempt
+
+import (
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultListLimit = 100
+
+type LoginAttempt struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    *int      `gorm:"column:user_id;index"`
+	Email     string    `gorm:"column:email;index"`
+	IP        string    `gorm:"column:ip;index"`
+	Success   bool      `gorm:"column:success"`
+	Reason    string    `gorm:"column:reason"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili;index"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// LoginAttemptRepositoryInterface defines the interface for login attempt repository operations
+type LoginAttemptRepositoryInterface interface {
+	Create(attempt *domainLoginAttempt.LoginAttempt) (*domainLoginAttempt.LoginAttempt, error)
+	GetFailureStatsByIP(ip string, since time.Time) (*domainLoginAttempt.FailureStats, error)
+	List(filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error)
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewLoginAttemptRepository(db *gorm.DB, loggerInstance *logger.Logger) LoginAttemptRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) Create(attempt *domainLoginAttempt.LoginAttempt) (*domainLoginAttempt.LoginAttempt, error) {
+	attemptRepository := fromDomainMapper(attempt)
+	if err := r.DB.Create(attemptRepository).Error; err != nil {
+		r.Logger.Error("Error recording login attempt", zap.Error(err), zap.String("email", attempt.Email), zap.String("ip", attempt.IP))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return attemptRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetFailureStatsByIP(ip string, since time.Time) (*domainLoginAttempt.FailureStats, error) {
+	var result struct {
+		Count         int64
+		LastFailureAt *time.Time
+	}
+	err := r.DB.Model(&LoginAttempt{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last_failure_at").
+		Where("ip = ? AND reason = ? AND created_at >= ?", ip, string(domainLoginAttempt.ReasonInvalidCredentials), since).
+		Scan(&result).Error
+	if err != nil {
+		r.Logger.Error("Error getting login failure stats by IP", zap.Error(err), zap.String("ip", ip))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return &domainLoginAttempt.FailureStats{
+		Count:         result.Count,
+		LastFailureAt: result.LastFailureAt,
+	}, nil
+}
+
+func (r *Repository) List(filter domainLoginAttempt.Filter) (*[]domainLoginAttempt.LoginAttempt, error) {
+	query := r.DB.Model(&LoginAttempt{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	limit := filter.Limit
+	if limit < 1 || limit > defaultListLimit {
+		limit = defaultListLimit
+	}
+
+	var attempts []LoginAttempt
+	if err := query.Order("created_at desc").Limit(limit).Find(&attempts).Error; err != nil {
+		r.Logger.Error("Error listing login attempts", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved login attempts", zap.Int("count", len(attempts)))
+	return arrayToDomainMapper(&attempts), nil
+}
+
+// Mappers
+func (a *LoginAttempt) toDomainMapper() *domainLoginAttempt.LoginAttempt {
+	return &domainLoginAttempt.LoginAttempt{
+		ID:        a.ID,
+		UserID:    a.UserID,
+		Email:     a.Email,
+		IP:        a.IP,
+		Success:   a.Success,
+		Reason:    domainLoginAttempt.Reason(a.Reason),
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+func fromDomainMapper(a *domainLoginAttempt.LoginAttempt) *LoginAttempt {
+	return &LoginAttempt{
+		ID:        a.ID,
+		UserID:    a.UserID,
+		Email:     a.Email,
+		IP:        a.IP,
+		Success:   a.Success,
+		Reason:    string(a.Reason),
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+func arrayToDomainMapper(attempts *[]LoginAttempt) *[]domainLoginAttempt.LoginAttempt {
+	attemptsDomain := make([]domainLoginAttempt.LoginAttempt, len(*attempts))
+	for i, attempt := range *attempts {
+		attemptsDomain[i] = *attempt.toDomainMapper()
+	}
+	return &attemptsDomain
+}
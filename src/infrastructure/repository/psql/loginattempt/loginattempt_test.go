@@ -0,0 +1,107 @@
+package loginattempt
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "login_attempts", LoginAttempt{}.TableName())
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewLoginAttemptRepository(db, setupLogger(t))
+	userID := 10
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "login_attempts"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	attempt, err := repo.Create(&domainLoginAttempt.LoginAttempt{
+		UserID: &userID,
+		Email:  "test@example.com",
+		IP:     "10.0.0.1",
+		Reason: domainLoginAttempt.ReasonInvalidCredentials,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.ID)
+	assert.Equal(t, domainLoginAttempt.ReasonInvalidCredentials, attempt.Reason)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "login_attempts"`)).WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+	_, err = repo.Create(&domainLoginAttempt.LoginAttempt{Email: "test@example.com"})
+	assert.Error(t, err)
+}
+
+func TestRepository_GetFailureStatsByIP(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewLoginAttemptRepository(db, setupLogger(t))
+	since := time.Now().Add(-15 * time.Minute)
+	lastFailure := time.Now().Add(-time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) AS count, MAX(created_at) AS last_failure_at FROM "login_attempts" WHERE ip = $1 AND reason = $2 AND created_at >= $3`)).
+		WithArgs("10.0.0.1", "invalid_credentials", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "last_failure_at"}).AddRow(4, lastFailure))
+	stats, err := repo.GetFailureStatsByIP("10.0.0.1", since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Count)
+	require.NotNil(t, stats.LastFailureAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*)`)).WillReturnError(errors.New("db error"))
+	_, err = repo.GetFailureStatsByIP("10.0.0.1", since)
+	assert.Error(t, err)
+}
+
+func TestRepository_List(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewLoginAttemptRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "email", "ip", "success", "reason", "created_at"}).
+		AddRow(2, 10, "test@example.com", "10.0.0.1", true, "success", time.Now()).
+		AddRow(1, 10, "test@example.com", "10.0.0.1", false, "invalid_credentials", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_attempts" WHERE user_id = $1 AND ip = $2 ORDER BY created_at desc LIMIT $3`)).
+		WithArgs(10, "10.0.0.1", defaultListLimit).
+		WillReturnRows(rows)
+	attempts, err := repo.List(domainLoginAttempt.Filter{UserID: 10, IP: "10.0.0.1", Limit: 500})
+	require.NoError(t, err)
+	assert.Len(t, *attempts, 2)
+	assert.True(t, (*attempts)[0].Success)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_attempts"`)).WillReturnError(errors.New("db error"))
+	_, err = repo.List(domainLoginAttempt.Filter{})
+	assert.Error(t, err)
+}
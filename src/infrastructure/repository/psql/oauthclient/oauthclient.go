@@ -0,0 +1,136 @@
+package oauthclient
+
+import (
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Client struct {
+	ID         int       `gorm:"primaryKey"`
+	ClientID   string    `gorm:"column:client_id;unique"`
+	Name       string    `gorm:"column:name"`
+	SecretHash string    `gorm:"column:secret_hash"`
+	Scopes     string    `gorm:"column:scopes"`
+	Audiences  string    `gorm:"column:audiences"`
+	CreatedBy  int       `gorm:"column:created_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+// OAuthClientRepositoryInterface defines the interface for OAuth2 client repository operations
+type OAuthClientRepositoryInterface interface {
+	Create(client *domainOAuthClient.Client) (*domainOAuthClient.Client, error)
+	GetByClientID(clientID string) (*domainOAuthClient.Client, error)
+	GetAll() (*[]domainOAuthClient.Client, error)
+	Delete(id int) error
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewOAuthClientRepository(db *gorm.DB, loggerInstance *logger.Logger) OAuthClientRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) Create(client *domainOAuthClient.Client) (*domainOAuthClient.Client, error) {
+	r.Logger.Info("Creating new OAuth client", zap.String("clientID", client.ClientID), zap.String("name", client.Name))
+	clientRepository := fromDomainMapper(client)
+	if err := r.DB.Create(clientRepository).Error; err != nil {
+		r.Logger.Error("Error creating OAuth client", zap.Error(err), zap.String("clientID", client.ClientID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully created OAuth client", zap.Int("id", clientRepository.ID))
+	return clientRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByClientID(clientID string) (*domainOAuthClient.Client, error) {
+	var client Client
+	err := r.DB.Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("OAuth client not found", zap.String("clientID", clientID))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting OAuth client", zap.Error(err), zap.String("clientID", clientID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return client.toDomainMapper(), nil
+}
+
+func (r *Repository) GetAll() (*[]domainOAuthClient.Client, error) {
+	var clients []Client
+	if err := r.DB.Order("id").Find(&clients).Error; err != nil {
+		r.Logger.Error("Error getting all OAuth clients", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved all OAuth clients", zap.Int("count", len(clients)))
+	return arrayToDomainMapper(&clients), nil
+}
+
+func (r *Repository) Delete(id int) error {
+	tx := r.DB.Delete(&Client{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting OAuth client", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("OAuth client not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted OAuth client", zap.Int("id", id))
+	return nil
+}
+
+// Mappers
+func (c *Client) toDomainMapper() *domainOAuthClient.Client {
+	return &domainOAuthClient.Client{
+		ID:         c.ID,
+		ClientID:   c.ClientID,
+		Name:       c.Name,
+		SecretHash: c.SecretHash,
+		Scopes:     splitList(c.Scopes),
+		Audiences:  splitList(c.Audiences),
+		CreatedBy:  c.CreatedBy,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
+
+func fromDomainMapper(c *domainOAuthClient.Client) *Client {
+	return &Client{
+		ID:         c.ID,
+		ClientID:   c.ClientID,
+		Name:       c.Name,
+		SecretHash: c.SecretHash,
+		Scopes:     strings.Join(c.Scopes, ","),
+		Audiences:  strings.Join(c.Audiences, ","),
+		CreatedBy:  c.CreatedBy,
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func arrayToDomainMapper(clients *[]Client) *[]domainOAuthClient.Client {
+	clientsDomain := make([]domainOAuthClient.Client, len(*clients))
+	for i, client := range *clients {
+		clientsDomain[i] = *client.toDomainMapper()
+	}
+	return &clientsDomain
+}
@@ -0,0 +1,109 @@
+package oauthclient
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "oauth_clients", Client{}.TableName())
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewOAuthClientRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "oauth_clients"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	client, err := repo.Create(&domainOAuthClient.Client{
+		ClientID:   "cli_test",
+		Name:       "billing",
+		SecretHash: "hash",
+		Scopes:     []string{"currency:read"},
+		CreatedBy:  1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.ID)
+	assert.Equal(t, []string{"currency:read"}, client.Scopes)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "oauth_clients"`)).WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+	_, err = repo.Create(&domainOAuthClient.Client{ClientID: "cli_test"})
+	assert.Error(t, err)
+}
+
+func TestRepository_GetByClientID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewOAuthClientRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "client_id", "name", "secret_hash", "scopes", "audiences"}).
+		AddRow(1, "cli_test", "billing", "hash", "currency:read,exchanger:read", "billing-api")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "oauth_clients" WHERE client_id = $1`)).
+		WithArgs("cli_test", 1).WillReturnRows(rows)
+	client, err := repo.GetByClientID("cli_test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"currency:read", "exchanger:read"}, client.Scopes)
+	assert.Equal(t, []string{"billing-api"}, client.Audiences)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "oauth_clients" WHERE client_id = $1`)).
+		WithArgs("cli_missing", 1).WillReturnError(gorm.ErrRecordNotFound)
+	_, err = repo.GetByClientID("cli_missing")
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewOAuthClientRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "oauth_clients"`)).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Delete(1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "oauth_clients"`)).
+		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err := repo.Delete(2)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
@@ -7,10 +7,17 @@ import (
 
 	"github.com/joho/godotenv"
 
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/alert"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/apitoken"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/exchanger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/loginattempt"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/oauthclient"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/watchlist"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/webhook"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
@@ -153,10 +160,23 @@ func (r *PSQLRepository) MigrateEntitiesGORM() error {
 	// Import the models to register them with GORM
 	userModel := &user.User{}
 	currencyModel := &currency.Currency{}
+	currencyRateModel := &currency.CurrencyRate{}
 	exchangerModel := &exchanger.Exchanger{}
+	exchangerHealthModel := &exchanger.Health{}
+	exchangerFetchModel := &exchanger.Fetch{}
+	exchangerStatusChangeModel := &exchanger.StatusChange{}
+	exchangerUsageModel := &exchanger.Usage{}
+	loginAttemptModel := &loginattempt.LoginAttempt{}
+	apiTokenModel := &apitoken.APIToken{}
+	oauthClientModel := &oauthclient.Client{}
+	watchlistModel := &watchlist.Watchlist{}
+	alertRuleModel := &alert.Rule{}
+	alertEventModel := &alert.Event{}
+	webhookSubscriptionModel := &webhook.Subscription{}
+	webhookDeliveryModel := &webhook.Delivery{}
 
 	// Auto migrate the models to create/update tables
-	err := r.DB.AutoMigrate(userModel, currencyModel, exchangerModel)
+	err := r.DB.AutoMigrate(userModel, currencyModel, currencyRateModel, exchangerModel, exchangerHealthModel, exchangerFetchModel, exchangerStatusChangeModel, exchangerUsageModel, loginAttemptModel, apiTokenModel, oauthClientModel, watchlistModel, alertRuleModel, alertEventModel, webhookSubscriptionModel, webhookDeliveryModel)
 	if err != nil {
 		r.Logger.Error("Error migrating database entities", zap.Error(err))
 		return err
@@ -189,9 +209,12 @@ func (r *PSQLRepository) SeedInitialUser() error {
 		return err
 	}
 
+	// the seeded user bootstraps administration, so it is the first admin
 	newUser := user.User{
 		Email:        email,
 		HashPassword: string(hashedPassword),
+		Role:         domainUser.RoleAdmin,
+		Status:       true,
 	}
 
 	err = r.DB.Create(&newUser).Error
@@ -0,0 +1,219 @@
+package search
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned for a cursor that was tampered with, is malformed or was issued for
+// a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position a cursor page starts from: the sort key of the last row seen, or of the
+// first one when paging backwards
+type cursor struct {
+	Order    string            `json:"o"`
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+// keyset is the order of a cursor search: the requested sort columns followed by the primary key,
+// so that every row has a distinct position
+type keyset struct {
+	fields    []*schema.Field
+	direction domain.SortDirection
+}
+
+func newKeyset(s *schema.Schema, columns ColumnMapping, filters domain.DataFilters) (*keyset, error) {
+	primaryKey := s.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return nil, errors.New("cursor pagination requires a primary key")
+	}
+
+	k := &keyset{direction: filters.SortDirection}
+	if !k.direction.IsValid() {
+		k.direction = domain.SortAsc
+	}
+	seen := make(map[string]bool)
+	for _, sortField := range filters.SortBy {
+		field := s.LookUpField(columns[sortField])
+		if field == nil || seen[field.DBName] || field == primaryKey {
+			continue
+		}
+		seen[field.DBName] = true
+		k.fields = append(k.fields, field)
+	}
+	k.fields = append(k.fields, primaryKey)
+	return k, nil
+}
+
+// signature identifies the order a cursor was issued for
+func (k *keyset) signature() string {
+	names := make([]string, len(k.fields))
+	for i, field := range k.fields {
+		names[i] = field.DBName
+	}
+	return strings.Join(names, ",") + ":" + string(k.direction)
+}
+
+// condition selects the rows after the cursor, or before it when paging backwards
+func (k *keyset) condition(backward bool) string {
+	operator := ">"
+	if (k.direction == domain.SortDesc) != backward {
+		operator = "<"
+	}
+	names := make([]string, len(k.fields))
+	placeholders := make([]string, len(k.fields))
+	for i, field := range k.fields {
+		names[i] = field.DBName
+		placeholders[i] = "?"
+	}
+	return "(" + strings.Join(names, ", ") + ") " + operator + " (" + strings.Join(placeholders, ", ") + ")"
+}
+
+func (k *keyset) order(backward bool) string {
+	direction := k.direction
+	if backward {
+		direction = domain.SortAsc
+		if k.direction == domain.SortAsc {
+			direction = domain.SortDesc
+		}
+	}
+	clauses := make([]string, len(k.fields))
+	for i, field := range k.fields {
+		clauses[i] = field.DBName + " " + string(direction)
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// encode returns a signed cursor positioned on row
+func (k *keyset) encode(row reflect.Value, backward bool) (string, error) {
+	c := cursor{Order: k.signature(), Backward: backward}
+	for _, field := range k.fields {
+		value, _ := field.ValueOf(context.Background(), row)
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, raw)
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded), nil
+}
+
+// decode checks the signature and order of a cursor and returns its sort key values, typed like
+// the columns they are compared with
+func (k *keyset) decode(token string) ([]any, bool, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, false, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Order != k.signature() || len(c.Values) != len(k.fields) {
+		return nil, false, ErrInvalidCursor
+	}
+
+	values := make([]any, len(k.fields))
+	for i, field := range k.fields {
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, c.Backward, nil
+}
+
+// paginateCursor loads the page after filters.Cursor, or the first page when there is none.
+// It reads one row past the page to tell whether another page follows and never counts the
+// matching rows.
+func paginateCursor[T any](query *gorm.DB, columns ColumnMapping, filters domain.DataFilters, pageSize int) (*Result[T], error) {
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	k, err := newKeyset(stmt.Schema, columns, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	query = applyFilters(query, columns, filters)
+	backward := false
+	if filters.Cursor != "" {
+		var values []any
+		values, backward, err = k.decode(filters.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(k.condition(backward), values...)
+	}
+
+	var rows []T
+	if err := query.Order(k.order(backward)).Limit(pageSize + 1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	hasMore := len(rows) > pageSize
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	result := &Result[T]{Rows: rows, PageSize: pageSize}
+	if len(rows) == 0 {
+		return result, nil
+	}
+	first, last := reflect.ValueOf(&rows[0]).Elem(), reflect.ValueOf(&rows[len(rows)-1]).Elem()
+	// A forward page follows the page its cursor came from; a backward page precedes one
+	hasNext, hasPrev := hasMore, filters.Cursor != ""
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		if result.NextCursor, err = k.encode(last, false); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if result.PrevCursor, err = k.encode(first, true); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, cursorSecret())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cursorSecret is the key cursors are signed with. Every instance behind the same API must share it.
+func cursorSecret() []byte {
+	if secret := os.Getenv("SEARCH_CURSOR_SECRET_KEY"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte("default_cursor_secret")
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cursorFilters(cursor string) domain.DataFilters {
+	return domain.DataFilters{
+		Matches:       map[string][]string{"code": {"EUR"}},
+		SortBy:        []string{"name"},
+		SortDirection: domain.SortAsc,
+		PageSize:      2,
+		Pagination:    domain.PaginationCursor,
+		Cursor:        cursor,
+	}
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	rows := []string{"id", "name", "code"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE code IN ($1) ORDER BY name asc, id asc LIMIT $2`)).
+		WithArgs("EUR", 3).
+		WillReturnRows(sqlmock.NewRows(rows).AddRow(1, "a", "EUR").AddRow(2, "b", "EUR").AddRow(3, "c", "EUR"))
+
+	first, err := Paginate[item](db.Model(&item{}), columns, cursorFilters(""))
+	require.NoError(t, err)
+	assert.Len(t, first.Rows, 2)
+	assert.Empty(t, first.PrevCursor)
+	require.NotEmpty(t, first.NextCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE code IN ($1) AND (name, id) > ($2, $3) ORDER BY name asc, id asc LIMIT $4`)).
+		WithArgs("EUR", "b", 2, 3).
+		WillReturnRows(sqlmock.NewRows(rows).AddRow(3, "c", "EUR"))
+
+	second, err := Paginate[item](db.Model(&item{}), columns, cursorFilters(first.NextCursor))
+	require.NoError(t, err)
+	assert.Equal(t, 3, second.Rows[0].ID)
+	assert.Empty(t, second.NextCursor)
+	require.NotEmpty(t, second.PrevCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE code IN ($1) AND (name, id) < ($2, $3) ORDER BY name desc, id desc LIMIT $4`)).
+		WithArgs("EUR", "c", 3, 3).
+		WillReturnRows(sqlmock.NewRows(rows).AddRow(2, "b", "EUR").AddRow(1, "a", "EUR"))
+
+	back, err := Paginate[item](db.Model(&item{}), columns, cursorFilters(second.PrevCursor))
+	require.NoError(t, err)
+	assert.Equal(t, []item{{ID: 1, Name: "a", Code: "EUR"}, {ID: 2, Name: "b", Code: "EUR"}}, back.Rows)
+	assert.Empty(t, back.PrevCursor)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaginate_CursorDescending(t *testing.T) {
+	db, mock := setupMockDB(t)
+	filters := domain.DataFilters{SortDirection: domain.SortDesc, Pagination: domain.PaginationCursor, PageSize: 1}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" ORDER BY id desc LIMIT $1`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(8))
+	page, err := Paginate[item](db.Model(&item{}), columns, filters)
+	require.NoError(t, err)
+
+	filters.Cursor = page.NextCursor
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE (id) < ($1) ORDER BY id desc LIMIT $2`)).
+		WithArgs(9, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = Paginate[item](db.Model(&item{}), columns, filters)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaginate_RejectsInvalidCursors(t *testing.T) {
+	db, mock := setupMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code"}).AddRow(1, "a", "EUR").AddRow(2, "b", "EUR").AddRow(3, "c", "EUR"))
+	page, err := Paginate[item](db.Model(&item{}), columns, cursorFilters(""))
+	require.NoError(t, err)
+
+	encoded, signature, _ := strings.Cut(page.NextCursor, ".")
+	otherOrder := cursorFilters(page.NextCursor)
+	otherOrder.SortBy = []string{"code"}
+
+	for name, filters := range map[string]domain.DataFilters{
+		"malformed":   cursorFilters("garbage"),
+		"tampered":    cursorFilters(encoded + "x." + signature),
+		"other order": otherOrder,
+	} {
+		_, err := Paginate[item](db.Model(&item{}), columns, filters)
+		assert.ErrorIs(t, err, ErrInvalidCursor, name)
+	}
+
+	t.Setenv("SEARCH_CURSOR_SECRET_KEY", "rotated")
+	_, err = Paginate[item](db.Model(&item{}), columns, cursorFilters(page.NextCursor))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,170 @@
+package search
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 10
+	// propertyLimit bounds the values returned by Distinct
+	propertyLimit = 20
+)
+
+// ErrUnknownProperty is returned by Distinct for a property missing from the column mapping
+var ErrUnknownProperty = errors.New("unknown search property")
+
+// ColumnMapping maps the field names clients use to database columns. Fields missing from the
+// mapping are ignored, so clients can only filter and sort on what a repository exposes.
+type ColumnMapping map[string]string
+
+// Result is one page of the rows matching a search. Offset pages fill Total, Page and TotalPages;
+// cursor pages fill NextCursor and PrevCursor, which are empty at either end of the results.
+type Result[T any] struct {
+	Rows       []T
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+	NextCursor string
+	PrevCursor string
+}
+
+// Apply adds the like, match, date range and sort filters to query
+func Apply(query *gorm.DB, columns ColumnMapping, filters domain.DataFilters) *gorm.DB {
+	query = applyFilters(query, columns, filters)
+	if filters.SortDirection.IsValid() {
+		for _, sortField := range filters.SortBy {
+			if column := columns[sortField]; column != "" {
+				query = query.Order(column + " " + string(filters.SortDirection))
+			}
+		}
+	}
+	return query
+}
+
+func applyFilters(query *gorm.DB, columns ColumnMapping, filters domain.DataFilters) *gorm.DB {
+	for _, field := range sortedKeys(filters.LikeFilters) {
+		column := columns[field]
+		if column == "" {
+			continue
+		}
+		for _, value := range filters.LikeFilters[field] {
+			if value != "" {
+				query = query.Where(column+" ILIKE ?", "%"+value+"%")
+			}
+		}
+	}
+
+	for _, field := range sortedKeys(filters.Matches) {
+		column := columns[field]
+		if column != "" && len(filters.Matches[field]) > 0 {
+			query = query.Where(column+" IN ?", filters.Matches[field])
+		}
+	}
+
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := columns[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		if dateFilter.Start != nil {
+			query = query.Where(column+" >= ?", dateFilter.Start)
+		}
+		if dateFilter.End != nil {
+			query = query.Where(column+" <= ?", dateFilter.End)
+		}
+	}
+	return query
+}
+
+// Paginate applies the filters to query and loads the requested page. Pages default to 10 rows.
+// In offset mode pages start at 1 and the matching rows are counted. In cursor mode the page
+// starts after filters.Cursor and the rows are ordered by the sort fields and then the primary
+// key; ErrInvalidCursor is returned for a cursor this package did not sign for that order.
+func Paginate[T any](query *gorm.DB, columns ColumnMapping, filters domain.DataFilters) (*Result[T], error) {
+	pageSize := filters.PageSize
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if filters.Pagination == domain.PaginationCursor {
+		return paginateCursor[T](query, columns, filters, pageSize)
+	}
+
+	query = Apply(query, columns, filters)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	page := filters.Page
+	if page < 1 {
+		page = 1
+	}
+
+	var rows []T
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return &Result[T]{
+		Rows:       rows,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Each applies the filters to query and calls fn with every matching row in order. Rows are read
+// from the database one at a time, so the full result is never held in memory; pagination is
+// ignored.
+func Each[T any](query *gorm.DB, columns ColumnMapping, filters domain.DataFilters, fn func(row *T) error) error {
+	rows, err := Apply(query, columns, filters).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row T
+		if err := query.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Distinct returns up to 20 distinct values of the column mapped to property that contain searchText
+func Distinct(query *gorm.DB, columns ColumnMapping, property string, searchText string) ([]string, error) {
+	column := columns[property]
+	if column == "" {
+		return nil, ErrUnknownProperty
+	}
+
+	var values []string
+	if err := query.
+		Distinct(column).
+		Where(column+" ILIKE ?", "%"+searchText+"%").
+		Limit(propertyLimit).
+		Pluck(column, &values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// sortedKeys keeps the generated SQL stable from one request to the next
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gbrayhan/microservices-go/src/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   int
+	Name string
+	Code string
+}
+
+func (item) TableName() string {
+	return "items"
+}
+
+var columns = ColumnMapping{
+	"id":        "id",
+	"name":      "name",
+	"code":      "code",
+	"createdAt": "created_at",
+}
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	return gormDB, mock
+}
+
+func TestPaginate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filters := domain.DataFilters{
+		LikeFilters:      map[string][]string{"name": {"euro", ""}, "secret": {"x"}},
+		Matches:          map[string][]string{"code": {"EUR", "GBP"}},
+		DateRangeFilters: []domain.DateRangeFilter{{Field: "createdAt", Start: &start}},
+		SortBy:           []string{"code", "unknown"},
+		SortDirection:    domain.SortDesc,
+		Page:             2,
+		PageSize:         2,
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "items" WHERE name ILIKE $1 AND code IN ($2,$3) AND created_at >= $4`)+"$").
+		WithArgs("%euro%", "EUR", "GBP", start).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE name ILIKE $1 AND code IN ($2,$3) AND created_at >= $4 ORDER BY code desc LIMIT $5 OFFSET $6`)).
+		WithArgs("%euro%", "EUR", "GBP", start, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code"}).AddRow(3, "Euro", "EUR").AddRow(4, "Euro bis", "EUR"))
+
+	result, err := Paginate[item](db.Model(&item{}), columns, filters)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Len(t, result.Rows, 2)
+	assert.Equal(t, int64(5), result.Total)
+	assert.Equal(t, 2, result.Page)
+	assert.Equal(t, 3, result.TotalPages)
+}
+
+func TestPaginate_Defaults(t *testing.T) {
+	db, mock := setupMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "items"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" LIMIT $1`)).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code"}))
+
+	result, err := Paginate[item](db.Model(&item{}), columns, domain.DataFilters{SortBy: []string{"code"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Page)
+	assert.Equal(t, 10, result.PageSize)
+	assert.Equal(t, 0, result.TotalPages)
+}
+
+func TestDistinct(t *testing.T) {
+	db, mock := setupMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "code" FROM "items" WHERE code ILIKE $1 LIMIT $2`)).
+		WithArgs("%eu%", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("EUR"))
+
+	values, err := Distinct(db.Model(&item{}), columns, "code", "eu")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EUR"}, values)
+
+	_, err = Distinct(db.Model(&item{}), columns, "secret", "x")
+	assert.ErrorIs(t, err, ErrUnknownProperty)
+}
+
+func TestEach(t *testing.T) {
+	db, mock := setupMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "items" WHERE code IN ($1) ORDER BY name asc`)).
+		WithArgs("EUR").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code"}).AddRow(1, "Euro", "EUR").AddRow(2, "Euro bis", "EUR"))
+
+	var names []string
+	filters := domain.DataFilters{Matches: map[string][]string{"code": {"EUR"}}, SortBy: []string{"name"}, SortDirection: domain.SortAsc, Page: 3, PageSize: 1}
+	err := Each[item](db.Model(&item{}), columns, filters, func(row *item) error {
+		names = append(names, row.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Euro", "Euro bis"}, names)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
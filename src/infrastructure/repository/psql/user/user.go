@@ -2,27 +2,37 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/search"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID           int             `gorm:"primaryKey"`
-	UserName     string          `gorm:"column:user_name;unique"`
-	Email        string          `gorm:"unique"`
-	FirstName    string          `gorm:"column:first_name"`
-	LastName     string          `gorm:"column:last_name"`
-	Status       bool            `gorm:"column:status"`
-	Role         domainUser.Role `gorm:"column:role"`
-	HashPassword string          `gorm:"column:hash_password"`
-	CreatedAt    time.Time       `gorm:"autoCreateTime:mili"`
-	UpdatedAt    time.Time       `gorm:"autoUpdateTime:mili"`
+	ID                  int             `gorm:"primaryKey"`
+	UserName            string          `gorm:"column:user_name;unique"`
+	Email               string          `gorm:"unique"`
+	FirstName           string          `gorm:"column:first_name"`
+	LastName            string          `gorm:"column:last_name"`
+	Status              bool            `gorm:"column:status"`
+	Role                domainUser.Role `gorm:"column:role"`
+	HashPassword        string          `gorm:"column:hash_password"`
+	FailedLoginAttempts int             `gorm:"column:failed_login_attempts;default:0"`
+	LastFailedLoginAt   *time.Time      `gorm:"column:last_failed_login_at"`
+	LockedUntil         *time.Time      `gorm:"column:locked_until"`
+	TwoFactorEnabled    bool            `gorm:"column:two_factor_enabled;default:false"`
+	TwoFactorSecret     string          `gorm:"column:two_factor_secret"`
+	RecoveryCodes       string          `gorm:"column:two_factor_recovery_codes"`
+	TwoFactorLastStep   int64           `gorm:"column:two_factor_last_step;not null;default:0"`
+	CreatedAt           time.Time       `gorm:"autoCreateTime:mili"`
+	UpdatedAt           time.Time       `gorm:"autoUpdateTime:mili"`
 }
 
 func (User) TableName() string {
@@ -38,6 +48,8 @@ var ColumnsUserMapping = map[string]string{
 	"lastName":     "last_name",
 	"status":       "status",
 	"hashPassword": "hash_password",
+	"lockedUntil":  "locked_until",
+	"twoFactor":    "two_factor_enabled",
 	"createdAt":    "created_at",
 	"updatedAt":    "updated_at",
 }
@@ -50,6 +62,13 @@ type UserRepositoryInterface interface {
 	GetByEmail(email string) (*domainUser.User, error)
 	GetByUserName(userName string) (*domainUser.User, error)
 	Update(id int, userMap map[string]interface{}) (*domainUser.User, error)
+	UpdateLoginState(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error
+	IncrementFailedLogins(id int, failedAt time.Time) (int, error)
+	LockAccount(id int, lockedUntil time.Time) error
+	UpdateTwoFactor(id int, enabled bool, secret string, recoveryCodes []string) error
+	ClaimTwoFactorStep(id int, step int64) (bool, error)
+	ConsumeRecoveryCode(id int, hash string) (bool, error)
+	UpdatePassword(id int, hashPassword string) error
 	Delete(id int) error
 	SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error)
 	SearchByProperty(property string, searchText string) (*[]string, error)
@@ -186,121 +205,162 @@ func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainUser
 	return userObj.toDomainMapper(), nil
 }
 
-func (r *Repository) Delete(id int) error {
-	tx := r.DB.Delete(&User{}, id)
-	if tx.Error != nil {
-		r.Logger.Error("Error deleting user", zap.Error(tx.Error), zap.Int("id", id))
-		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
-	}
-	if tx.RowsAffected == 0 {
-		r.Logger.Warn("User not found for deletion", zap.Int("id", id))
-		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+func (r *Repository) UpdateLoginState(id int, failedAttempts int, lastFailedLoginAt *time.Time, lockedUntil *time.Time) error {
+	err := r.DB.Model(&User{ID: id}).
+		Select("failed_login_attempts", "last_failed_login_at", "locked_until").
+		Updates(map[string]interface{}{
+			"failed_login_attempts": failedAttempts,
+			"last_failed_login_at":  lastFailedLoginAt,
+			"locked_until":          lockedUntil,
+		}).Error
+	if err != nil {
+		r.Logger.Error("Error updating user login state", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
 	}
-	r.Logger.Info("Successfully deleted user", zap.Int("id", id))
+	r.Logger.Info("Successfully updated user login state", zap.Int("id", id), zap.Int("failedAttempts", failedAttempts))
 	return nil
 }
 
-func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
-	query := r.DB.Model(&User{})
-
-	// Apply like filters
-	for field, values := range filters.LikeFilters {
-		if len(values) > 0 {
-			for _, value := range values {
-				if value != "" {
-					column := ColumnsUserMapping[field]
-					if column != "" {
-						query = query.Where(column+" ILIKE ?", "%"+value+"%")
-					}
-				}
-			}
-		}
+// incrementFailedLoginsQuery counts one more failure in the database itself, so concurrent failures
+// cannot overwrite each other. A lock that has elapsed is cleared and the count starts again from 1.
+const incrementFailedLoginsQuery = `UPDATE users SET
+	failed_login_attempts = CASE WHEN locked_until <= ? THEN 1 ELSE failed_login_attempts + 1 END,
+	locked_until = CASE WHEN locked_until <= ? THEN NULL ELSE locked_until END,
+	last_failed_login_at = ?
+WHERE id = ?
+RETURNING failed_login_attempts`
+
+// IncrementFailedLogins atomically adds one consecutive failure and returns the new count
+func (r *Repository) IncrementFailedLogins(id int, failedAt time.Time) (int, error) {
+	var failedAttempts []int
+	if err := r.DB.Raw(incrementFailedLoginsQuery, failedAt, failedAt, failedAt, id).Scan(&failedAttempts).Error; err != nil {
+		r.Logger.Error("Error incrementing failed logins", zap.Error(err), zap.Int("id", id))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	if len(failedAttempts) == 0 {
+		r.Logger.Warn("User not found for failed login", zap.Int("id", id))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
 	}
+	r.Logger.Info("Successfully incremented failed logins", zap.Int("id", id), zap.Int("failedAttempts", failedAttempts[0]))
+	return failedAttempts[0], nil
+}
 
-	// Apply exact matches
-	for field, values := range filters.Matches {
-		if len(values) > 0 {
-			column := ColumnsUserMapping[field]
-			if column != "" {
-				query = query.Where(column+" IN ?", values)
-			}
-		}
+func (r *Repository) LockAccount(id int, lockedUntil time.Time) error {
+	err := r.DB.Model(&User{ID: id}).
+		Select("locked_until").
+		Updates(map[string]interface{}{"locked_until": lockedUntil}).Error
+	if err != nil {
+		r.Logger.Error("Error locking user account", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
 	}
+	r.Logger.Info("Successfully locked user account", zap.Int("id", id), zap.Time("lockedUntil", lockedUntil))
+	return nil
+}
 
-	// Apply date range filters
-	for _, dateFilter := range filters.DateRangeFilters {
-		column := ColumnsUserMapping[dateFilter.Field]
-		if column != "" {
-			if dateFilter.Start != nil {
-				query = query.Where(column+" >= ?", dateFilter.Start)
-			}
-			if dateFilter.End != nil {
-				query = query.Where(column+" <= ?", dateFilter.End)
-			}
-		}
+func (r *Repository) UpdateTwoFactor(id int, enabled bool, secret string, recoveryCodes []string) error {
+	err := r.DB.Model(&User{ID: id}).
+		Select("two_factor_enabled", "two_factor_secret", "two_factor_recovery_codes").
+		Updates(map[string]interface{}{
+			"two_factor_enabled":        enabled,
+			"two_factor_secret":         secret,
+			"two_factor_recovery_codes": strings.Join(recoveryCodes, ","),
+		}).Error
+	if err != nil {
+		r.Logger.Error("Error updating user two-factor settings", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
 	}
+	r.Logger.Info("Successfully updated user two-factor settings", zap.Int("id", id), zap.Bool("enabled", enabled))
+	return nil
+}
 
-	// Apply sorting
-	if len(filters.SortBy) > 0 && filters.SortDirection.IsValid() {
-		for _, sortField := range filters.SortBy {
-			column := ColumnsUserMapping[sortField]
-			if column != "" {
-				query = query.Order(column + " " + string(filters.SortDirection))
-			}
-		}
+// ClaimTwoFactorStep records step as the last accepted TOTP time step. It reports false, leaving
+// the user untouched, when a code of that step or a later one was already accepted.
+func (r *Repository) ClaimTwoFactorStep(id int, step int64) (bool, error) {
+	tx := r.DB.Model(&User{}).
+		Where("id = ? AND two_factor_last_step < ?", id, step).
+		UpdateColumn("two_factor_last_step", step)
+	if tx.Error != nil {
+		r.Logger.Error("Error claiming two-factor step", zap.Error(tx.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
 	}
+	return tx.RowsAffected == 1, nil
+}
 
-	// Count total records
-	var total int64
-	clonedQuery := query
-	clonedQuery.Count(&total)
+// ConsumeRecoveryCode removes the recovery code hash from the user's unused ones. It reports false,
+// leaving the user untouched, when the code is not among them, so a code is only ever accepted once.
+func (r *Repository) ConsumeRecoveryCode(id int, hash string) (bool, error) {
+	tx := r.DB.Model(&User{}).
+		Where("id = ? AND ? = ANY(string_to_array(two_factor_recovery_codes, ','))", id, hash).
+		UpdateColumn("two_factor_recovery_codes", gorm.Expr("array_to_string(array_remove(string_to_array(two_factor_recovery_codes, ','), ?), ',')", hash))
+	if tx.Error != nil {
+		r.Logger.Error("Error consuming recovery code", zap.Error(tx.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return tx.RowsAffected == 1, nil
+}
 
-	// Apply pagination
-	if filters.Page < 1 {
-		filters.Page = 1
+func (r *Repository) UpdatePassword(id int, hashPassword string) error {
+	err := r.DB.Model(&User{ID: id}).
+		Select("hash_password").
+		Updates(map[string]interface{}{"hash_password": hashPassword}).Error
+	if err != nil {
+		r.Logger.Error("Error updating user password", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully updated user password", zap.Int("id", id))
+	return nil
+}
+
+func (r *Repository) Delete(id int) error {
+	tx := r.DB.Delete(&User{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting user", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
-	if filters.PageSize < 1 {
-		filters.PageSize = 10
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("User not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
 	}
-	offset := (filters.Page - 1) * filters.PageSize
+	r.Logger.Info("Successfully deleted user", zap.Int("id", id))
+	return nil
+}
 
-	var users []User
-	if err := query.Offset(offset).Limit(filters.PageSize).Find(&users).Error; err != nil {
+func (r *Repository) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	page, err := search.Paginate[User](r.DB.Model(&User{}), ColumnsUserMapping, filters)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidCursor) {
+			r.Logger.Warn("Invalid search cursor")
+			return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
 		r.Logger.Error("Error searching users", zap.Error(err))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
 
-	totalPages := int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize))
-
 	result := &domainUser.SearchResultUser{
-		Data:       arrayToDomainMapper(&users),
-		Total:      total,
-		Page:       filters.Page,
-		PageSize:   filters.PageSize,
-		TotalPages: totalPages,
+		Data:       arrayToDomainMapper(&page.Rows),
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
 	}
 
 	r.Logger.Info("Successfully searched users",
-		zap.Int64("total", total),
-		zap.Int("page", filters.Page),
-		zap.Int("pageSize", filters.PageSize))
+		zap.Int64("total", page.Total),
+		zap.Int("page", page.Page),
+		zap.Int("pageSize", page.PageSize))
 
 	return result, nil
 }
 
 func (r *Repository) SearchByProperty(property string, searchText string) (*[]string, error) {
-	column := ColumnsUserMapping[property]
-	if column == "" {
-		r.Logger.Warn("Invalid property for search", zap.String("property", property))
-		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
-	}
-
-	var coincidences []string
-	if err := r.DB.Model(&User{}).
-		Distinct(column).
-		Where(column+" ILIKE ?", "%"+searchText+"%").
-		Limit(20).
-		Pluck(column, &coincidences).Error; err != nil {
+	coincidences, err := search.Distinct(r.DB.Model(&User{}), ColumnsUserMapping, property, searchText)
+	if err != nil {
+		if errors.Is(err, search.ErrUnknownProperty) {
+			r.Logger.Warn("Invalid property for search", zap.String("property", property))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+		}
 		r.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
@@ -315,32 +375,51 @@ func (r *Repository) SearchByProperty(property string, searchText string) (*[]st
 // Mappers
 func (u *User) toDomainMapper() *domainUser.User {
 	return &domainUser.User{
-		ID:           u.ID,
-		UserName:     u.UserName,
-		Email:        u.Email,
-		Role:         u.Role,
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Status:       u.Status,
-		HashPassword: u.HashPassword,
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
+		ID:                     u.ID,
+		UserName:               u.UserName,
+		Email:                  u.Email,
+		Role:                   u.Role,
+		FirstName:              u.FirstName,
+		LastName:               u.LastName,
+		Status:                 u.Status,
+		HashPassword:           u.HashPassword,
+		FailedLoginAttempts:    u.FailedLoginAttempts,
+		LastFailedLoginAt:      u.LastFailedLoginAt,
+		LockedUntil:            u.LockedUntil,
+		TwoFactorEnabled:       u.TwoFactorEnabled,
+		TwoFactorSecret:        u.TwoFactorSecret,
+		TwoFactorRecoveryCodes: splitRecoveryCodes(u.RecoveryCodes),
+		CreatedAt:              u.CreatedAt,
+		UpdatedAt:              u.UpdatedAt,
 	}
 }
 
 func fromDomainMapper(u *domainUser.User) *User {
 	return &User{
-		ID:           u.ID,
-		UserName:     u.UserName,
-		Email:        u.Email,
-		Role:         u.Role,
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Status:       u.Status,
-		HashPassword: u.HashPassword,
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
+		ID:                  u.ID,
+		UserName:            u.UserName,
+		Email:               u.Email,
+		Role:                u.Role,
+		FirstName:           u.FirstName,
+		LastName:            u.LastName,
+		Status:              u.Status,
+		HashPassword:        u.HashPassword,
+		FailedLoginAttempts: u.FailedLoginAttempts,
+		LastFailedLoginAt:   u.LastFailedLoginAt,
+		LockedUntil:         u.LockedUntil,
+		TwoFactorEnabled:    u.TwoFactorEnabled,
+		TwoFactorSecret:     u.TwoFactorSecret,
+		RecoveryCodes:       strings.Join(u.TwoFactorRecoveryCodes, ","),
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+	}
+}
+
+func splitRecoveryCodes(codes string) []string {
+	if codes == "" {
+		return nil
 	}
+	return strings.Split(codes, ",")
 }
 
 func arrayToDomainMapper(users *[]User) *[]domainUser.User {
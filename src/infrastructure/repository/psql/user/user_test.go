@@ -196,3 +196,117 @@ func TestRepository_GetByEmail(t *testing.T) {
 // TestRepository_Update_WithMultipleFields
 //
 // If you want me to refactor these as well, let me know and I'll do them one by one.
+
+func TestRepository_UpdateTwoFactor(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "two_factor_enabled"=$1,"two_factor_recovery_codes"=$2,"two_factor_secret"=$3,"updated_at"=$4 WHERE "id" = $5`)).
+		WithArgs(true, "hash1,hash2", "SECRET", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err := repo.UpdateTwoFactor(1, true, "SECRET", []string{"hash1", "hash2"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_IncrementFailedLogins(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewUserRepository(db, setupLogger(t))
+	at := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`failed_login_attempts = CASE WHEN locked_until <= $1 THEN 1 ELSE failed_login_attempts + 1 END`)+`(?s).*`+regexp.QuoteMeta(`RETURNING failed_login_attempts`)).
+		WithArgs(at, at, at, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts"}).AddRow(4))
+	failedAttempts, err := repo.IncrementFailedLogins(1, at)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, failedAttempts)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET`)).
+		WithArgs(at, at, at, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts"}))
+	_, err = repo.IncrementFailedLogins(2, at)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_LockAccount(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewUserRepository(db, setupLogger(t))
+	until := time.Now().Add(time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "locked_until"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+		WithArgs(until, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.LockAccount(1, until))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ClaimTwoFactorStep(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewUserRepository(db, setupLogger(t))
+	query := regexp.QuoteMeta(`UPDATE "users" SET "two_factor_last_step"=$1 WHERE id = $2 AND two_factor_last_step < $3`)
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(int64(100), 1, int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	claimed, err := repo.ClaimTwoFactorStep(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(int64(100), 1, int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	claimed, err = repo.ClaimTwoFactorStep(1, 100)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ConsumeRecoveryCode(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewUserRepository(db, setupLogger(t))
+	query := regexp.QuoteMeta(`UPDATE "users" SET "two_factor_recovery_codes"=array_to_string(array_remove(string_to_array(two_factor_recovery_codes, ','), $1), ',') WHERE id = $2 AND $3 = ANY(string_to_array(two_factor_recovery_codes, ','))`)
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("hash", 1, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	consumed, err := repo.ConsumeRecoveryCode(1, "hash")
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("hash", 1, "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	consumed, err = repo.ConsumeRecoveryCode(1, "hash")
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdatePassword(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	logger := setupLogger(t)
+	repo := NewUserRepository(db, logger)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "hash_password"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+		WithArgs("new-hash", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err := repo.UpdatePassword(1, "new-hash")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecoveryCodesMapping(t *testing.T) {
+	u := &User{ID: 1, TwoFactorEnabled: true, RecoveryCodes: "a,b"}
+	d := u.toDomainMapper()
+	assert.Equal(t, []string{"a", "b"}, d.TwoFactorRecoveryCodes)
+	assert.Equal(t, "a,b", fromDomainMapper(d).RecoveryCodes)
+	assert.Nil(t, (&User{}).toDomainMapper().TwoFactorRecoveryCodes)
+}
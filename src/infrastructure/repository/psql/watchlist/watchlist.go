@@ -0,0 +1,156 @@
+package watchlist
+
+import (
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Watchlist struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_watchlist_user_name"`
+	Name      string    `gorm:"column:name;uniqueIndex:idx_watchlist_user_name"`
+	Pairs     string    `gorm:"column:pairs"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (Watchlist) TableName() string {
+	return "watchlists"
+}
+
+// WatchlistRepositoryInterface defines the interface for watchlist repository operations.
+// Every lookup is scoped to the owning user.
+type WatchlistRepositoryInterface interface {
+	Create(watchlist *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error)
+	GetByID(userID int, id int) (*domainWatchlist.Watchlist, error)
+	GetByUserID(userID int) (*[]domainWatchlist.Watchlist, error)
+	Update(watchlist *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error)
+	Delete(userID int, id int) error
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewWatchlistRepository(db *gorm.DB, loggerInstance *logger.Logger) WatchlistRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) Create(watchlist *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error) {
+	r.Logger.Info("Creating new watchlist", zap.Int("userID", watchlist.UserID), zap.String("name", watchlist.Name))
+	watchlistRepository := fromDomainMapper(watchlist)
+	if err := r.DB.Create(watchlistRepository).Error; err != nil {
+		r.Logger.Error("Error creating watchlist", zap.Error(err), zap.Int("userID", watchlist.UserID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully created watchlist", zap.Int("id", watchlistRepository.ID))
+	return watchlistRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByID(userID int, id int) (*domainWatchlist.Watchlist, error) {
+	var watchlist Watchlist
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&watchlist).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Watchlist not found", zap.Int("id", id), zap.Int("userID", userID))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting watchlist by ID", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return watchlist.toDomainMapper(), nil
+}
+
+func (r *Repository) GetByUserID(userID int) (*[]domainWatchlist.Watchlist, error) {
+	var watchlists []Watchlist
+	if err := r.DB.Where("user_id = ?", userID).Order("name").Find(&watchlists).Error; err != nil {
+		r.Logger.Error("Error getting watchlists by user", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully retrieved watchlists", zap.Int("userID", userID), zap.Int("count", len(watchlists)))
+	return arrayToDomainMapper(&watchlists), nil
+}
+
+func (r *Repository) Update(watchlist *domainWatchlist.Watchlist) (*domainWatchlist.Watchlist, error) {
+	watchlistRepository := fromDomainMapper(watchlist)
+	tx := r.DB.Model(&Watchlist{}).
+		Where("id = ? AND user_id = ?", watchlist.ID, watchlist.UserID).
+		Updates(map[string]interface{}{
+			"name":  watchlistRepository.Name,
+			"pairs": watchlistRepository.Pairs,
+		})
+	if tx.Error != nil {
+		r.Logger.Error("Error updating watchlist", zap.Error(tx.Error), zap.Int("id", watchlist.ID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Watchlist not found for update", zap.Int("id", watchlist.ID), zap.Int("userID", watchlist.UserID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully updated watchlist", zap.Int("id", watchlist.ID))
+	return r.GetByID(watchlist.UserID, watchlist.ID)
+}
+
+func (r *Repository) Delete(userID int, id int) error {
+	tx := r.DB.Where("user_id = ?", userID).Delete(&Watchlist{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting watchlist", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Watchlist not found for deletion", zap.Int("id", id), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted watchlist", zap.Int("id", id))
+	return nil
+}
+
+// Mappers
+func (w *Watchlist) toDomainMapper() *domainWatchlist.Watchlist {
+	var pairs []domainWatchlist.Pair
+	if w.Pairs != "" {
+		for _, raw := range strings.Split(w.Pairs, ",") {
+			if pair, err := domainWatchlist.ParsePair(raw); err == nil {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return &domainWatchlist.Watchlist{
+		ID:        w.ID,
+		UserID:    w.UserID,
+		Name:      w.Name,
+		Pairs:     pairs,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func fromDomainMapper(w *domainWatchlist.Watchlist) *Watchlist {
+	pairs := make([]string, len(w.Pairs))
+	for i, pair := range w.Pairs {
+		pairs[i] = pair.String()
+	}
+	return &Watchlist{
+		ID:        w.ID,
+		UserID:    w.UserID,
+		Name:      w.Name,
+		Pairs:     strings.Join(pairs, ","),
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func arrayToDomainMapper(watchlists *[]Watchlist) *[]domainWatchlist.Watchlist {
+	watchlistsDomain := make([]domainWatchlist.Watchlist, len(*watchlists))
+	for i, watchlist := range *watchlists {
+		watchlistsDomain[i] = *watchlist.toDomainMapper()
+	}
+	return &watchlistsDomain
+}
@@ -0,0 +1,140 @@
+package watchlist
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "watchlists", Watchlist{}.TableName())
+}
+
+func TestRepository_Create(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWatchlistRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "watchlists"`)).
+		WithArgs(3, "majors", "EUR/USD,GBP/USD", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	watchlist, err := repo.Create(&domainWatchlist.Watchlist{
+		UserID: 3,
+		Name:   "majors",
+		Pairs:  []domainWatchlist.Pair{{Base: "EUR", Quote: "USD"}, {Base: "GBP", Quote: "USD"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, watchlist.ID)
+	assert.Len(t, watchlist.Pairs, 2)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "watchlists"`)).WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+	_, err = repo.Create(&domainWatchlist.Watchlist{UserID: 3, Name: "majors"})
+	assertErrorType(t, err, domainErrors.RepositoryError)
+}
+
+func TestRepository_GetByID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWatchlistRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "pairs", "created_at", "updated_at"}).
+		AddRow(2, 3, "majors", "EUR/USD,USD/JPY", time.Now(), time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "watchlists" WHERE id = $1 AND user_id = $2`)).
+		WithArgs(2, 3, 1).WillReturnRows(rows)
+	watchlist, err := repo.GetByID(3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []domainWatchlist.Pair{{Base: "EUR", Quote: "USD"}, {Base: "USD", Quote: "JPY"}}, watchlist.Pairs)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "watchlists" WHERE id = $1 AND user_id = $2`)).
+		WithArgs(2, 4, 1).WillReturnError(gorm.ErrRecordNotFound)
+	_, err = repo.GetByID(4, 2)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_GetByUserID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWatchlistRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "pairs"}).
+		AddRow(1, 3, "majors", "EUR/USD").
+		AddRow(2, 3, "empty", "")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "watchlists" WHERE user_id = $1 ORDER BY name`)).
+		WithArgs(3).WillReturnRows(rows)
+	watchlists, err := repo.GetByUserID(3)
+	require.NoError(t, err)
+	assert.Len(t, *watchlists, 2)
+	assert.Nil(t, (*watchlists)[1].Pairs)
+}
+
+func TestRepository_Update(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWatchlistRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "watchlists" SET "name"=$1,"pairs"=$2,"updated_at"=$3 WHERE id = $4 AND user_id = $5`)).
+		WithArgs("crosses", "EUR/GBP", sqlmock.AnyArg(), 2, 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	_, err := repo.Update(&domainWatchlist.Watchlist{ID: 2, UserID: 4, Name: "crosses", Pairs: []domainWatchlist.Pair{{Base: "EUR", Quote: "GBP"}}})
+	assertErrorType(t, err, domainErrors.NotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Delete(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWatchlistRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "watchlists" WHERE user_id = $1 AND "watchlists"."id" = $2`)).
+		WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Delete(3, 2))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "watchlists" WHERE user_id = $1 AND "watchlists"."id" = $2`)).
+		WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assertErrorType(t, repo.Delete(4, 2), domainErrors.NotFound)
+}
@@ -0,0 +1,320 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Subscription struct {
+	ID         int       `gorm:"primaryKey"`
+	URL        string    `gorm:"column:url"`
+	Secret     string    `gorm:"column:secret"`
+	EventTypes string    `gorm:"column:event_types"`
+	Currencies string    `gorm:"column:currencies"`
+	Active     bool      `gorm:"column:active"`
+	CreatedBy  int       `gorm:"column:created_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+type Delivery struct {
+	ID             int        `gorm:"primaryKey"`
+	SubscriptionID int        `gorm:"column:subscription_id;index"`
+	EventID        string     `gorm:"column:event_id"`
+	EventType      string     `gorm:"column:event_type"`
+	Payload        string     `gorm:"column:payload;type:text"`
+	Status         string     `gorm:"column:status;index:idx_webhook_delivery_due"`
+	Attempts       int        `gorm:"column:attempts"`
+	ResponseStatus int        `gorm:"column:response_status"`
+	LastError      string     `gorm:"column:last_error"`
+	NextAttemptAt  *time.Time `gorm:"column:next_attempt_at;index:idx_webhook_delivery_due"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookRepositoryInterface defines the interface for webhook subscription and delivery repository operations
+type WebhookRepositoryInterface interface {
+	CreateSubscription(subscription *domainWebhook.Subscription) (*domainWebhook.Subscription, error)
+	GetSubscriptions() (*[]domainWebhook.Subscription, error)
+	GetActiveSubscriptions() (*[]domainWebhook.Subscription, error)
+	GetSubscriptionByID(id int) (*domainWebhook.Subscription, error)
+	UpdateSubscription(subscription *domainWebhook.Subscription) (*domainWebhook.Subscription, error)
+	DeleteSubscription(id int) error
+	CreateDelivery(delivery *domainWebhook.Delivery) (*domainWebhook.Delivery, error)
+	GetDeliveryByID(id int) (*domainWebhook.Delivery, error)
+	GetDeliveriesBySubscriptionID(subscriptionID int, limit int) (*[]domainWebhook.Delivery, error)
+	ClaimDueDeliveries(now time.Time, claimedUntil time.Time, limit int) (*[]domainWebhook.Delivery, error)
+	UpdateDelivery(delivery *domainWebhook.Delivery) error
+}
+
+type Repository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, loggerInstance *logger.Logger) WebhookRepositoryInterface {
+	return &Repository{DB: db, Logger: loggerInstance}
+}
+
+func (r *Repository) CreateSubscription(subscription *domainWebhook.Subscription) (*domainWebhook.Subscription, error) {
+	r.Logger.Info("Creating new webhook subscription", zap.String("url", subscription.URL))
+	subscriptionRepository := fromDomainSubscriptionMapper(subscription)
+	if err := r.DB.Create(subscriptionRepository).Error; err != nil {
+		r.Logger.Error("Error creating webhook subscription", zap.Error(err), zap.String("url", subscription.URL))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	r.Logger.Info("Successfully created webhook subscription", zap.Int("id", subscriptionRepository.ID))
+	return subscriptionRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetSubscriptions() (*[]domainWebhook.Subscription, error) {
+	var subscriptions []Subscription
+	if err := r.DB.Order("id").Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting webhook subscriptions", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainSubscriptionMapper(&subscriptions), nil
+}
+
+func (r *Repository) GetActiveSubscriptions() (*[]domainWebhook.Subscription, error) {
+	var subscriptions []Subscription
+	if err := r.DB.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting active webhook subscriptions", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainSubscriptionMapper(&subscriptions), nil
+}
+
+func (r *Repository) GetSubscriptionByID(id int) (*domainWebhook.Subscription, error) {
+	var subscription Subscription
+	err := r.DB.Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Webhook subscription not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting webhook subscription by ID", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return subscription.toDomainMapper(), nil
+}
+
+// UpdateSubscription replaces the URL, filters and active flag; the secret is never changed
+func (r *Repository) UpdateSubscription(subscription *domainWebhook.Subscription) (*domainWebhook.Subscription, error) {
+	subscriptionRepository := fromDomainSubscriptionMapper(subscription)
+	tx := r.DB.Model(&Subscription{}).
+		Where("id = ?", subscription.ID).
+		Updates(map[string]interface{}{
+			"url":         subscriptionRepository.URL,
+			"event_types": subscriptionRepository.EventTypes,
+			"currencies":  subscriptionRepository.Currencies,
+			"active":      subscriptionRepository.Active,
+		})
+	if tx.Error != nil {
+		r.Logger.Error("Error updating webhook subscription", zap.Error(tx.Error), zap.Int("id", subscription.ID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Webhook subscription not found for update", zap.Int("id", subscription.ID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully updated webhook subscription", zap.Int("id", subscription.ID))
+	return r.GetSubscriptionByID(subscription.ID)
+}
+
+func (r *Repository) DeleteSubscription(id int) error {
+	tx := r.DB.Delete(&Subscription{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting webhook subscription", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		r.Logger.Warn("Webhook subscription not found for deletion", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted webhook subscription", zap.Int("id", id))
+	return nil
+}
+
+func (r *Repository) CreateDelivery(delivery *domainWebhook.Delivery) (*domainWebhook.Delivery, error) {
+	deliveryRepository := fromDomainDeliveryMapper(delivery)
+	if err := r.DB.Create(deliveryRepository).Error; err != nil {
+		r.Logger.Error("Error creating webhook delivery", zap.Error(err), zap.Int("subscriptionID", delivery.SubscriptionID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return deliveryRepository.toDomainMapper(), nil
+}
+
+func (r *Repository) GetDeliveryByID(id int) (*domainWebhook.Delivery, error) {
+	var delivery Delivery
+	err := r.DB.Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Webhook delivery not found", zap.Int("id", id))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting webhook delivery by ID", zap.Error(err), zap.Int("id", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return delivery.toDomainMapper(), nil
+}
+
+func (r *Repository) GetDeliveriesBySubscriptionID(subscriptionID int, limit int) (*[]domainWebhook.Delivery, error) {
+	var deliveries []Delivery
+	err := r.DB.Where("subscription_id = ?", subscriptionID).Order("id desc").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		r.Logger.Error("Error getting webhook deliveries", zap.Error(err), zap.Int("subscriptionID", subscriptionID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainDeliveryMapper(&deliveries), nil
+}
+
+// ClaimDueDeliveries returns the pending deliveries whose next attempt is due, oldest first, and
+// moves their next attempt to claimedUntil in the same transaction. Rows another instance is
+// claiming are skipped, so each delivery is attempted by one instance; if that instance stops
+// before recording the outcome, the delivery is due again at claimedUntil.
+func (r *Repository) ClaimDueDeliveries(now time.Time, claimedUntil time.Time, limit int) (*[]domainWebhook.Delivery, error) {
+	var deliveries []Delivery
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", string(domainWebhook.DeliveryPending), now).
+			Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]int, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].NextAttemptAt = &claimedUntil
+		}
+		return tx.Model(&Delivery{}).Where("id IN ?", ids).UpdateColumn("next_attempt_at", claimedUntil).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error getting due webhook deliveries", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return arrayToDomainDeliveryMapper(&deliveries), nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt
+func (r *Repository) UpdateDelivery(delivery *domainWebhook.Delivery) error {
+	err := r.DB.Model(&Delivery{ID: delivery.ID}).
+		Select("status", "attempts", "response_status", "last_error", "next_attempt_at", "delivered_at").
+		Updates(map[string]interface{}{
+			"status":          string(delivery.Status),
+			"attempts":        delivery.Attempts,
+			"response_status": delivery.ResponseStatus,
+			"last_error":      delivery.LastError,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"delivered_at":    delivery.DeliveredAt,
+		}).Error
+	if err != nil {
+		r.Logger.Error("Error updating webhook delivery", zap.Error(err), zap.Int("id", delivery.ID))
+		return domainErrors.NewAppErrorWithType(domainErrors.RepositoryError)
+	}
+	return nil
+}
+
+// Mappers
+func (s *Subscription) toDomainMapper() *domainWebhook.Subscription {
+	return &domainWebhook.Subscription{
+		ID:         s.ID,
+		URL:        s.URL,
+		Secret:     s.Secret,
+		EventTypes: splitList(s.EventTypes),
+		Currencies: splitList(s.Currencies),
+		Active:     s.Active,
+		CreatedBy:  s.CreatedBy,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+func fromDomainSubscriptionMapper(s *domainWebhook.Subscription) *Subscription {
+	return &Subscription{
+		ID:         s.ID,
+		URL:        s.URL,
+		Secret:     s.Secret,
+		EventTypes: strings.Join(s.EventTypes, ","),
+		Currencies: strings.Join(s.Currencies, ","),
+		Active:     s.Active,
+		CreatedBy:  s.CreatedBy,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+func arrayToDomainSubscriptionMapper(subscriptions *[]Subscription) *[]domainWebhook.Subscription {
+	subscriptionsDomain := make([]domainWebhook.Subscription, len(*subscriptions))
+	for i, subscription := range *subscriptions {
+		subscriptionsDomain[i] = *subscription.toDomainMapper()
+	}
+	return &subscriptionsDomain
+}
+
+func (d *Delivery) toDomainMapper() *domainWebhook.Delivery {
+	return &domainWebhook.Delivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         domainWebhook.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		NextAttemptAt:  d.NextAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func fromDomainDeliveryMapper(d *domainWebhook.Delivery) *Delivery {
+	return &Delivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		NextAttemptAt:  d.NextAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func arrayToDomainDeliveryMapper(deliveries *[]Delivery) *[]domainWebhook.Delivery {
+	deliveriesDomain := make([]domainWebhook.Delivery, len(*deliveries))
+	for i, delivery := range *deliveries {
+		deliveriesDomain[i] = *delivery.toDomainMapper()
+	}
+	return &deliveriesDomain
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
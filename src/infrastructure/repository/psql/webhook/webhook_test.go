@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	cleanup := func() { db.Close() }
+	return gormDB, mock, cleanup
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func assertErrorType(t *testing.T, err error, expected domainErrors.ErrorType) {
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, expected, appErr.Type)
+}
+
+func TestTableNames(t *testing.T) {
+	assert.Equal(t, "webhook_subscriptions", Subscription{}.TableName())
+	assert.Equal(t, "webhook_deliveries", Delivery{}.TableName())
+}
+
+func TestRepository_CreateSubscription(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWebhookRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "webhook_subscriptions"`)).
+		WithArgs("https://example.com/hook", "encrypted", "rates.updated,currency.deleted", "EUR,GBP", true, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+	subscription, err := repo.CreateSubscription(&domainWebhook.Subscription{
+		URL:        "https://example.com/hook",
+		Secret:     "encrypted",
+		EventTypes: []string{domainWebhook.EventRatesUpdated, domainWebhook.EventCurrencyDeleted},
+		Currencies: []string{"EUR", "GBP"},
+		Active:     true,
+		CreatedBy:  1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, subscription.ID)
+	assert.Equal(t, []string{"EUR", "GBP"}, subscription.Currencies)
+}
+
+func TestRepository_GetSubscriptionByID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWebhookRepository(db, setupLogger(t))
+
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "currencies", "active"}).
+		AddRow(3, "https://example.com/hook", "rates.updated", "", true)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_subscriptions" WHERE id = $1`)).
+		WithArgs(3, 1).WillReturnRows(rows)
+	subscription, err := repo.GetSubscriptionByID(3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{domainWebhook.EventRatesUpdated}, subscription.EventTypes)
+	assert.Empty(t, subscription.Currencies)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_subscriptions" WHERE id = $1`)).
+		WithArgs(4, 1).WillReturnError(gorm.ErrRecordNotFound)
+	_, err = repo.GetSubscriptionByID(4)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRepository_DeleteSubscription(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWebhookRepository(db, setupLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "webhook_subscriptions" WHERE "webhook_subscriptions"."id" = $1`)).
+		WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assertErrorType(t, repo.DeleteSubscription(9), domainErrors.NotFound)
+}
+
+func TestRepository_ClaimDueDeliveries(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWebhookRepository(db, setupLogger(t))
+
+	now := time.Now()
+	claimedUntil := now.Add(10 * time.Minute)
+	query := regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED`)
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_type", "payload", "status", "attempts", "next_attempt_at"}).
+		AddRow(5, 3, "rates.updated", "{}", "pending", 1, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs("pending", now, 10).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "next_attempt_at"=$1 WHERE id IN ($2)`)).
+		WithArgs(claimedUntil, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	deliveries, err := repo.ClaimDueDeliveries(now, claimedUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, *deliveries, 1)
+	assert.Equal(t, domainWebhook.DeliveryPending, (*deliveries)[0].Status)
+	assert.Equal(t, 1, (*deliveries)[0].Attempts)
+	assert.Equal(t, claimedUntil, *(*deliveries)[0].NextAttemptAt)
+
+	// rows locked by another instance are skipped and nothing is claimed
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs("pending", now, 10).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+	deliveries, err = repo.ClaimDueDeliveries(now, claimedUntil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, *deliveries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateDelivery(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	repo := NewWebhookRepository(db, setupLogger(t))
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "attempts"=$1,"delivered_at"=$2,"last_error"=$3,"next_attempt_at"=$4,"response_status"=$5,"status"=$6,"updated_at"=$7 WHERE "id" = $8`)).
+		WithArgs(2, &now, "", nil, 200, "delivered", sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err := repo.UpdateDelivery(&domainWebhook.Delivery{ID: 5, Status: domainWebhook.DeliveryDelivered, Attempts: 2, ResponseStatus: 200, DeliveredAt: &now})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
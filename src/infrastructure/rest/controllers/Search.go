@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// ParseDataFilters reads the query parameters shared by every /search endpoint: <field>_like,
+// <field>_match, <field>_start and <field>_end for each searchable field, sortBy, sortDirection,
+// page and pageSize. pagination=cursor, or a cursor parameter, switches to cursor pagination.
+func ParseDataFilters(ctx *gin.Context, columns map[string]string) domain.DataFilters {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	filters := domain.DataFilters{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	// Parse like filters
+	likeFilters := make(map[string][]string)
+	for field := range columns {
+		if values := ctx.QueryArray(field + "_like"); len(values) > 0 {
+			likeFilters[field] = values
+		}
+	}
+	filters.LikeFilters = likeFilters
+
+	// Parse exact matches
+	matches := make(map[string][]string)
+	for field := range columns {
+		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
+			matches[field] = values
+		}
+	}
+	filters.Matches = matches
+
+	// Parse date range filters
+	var dateRanges []domain.DateRangeFilter
+	for field := range columns {
+		startStr := ctx.Query(field + "_start")
+		endStr := ctx.Query(field + "_end")
+
+		if startStr != "" || endStr != "" {
+			dateRange := domain.DateRangeFilter{Field: field}
+
+			if startStr != "" {
+				if startTime, err := time.Parse(time.RFC3339, startStr); err == nil {
+					dateRange.Start = &startTime
+				}
+			}
+
+			if endStr != "" {
+				if endTime, err := time.Parse(time.RFC3339, endStr); err == nil {
+					dateRange.End = &endTime
+				}
+			}
+
+			dateRanges = append(dateRanges, dateRange)
+		}
+	}
+	filters.DateRangeFilters = dateRanges
+
+	// Parse sorting
+	sortBy := ctx.QueryArray("sortBy")
+	if len(sortBy) > 0 {
+		filters.SortBy = sortBy
+	}
+
+	sortDirection := domain.SortDirection(ctx.DefaultQuery("sortDirection", "asc"))
+	if sortDirection.IsValid() {
+		filters.SortDirection = sortDirection
+	}
+
+	// Parse pagination mode
+	filters.Cursor = ctx.Query("cursor")
+	if filters.Cursor != "" || domain.PaginationMode(ctx.Query("pagination")) == domain.PaginationCursor {
+		filters.Pagination = domain.PaginationCursor
+	} else {
+		filters.Pagination = domain.PaginationOffset
+	}
+	return filters
+}
+
+// SearchPageResponse renders a page of search results. Offset pages report the total and the
+// page numbers; cursor pages report links to the next and previous pages instead, null at either
+// end of the results.
+func SearchPageResponse(ctx *gin.Context, filters domain.DataFilters, data any, total int64, page, pageSize, totalPages int, nextCursor, prevCursor string) gin.H {
+	response := gin.H{
+		"data":     data,
+		"pageSize": pageSize,
+		"filters":  filters,
+	}
+	if filters.Pagination == domain.PaginationCursor {
+		response["next"] = cursorLink(ctx, nextCursor)
+		response["prev"] = cursorLink(ctx, prevCursor)
+		return response
+	}
+	response["total"] = total
+	response["page"] = page
+	response["totalPages"] = totalPages
+	return response
+}
+
+// cursorLink repeats the current request from cursor
+func cursorLink(ctx *gin.Context, cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+	link := *ctx.Request.URL
+	query := link.Query()
+	query.Del("page")
+	query.Set("pagination", string(domain.PaginationCursor))
+	query.Set("cursor", cursor)
+	link.RawQuery = query.Encode()
+	value := link.RequestURI()
+	return &value
+}
+
+// ParseSearchProperty reads the property and searchText parameters of a /search-property
+// endpoint and checks the property is one the endpoint allows
+func ParseSearchProperty(ctx *gin.Context, allowed map[string]bool) (string, string, error) {
+	property := ctx.Query("property")
+	searchText := ctx.Query("searchText")
+	if property == "" || searchText == "" {
+		return "", "", domainErrors.NewAppError(errors.New("missing property or searchText parameter"), domainErrors.ValidationError)
+	}
+	if !allowed[property] {
+		return "", "", domainErrors.NewAppError(errors.New("invalid property"), domainErrors.ValidationError)
+	}
+	return property, searchText, nil
+}
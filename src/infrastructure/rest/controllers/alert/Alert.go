@@ -0,0 +1,139 @@
+package alert
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	useCaseAlert "github.com/gbrayhan/microservices-go/src/application/usecases/alert"
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IAlertController interface {
+	NewAlert(ctx *gin.Context)
+	GetAlerts(ctx *gin.Context)
+	DeleteAlert(ctx *gin.Context)
+	GetAlertEvents(ctx *gin.Context)
+}
+
+type AlertController struct {
+	alertUseCase useCaseAlert.IAlertUseCase
+	Logger       *logger.Logger
+}
+
+func NewAlertController(alertUseCase useCaseAlert.IAlertUseCase, loggerInstance *logger.Logger) IAlertController {
+	return &AlertController{alertUseCase: alertUseCase, Logger: loggerInstance}
+}
+
+func (c *AlertController) NewAlert(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Creating new alert", zap.Int("userID", userID))
+	var request NewAlertRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new alert", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	rule, err := c.alertUseCase.Create(userID, request.Pair, request.Condition, request.Threshold, request.CooldownMinutes)
+	if err != nil {
+		c.Logger.Error("Error creating alert", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Alert created successfully", zap.Int("userID", userID), zap.Int("id", rule.ID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(rule))
+}
+
+func (c *AlertController) GetAlerts(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting alerts", zap.Int("userID", userID))
+	rules, err := c.alertUseCase.GetByUser(userID)
+	if err != nil {
+		c.Logger.Error("Error getting alerts", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]AlertResponse, len(*rules))
+	for i, rule := range *rules {
+		response[i] = *domainToResponseMapper(&rule)
+	}
+	c.Logger.Info("Successfully retrieved alerts", zap.Int("count", len(response)))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AlertController) DeleteAlert(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	alertID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid alert ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	if err := c.alertUseCase.Delete(userID, alertID); err != nil {
+		c.Logger.Error("Error deleting alert", zap.Error(err), zap.Int("id", alertID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Alert deleted successfully", zap.Int("id", alertID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+func (c *AlertController) GetAlertEvents(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting alert events", zap.Int("userID", userID))
+	events, err := c.alertUseCase.GetEvents(userID)
+	if err != nil {
+		c.Logger.Error("Error getting alert events", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]AlertEventResponse, len(*events))
+	for i, event := range *events {
+		response[i] = eventToResponseMapper(&event)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Mappers
+func domainToResponseMapper(rule *domainAlert.Rule) *AlertResponse {
+	response := &AlertResponse{
+		ID:              rule.ID,
+		Pair:            rule.Pair.String(),
+		Condition:       string(rule.Condition),
+		Threshold:       rule.Threshold,
+		CooldownMinutes: int(rule.Cooldown / time.Minute),
+		LastTriggeredAt: rule.LastTriggeredAt,
+		CreatedAt:       rule.CreatedAt,
+	}
+	if rule.ReferenceAt != nil {
+		referenceRate := rule.ReferenceRate
+		response.ReferenceRate = &referenceRate
+	}
+	return response
+}
+
+func eventToResponseMapper(event *domainAlert.Event) AlertEventResponse {
+	response := AlertEventResponse{
+		ID:          event.ID,
+		AlertID:     event.RuleID,
+		Pair:        event.Pair.String(),
+		Condition:   string(event.Condition),
+		Threshold:   event.Threshold,
+		Rate:        event.Rate,
+		TriggeredAt: event.TriggeredAt,
+	}
+	if event.Condition == domainAlert.ConditionChangePercent {
+		referenceRate := event.ReferenceRate
+		response.ReferenceRate = &referenceRate
+	}
+	return response
+}
@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// MockAlertUseCase implements IAlertUseCase for testing
+type MockAlertUseCase struct {
+	createdFor int
+	deletedID  int
+}
+
+func (m *MockAlertUseCase) Create(userID int, pair string, condition string, threshold float64, cooldownMinutes int) (*domainAlert.Rule, error) {
+	m.createdFor = userID
+	parsed, _ := domainWatchlist.ParsePair(pair)
+	return &domainAlert.Rule{ID: 1, UserID: userID, Pair: parsed, Condition: domainAlert.Condition(condition), Threshold: threshold, Cooldown: time.Duration(cooldownMinutes) * time.Minute}, nil
+}
+
+func (m *MockAlertUseCase) GetByUser(userID int) (*[]domainAlert.Rule, error) {
+	return &[]domainAlert.Rule{}, nil
+}
+
+func (m *MockAlertUseCase) Delete(userID int, id int) error {
+	m.deletedID = id
+	return nil
+}
+
+func (m *MockAlertUseCase) GetEvents(userID int) (*[]domainAlert.Event, error) {
+	return &[]domainAlert.Event{
+		{ID: 4, RuleID: 1, UserID: userID, Pair: domainWatchlist.Pair{Base: "EUR", Quote: "USD"}, Condition: domainAlert.ConditionAbove, Threshold: 1.12, Rate: 1.13, TriggeredAt: time.Now()},
+	}, nil
+}
+
+func (m *MockAlertUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func setupContext(method string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/v1/user/me/alerts", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(middlewares.AuthUserIDKey, 7)
+	return c, w
+}
+
+func TestAlertController_NewAlert(t *testing.T) {
+	useCase := &MockAlertUseCase{}
+	controller := NewAlertController(useCase, setupLogger(t))
+
+	c, w := setupContext("POST", `{"pair":"EUR/USD","condition":"above","threshold":1.12,"cooldownMinutes":30}`)
+	controller.NewAlert(c)
+
+	if w.Code != http.StatusOK || useCase.createdFor != 7 {
+		t.Fatalf("expected alert created for user 7, got status %d for user %d", w.Code, useCase.createdFor)
+	}
+	var response AlertResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Pair != "EUR/USD" || response.CooldownMinutes != 30 || response.ReferenceRate != nil {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	c, _ = setupContext("POST", `{"pair":"EUR/USD","condition":"above"}`)
+	controller.NewAlert(c)
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error for a missing threshold, got %v", c.Errors)
+	}
+}
+
+func TestAlertController_GetAlertEvents(t *testing.T) {
+	controller := NewAlertController(&MockAlertUseCase{}, setupLogger(t))
+
+	c, w := setupContext("GET", "")
+	controller.GetAlertEvents(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var response []AlertEventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(response) != 1 || response[0].AlertID != 1 || response[0].Rate != 1.13 {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestAlertController_DeleteAlert(t *testing.T) {
+	useCase := &MockAlertUseCase{}
+	controller := NewAlertController(useCase, setupLogger(t))
+
+	c, w := setupContext("DELETE", "")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	controller.DeleteAlert(c)
+	if w.Code != http.StatusOK || useCase.deletedID != 5 {
+		t.Errorf("expected alert 5 deleted, got status %d id %d", w.Code, useCase.deletedID)
+	}
+
+	c, _ = setupContext("DELETE", "")
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+	controller.DeleteAlert(c)
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error, got %v", c.Errors)
+	}
+}
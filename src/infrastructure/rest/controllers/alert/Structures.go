@@ -0,0 +1,34 @@
+package alert
+
+import (
+	"time"
+)
+
+type NewAlertRequest struct {
+	Pair            string  `json:"pair" binding:"required"`
+	Condition       string  `json:"condition" binding:"required"`
+	Threshold       float64 `json:"threshold" binding:"required"`
+	CooldownMinutes int     `json:"cooldownMinutes"`
+}
+
+type AlertResponse struct {
+	ID              int        `json:"id"`
+	Pair            string     `json:"pair"`
+	Condition       string     `json:"condition"`
+	Threshold       float64    `json:"threshold"`
+	CooldownMinutes int        `json:"cooldownMinutes"`
+	ReferenceRate   *float64   `json:"referenceRate,omitempty"`
+	LastTriggeredAt *time.Time `json:"lastTriggeredAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+type AlertEventResponse struct {
+	ID            int       `json:"id"`
+	AlertID       int       `json:"alertId"`
+	Pair          string    `json:"pair"`
+	Condition     string    `json:"condition"`
+	Threshold     float64   `json:"threshold"`
+	Rate          float64   `json:"rate"`
+	ReferenceRate *float64  `json:"referenceRate,omitempty"`
+	TriggeredAt   time.Time `json:"triggeredAt"`
+}
@@ -0,0 +1,111 @@
+package apitoken
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	useCaseAPIToken "github.com/gbrayhan/microservices-go/src/application/usecases/apitoken"
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IAPITokenController interface {
+	NewAPIToken(ctx *gin.Context)
+	GetAPITokens(ctx *gin.Context)
+	RevokeAPIToken(ctx *gin.Context)
+}
+
+type APITokenController struct {
+	apiTokenUseCase useCaseAPIToken.IAPITokenUseCase
+	Logger          *logger.Logger
+}
+
+func NewAPITokenController(apiTokenUseCase useCaseAPIToken.IAPITokenUseCase, loggerInstance *logger.Logger) IAPITokenController {
+	return &APITokenController{apiTokenUseCase: apiTokenUseCase, Logger: loggerInstance}
+}
+
+func (c *APITokenController) NewAPIToken(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Creating new API token", zap.Int("userID", userID))
+	var request NewAPITokenRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new API token", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	token, plainToken, err := c.apiTokenUseCase.Create(userID, request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		c.Logger.Error("Error creating API token", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("API token created successfully", zap.Int("userID", userID), zap.Int("id", token.ID))
+	ctx.JSON(http.StatusOK, NewAPITokenResponse{
+		APITokenResponse: *domainToResponseMapper(token),
+		Token:            plainToken,
+	})
+}
+
+func (c *APITokenController) GetAPITokens(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting API tokens", zap.Int("userID", userID))
+	tokens, err := c.apiTokenUseCase.GetByUser(userID)
+	if err != nil {
+		c.Logger.Error("Error getting API tokens", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved API tokens", zap.Int("count", len(*tokens)))
+	ctx.JSON(http.StatusOK, arrayDomainToResponseMapper(tokens))
+}
+
+func (c *APITokenController) RevokeAPIToken(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	tokenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid API token ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Revoking API token", zap.Int("userID", userID), zap.Int("id", tokenID))
+	if err := c.apiTokenUseCase.Revoke(userID, tokenID); err != nil {
+		c.Logger.Error("Error revoking API token", zap.Error(err), zap.Int("id", tokenID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("API token revoked successfully", zap.Int("id", tokenID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+// Mappers
+func domainToResponseMapper(token *domainAPIToken.APIToken) *APITokenResponse {
+	scopes := token.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return &APITokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func arrayDomainToResponseMapper(tokens *[]domainAPIToken.APIToken) *[]APITokenResponse {
+	res := make([]APITokenResponse, len(*tokens))
+	for i, t := range *tokens {
+		res[i] = *domainToResponseMapper(&t)
+	}
+	return &res
+}
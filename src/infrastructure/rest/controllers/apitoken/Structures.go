@@ -0,0 +1,27 @@
+package apitoken
+
+import (
+	"time"
+)
+
+type NewAPITokenRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type APITokenResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// NewAPITokenResponse is the only response that ever contains the plain token
+type NewAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
@@ -1,13 +1,17 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	useCaseAuth "github.com/gbrayhan/microservices-go/src/application/usecases/auth"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainLoginAttempt "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -16,6 +20,14 @@ type IAuthController interface {
 	Login(ctx *gin.Context)
 	Register(ctx *gin.Context)
 	GetAccessTokenByRefreshToken(ctx *gin.Context)
+	UnlockAccount(ctx *gin.Context)
+	GetLoginAttempts(ctx *gin.Context)
+	VerifyTwoFactor(ctx *gin.Context)
+	EnrollTwoFactor(ctx *gin.Context)
+	ConfirmTwoFactor(ctx *gin.Context)
+	DisableTwoFactor(ctx *gin.Context)
+	EnrollTwoFactorWithChallenge(ctx *gin.Context)
+	ConfirmTwoFactorWithChallenge(ctx *gin.Context)
 }
 
 type AuthController struct {
@@ -40,13 +52,19 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	domainUser, authTokens, err := c.authUseCase.Login(request.Email, request.Password)
+	domainUser, authTokens, err := c.authUseCase.Login(request.Email, request.Password, ctx.ClientIP())
 	if err != nil {
 		c.Logger.Error("Login failed", zap.Error(err), zap.String("email", request.Email))
 		_ = ctx.Error(err)
 		return
 	}
 
+	if authTokens.ChallengeToken != "" {
+		c.Logger.Info("Login requires a second factor", zap.String("email", request.Email), zap.String("type", authTokens.ChallengeType))
+		ctx.JSON(http.StatusOK, toTwoFactorChallengeResponse(authTokens))
+		return
+	}
+
 	response := LoginResponse{
 		Data: UserData{
 			UserName:  domainUser.UserName,
@@ -140,6 +158,199 @@ func (c *AuthController) GetAccessTokenByRefreshToken(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+func (c *AuthController) UnlockAccount(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid user ID parameter for unlock", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Account unlock request", zap.Int("userID", userID), zap.Int("adminID", adminID))
+	if err := c.authUseCase.UnlockAccount(adminID, userID); err != nil {
+		c.Logger.Error("Account unlock failed", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Account unlocked successfully", zap.Int("userID", userID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked successfully"})
+}
+
+func (c *AuthController) GetLoginAttempts(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Login attempts request", zap.Int("adminID", adminID))
+	userID, _ := strconv.Atoi(ctx.DefaultQuery("userId", "0"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	filter := domainLoginAttempt.Filter{
+		UserID: userID,
+		Email:  ctx.Query("email"),
+		IP:     ctx.Query("ip"),
+		Limit:  limit,
+	}
+
+	attempts, err := c.authUseCase.GetLoginAttempts(adminID, filter)
+	if err != nil {
+		c.Logger.Error("Error getting login attempts", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved login attempts", zap.Int("count", len(*attempts)))
+	ctx.JSON(http.StatusOK, arrayLoginAttemptToResponseMapper(attempts))
+}
+
+func (c *AuthController) VerifyTwoFactor(ctx *gin.Context) {
+	c.Logger.Info("Two-factor verification request")
+	var request TwoFactorVerifyRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for two-factor verification", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+
+	domainUser, authTokens, err := c.authUseCase.VerifyTwoFactor(request.ChallengeToken, request.Code, ctx.ClientIP())
+	if err != nil {
+		c.Logger.Error("Two-factor verification failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := LoginResponse{
+		Data: UserData{
+			UserName:  domainUser.UserName,
+			Email:     domainUser.Email,
+			FirstName: domainUser.FirstName,
+			LastName:  domainUser.LastName,
+			Role:      domainUser.Role,
+			Status:    domainUser.Status,
+			ID:        domainUser.ID,
+		},
+		Security: SecurityData{
+			JWTAccessToken:            authTokens.AccessToken,
+			JWTRefreshToken:           authTokens.RefreshToken,
+			ExpirationAccessDateTime:  authTokens.ExpirationAccessDateTime,
+			ExpirationRefreshDateTime: authTokens.ExpirationRefreshDateTime,
+		},
+	}
+
+	c.Logger.Info("Two-factor verification successful", zap.Int("userID", domainUser.ID))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AuthController) EnrollTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Two-factor enrollment request", zap.Int("userID", userID))
+	enrollment, err := c.authUseCase.EnrollTwoFactor(userID)
+	if err != nil {
+		c.Logger.Error("Two-factor enrollment failed", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, TwoFactorEnrollmentResponse{Secret: enrollment.Secret, OtpauthURI: enrollment.ProvisioningURI})
+}
+
+func (c *AuthController) ConfirmTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Two-factor confirmation request", zap.Int("userID", userID))
+	var request TwoFactorCodeRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for two-factor confirmation", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	recoveryCodes, err := c.authUseCase.ConfirmTwoFactor(userID, request.Code)
+	if err != nil {
+		c.Logger.Error("Two-factor confirmation failed", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Two-factor authentication enabled", zap.Int("userID", userID))
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (c *AuthController) DisableTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Two-factor disable request", zap.Int("userID", userID))
+	var request TwoFactorCodeRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for two-factor disable", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	if err := c.authUseCase.DisableTwoFactor(userID, request.Code); err != nil {
+		c.Logger.Error("Two-factor disable failed", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (c *AuthController) EnrollTwoFactorWithChallenge(ctx *gin.Context) {
+	c.Logger.Info("Required two-factor enrollment request")
+	var request TwoFactorEnrollmentRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for two-factor enrollment", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	enrollment, err := c.authUseCase.EnrollTwoFactorWithChallenge(request.ChallengeToken)
+	if err != nil {
+		c.Logger.Error("Required two-factor enrollment failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, TwoFactorEnrollmentResponse{Secret: enrollment.Secret, OtpauthURI: enrollment.ProvisioningURI})
+}
+
+func (c *AuthController) ConfirmTwoFactorWithChallenge(ctx *gin.Context) {
+	c.Logger.Info("Required two-factor confirmation request")
+	var request TwoFactorVerifyRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for two-factor confirmation", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	recoveryCodes, err := c.authUseCase.ConfirmTwoFactorWithChallenge(request.ChallengeToken, request.Code)
+	if err != nil {
+		c.Logger.Error("Required two-factor confirmation failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func toTwoFactorChallengeResponse(authTokens *useCaseAuth.AuthTokens) TwoFactorChallengeResponse {
+	return TwoFactorChallengeResponse{
+		TwoFactor: TwoFactorChallengeData{
+			Required:           true,
+			Type:               authTokens.ChallengeType,
+			ChallengeToken:     authTokens.ChallengeToken,
+			ExpirationDateTime: authTokens.ExpirationChallengeDateTime,
+		},
+	}
+}
+
+func arrayLoginAttemptToResponseMapper(attempts *[]domainLoginAttempt.LoginAttempt) *[]LoginAttemptResponse {
+	res := make([]LoginAttemptResponse, len(*attempts))
+	for i, a := range *attempts {
+		res[i] = LoginAttemptResponse{
+			ID:        a.ID,
+			UserID:    a.UserID,
+			Email:     a.Email,
+			IP:        a.IP,
+			Success:   a.Success,
+			Reason:    string(a.Reason),
+			CreatedAt: a.CreatedAt,
+		}
+	}
+	return &res
+}
+
 func toUsecaseMapper(req *RegisterRequest) *domainUser.User {
 	return &domainUser.User{
 		UserName:  req.UserName,
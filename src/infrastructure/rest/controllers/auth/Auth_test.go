@@ -3,14 +3,18 @@ package auth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	useCaseAuth "github.com/gbrayhan/microservices-go/src/application/usecases/auth"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	loginAttemptDomain "github.com/gbrayhan/microservices-go/src/domain/loginattempt"
 	userDomain "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,15 +23,65 @@ type MockAuthUseCase struct {
 	loginFunc                func(string, string) (*userDomain.User, *useCaseAuth.AuthTokens, error)
 	accessTokenByRefreshFunc func(string) (*userDomain.User, *useCaseAuth.AuthTokens, error)
 	registerFunc             func(*userDomain.User) (*userDomain.User, error)
+	unlockAccountFunc        func(int, int) error
+	getLoginAttemptsFunc     func(int, loginAttemptDomain.Filter) (*[]loginAttemptDomain.LoginAttempt, error)
+	verifyTwoFactorFunc      func(string, string) (*userDomain.User, *useCaseAuth.AuthTokens, error)
+	confirmTwoFactorFunc     func(int, string) ([]string, error)
+	clientIPs                []string
 }
 
-func (m *MockAuthUseCase) Login(email, password string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+func (m *MockAuthUseCase) Login(email, password, clientIP string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+	m.clientIPs = append(m.clientIPs, clientIP)
 	if m.loginFunc != nil {
 		return m.loginFunc(email, password)
 	}
 	return nil, nil, nil
 }
 
+func (m *MockAuthUseCase) UnlockAccount(adminID int, userID int) error {
+	if m.unlockAccountFunc != nil {
+		return m.unlockAccountFunc(adminID, userID)
+	}
+	return nil
+}
+
+func (m *MockAuthUseCase) VerifyTwoFactor(challengeToken, code, clientIP string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+	if m.verifyTwoFactorFunc != nil {
+		return m.verifyTwoFactorFunc(challengeToken, code)
+	}
+	return nil, nil, nil
+}
+
+func (m *MockAuthUseCase) EnrollTwoFactor(userID int) (*useCaseAuth.TwoFactorEnrollment, error) {
+	return &useCaseAuth.TwoFactorEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/test"}, nil
+}
+
+func (m *MockAuthUseCase) ConfirmTwoFactor(userID int, code string) ([]string, error) {
+	if m.confirmTwoFactorFunc != nil {
+		return m.confirmTwoFactorFunc(userID, code)
+	}
+	return []string{}, nil
+}
+
+func (m *MockAuthUseCase) DisableTwoFactor(userID int, code string) error {
+	return nil
+}
+
+func (m *MockAuthUseCase) EnrollTwoFactorWithChallenge(enrollmentToken string) (*useCaseAuth.TwoFactorEnrollment, error) {
+	return &useCaseAuth.TwoFactorEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/test"}, nil
+}
+
+func (m *MockAuthUseCase) ConfirmTwoFactorWithChallenge(enrollmentToken, code string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (m *MockAuthUseCase) GetLoginAttempts(adminID int, filter loginAttemptDomain.Filter) (*[]loginAttemptDomain.LoginAttempt, error) {
+	if m.getLoginAttemptsFunc != nil {
+		return m.getLoginAttemptsFunc(adminID, filter)
+	}
+	return &[]loginAttemptDomain.LoginAttempt{}, nil
+}
+
 func (m *MockAuthUseCase) Register(data *userDomain.User) (*userDomain.User, error) {
 	if m.registerFunc != nil {
 		return m.registerFunc(data)
@@ -115,6 +169,107 @@ func TestAuthController_Login_Success(t *testing.T) {
 	}
 }
 
+func TestAuthController_Login_IgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRUSTED_PROXIES", "")
+
+	failures := map[string]int{}
+	mockUseCase := &MockAuthUseCase{}
+	mockUseCase.loginFunc = func(email, password string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+		failures[mockUseCase.clientIPs[len(mockUseCase.clientIPs)-1]]++
+		return nil, nil, domainErrors.NewAppError(errors.New("email or password does not match"), domainErrors.NotAuthenticated)
+	}
+	router := gin.New()
+	if err := router.SetTrustedProxies(middlewares.TrustedProxies()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router.Use(middlewares.ErrorHandler())
+	router.POST("/login", NewAuthController(mockUseCase, setupLogger(t)).Login)
+
+	for _, spoofed := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"email":"test@example.com","password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", spoofed)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(failures) != 1 || failures["192.0.2.1"] != 3 {
+		t.Errorf("expected every failure to count against the peer address, got %v", failures)
+	}
+}
+
+func TestAuthController_Login_TwoFactorChallenge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUseCase := &MockAuthUseCase{
+		loginFunc: func(email, password string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+			return &userDomain.User{ID: 1, Email: email}, &useCaseAuth.AuthTokens{
+				ChallengeToken:              "challenge-token",
+				ChallengeType:               "2fa_challenge",
+				ExpirationChallengeDateTime: time.Now().Add(5 * time.Minute),
+			}, nil
+		},
+	}
+	controller := NewAuthController(mockUseCase, setupLogger(t))
+
+	requestBody, _ := json.Marshal(LoginRequest{Email: "admin@example.com", Password: "password123"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Login(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response TwoFactorChallengeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.TwoFactor.Required || response.TwoFactor.ChallengeToken != "challenge-token" {
+		t.Errorf("Expected a two-factor challenge, got %+v", response.TwoFactor)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("jwtAccessToken")) {
+		t.Error("Challenge response must not contain access tokens")
+	}
+}
+
+func TestAuthController_VerifyTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUseCase := &MockAuthUseCase{
+		verifyTwoFactorFunc: func(challengeToken, code string) (*userDomain.User, *useCaseAuth.AuthTokens, error) {
+			if challengeToken != "challenge-token" || code != "123456" {
+				t.Errorf("Unexpected verification input %q %q", challengeToken, code)
+			}
+			return &userDomain.User{ID: 1}, &useCaseAuth.AuthTokens{AccessToken: "test-access-token", RefreshToken: "test-refresh-token"}, nil
+		},
+	}
+	controller := NewAuthController(mockUseCase, setupLogger(t))
+
+	requestBody, _ := json.Marshal(TwoFactorVerifyRequest{ChallengeToken: "challenge-token", Code: "123456"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/2fa/verify", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.VerifyTwoFactor(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Security.JWTAccessToken != "test-access-token" {
+		t.Errorf("Expected access token in response, got %+v", response.Security)
+	}
+}
+
 func TestAuthController_Login_InvalidRequest(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
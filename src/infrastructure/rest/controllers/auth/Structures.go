@@ -48,3 +48,46 @@ type LoginResponse struct {
 	Data     UserData     `json:"data"`
 	Security SecurityData `json:"security"`
 }
+
+type LoginAttemptResponse struct {
+	ID        int       `json:"id"`
+	UserID    *int      `json:"userId,omitempty"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type TwoFactorChallengeData struct {
+	Required           bool      `json:"required"`
+	Type               string    `json:"type"`
+	ChallengeToken     string    `json:"challengeToken"`
+	ExpirationDateTime time.Time `json:"expirationDateTime"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactor TwoFactorChallengeData `json:"twoFactor"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorEnrollmentRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -1,14 +1,20 @@
 package currency
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/gbrayhan/microservices-go/src/domain"
 	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/currency"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/export"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -31,11 +37,82 @@ type ResponseUser struct {
 	UpdatedAt time.Time `json:"updatedAt,omitempty"`
 }
 
+type OHLCResponse struct {
+	Start   time.Time `json:"start"`
+	Open    float64   `json:"open"`
+	High    float64   `json:"high"`
+	Low     float64   `json:"low"`
+	Close   float64   `json:"close"`
+	Samples int       `json:"samples"`
+}
+
+type RateStatsResponse struct {
+	Code          string    `json:"code"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Samples       int       `json:"samples"`
+	Min           float64   `json:"min"`
+	Max           float64   `json:"max"`
+	Mean          float64   `json:"mean"`
+	Volatility    float64   `json:"volatility"`
+	First         float64   `json:"first"`
+	Last          float64   `json:"last"`
+	ChangePercent float64   `json:"changePercent"`
+}
+
+type ConversionLegResponse struct {
+	Code       string     `json:"code"`
+	Rate       float64    `json:"rate"`
+	RecordedAt *time.Time `json:"recordedAt,omitempty"`
+}
+
+type ConversionResponse struct {
+	From   ConversionLegResponse `json:"from"`
+	To     ConversionLegResponse `json:"to"`
+	Amount float64               `json:"amount"`
+	Rate   float64               `json:"rate"`
+	Result float64               `json:"result"`
+	At     *time.Time            `json:"at,omitempty"`
+}
+
+type ConvertBatchItemRequest struct {
+	ID     json.RawMessage `json:"id"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount float64         `json:"amount"`
+	At     string          `json:"at"`
+}
+
+// ConvertBatchItemResponse echoes the item id with either the conversion or its error
+type ConvertBatchItemResponse struct {
+	ID json.RawMessage `json:"id,omitempty"`
+	*ConversionResponse
+	Error string `json:"error,omitempty"`
+}
+
+type ConvertBatchResponse struct {
+	SnapshotAt time.Time                  `json:"snapshotAt"`
+	Succeeded  int                        `json:"succeeded"`
+	Failed     int                        `json:"failed"`
+	Results    []ConvertBatchItemResponse `json:"results"`
+}
+
+// maxBatchBodySize bounds the body of a batch conversion
+const maxBatchBodySize = 1 << 20
+
 type ICurrencyController interface {
 	GetAllCurrencies(ctx *gin.Context)
 	GetCurrenciesByID(ctx *gin.Context)
 	DeleteCurrency(ctx *gin.Context)
 	UpdateExchanges(ctx *gin.Context)
+	GetOHLC(ctx *gin.Context)
+	GetStats(ctx *gin.Context)
+	Convert(ctx *gin.Context)
+	ConvertBatch(ctx *gin.Context)
+	SearchPaginated(ctx *gin.Context)
+	SearchByProperty(ctx *gin.Context)
+	ExportHistory(ctx *gin.Context)
+	Import(ctx *gin.Context)
 }
 
 type CurrencyController struct {
@@ -48,6 +125,15 @@ func NewCurrencyController(currencyService domainCurrency.ICurrencyService, logg
 }
 
 func (c *CurrencyController) GetAllCurrencies(ctx *gin.Context) {
+	options, err := export.Negotiate(ctx, export.FormatJSON)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if options != nil {
+		c.exportCurrencies(ctx, options, "currencies", domain.DataFilters{SortBy: []string{"code"}, SortDirection: domain.SortAsc})
+		return
+	}
 	c.Logger.Info("Getting all users")
 	users, err := c.currencyService.GetAll()
 	if err != nil {
@@ -112,6 +198,306 @@ func (c *CurrencyController) DeleteCurrency(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
 }
 
+// GetOHLC returns open/high/low/close buckets of a currency. The code is read from the id segment,
+// which gin requires to share its name with /currency/:id.
+func (c *CurrencyController) GetOHLC(ctx *gin.Context) {
+	from, to, err := parsePeriod(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	options, err := export.Negotiate(ctx, export.FormatJSON)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	code := ctx.Param("id")
+	buckets, err := c.currencyService.GetOHLC(code, domainCurrency.Interval(ctx.Query("interval")), from, to)
+	if err != nil {
+		c.Logger.Error("Error getting OHLC", zap.Error(err), zap.String("code", code))
+		_ = ctx.Error(err)
+		return
+	}
+	if options != nil {
+		err := export.Stream(ctx, options, code+"-ohlc", ohlcExportHeader, func(write func(values ...any) error) error {
+			for _, b := range *buckets {
+				if err := write(b.Start, b.Open, b.High, b.Low, b.Close, b.Samples); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		c.finishExport(ctx, code+"-ohlc", err)
+		return
+	}
+	res := make([]OHLCResponse, len(*buckets))
+	for i, b := range *buckets {
+		res[i] = OHLCResponse{Start: b.Start, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Samples: b.Samples}
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExportHistory streams every rate of a currency recorded in a period as CSV, or XLSX when asked
+func (c *CurrencyController) ExportHistory(ctx *gin.Context) {
+	from, to, err := parsePeriod(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	options, err := export.Negotiate(ctx, export.FormatCSV)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	code := ctx.Param("id")
+	name := code + "-history"
+	err = export.Stream(ctx, options, name, historyExportHeader, func(write func(values ...any) error) error {
+		return c.currencyService.StreamHistory(code, from, to, func(point *domainCurrency.RatePoint) error {
+			return write(point.RecordedAt, point.Code, point.Rate, point.Source)
+		})
+	})
+	c.finishExport(ctx, name, err)
+}
+
+// GetStats returns min, max, mean, volatility and percent change of a currency over a period
+func (c *CurrencyController) GetStats(ctx *gin.Context) {
+	from, to, err := parsePeriod(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	code := ctx.Param("id")
+	stats, err := c.currencyService.GetStats(code, from, to)
+	if err != nil {
+		c.Logger.Error("Error getting rate statistics", zap.Error(err), zap.String("code", code))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, RateStatsResponse{
+		Code:          stats.Code,
+		From:          stats.From,
+		To:            stats.To,
+		Samples:       stats.Samples,
+		Min:           stats.Min,
+		Max:           stats.Max,
+		Mean:          stats.Mean,
+		Volatility:    stats.Volatility,
+		First:         stats.First,
+		Last:          stats.Last,
+		ChangePercent: stats.ChangePercent,
+	})
+}
+
+// Convert converts amount of one currency into another, at the current rates or, with at=, at the
+// last rates recorded at or before that time
+func (c *CurrencyController) Convert(ctx *gin.Context) {
+	amount, err := strconv.ParseFloat(ctx.Query("amount"), 64)
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("amount must be a number"), domainErrors.ValidationError))
+		return
+	}
+	at, err := parseAt(ctx.Query("at"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	conversion, err := c.currencyService.Convert(ctx.Query("from"), ctx.Query("to"), amount, at)
+	if err != nil {
+		c.Logger.Warn("Error converting currency", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, conversionToResponseMapper(conversion))
+}
+
+// ConvertBatch converts a JSON array of items against one rate snapshot. Items that cannot be
+// converted carry an error in their result; the rest of the batch is unaffected.
+func (c *CurrencyController) ConvertBatch(ctx *gin.Context) {
+	// batches outgrow the buffer of controllers.BindJSON, so the body is decoded directly
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBatchBodySize)
+	var items []ConvertBatchItemRequest
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&items); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("the body must be a JSON array of conversion items"), domainErrors.ValidationError))
+		return
+	}
+	// checked here too, as items with an unreadable at never reach the use case
+	if len(items) > domainCurrency.MaxBatchConversions {
+		_ = ctx.Error(domainErrors.NewAppError(fmt.Errorf("a batch may hold at most %d items", domainCurrency.MaxBatchConversions), domainErrors.ValidationError))
+		return
+	}
+
+	results := make([]ConvertBatchItemResponse, len(items))
+	requests := make([]domainCurrency.ConversionRequest, 0, len(items))
+	positions := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].ID = item.ID
+		at, err := parseAt(item.At)
+		if err != nil {
+			results[i].Error = errorMessage(err)
+			continue
+		}
+		requests = append(requests, domainCurrency.ConversionRequest{From: item.From, To: item.To, Amount: item.Amount, At: at})
+		positions = append(positions, i)
+	}
+	if len(requests) == 0 && len(items) > 0 {
+		ctx.JSON(http.StatusOK, ConvertBatchResponse{SnapshotAt: time.Now(), Failed: len(items), Results: results})
+		return
+	}
+
+	batch, err := c.currencyService.ConvertBatch(requests)
+	if err != nil {
+		c.Logger.Warn("Error converting currency batch", zap.Error(err), zap.Int("items", len(items)))
+		_ = ctx.Error(err)
+		return
+	}
+	for j, result := range batch.Results {
+		if result.Err != nil {
+			results[positions[j]].Error = errorMessage(result.Err)
+			continue
+		}
+		results[positions[j]].ConversionResponse = conversionToResponseMapper(result.Conversion)
+	}
+	response := ConvertBatchResponse{SnapshotAt: batch.SnapshotAt, Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// searchProperties are the properties SearchByProperty may look into
+var searchProperties = map[string]bool{
+	"name": true,
+	"code": true,
+}
+
+func (c *CurrencyController) SearchPaginated(ctx *gin.Context) {
+	options, err := export.Negotiate(ctx, export.FormatJSON)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	filters := controllers.ParseDataFilters(ctx, currency.ColumnsUserMapping)
+	if options != nil {
+		c.exportCurrencies(ctx, options, "currencies", filters)
+		return
+	}
+	c.Logger.Info("Searching currencies with pagination")
+
+	result, err := c.currencyService.SearchPaginated(filters)
+	if err != nil {
+		c.Logger.Error("Error searching currencies", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := controllers.SearchPageResponse(ctx, filters, arrayDomainToResponseMapper(result.Data),
+		result.Total, result.Page, result.PageSize, result.TotalPages, result.NextCursor, result.PrevCursor)
+
+	c.Logger.Info("Successfully searched currencies",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *CurrencyController) SearchByProperty(ctx *gin.Context) {
+	property, searchText, err := controllers.ParseSearchProperty(ctx, searchProperties)
+	if err != nil {
+		c.Logger.Error("Invalid property search", zap.Error(err), zap.String("property", ctx.Query("property")))
+		_ = ctx.Error(err)
+		return
+	}
+
+	coincidences, err := c.currencyService.SearchByProperty(property, searchText)
+	if err != nil {
+		c.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
+		_ = ctx.Error(err)
+		return
+	}
+
+	c.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(*coincidences)))
+	ctx.JSON(http.StatusOK, coincidences)
+}
+
+// errorMessage is what a client would see for err as a response of its own
+// Column headers of the spreadsheet exports
+var (
+	currencyExportHeader = []string{"id", "name", "code", "rate", "status", "createdAt", "updatedAt"}
+	ohlcExportHeader     = []string{"start", "open", "high", "low", "close", "samples"}
+	historyExportHeader  = []string{"recordedAt", "code", "rate", "source"}
+)
+
+// exportCurrencies streams every currency matching filters, whatever page they ask for
+func (c *CurrencyController) exportCurrencies(ctx *gin.Context, options *export.Options, name string, filters domain.DataFilters) {
+	err := export.Stream(ctx, options, name, currencyExportHeader, func(write func(values ...any) error) error {
+		return c.currencyService.StreamSearch(filters, func(currency *domainCurrency.Currency) error {
+			return write(currency.ID, currency.Name, currency.Code, currency.Rate, currency.Status, currency.CreatedAt, currency.UpdatedAt)
+		})
+	})
+	c.finishExport(ctx, name, err)
+}
+
+// finishExport reports an export error through the error handler while nothing has been sent;
+// once the file has started, it can only be cut short
+func (c *CurrencyController) finishExport(ctx *gin.Context, name string, err error) {
+	if err == nil {
+		c.Logger.Info("Export completed", zap.String("export", name))
+		return
+	}
+	c.Logger.Error("Error exporting", zap.Error(err), zap.String("export", name))
+	if !ctx.Writer.Written() {
+		_ = ctx.Error(err)
+	}
+}
+
+func errorMessage(err error) string {
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) {
+		_, message := domainErrors.AppErrorToHTTP(appErr)
+		return message
+	}
+	return "Internal Server Error"
+}
+
+// parseAt reads an RFC 3339 time or a bare date, which stands for the end of that day (UTC) so
+// the rate valid on the date is used
+func parseAt(raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, domainErrors.NewAppError(errors.New("at must be an RFC 3339 time or a YYYY-MM-DD date"), domainErrors.ValidationError)
+	}
+	endOfDay := day.AddDate(0, 0, 1).Add(-time.Microsecond)
+	return &endOfDay, nil
+}
+
+// parsePeriod reads the optional RFC 3339 from and to query parameters
+func parsePeriod(ctx *gin.Context) (*time.Time, *time.Time, error) {
+	var period [2]*time.Time
+	for i, name := range []string{"from", "to"} {
+		raw := ctx.Query(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, nil, domainErrors.NewAppError(fmt.Errorf("%s must be an RFC 3339 time", name), domainErrors.ValidationError)
+		}
+		period[i] = &t
+	}
+	return period[0], period[1], nil
+}
+
 // Mappers
 func domainToResponseMapper(domainUser *domainCurrency.Currency) *ResponseUser {
 	return &ResponseUser{
@@ -133,6 +519,17 @@ func arrayDomainToResponseMapper(users *[]domainCurrency.Currency) *[]ResponseUs
 	return &res
 }
 
+func conversionToResponseMapper(conversion *domainCurrency.Conversion) *ConversionResponse {
+	return &ConversionResponse{
+		From:   ConversionLegResponse{Code: conversion.From.Code, Rate: conversion.From.Rate, RecordedAt: conversion.From.RecordedAt},
+		To:     ConversionLegResponse{Code: conversion.To.Code, Rate: conversion.To.Rate, RecordedAt: conversion.To.RecordedAt},
+		Amount: conversion.Amount,
+		Rate:   conversion.Rate,
+		Result: conversion.Result,
+		At:     conversion.At,
+	}
+}
+
 func toUsecaseMapper(req *NewCurrencyRequest) *domainCurrency.Currency {
 	return &domainCurrency.Currency{
 		Name:   req.Name,
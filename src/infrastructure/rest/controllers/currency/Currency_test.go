@@ -0,0 +1,354 @@
+package currency
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrayhan/microservices-go/src/domain"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// MockCurrencyService implements ICurrencyService for testing
+type MockCurrencyService struct {
+	batchRequests []domainCurrency.ConversionRequest
+	searchFilters domain.DataFilters
+	importRows    []domainCurrency.ImportRow
+}
+
+func (m *MockCurrencyService) GetAll() (*[]domainCurrency.Currency, error) { return nil, nil }
+func (m *MockCurrencyService) GetByID(id int) (*domainCurrency.Currency, error) {
+	return nil, nil
+}
+func (m *MockCurrencyService) Delete(id int) error           { return nil }
+func (m *MockCurrencyService) UpdateExchanges() (any, error) { return nil, nil }
+func (m *MockCurrencyService) GetOHLC(code string, interval domainCurrency.Interval, from, to *time.Time) (*[]domainCurrency.OHLC, error) {
+	return &[]domainCurrency.OHLC{}, nil
+}
+func (m *MockCurrencyService) GetStats(code string, from, to *time.Time) (*domainCurrency.RateStats, error) {
+	return &domainCurrency.RateStats{Code: code}, nil
+}
+func (m *MockCurrencyService) Convert(from, to string, amount float64, at *time.Time) (*domainCurrency.Conversion, error) {
+	return &domainCurrency.Conversion{From: domainCurrency.ConversionLeg{Code: from}, To: domainCurrency.ConversionLeg{Code: to}, Amount: amount, At: at}, nil
+}
+
+// ConvertBatch converts every item at a rate of 2 and rejects non-positive amounts
+func (m *MockCurrencyService) ConvertBatch(items []domainCurrency.ConversionRequest) (*domainCurrency.BatchConversion, error) {
+	m.batchRequests = items
+	batch := &domainCurrency.BatchConversion{SnapshotAt: time.Now(), Results: make([]domainCurrency.ConversionResult, len(items))}
+	for i, item := range items {
+		if item.Amount <= 0 {
+			batch.Results[i].Err = domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+			continue
+		}
+		batch.Results[i].Conversion = &domainCurrency.Conversion{Amount: item.Amount, Rate: 2, Result: item.Amount * 2, At: item.At}
+	}
+	return batch, nil
+}
+
+func (m *MockCurrencyService) SearchPaginated(filters domain.DataFilters) (*domainCurrency.SearchResultCurrency, error) {
+	m.searchFilters = filters
+	data := []domainCurrency.Currency{{ID: 1, Name: "Euro", Code: "EUR"}}
+	if filters.Pagination == domain.PaginationCursor {
+		return &domainCurrency.SearchResultCurrency{Data: &data, PageSize: filters.PageSize, NextCursor: "next.sig"}, nil
+	}
+	return &domainCurrency.SearchResultCurrency{Data: &data, Total: 1, Page: filters.Page, PageSize: filters.PageSize, TotalPages: 1}, nil
+}
+func (m *MockCurrencyService) SearchByProperty(property string, searchText string) (*[]string, error) {
+	return &[]string{"EUR"}, nil
+}
+func (m *MockCurrencyService) StreamSearch(filters domain.DataFilters, fn func(*domainCurrency.Currency) error) error {
+	return fn(&domainCurrency.Currency{ID: 1, Name: "Euro", Code: "EUR", Rate: 0.5})
+}
+
+// StreamHistory streams two rates, or fails before the first one for an unknown code
+func (m *MockCurrencyService) StreamHistory(code string, from, to *time.Time, fn func(*domainCurrency.RatePoint) error) error {
+	if code != "EUR" {
+		return domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+	recordedAt := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	for _, rate := range []float64{0.91, 0.925} {
+		if err := fn(&domainCurrency.RatePoint{Code: code, Rate: rate, Source: domainCurrency.RateSourceProvider, RecordedAt: recordedAt}); err != nil {
+			return err
+		}
+		recordedAt = recordedAt.Add(time.Hour)
+	}
+	return nil
+}
+
+// Import updates every row and rejects the code XXX
+func (m *MockCurrencyService) Import(rows []domainCurrency.ImportRow, dryRun bool) (*domainCurrency.ImportReport, error) {
+	m.importRows = rows
+	report := &domainCurrency.ImportReport{DryRun: dryRun, Source: domainCurrency.RateSourceManualImport}
+	for _, row := range rows {
+		change := domainCurrency.ImportChange{Line: row.Line, Code: row.Code, Action: domainCurrency.ImportUpdate, Rate: row.Rate}
+		if row.Code == "XXX" {
+			change.Action, change.Err = "", domainErrors.NewAppError(errors.New("unknown currency"), domainErrors.ValidationError)
+			report.Failed++
+		} else {
+			report.Updated++
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	report.Applied = !dryRun && report.Failed == 0
+	return report, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func setupContext(method string, url string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+func TestCurrencyController_ConvertBatch(t *testing.T) {
+	service := &MockCurrencyService{}
+	controller := NewCurrencyController(service, setupLogger(t))
+	c, w := setupContext(http.MethodPost, "/v1/convert/batch", `[
+		{"id": "line-1", "from": "EUR", "to": "USD", "amount": 10},
+		{"id": 2, "from": "EUR", "to": "USD", "amount": 5, "at": "yesterday"},
+		{"id": 3, "from": "EUR", "to": "USD", "amount": 0},
+		{"id": 4, "from": "EUR", "to": "USD", "amount": 1, "at": "2026-03-31"}
+	]`)
+
+	controller.ConvertBatch(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Results   []struct {
+			ID     json.RawMessage `json:"id"`
+			Result *float64        `json:"result"`
+			At     *time.Time      `json:"at"`
+			Error  string          `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Succeeded != 2 || response.Failed != 2 || len(response.Results) != 4 {
+		t.Fatalf("unexpected summary %+v", response)
+	}
+	if string(response.Results[0].ID) != `"line-1"` || response.Results[0].Result == nil || *response.Results[0].Result != 20 {
+		t.Errorf("unexpected first result %+v", response.Results[0])
+	}
+	if string(response.Results[1].ID) != "2" || response.Results[1].Error == "" || response.Results[1].Result != nil {
+		t.Errorf("expected an error for an unreadable at, got %+v", response.Results[1])
+	}
+	if response.Results[2].Error == "" {
+		t.Errorf("expected the use case error to be reported, got %+v", response.Results[2])
+	}
+	endOfDay := time.Date(2026, 3, 31, 23, 59, 59, 999999000, time.UTC)
+	if response.Results[3].At == nil || !response.Results[3].At.Equal(endOfDay) {
+		t.Errorf("expected a date to stand for the end of that day, got %+v", response.Results[3])
+	}
+	if len(service.batchRequests) != 3 {
+		t.Errorf("expected only items with a readable at to reach the use case, got %d", len(service.batchRequests))
+	}
+}
+
+func TestCurrencyController_ConvertBatchRejectsInvalidBodies(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	items := make([]map[string]any, domainCurrency.MaxBatchConversions+1)
+	oversized, _ := json.Marshal(items)
+
+	for _, body := range []string{`{"from": "EUR"}`, string(oversized)} {
+		c, _ := setupContext(http.MethodPost, "/v1/convert/batch", body)
+		controller.ConvertBatch(c)
+		if len(c.Errors) != 1 {
+			t.Fatalf("expected an error for %.30s", body)
+		}
+		appErr, ok := c.Errors.Last().Err.(*domainErrors.AppError)
+		if !ok || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error, got %v", c.Errors.Last().Err)
+		}
+	}
+}
+
+func TestCurrencyController_SearchPaginated(t *testing.T) {
+	service := &MockCurrencyService{}
+	controller := NewCurrencyController(service, setupLogger(t))
+	c, w := setupContext(http.MethodGet, "/v1/currency/search?name_like=eur&code_match=EUR&sortBy=code&sortDirection=desc&page=2&pageSize=5", "")
+
+	controller.SearchPaginated(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	filters := service.searchFilters
+	if filters.Page != 2 || filters.PageSize != 5 || filters.SortDirection != domain.SortDesc {
+		t.Errorf("unexpected paging %+v", filters)
+	}
+	if filters.LikeFilters["name"][0] != "eur" || filters.Matches["code"][0] != "EUR" || filters.SortBy[0] != "code" {
+		t.Errorf("unexpected filters %+v", filters)
+	}
+	var response struct {
+		Data  []ResponseUser `json:"data"`
+		Total int64          `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Total != 1 || len(response.Data) != 1 {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestCurrencyController_SearchPaginatedWithCursor(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	c, w := setupContext(http.MethodGet, "/v1/currency/search?pagination=cursor&code_match=EUR&page=3", "")
+
+	controller.SearchPaginated(c)
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response["next"] != "/v1/currency/search?code_match=EUR&cursor=next.sig&pagination=cursor" {
+		t.Errorf("unexpected next link %v", response["next"])
+	}
+	if prev, ok := response["prev"]; !ok || prev != nil {
+		t.Errorf("expected a null prev link, got %v", prev)
+	}
+	if _, ok := response["total"]; ok {
+		t.Errorf("cursor pages should not report a total: %s", w.Body.String())
+	}
+}
+
+func TestCurrencyController_SearchByProperty(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	c, w := setupContext(http.MethodGet, "/v1/currency/search-property?property=code&searchText=E", "")
+	controller.SearchByProperty(c)
+	if w.Code != http.StatusOK || w.Body.String() != `["EUR"]` {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	for _, query := range []string{"property=rate&searchText=1", "property=code"} {
+		c, _ := setupContext(http.MethodGet, "/v1/currency/search-property?"+query, "")
+		controller.SearchByProperty(c)
+		appErr, ok := c.Errors.Last().Err.(*domainErrors.AppError)
+		if !ok || appErr.Type != domainErrors.ValidationError {
+			t.Errorf("expected a validation error for %s, got %v", query, c.Errors)
+		}
+	}
+}
+
+func TestCurrencyController_ExportHistory(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	c, w := setupContext(http.MethodGet, "/v1/currency/EUR/history?locale=de-DE", "")
+	c.Params = gin.Params{{Key: "id", Value: "EUR"}}
+
+	controller.ExportHistory(c)
+
+	expected := "recordedAt;code;rate;source\n2026-04-01T12:00:00Z;EUR;0,91;provider\n2026-04-01T13:00:00Z;EUR;0,925;provider\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected export %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Disposition") != `attachment; filename="EUR-history.csv"` {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	c, w = setupContext(http.MethodGet, "/v1/currency/EURO/history", "")
+	c.Params = gin.Params{{Key: "id", Value: "EURO"}}
+	controller.ExportHistory(c)
+	if len(c.Errors) != 1 || w.Body.Len() != 0 || w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected the error to be left to the error handler, got %q %v", w.Body.String(), c.Errors)
+	}
+}
+
+func TestCurrencyController_GetAllCurrenciesExport(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	c, w := setupContext(http.MethodGet, "/v1/currency/", "")
+	c.Request.Header.Set("Accept", "text/csv")
+
+	controller.GetAllCurrencies(c)
+
+	if !strings.HasPrefix(w.Body.String(), "id,name,code,rate,status,createdAt,updatedAt\n1,Euro,EUR,0.5,false,") {
+		t.Errorf("unexpected export %q", w.Body.String())
+	}
+}
+
+func TestCurrencyController_ImportCSV(t *testing.T) {
+	service := &MockCurrencyService{}
+	controller := NewCurrencyController(service, setupLogger(t))
+	c, w := setupContext(http.MethodPost, "/v1/currency/import?dryRun=true", "\ufeffCode;Name;Rate\nEUR;Euro;0,92\n\nCHF;;0,88\n")
+	c.Request.Header.Set("Content-Type", "text/csv")
+
+	controller.Import(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	expected := []domainCurrency.ImportRow{{Line: 2, Code: "EUR", Name: "Euro", Rate: 0.92}, {Line: 4, Code: "CHF", Rate: 0.88}}
+	if len(service.importRows) != 2 || service.importRows[0] != expected[0] || service.importRows[1] != expected[1] {
+		t.Errorf("unexpected rows %+v", service.importRows)
+	}
+	var response ImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !response.DryRun || response.Applied || response.Updated != 2 || response.Source != "manual-import" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestCurrencyController_ImportMultipartJSON(t *testing.T) {
+	service := &MockCurrencyService{}
+	controller := NewCurrencyController(service, setupLogger(t))
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "rates.json")
+	_, _ = part.Write([]byte(`[{"code": "EUR", "rate": 0.92}, {"code": "XXX", "rate": 1}]`))
+	_ = form.Close()
+	c, w := setupContext(http.MethodPost, "/v1/currency/import", body.String())
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+
+	controller.Import(c)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var response ImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Applied || response.Failed != 1 || response.Changes[1].Line != 2 || response.Changes[1].Error == "" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestCurrencyController_ImportRejectsInvalidFiles(t *testing.T) {
+	controller := NewCurrencyController(&MockCurrencyService{}, setupLogger(t))
+	for name, tc := range map[string]struct{ contentType, body string }{
+		"unknown type":   {"application/xml", "<rates/>"},
+		"no rate column": {"text/csv", "code,name\nEUR,Euro\n"},
+		"bad rate":       {"text/csv", "code,rate\nEUR,n/a\n"},
+		"bad json":       {"application/json", `{"code": "EUR"}`},
+	} {
+		c, _ := setupContext(http.MethodPost, "/v1/currency/import", tc.body)
+		c.Request.Header.Set("Content-Type", tc.contentType)
+		controller.Import(c)
+		if len(c.Errors) != 1 {
+			t.Errorf("%s: expected an error, got %v", name, c.Errors)
+		}
+	}
+}
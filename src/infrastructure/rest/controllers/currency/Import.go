@@ -0,0 +1,246 @@
+package currency
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxImportBodySize bounds an import file, which comfortably fits MaxImportRows rows
+const maxImportBodySize = 2 << 20
+
+type ImportRowRequest struct {
+	Code string  `json:"code"`
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"`
+}
+
+type ImportChangeResponse struct {
+	Line    int      `json:"line"`
+	Code    string   `json:"code"`
+	Action  string   `json:"action,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	OldRate *float64 `json:"oldRate,omitempty"`
+	Rate    float64  `json:"rate"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type ImportResponse struct {
+	DryRun    bool                   `json:"dryRun"`
+	Applied   bool                   `json:"applied"`
+	Source    string                 `json:"source"`
+	Created   int                    `json:"created"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Failed    int                    `json:"failed"`
+	Changes   []ImportChangeResponse `json:"changes"`
+}
+
+// Import loads currencies and their rates from a CSV or JSON file, sent as the file field of a
+// multipart form or as the body itself. With dryRun=true it only reports what would change; an
+// import with a rejected row changes nothing and answers 422 with the report.
+func (c *CurrencyController) Import(ctx *gin.Context) {
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("dryRun must be true or false"), domainErrors.ValidationError))
+		return
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBodySize)
+	rows, err := readImport(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	report, err := c.currencyService.Import(rows, dryRun)
+	if err != nil {
+		c.Logger.Error("Error importing currencies", zap.Error(err), zap.Int("rows", len(rows)))
+		_ = ctx.Error(err)
+		return
+	}
+	status := http.StatusOK
+	if !report.DryRun && report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.Logger.Info("Currency import processed", zap.Bool("dryRun", dryRun), zap.Bool("applied", report.Applied), zap.Int("rows", len(rows)))
+	ctx.JSON(status, importReportToResponseMapper(report))
+}
+
+// readImport reads the rows of an import, telling CSV from JSON by the file extension or the
+// content type
+func readImport(ctx *gin.Context) ([]domainCurrency.ImportRow, error) {
+	var body io.Reader = ctx.Request.Body
+	format := importFormat(ctx.ContentType(), "")
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, invalidImport(errors.New("the form must hold the import in a file field"))
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		body = file
+		format = importFormat(header.Header.Get("Content-Type"), header.Filename)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, invalidImport(fmt.Errorf("an import may not exceed %d bytes", maxImportBodySize))
+		}
+		return nil, err
+	}
+
+	switch format {
+	case "json":
+		return parseImportJSON(data)
+	case "csv":
+		return parseImportCSV(data)
+	default:
+		return nil, invalidImport(errors.New("the import must be a CSV or JSON file"))
+	}
+}
+
+func importFormat(contentType, filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case gin.MIMEJSON:
+		return "json"
+	case "text/csv", gin.MIMEPlain:
+		return "csv"
+	}
+	return ""
+}
+
+func parseImportJSON(data []byte) ([]domainCurrency.ImportRow, error) {
+	var items []ImportRowRequest
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, invalidImport(errors.New("the body must be a JSON array of currencies"))
+	}
+	rows := make([]domainCurrency.ImportRow, len(items))
+	for i, item := range items {
+		rows[i] = domainCurrency.ImportRow{Line: i + 1, Code: item.Code, Name: item.Name, Rate: item.Rate}
+	}
+	return rows, nil
+}
+
+// parseImportCSV reads a CSV file with a code, rate and optional name header. Files separated by
+// semicolons, as spreadsheets write them in decimal comma locales, may use decimal commas.
+func parseImportCSV(data []byte) ([]domainCurrency.ImportRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for _, delimiter := range []rune{';', '\t', '|'} {
+		if bytes.ContainsRune(firstLine, delimiter) {
+			reader.Comma = delimiter
+			break
+		}
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalidImport(errors.New("the CSV file must start with a header row"))
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	codeColumn, hasCode := columns["code"]
+	rateColumn, hasRate := columns["rate"]
+	if !hasCode || !hasRate {
+		return nil, invalidImport(errors.New("the CSV header must name a code and a rate column"))
+	}
+	nameColumn, hasName := columns["name"]
+
+	var rows []domainCurrency.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalidImport(fmt.Errorf("the CSV file is malformed: %v", err))
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(rows) == domainCurrency.MaxImportRows {
+			return nil, invalidImport(fmt.Errorf("an import is limited to %d rows", domainCurrency.MaxImportRows))
+		}
+		row := domainCurrency.ImportRow{Line: line, Code: field(record, codeColumn)}
+		if hasName {
+			row.Name = field(record, nameColumn)
+		}
+		raw := field(record, rateColumn)
+		if reader.Comma != ',' {
+			raw = strings.Replace(raw, ",", ".", 1)
+		}
+		if row.Rate, err = strconv.ParseFloat(raw, 64); err != nil {
+			return nil, invalidImport(fmt.Errorf("line %d: rate %q is not a number", line, field(record, rateColumn)))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func field(record []string, column int) string {
+	if column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+func invalidImport(err error) error {
+	return domainErrors.NewAppError(err, domainErrors.ValidationError)
+}
+
+func importReportToResponseMapper(report *domainCurrency.ImportReport) ImportResponse {
+	response := ImportResponse{
+		DryRun:    report.DryRun,
+		Applied:   report.Applied,
+		Source:    report.Source,
+		Created:   report.Created,
+		Updated:   report.Updated,
+		Unchanged: report.Unchanged,
+		Failed:    report.Failed,
+		Changes:   make([]ImportChangeResponse, len(report.Changes)),
+	}
+	for i, change := range report.Changes {
+		response.Changes[i] = ImportChangeResponse{
+			Line:    change.Line,
+			Code:    change.Code,
+			Action:  string(change.Action),
+			Name:    change.Name,
+			OldRate: change.OldRate,
+			Rate:    change.Rate,
+		}
+		if change.Err != nil {
+			response.Changes[i].Error = errorMessage(change.Err)
+		}
+	}
+	return response
+}
@@ -9,6 +9,7 @@ import (
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	domainExchanger "github.com/gbrayhan/microservices-go/src/domain/exchanger"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/exchanger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,20 +17,81 @@ import (
 
 // Structures
 type NewExchangerRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Url      string `json:"url" binding:"required"`
-	ApiKey   string `json:"apiKey" binding:"required"`
-	IsActive bool   `json:"isActive"`
+	Name         string   `json:"name" binding:"required"`
+	Url          string   `json:"url" binding:"required"`
+	ApiKey       string   `json:"apiKey" binding:"required"`
+	IsActive     bool     `json:"isActive"`
+	Priority     int      `json:"priority" binding:"min=0"`
+	Weight       *float64 `json:"weight" binding:"omitempty,gt=0"`
+	AllowedCodes []string `json:"allowedCodes"`
+	DeniedCodes  []string `json:"deniedCodes"`
+	MonthlyQuota int      `json:"monthlyQuota" binding:"min=0"`
+	MinInterval  int      `json:"minInterval" binding:"min=0"`
 }
 
 type ResponseUser struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	IsActive     bool      `json:"isActive"`
+	Url          string    `json:"url"`
+	ApiKey       string    `json:"apiKey"`
+	Priority     int       `json:"priority"`
+	Weight       float64   `json:"weight"`
+	AllowedCodes []string  `json:"allowedCodes"`
+	DeniedCodes  []string  `json:"deniedCodes"`
+	MonthlyQuota int       `json:"monthlyQuota"`
+	MinInterval  int       `json:"minInterval"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+	UpdatedAt    time.Time `json:"updatedAt,omitempty"`
+}
+
+type StatusChangeResponse struct {
 	IsActive  bool      `json:"isActive"`
-	Url       string    `json:"url"`
-	ApiKey    string    `json:"apiKey"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type LatencyResponse struct {
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50Ms"`
+	P95     float64 `json:"p95Ms"`
+	P99     float64 `json:"p99Ms"`
+}
+
+type HealthResponse struct {
+	ExchangerID         int                    `json:"exchangerId"`
+	Circuit             string                 `json:"circuit"`
+	Successes           int64                  `json:"successes"`
+	Failures            int64                  `json:"failures"`
+	ConsecutiveFailures int                    `json:"consecutiveFailures"`
+	LastError           string                 `json:"lastError,omitempty"`
+	LastErrorAt         *time.Time             `json:"lastErrorAt,omitempty"`
+	LastSuccessAt       *time.Time             `json:"lastSuccessAt,omitempty"`
+	OpenedAt            *time.Time             `json:"openedAt,omitempty"`
+	AutoDisabled        bool                   `json:"autoDisabled"`
+	Latency             LatencyResponse        `json:"latency"`
+	StatusChanges       []StatusChangeResponse `json:"statusChanges"`
+}
+
+type UsagePeriodResponse struct {
+	Period     string     `json:"period"`
+	Calls      int        `json:"calls"`
+	LastCallAt *time.Time `json:"lastCallAt,omitempty"`
+}
+
+type UsageResponse struct {
+	ExchangerID  int                   `json:"exchangerId"`
+	Period       string                `json:"period"`
+	Calls        int                   `json:"calls"`
+	MonthlyQuota int                   `json:"monthlyQuota"`
+	Remaining    *int                  `json:"remaining"`
+	PercentUsed  *float64              `json:"percentUsed"`
+	MinInterval  int                   `json:"minInterval"`
+	LastCallAt   *time.Time            `json:"lastCallAt,omitempty"`
+	NextCallAt   *time.Time            `json:"nextCallAt,omitempty"`
+	Warnings     []string              `json:"warnings"`
+	History      []UsagePeriodResponse `json:"history"`
 }
 
 type IExchangerController interface {
@@ -38,6 +100,10 @@ type IExchangerController interface {
 	GetExchangersById(ctx *gin.Context)
 	UpdateExchanger(ctx *gin.Context)
 	DeleteExchanger(ctx *gin.Context)
+	SearchPaginated(ctx *gin.Context)
+	SearchByProperty(ctx *gin.Context)
+	GetHealth(ctx *gin.Context)
+	GetUsage(ctx *gin.Context)
 }
 
 type ExchangerController struct {
@@ -158,16 +224,105 @@ func (c *ExchangerController) DeleteExchanger(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
 }
 
+// searchProperties are the properties SearchByProperty may look into
+var searchProperties = map[string]bool{
+	"name": true,
+	"url":  true,
+}
+
+func (c *ExchangerController) SearchPaginated(ctx *gin.Context) {
+	c.Logger.Info("Searching exchangers with pagination")
+	filters := controllers.ParseDataFilters(ctx, exchanger.SearchColumns)
+
+	result, err := c.exchangerService.SearchPaginated(filters)
+	if err != nil {
+		c.Logger.Error("Error searching exchangers", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := controllers.SearchPageResponse(ctx, filters, arrayDomainToResponseMapper(result.Data),
+		result.Total, result.Page, result.PageSize, result.TotalPages, result.NextCursor, result.PrevCursor)
+
+	c.Logger.Info("Successfully searched exchangers",
+		zap.Int64("total", result.Total),
+		zap.Int("page", result.Page))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ExchangerController) SearchByProperty(ctx *gin.Context) {
+	property, searchText, err := controllers.ParseSearchProperty(ctx, searchProperties)
+	if err != nil {
+		c.Logger.Error("Invalid property search", zap.Error(err), zap.String("property", ctx.Query("property")))
+		_ = ctx.Error(err)
+		return
+	}
+
+	coincidences, err := c.exchangerService.SearchByProperty(property, searchText)
+	if err != nil {
+		c.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
+		_ = ctx.Error(err)
+		return
+	}
+
+	c.Logger.Info("Successfully searched by property",
+		zap.String("property", property),
+		zap.Int("results", len(*coincidences)))
+	ctx.JSON(http.StatusOK, coincidences)
+}
+
+// GetHealth returns the refresh record of an exchanger: call counts, latency percentiles, circuit
+// state and the audit trail of its status
+func (c *ExchangerController) GetHealth(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid exchanger ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("exchanger id is invalid"), domainErrors.ValidationError))
+		return
+	}
+	health, err := c.exchangerService.GetHealth(id)
+	if err != nil {
+		c.Logger.Error("Error getting exchanger health", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, healthToResponseMapper(health))
+}
+
+// GetUsage returns the calls refreshes made to an exchanger this month against its quota, with
+// warnings once configured shares of the quota are used
+func (c *ExchangerController) GetUsage(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid exchanger ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("exchanger id is invalid"), domainErrors.ValidationError))
+		return
+	}
+	usage, err := c.exchangerService.GetUsage(id)
+	if err != nil {
+		c.Logger.Error("Error getting exchanger usage", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, usageToResponseMapper(usage))
+}
+
 // Mappers
 func domainToResponseMapper(domainExchanger *domainExchanger.Exchanger) *ResponseUser {
 	return &ResponseUser{
-		ID:        domainExchanger.ID,
-		Name:      domainExchanger.Name,
-		Url:       domainExchanger.Url,
-		IsActive:  domainExchanger.IsActive,
-		ApiKey:    domainExchanger.ApiKey,
-		CreatedAt: domainExchanger.CreatedAt,
-		UpdatedAt: domainExchanger.UpdatedAt,
+		ID:           domainExchanger.ID,
+		Name:         domainExchanger.Name,
+		Url:          domainExchanger.Url,
+		IsActive:     domainExchanger.IsActive,
+		ApiKey:       domainExchanger.ApiKey,
+		Priority:     domainExchanger.Priority,
+		Weight:       domainExchanger.Weight,
+		AllowedCodes: domainExchanger.AllowedCodes,
+		DeniedCodes:  domainExchanger.DeniedCodes,
+		MonthlyQuota: domainExchanger.MonthlyQuota,
+		MinInterval:  int(domainExchanger.MinInterval / time.Second),
+		CreatedAt:    domainExchanger.CreatedAt,
+		UpdatedAt:    domainExchanger.UpdatedAt,
 	}
 }
 
@@ -180,10 +335,75 @@ func arrayDomainToResponseMapper(users *[]domainExchanger.Exchanger) *[]Response
 }
 
 func toUsecaseMapper(req *NewExchangerRequest) *domainExchanger.Exchanger {
+	weight := domainExchanger.DefaultWeight
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
 	return &domainExchanger.Exchanger{
-		Name:     req.Name,
-		Url:      req.Url,
-		IsActive: req.IsActive,
-		ApiKey:   req.ApiKey,
+		Name:         req.Name,
+		Url:          req.Url,
+		IsActive:     req.IsActive,
+		ApiKey:       req.ApiKey,
+		Priority:     req.Priority,
+		Weight:       weight,
+		AllowedCodes: req.AllowedCodes,
+		DeniedCodes:  req.DeniedCodes,
+		MonthlyQuota: req.MonthlyQuota,
+		MinInterval:  time.Duration(req.MinInterval) * time.Second,
+	}
+}
+
+func healthToResponseMapper(health *domainExchanger.Health) *HealthResponse {
+	response := &HealthResponse{
+		ExchangerID:         health.ExchangerID,
+		Circuit:             string(health.State),
+		Successes:           health.Successes,
+		Failures:            health.Failures,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		LastError:           health.LastError,
+		LastErrorAt:         health.LastErrorAt,
+		LastSuccessAt:       health.LastSuccessAt,
+		OpenedAt:            health.OpenedAt,
+		AutoDisabled:        health.AutoDisabled,
+		Latency: LatencyResponse{
+			Samples: health.Samples,
+			P50:     milliseconds(health.LatencyP50),
+			P95:     milliseconds(health.LatencyP95),
+			P99:     milliseconds(health.LatencyP99),
+		},
+		StatusChanges: make([]StatusChangeResponse, len(health.StatusChanges)),
+	}
+	for i, change := range health.StatusChanges {
+		response.StatusChanges[i] = StatusChangeResponse{IsActive: change.IsActive, Actor: change.Actor, Reason: change.Reason, CreatedAt: change.CreatedAt}
 	}
+	return response
+}
+
+// usageToResponseMapper leaves remaining and percentUsed null for an exchanger without a quota
+func usageToResponseMapper(usage *domainExchanger.UsageReport) *UsageResponse {
+	response := &UsageResponse{
+		ExchangerID:  usage.ExchangerID,
+		Period:       usage.Period,
+		Calls:        usage.Calls,
+		MonthlyQuota: usage.MonthlyQuota,
+		MinInterval:  int(usage.MinInterval / time.Second),
+		LastCallAt:   usage.LastCallAt,
+		NextCallAt:   usage.NextCallAt,
+		Warnings:     usage.Warnings,
+		History:      make([]UsagePeriodResponse, len(usage.History)),
+	}
+	if usage.MonthlyQuota > 0 {
+		remaining := max(usage.MonthlyQuota-usage.Calls, 0)
+		percentUsed := float64(usage.Calls) * 100 / float64(usage.MonthlyQuota)
+		response.Remaining = &remaining
+		response.PercentUsed = &percentUsed
+	}
+	for i, period := range usage.History {
+		response.History[i] = UsagePeriodResponse{Period: period.Period, Calls: period.Calls, LastCallAt: period.LastCallAt}
+	}
+	return response
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
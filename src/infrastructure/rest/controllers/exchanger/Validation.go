@@ -21,7 +21,7 @@ func updateValidation(request map[string]any) error {
 		"name":     "omitempty,gt=3,lt=100",
 		"apiKey":   "omitempty,gt=10,lt=200",
 		"url":      "omitempty,gt=1,lt=100",
-		"isActive": "omitempty,gt=1,lt=100",
+		"isActive": "boolean",
 	}
 
 	validate := validator.New()
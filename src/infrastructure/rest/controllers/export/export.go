@@ -0,0 +1,277 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	MIMECSV  = "text/csv"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatJSON Format = "json"
+)
+
+// decimalCommaLanguages write numbers with a decimal comma. Spreadsheets set up for them expect
+// CSV fields to be separated by semicolons.
+var decimalCommaLanguages = map[string]bool{
+	"cs": true, "da": true, "de": true, "es": true, "fi": true, "fr": true, "id": true, "it": true,
+	"nb": true, "nl": true, "pl": true, "pt": true, "ru": true, "sv": true, "tr": true, "uk": true,
+}
+
+var delimiters = map[string]rune{",": ',', ";": ';', "tab": '\t', "|": '|'}
+
+// Options describes how an export is written. Decimal and Delimiter only apply to CSV: XLSX cells
+// hold plain numbers, which the spreadsheet displays in the reader's own locale.
+type Options struct {
+	Format    Format
+	Decimal   string
+	Delimiter rune
+}
+
+// Negotiate reads the export options of a request. The format comes from format= or else from the
+// Accept header, and defaults to fallback; nil is returned when the client gets JSON, which only
+// endpoints falling back to JSON serve. locale= presets the CSV number formatting, which decimal=
+// and delimiter= override.
+func Negotiate(ctx *gin.Context, fallback Format) (*Options, error) {
+	format := Format(strings.ToLower(ctx.Query("format")))
+	if format == "" {
+		offers := []string{MIMECSV, MIMEXLSX}
+		if fallback == FormatJSON {
+			offers = append([]string{gin.MIMEJSON}, offers...)
+		}
+		switch ctx.NegotiateFormat(offers...) {
+		case MIMECSV:
+			format = FormatCSV
+		case MIMEXLSX:
+			format = FormatXLSX
+		default:
+			format = fallback
+		}
+	}
+	switch format {
+	case FormatJSON:
+		if fallback == FormatJSON {
+			return nil, nil
+		}
+		return nil, invalid(fmt.Errorf("format must be %s or %s", FormatCSV, FormatXLSX))
+	case FormatCSV, FormatXLSX:
+	default:
+		return nil, invalid(fmt.Errorf("unsupported format %q", format))
+	}
+
+	options := &Options{Format: format, Decimal: ".", Delimiter: ','}
+	if locale := ctx.Query("locale"); locale != "" {
+		language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+		if decimalCommaLanguages[strings.ToLower(language)] {
+			options.Decimal, options.Delimiter = ",", ';'
+		}
+	}
+	if decimal := ctx.Query("decimal"); decimal != "" {
+		if decimal != "." && decimal != "," {
+			return nil, invalid(errors.New("decimal must be . or ,"))
+		}
+		options.Decimal = decimal
+	}
+	if name := ctx.Query("delimiter"); name != "" {
+		delimiter, ok := delimiters[name]
+		if !ok {
+			return nil, invalid(errors.New("delimiter must be one of , ; | or tab"))
+		}
+		options.Delimiter = delimiter
+	}
+	if options.Format == FormatCSV && options.Decimal == string(options.Delimiter) {
+		return nil, invalid(errors.New("decimal and delimiter must differ"))
+	}
+	return options, nil
+}
+
+// Stream writes an export named name with a header row and the rows produce writes. Nothing is
+// sent before produce returns its first row, or before the end for XLSX, so an early error can
+// still be reported as usual; callers can tell with ctx.Writer.Written().
+func Stream(ctx *gin.Context, options *Options, name string, header []string, produce func(write func(values ...any) error) error) error {
+	var w rowWriter
+	if options.Format == FormatXLSX {
+		xw, err := newXLSXWriter(name)
+		if err != nil {
+			return err
+		}
+		defer xw.file.Close()
+		w = xw
+	} else {
+		w = newCSVWriter(ctx, options)
+	}
+
+	started := false
+	begin := func() error {
+		started = true
+		ctx.Header("Content-Type", contentType(options.Format))
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+string(options.Format)))
+		return w.writeRow(stringValues(header))
+	}
+	write := func(values ...any) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		return w.writeRow(values)
+	}
+	if err := produce(write); err != nil {
+		if !ctx.Writer.Written() {
+			// Let the error handler answer with its own content type
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+		}
+		return err
+	}
+	if !started {
+		if err := begin(); err != nil {
+			return err
+		}
+	}
+	return w.close(ctx)
+}
+
+type rowWriter interface {
+	writeRow(values []any) error
+	close(ctx *gin.Context) error
+}
+
+type csvWriter struct {
+	options *Options
+	writer  *csv.Writer
+}
+
+func newCSVWriter(ctx *gin.Context, options *Options) *csvWriter {
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Comma = options.Delimiter
+	return &csvWriter{options: options, writer: writer}
+}
+
+func (w *csvWriter) writeRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = w.format(value)
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) close(*gin.Context) error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		// Keep spreadsheets from evaluating text as a formula
+		if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case float64:
+		return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", w.options.Decimal, 1)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// xlsxWriter keeps memory flat with excelize's stream writer, which moves rows to a temporary file
+// past a few megabytes. The workbook is written out when it is complete.
+type xlsxWriter struct {
+	file      *excelize.File
+	stream    *excelize.StreamWriter
+	dateStyle int
+	row       int
+}
+
+func newXLSXWriter(name string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	sheet := name
+	if len(sheet) > 31 {
+		sheet = sheet[:31]
+	}
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	dateStyle, err := file.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{file: file, stream: stream, dateStyle: dateStyle}, nil
+}
+
+func (w *xlsxWriter) writeRow(values []any) error {
+	w.row++
+	cells := make([]any, len(values))
+	for i, value := range values {
+		if t, ok := value.(time.Time); ok {
+			cells[i] = excelize.Cell{StyleID: w.dateStyle, Value: t.UTC()}
+			continue
+		}
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w *xlsxWriter) close(ctx *gin.Context) error {
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(ctx.Writer)
+}
+
+func contentType(format Format) string {
+	if format == FormatXLSX {
+		return MIMEXLSX
+	}
+	return MIMECSV + "; charset=utf-8"
+}
+
+func stringValues(values []string) []any {
+	converted := make([]any, len(values))
+	for i, value := range values {
+		converted[i] = value
+	}
+	return converted
+}
+
+func invalid(err error) error {
+	return domainErrors.NewAppError(err, domainErrors.ValidationError)
+}
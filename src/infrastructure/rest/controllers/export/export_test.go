@@ -0,0 +1,138 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+func setupContext(url string, accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	return c, w
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		url       string
+		accept    string
+		fallback  Format
+		format    Format
+		decimal   string
+		delimiter rune
+	}{
+		{"/", "", FormatJSON, "", "", 0},
+		{"/", "*/*", FormatJSON, "", "", 0},
+		{"/", "text/csv", FormatJSON, FormatCSV, ".", ','},
+		{"/", MIMEXLSX, FormatJSON, FormatXLSX, ".", ','},
+		{"/?format=CSV", "application/json", FormatJSON, FormatCSV, ".", ','},
+		{"/", "*/*", FormatCSV, FormatCSV, ".", ','},
+		{"/", "application/json", FormatCSV, FormatCSV, ".", ','},
+		{"/?format=csv&locale=de-DE", "", FormatJSON, FormatCSV, ",", ';'},
+		{"/?format=csv&locale=fr_FR&delimiter=tab", "", FormatJSON, FormatCSV, ",", '\t'},
+		{"/?format=csv&locale=en-US&decimal=,&delimiter=%3B", "", FormatJSON, FormatCSV, ",", ';'},
+	}
+	for _, tc := range cases {
+		c, _ := setupContext(tc.url, tc.accept)
+		options, err := Negotiate(c, tc.fallback)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error %v", tc.url, tc.accept, err)
+			continue
+		}
+		if tc.format == "" {
+			if options != nil {
+				t.Errorf("%s %s: expected JSON, got %+v", tc.url, tc.accept, options)
+			}
+			continue
+		}
+		if options == nil || options.Format != tc.format || options.Decimal != tc.decimal || options.Delimiter != tc.delimiter {
+			t.Errorf("%s %s: unexpected options %+v", tc.url, tc.accept, options)
+		}
+	}
+}
+
+func TestNegotiate_RejectsInvalidOptions(t *testing.T) {
+	for _, url := range []string{"/?format=pdf", "/?format=csv&decimal=%3B", "/?format=csv&delimiter=x", "/?format=csv&locale=de&delimiter=,"} {
+		c, _ := setupContext(url, "")
+		if _, err := Negotiate(c, FormatJSON); err == nil {
+			t.Errorf("expected an error for %s", url)
+		}
+	}
+	c, _ := setupContext("/?format=json", "")
+	if _, err := Negotiate(c, FormatCSV); err == nil {
+		t.Error("expected JSON to be refused by an export-only endpoint")
+	}
+}
+
+func TestStream_CSV(t *testing.T) {
+	c, w := setupContext("/", "")
+	at := time.Date(2026, 4, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	err := Stream(c, &Options{Format: FormatCSV, Decimal: ",", Delimiter: ';'}, "rates", []string{"at", "name", "rate", "samples"}, func(write func(values ...any) error) error {
+		return write(at, "=HYPERLINK()", 1234.5, 3)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "at;name;rate;samples\n2026-04-01T10:00:00Z;'=HYPERLINK();1234,5;3\n"; w.Body.String() != expected {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestStream_XLSX(t *testing.T) {
+	c, w := setupContext("/", "")
+	at := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	err := Stream(c, &Options{Format: FormatXLSX}, "rates", []string{"at", "rate"}, func(write func(values ...any) error) error {
+		if err := write(at, 0.91); err != nil {
+			return err
+		}
+		return write(at.Add(time.Hour), 0.925)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("invalid workbook: %v", err)
+	}
+	defer file.Close()
+	rows, err := file.GetRows("rates", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 || rows[0][1] != "rate" || rows[2][1] != "0.925" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestStream_EmptyAndFailing(t *testing.T) {
+	c, w := setupContext("/", "")
+	if err := Stream(c, &Options{Format: FormatCSV, Decimal: ".", Delimiter: ','}, "empty", []string{"a", "b"}, func(func(values ...any) error) error {
+		return nil
+	}); err != nil || w.Body.String() != "a,b\n" {
+		t.Errorf("expected only the header, got %q %v", w.Body.String(), err)
+	}
+
+	c, w = setupContext("/", "")
+	failure := errors.New("boom")
+	err := Stream(c, &Options{Format: FormatXLSX}, "failing", []string{"a"}, func(write func(values ...any) error) error {
+		_ = write(1)
+		return failure
+	})
+	if !errors.Is(err, failure) || c.Writer.Written() || w.Header().Get("Content-Type") != "" {
+		t.Errorf("expected nothing to be sent, got %v %v", err, w.Header())
+	}
+}
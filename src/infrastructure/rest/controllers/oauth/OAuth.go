@@ -0,0 +1,176 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	useCaseOAuth "github.com/gbrayhan/microservices-go/src/application/usecases/oauth"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IOAuthController interface {
+	Token(ctx *gin.Context)
+	NewClient(ctx *gin.Context)
+	GetClients(ctx *gin.Context)
+	DeleteClient(ctx *gin.Context)
+}
+
+type OAuthController struct {
+	oauthUseCase useCaseOAuth.IOAuthUseCase
+	Logger       *logger.Logger
+}
+
+func NewOAuthController(oauthUseCase useCaseOAuth.IOAuthUseCase, loggerInstance *logger.Logger) IOAuthController {
+	return &OAuthController{oauthUseCase: oauthUseCase, Logger: loggerInstance}
+}
+
+// Token implements the OAuth2 token endpoint. Errors are reported in the RFC 6749 format
+// instead of going through the error middleware so that standard OAuth2 clients understand them.
+func (c *OAuthController) Token(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+
+	var request TokenRequest
+	if err := ctx.ShouldBind(&request); err != nil {
+		c.Logger.Error("Error binding OAuth token request", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, TokenErrorResponse{Error: "invalid_request", ErrorDescription: "grant_type is required"})
+		return
+	}
+	if request.GrantType != domainOAuthClient.GrantClientCredentials {
+		c.Logger.Warn("Unsupported OAuth grant type", zap.String("grantType", request.GrantType))
+		ctx.JSON(http.StatusBadRequest, TokenErrorResponse{Error: "unsupported_grant_type"})
+		return
+	}
+
+	// Client authentication through HTTP Basic takes precedence over the request body
+	clientID, clientSecret, basicAuth := ctx.Request.BasicAuth()
+	if !basicAuth {
+		clientID, clientSecret = request.ClientID, request.ClientSecret
+	}
+	if clientID == "" || clientSecret == "" {
+		ctx.JSON(http.StatusBadRequest, TokenErrorResponse{Error: "invalid_request", ErrorDescription: "client credentials are required"})
+		return
+	}
+
+	token, scopes, err := c.oauthUseCase.IssueClientToken(clientID, clientSecret, strings.Fields(request.Scope))
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			switch appErr.Type {
+			case domainErrors.NotAuthenticated:
+				if basicAuth {
+					ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+				}
+				ctx.JSON(http.StatusUnauthorized, TokenErrorResponse{Error: "invalid_client"})
+				return
+			case domainErrors.ValidationError:
+				ctx.JSON(http.StatusBadRequest, TokenErrorResponse{Error: "invalid_scope", ErrorDescription: appErr.Error()})
+				return
+			}
+		}
+		c.Logger.Error("Error issuing OAuth client token", zap.Error(err), zap.String("clientID", clientID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, TokenResponse{
+		AccessToken: token.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(token.ExpirationTime).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+func (c *OAuthController) NewClient(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Creating new OAuth client", zap.Int("adminID", adminID))
+	var request NewClientRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new OAuth client", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	client, secret, err := c.oauthUseCase.CreateClient(adminID, request.Name, request.Scopes, request.Audiences)
+	if err != nil {
+		c.Logger.Error("Error creating OAuth client", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("OAuth client created successfully", zap.Int("id", client.ID))
+	ctx.JSON(http.StatusOK, NewClientResponse{
+		ClientResponse: *domainToResponseMapper(client),
+		ClientSecret:   secret,
+	})
+}
+
+func (c *OAuthController) GetClients(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting OAuth clients", zap.Int("adminID", adminID))
+	clients, err := c.oauthUseCase.GetClients(adminID)
+	if err != nil {
+		c.Logger.Error("Error getting OAuth clients", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved OAuth clients", zap.Int("count", len(*clients)))
+	ctx.JSON(http.StatusOK, arrayDomainToResponseMapper(clients))
+}
+
+func (c *OAuthController) DeleteClient(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid OAuth client ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	c.Logger.Info("Deleting OAuth client", zap.Int("adminID", adminID), zap.Int("id", id))
+	if err := c.oauthUseCase.DeleteClient(adminID, id); err != nil {
+		c.Logger.Error("Error deleting OAuth client", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("OAuth client deleted successfully", zap.Int("id", id))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+// Mappers
+func domainToResponseMapper(client *domainOAuthClient.Client) *ClientResponse {
+	scopes := client.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	audiences := client.Audiences
+	if audiences == nil {
+		audiences = []string{}
+	}
+	return &ClientResponse{
+		ID:        client.ID,
+		ClientID:  client.ClientID,
+		Name:      client.Name,
+		Scopes:    scopes,
+		Audiences: audiences,
+		CreatedBy: client.CreatedBy,
+		CreatedAt: client.CreatedAt,
+		UpdatedAt: client.UpdatedAt,
+	}
+}
+
+func arrayDomainToResponseMapper(clients *[]domainOAuthClient.Client) *[]ClientResponse {
+	res := make([]ClientResponse, len(*clients))
+	for i, client := range *clients {
+		res[i] = *domainToResponseMapper(&client)
+	}
+	return &res
+}
@@ -0,0 +1,144 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainOAuthClient "github.com/gbrayhan/microservices-go/src/domain/oauthclient"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/gin-gonic/gin"
+)
+
+// MockOAuthUseCase implements IOAuthUseCase for testing
+type MockOAuthUseCase struct {
+	issueClientTokenFunc func(string, string, []string) (*security.AppToken, []string, error)
+}
+
+func (m *MockOAuthUseCase) CreateClient(adminID int, name string, scopes []string, audiences []string) (*domainOAuthClient.Client, string, error) {
+	return &domainOAuthClient.Client{ID: 1, Name: name, Scopes: scopes}, "secret", nil
+}
+
+func (m *MockOAuthUseCase) GetClients(adminID int) (*[]domainOAuthClient.Client, error) {
+	return &[]domainOAuthClient.Client{}, nil
+}
+
+func (m *MockOAuthUseCase) DeleteClient(adminID int, id int) error {
+	return nil
+}
+
+func (m *MockOAuthUseCase) IssueClientToken(clientID string, clientSecret string, requestedScopes []string) (*security.AppToken, []string, error) {
+	return m.issueClientTokenFunc(clientID, clientSecret, requestedScopes)
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func issueForTestClient(clientID, clientSecret string, requested []string) (*security.AppToken, []string, error) {
+	if clientID != "cli_test" || clientSecret != "s3cret" {
+		return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated)
+	}
+	for _, s := range requested {
+		if s != "currency:read" {
+			return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+		}
+	}
+	return &security.AppToken{Token: "signed", ExpirationTime: time.Now().Add(time.Hour)}, []string{"currency:read"}, nil
+}
+
+func TestOAuthController_Token(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		form          url.Values
+		basicUser     string
+		basicPass     string
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:         "credentials in body",
+			form:         url.Values{"grant_type": {"client_credentials"}, "client_id": {"cli_test"}, "client_secret": {"s3cret"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "credentials in basic auth",
+			form:         url.Values{"grant_type": {"client_credentials"}, "scope": {"currency:read"}},
+			basicUser:    "cli_test",
+			basicPass:    "s3cret",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "missing grant type",
+			form:          url.Values{"client_id": {"cli_test"}, "client_secret": {"s3cret"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid_request",
+		},
+		{
+			name:          "unsupported grant type",
+			form:          url.Values{"grant_type": {"password"}, "client_id": {"cli_test"}, "client_secret": {"s3cret"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "unsupported_grant_type",
+		},
+		{
+			name:          "invalid client",
+			form:          url.Values{"grant_type": {"client_credentials"}, "client_id": {"cli_test"}, "client_secret": {"wrong"}},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "invalid_client",
+		},
+		{
+			name:          "invalid scope",
+			form:          url.Values{"grant_type": {"client_credentials"}, "client_id": {"cli_test"}, "client_secret": {"s3cret"}, "scope": {"currency:write"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid_scope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewOAuthController(&MockOAuthUseCase{issueClientTokenFunc: issueForTestClient}, setupLogger(t))
+			router := gin.New()
+			router.POST("/oauth/token", controller.Token)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicUser != "" {
+				req.SetBasicAuth(tt.basicUser, tt.basicPass)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Header().Get("Cache-Control") != "no-store" {
+				t.Error("expected token responses not to be cached")
+			}
+			if tt.expectedError != "" {
+				var response TokenErrorResponse
+				_ = json.Unmarshal(w.Body.Bytes(), &response)
+				if response.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, response.Error)
+				}
+				return
+			}
+			var response TokenResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if response.AccessToken != "signed" || response.TokenType != "Bearer" || response.Scope != "currency:read" || response.ExpiresIn <= 0 {
+				t.Errorf("unexpected token response %+v", response)
+			}
+		})
+	}
+}
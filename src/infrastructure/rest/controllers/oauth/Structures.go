@@ -0,0 +1,49 @@
+package oauth
+
+import (
+	"time"
+)
+
+// TokenRequest accepts the form-encoded body defined by RFC 6749; JSON is accepted as well
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type" binding:"required"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+	Scope        string `form:"scope" json:"scope"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// TokenErrorResponse follows the error format of RFC 6749 section 5.2
+type TokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type NewClientRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required"`
+	Audiences []string `json:"audiences"`
+}
+
+type ClientResponse struct {
+	ID        int       `json:"id"`
+	ClientID  string    `json:"clientId"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	Audiences []string  `json:"audiences"`
+	CreatedBy int       `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewClientResponse is the only response that ever contains the plain client secret
+type NewClientResponse struct {
+	ClientResponse
+	ClientSecret string `json:"clientSecret"`
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	useCaseRateStream "github.com/gbrayhan/microservices-go/src/application/usecases/ratestream"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// rateEventName is the SSE event name of rate updates
+	rateEventName = "rates"
+	// defaultHeartbeat keeps idle connections open through proxies that close silent ones
+	defaultHeartbeat = 15 * time.Second
+)
+
+type IStreamController interface {
+	StreamRates(ctx *gin.Context)
+}
+
+type StreamController struct {
+	rateStream useCaseRateStream.IRateStreamUseCase
+	Logger     *logger.Logger
+	Heartbeat  time.Duration
+}
+
+func NewStreamController(rateStream useCaseRateStream.IRateStreamUseCase, loggerInstance *logger.Logger) IStreamController {
+	return &StreamController{rateStream: rateStream, Logger: loggerInstance, Heartbeat: defaultHeartbeat}
+}
+
+// StreamRates pushes every rate refresh as a Server-Sent Event until the client disconnects.
+// codes=EUR,GBP restricts the rates sent; the Last-Event-ID header (or lastEventId query
+// parameter) replays the refreshes missed since that event while they are still in history.
+func (c *StreamController) StreamRates(ctx *gin.Context) {
+	codes := parseCodes(ctx.Query("codes"))
+	lastEventID := parseLastEventID(ctx)
+	subscription, backlog, err := c.rateStream.Subscribe(codes, lastEventID)
+	if err != nil {
+		c.Logger.Warn("Error subscribing to the rate stream", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	defer c.rateStream.Unsubscribe(subscription)
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	for _, event := range backlog {
+		c.render(ctx, event)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(c.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				c.Logger.Info("Rate stream closed by the server, client should resume", zap.Uint64("lastEventID", lastEventID))
+				return
+			}
+			c.render(ctx, event)
+			lastEventID = event.ID
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(ctx.Writer, ": ping\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func (c *StreamController) render(ctx *gin.Context, event useCaseRateStream.RateEvent) {
+	ctx.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: rateEventName,
+		Data:  event,
+	})
+}
+
+func parseCodes(raw string) []string {
+	var codes []string
+	for _, code := range strings.Split(raw, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// parseLastEventID ignores ids it cannot read, which simply means no replay
+func parseLastEventID(ctx *gin.Context) uint64 {
+	raw := ctx.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = ctx.Query("lastEventId")
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	useCaseRateStream "github.com/gbrayhan/microservices-go/src/application/usecases/ratestream"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// MockRateStreamUseCase implements IRateStreamUseCase with a channel driven by the test
+type MockRateStreamUseCase struct {
+	events       chan useCaseRateStream.RateEvent
+	backlog      []useCaseRateStream.RateEvent
+	err          error
+	codes        []string
+	lastEventID  uint64
+	subscribed   chan struct{}
+	unsubscribed bool
+}
+
+func (m *MockRateStreamUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {}
+func (m *MockRateStreamUseCase) Subscribe(codes []string, lastEventID uint64) (*useCaseRateStream.Subscription, []useCaseRateStream.RateEvent, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	m.codes = codes
+	m.lastEventID = lastEventID
+	close(m.subscribed)
+	return &useCaseRateStream.Subscription{Events: m.events}, m.backlog, nil
+}
+func (m *MockRateStreamUseCase) Unsubscribe(subscription *useCaseRateStream.Subscription) {
+	m.unsubscribed = true
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestStreamController_StreamRates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useCase := &MockRateStreamUseCase{
+		events:     make(chan useCaseRateStream.RateEvent, 1),
+		backlog:    []useCaseRateStream.RateEvent{{ID: 4, Base: "USD", Rates: []useCaseRateStream.RateUpdate{{Code: "EUR", Rate: 0.91}}}},
+		subscribed: make(chan struct{}),
+	}
+	controller := NewStreamController(useCase, setupLogger(t))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	requestCtx, cancel := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest("GET", "/v1/currency/stream?codes=eur,%20gbp", nil).WithContext(requestCtx)
+	c.Request.Header.Set("Last-Event-ID", "3")
+
+	go func() {
+		<-useCase.subscribed
+		useCase.events <- useCaseRateStream.RateEvent{ID: 5, Base: "USD", Rates: []useCaseRateStream.RateUpdate{{Code: "EUR", Rate: 0.92}}}
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	controller.StreamRates(c)
+
+	if useCase.lastEventID != 3 || len(useCase.codes) != 2 || useCase.codes[1] != "gbp" {
+		t.Errorf("unexpected subscription codes=%v lastEventID=%d", useCase.codes, useCase.lastEventID)
+	}
+	if !useCase.unsubscribed {
+		t.Error("expected the subscriber to be removed when the client disconnects")
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	first := strings.Index(body, "id:4\nevent:rates\n")
+	second := strings.Index(body, "id:5\nevent:rates\n")
+	if first < 0 || second < first || !strings.Contains(body, `"code":"EUR","rate":0.92`) {
+		t.Errorf("expected the backlog followed by the live event, got %q", body)
+	}
+}
+
+func TestStreamController_SubscribeError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useCase := &MockRateStreamUseCase{err: domainErrors.NewAppError(errors.New("full"), domainErrors.TooManyRequests)}
+	controller := NewStreamController(useCase, setupLogger(t))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/v1/currency/stream", nil)
+	controller.StreamRates(c)
+
+	if len(c.Errors) != 1 || w.Body.Len() != 0 {
+		t.Errorf("expected the error to be passed to the error middleware, got %v", c.Errors)
+	}
+}
+
+func TestParseLastEventID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/v1/currency/stream?lastEventId=7", nil)
+	if id := parseLastEventID(c); id != 7 {
+		t.Errorf("expected the query parameter to be used, got %d", id)
+	}
+	c.Request.Header.Set("Last-Event-ID", "not-a-number")
+	if id := parseLastEventID(c); id != 0 {
+		t.Errorf("expected an unreadable id to be ignored, got %d", id)
+	}
+}
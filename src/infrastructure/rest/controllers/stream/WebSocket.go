@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	useCaseRateHub "github.com/gbrayhan/microservices-go/src/application/usecases/ratehub"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// Client actions
+	actionSubscribe   = "subscribe"
+	actionUnsubscribe = "unsubscribe"
+	actionPing        = "ping"
+
+	// maxClientMessageSize bounds what a client may send in a single frame
+	maxClientMessageSize = 4096
+	writeWait            = 10 * time.Second
+	defaultPongWait      = 60 * time.Second
+)
+
+// ClientMessage is a request sent by a WebSocket client
+type ClientMessage struct {
+	Action string   `json:"action"`
+	Pairs  []string `json:"pairs"`
+}
+
+type IWebSocketController interface {
+	Connect(ctx *gin.Context)
+}
+
+type WebSocketController struct {
+	hub      useCaseRateHub.IRateHubUseCase
+	Logger   *logger.Logger
+	PongWait time.Duration
+	upgrader websocket.Upgrader
+}
+
+func NewWebSocketController(hub useCaseRateHub.IRateHubUseCase, loggerInstance *logger.Logger) IWebSocketController {
+	return &WebSocketController{
+		hub:      hub,
+		Logger:   loggerInstance,
+		PongWait: defaultPongWait,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Connections authenticate with a bearer token rather than cookies, so a foreign
+			// page cannot ride on the user's session and any origin may connect.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Connect upgrades the request to a WebSocket connected to the rate hub. Clients send
+// {"action":"subscribe","pairs":["EUR/USD"]}, "unsubscribe" or "ping" and receive ticks for their
+// pairs, their own alert notifications and the status of every rate refresh.
+func (c *WebSocketController) Connect(ctx *gin.Context) {
+	client, err := c.hub.Connect(ctx.GetInt(middlewares.AuthUserIDKey))
+	if err != nil {
+		c.Logger.Warn("Error connecting to the rate hub", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	conn, err := c.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader has already answered the request
+		c.Logger.Warn("Error upgrading to WebSocket", zap.Error(err))
+		c.hub.Disconnect(client)
+		return
+	}
+
+	go c.writeMessages(conn, client)
+	c.readMessages(conn, client)
+}
+
+// readMessages handles client requests until the connection fails, then leaves the hub, which
+// stops writeMessages in turn
+func (c *WebSocketController) readMessages(conn *websocket.Conn, client *useCaseRateHub.Client) {
+	defer c.hub.Disconnect(client)
+	conn.SetReadLimit(maxClientMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(c.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.PongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(c.PongWait))
+		var message ClientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.hub.Send(client, useCaseRateHub.ErrorMessage{Type: useCaseRateHub.TypeError, Message: "messages must be JSON objects"})
+			continue
+		}
+
+		switch message.Action {
+		case actionSubscribe:
+			err := c.hub.Subscribe(client, message.Pairs)
+			c.replyError(client, err)
+		case actionUnsubscribe:
+			err := c.hub.Unsubscribe(client, message.Pairs)
+			c.replyError(client, err)
+		case actionPing:
+			c.hub.Send(client, useCaseRateHub.PongMessage{Type: useCaseRateHub.TypePong})
+		default:
+			c.hub.Send(client, useCaseRateHub.ErrorMessage{Type: useCaseRateHub.TypeError, Message: "unknown action " + message.Action})
+		}
+	}
+}
+
+// writeMessages is the only writer of the connection. It sends queued messages and keeps the
+// connection alive with pings until the hub closes the client's queue.
+func (c *WebSocketController) writeMessages(conn *websocket.Conn, client *useCaseRateHub.Client) {
+	ping := time.NewTicker(c.PongWait * 9 / 10)
+	defer func() {
+		ping.Stop()
+		_ = conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-client.Messages:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				if client.Dropped() {
+					c.Logger.Info("Closing WebSocket of a slow client", zap.Int("userID", client.UserID))
+					closeMessage = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow")
+				}
+				_ = conn.WriteMessage(websocket.CloseMessage, closeMessage)
+				return
+			}
+			if err := conn.WriteJSON(message); err != nil {
+				c.hub.Disconnect(client)
+				return
+			}
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.hub.Disconnect(client)
+				return
+			}
+		}
+	}
+}
+
+func (c *WebSocketController) replyError(client *useCaseRateHub.Client, err error) {
+	if err == nil {
+		return
+	}
+	message := "internal error"
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.ValidationError {
+		message = appErr.Err.Error()
+	}
+	c.hub.Send(client, useCaseRateHub.ErrorMessage{Type: useCaseRateHub.TypeError, Message: message})
+}
@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	useCaseRateHub "github.com/gbrayhan/microservices-go/src/application/usecases/ratehub"
+	domainAlert "github.com/gbrayhan/microservices-go/src/domain/alert"
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// wsMessage is the union of the fields of every message the hub sends
+type wsMessage struct {
+	Type    string   `json:"type"`
+	Pairs   []string `json:"pairs"`
+	Pair    string   `json:"pair"`
+	Rate    float64  `json:"rate"`
+	EventID int      `json:"eventId"`
+	Message string   `json:"message"`
+}
+
+func dialHub(t *testing.T, hub useCaseRateHub.IRateHubUseCase) *websocket.Conn {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", func(ctx *gin.Context) {
+		ctx.Set(middlewares.AuthUserIDKey, 3)
+	}, NewWebSocketController(hub, setupLogger(t)).Connect)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var message wsMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return message
+}
+
+func TestWebSocketController_SubscribeAndReceive(t *testing.T) {
+	hub := useCaseRateHub.NewRateHubUseCase(setupLogger(t))
+	conn := dialHub(t, hub)
+
+	if err := conn.WriteJSON(ClientMessage{Action: "subscribe", Pairs: []string{"EUR/USD"}}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	message := readMessage(t, conn)
+	if message.Type != useCaseRateHub.TypeSubscribed || len(message.Pairs) != 1 || message.Pairs[0] != "EUR/USD" {
+		t.Fatalf("unexpected acknowledgement %+v", message)
+	}
+
+	hub.OnRatesUpdated([]domainCurrency.Currency{{Code: "EUR", Rate: 0.8}})
+	message = readMessage(t, conn)
+	if message.Type != useCaseRateHub.TypeTick || message.Pair != "EUR/USD" || message.Rate != 1.25 {
+		t.Errorf("unexpected tick %+v", message)
+	}
+
+	_ = hub.Notify(&domainAlert.Event{ID: 5, UserID: 3, Pair: domainWatchlist.Pair{Base: "EUR", Quote: "USD"}})
+	message = readMessage(t, conn)
+	if message.Type != useCaseRateHub.TypeAlert || message.EventID != 5 {
+		t.Errorf("unexpected alert %+v", message)
+	}
+}
+
+func TestWebSocketController_RepliesToBadRequests(t *testing.T) {
+	conn := dialHub(t, useCaseRateHub.NewRateHubUseCase(setupLogger(t)))
+
+	_ = conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	if message := readMessage(t, conn); message.Type != useCaseRateHub.TypeError {
+		t.Errorf("expected an error for a malformed message, got %+v", message)
+	}
+
+	_ = conn.WriteJSON(ClientMessage{Action: "subscribe", Pairs: []string{"EURUSD"}})
+	if message := readMessage(t, conn); message.Type != useCaseRateHub.TypeError || !strings.Contains(message.Message, "EURUSD") {
+		t.Errorf("expected a validation error, got %+v", message)
+	}
+
+	_ = conn.WriteJSON(ClientMessage{Action: "ping"})
+	if message := readMessage(t, conn); message.Type != useCaseRateHub.TypePong {
+		t.Errorf("expected a pong, got %+v", message)
+	}
+}
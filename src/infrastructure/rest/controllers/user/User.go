@@ -2,16 +2,17 @@ package user
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/gbrayhan/microservices-go/src/domain"
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/repository/psql/user"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -23,7 +24,21 @@ type NewUserRequest struct {
 	FirstName string          `json:"firstName" binding:"required"`
 	LastName  string          `json:"lastName" binding:"required"`
 	Password  string          `json:"password" binding:"required"`
-	Role      domainUser.Role `json:"role" binding:"required"`
+	Role      domainUser.Role `json:"role"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// selfUpdatableFields are the profile fields a user may change on their own account;
+// role and status stay under admin control
+var selfUpdatableFields = map[string]bool{
+	"userName":  true,
+	"email":     true,
+	"firstName": true,
+	"lastName":  true,
 }
 
 type ResponseUser struct {
@@ -38,6 +53,14 @@ type ResponseUser struct {
 	UpdatedAt time.Time       `json:"updatedAt,omitempty"`
 }
 
+// PublicResponseUser is what anonymous callers and other users see of an account
+type PublicResponseUser struct {
+	ID        int    `json:"id"`
+	UserName  string `json:"user"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
 type IUserController interface {
 	NewUser(ctx *gin.Context)
 	GetAllUsers(ctx *gin.Context)
@@ -46,6 +69,9 @@ type IUserController interface {
 	DeleteUser(ctx *gin.Context)
 	SearchPaginated(ctx *gin.Context)
 	SearchByProperty(ctx *gin.Context)
+	GetMe(ctx *gin.Context)
+	UpdateMe(ctx *gin.Context)
+	ChangeMyPassword(ctx *gin.Context)
 }
 
 type UserController struct {
@@ -66,7 +92,7 @@ func (c *UserController) NewUser(ctx *gin.Context) {
 		_ = ctx.Error(appError)
 		return
 	}
-	userModel, err := c.userService.Create(toUsecaseMapper(&request))
+	userModel, err := c.userService.Create(ctx.GetInt(middlewares.AuthUserIDKey), toUsecaseMapper(&request))
 	if err != nil {
 		c.Logger.Error("Error creating user", zap.Error(err), zap.String("email", request.Email))
 		_ = ctx.Error(err)
@@ -79,11 +105,10 @@ func (c *UserController) NewUser(ctx *gin.Context) {
 
 func (c *UserController) GetAllUsers(ctx *gin.Context) {
 	c.Logger.Info("Getting all users")
-	users, err := c.userService.GetAll()
+	users, err := c.userService.GetAll(ctx.GetInt(middlewares.AuthUserIDKey))
 	if err != nil {
 		c.Logger.Error("Error getting all users", zap.Error(err))
-		appError := domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
-		_ = ctx.Error(appError)
+		_ = ctx.Error(err)
 		return
 	}
 	c.Logger.Info("Successfully retrieved all users", zap.Int("count", len(*users)))
@@ -99,14 +124,29 @@ func (c *UserController) GetUsersByID(ctx *gin.Context) {
 		return
 	}
 	c.Logger.Info("Getting user by ID", zap.Int("id", userID))
-	user, err := c.userService.GetByID(userID)
+	// the account owner and admins see the full record, everyone else the public profile
+	if actorID := ctx.GetInt(middlewares.AuthUserIDKey); actorID != 0 {
+		user, err := c.userService.GetByID(actorID, userID)
+		if err == nil {
+			c.Logger.Info("Successfully retrieved user by ID", zap.Int("id", userID))
+			ctx.JSON(http.StatusOK, domainToResponseMapper(user))
+			return
+		}
+		var appErr *domainErrors.AppError
+		if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotAuthorized {
+			c.Logger.Error("Error getting user by ID", zap.Error(err), zap.Int("id", userID))
+			_ = ctx.Error(err)
+			return
+		}
+	}
+	profile, err := c.userService.GetPublicProfile(userID)
 	if err != nil {
 		c.Logger.Error("Error getting user by ID", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
 		return
 	}
-	c.Logger.Info("Successfully retrieved user by ID", zap.Int("id", userID))
-	ctx.JSON(http.StatusOK, domainToResponseMapper(user))
+	c.Logger.Info("Successfully retrieved public user profile", zap.Int("id", userID))
+	ctx.JSON(http.StatusOK, domainToPublicResponseMapper(profile))
 }
 
 func (c *UserController) UpdateUser(ctx *gin.Context) {
@@ -132,7 +172,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	userUpdated, err := c.userService.Update(userID, requestMap)
+	userUpdated, err := c.userService.Update(ctx.GetInt(middlewares.AuthUserIDKey), userID, requestMap)
 	if err != nil {
 		c.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
@@ -151,7 +191,7 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 		return
 	}
 	c.Logger.Info("Deleting user", zap.Int("id", userID))
-	err = c.userService.Delete(userID)
+	err = c.userService.Delete(ctx.GetInt(middlewares.AuthUserIDKey), userID)
 	if err != nil {
 		c.Logger.Error("Error deleting user", zap.Error(err), zap.Int("id", userID))
 		_ = ctx.Error(err)
@@ -161,95 +201,29 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
 }
 
+// searchProperties are the properties SearchByProperty may look into
+var searchProperties = map[string]bool{
+	"userName":  true,
+	"email":     true,
+	"role":      true,
+	"firstName": true,
+	"lastName":  true,
+	"status":    true,
+}
+
 func (c *UserController) SearchPaginated(ctx *gin.Context) {
 	c.Logger.Info("Searching users with pagination")
+	filters := controllers.ParseDataFilters(ctx, user.ColumnsUserMapping)
 
-	// Parse query parameters
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
-	if pageSize < 1 {
-		pageSize = 10
-	}
-
-	// Build filters
-	filters := domain.DataFilters{
-		Page:     page,
-		PageSize: pageSize,
-	}
-
-	// Parse like filters
-	likeFilters := make(map[string][]string)
-	for field := range user.ColumnsUserMapping {
-		if values := ctx.QueryArray(field + "_like"); len(values) > 0 {
-			likeFilters[field] = values
-		}
-	}
-	filters.LikeFilters = likeFilters
-
-	// Parse exact matches
-	matches := make(map[string][]string)
-	for field := range user.ColumnsUserMapping {
-		if values := ctx.QueryArray(field + "_match"); len(values) > 0 {
-			matches[field] = values
-		}
-	}
-	filters.Matches = matches
-
-	// Parse date range filters
-	var dateRanges []domain.DateRangeFilter
-	for field := range user.ColumnsUserMapping {
-		startStr := ctx.Query(field + "_start")
-		endStr := ctx.Query(field + "_end")
-
-		if startStr != "" || endStr != "" {
-			dateRange := domain.DateRangeFilter{Field: field}
-
-			if startStr != "" {
-				if startTime, err := time.Parse(time.RFC3339, startStr); err == nil {
-					dateRange.Start = &startTime
-				}
-			}
-
-			if endStr != "" {
-				if endTime, err := time.Parse(time.RFC3339, endStr); err == nil {
-					dateRange.End = &endTime
-				}
-			}
-
-			dateRanges = append(dateRanges, dateRange)
-		}
-	}
-	filters.DateRangeFilters = dateRanges
-
-	// Parse sorting
-	sortBy := ctx.QueryArray("sortBy")
-	if len(sortBy) > 0 {
-		filters.SortBy = sortBy
-	}
-
-	sortDirection := domain.SortDirection(ctx.DefaultQuery("sortDirection", "asc"))
-	if sortDirection.IsValid() {
-		filters.SortDirection = sortDirection
-	}
-
-	result, err := c.userService.SearchPaginated(filters)
+	result, err := c.userService.SearchPaginated(ctx.GetInt(middlewares.AuthUserIDKey), filters)
 	if err != nil {
 		c.Logger.Error("Error searching users", zap.Error(err))
 		_ = ctx.Error(err)
 		return
 	}
 
-	response := gin.H{
-		"data":       arrayDomainToResponseMapper(result.Data),
-		"total":      result.Total,
-		"page":       result.Page,
-		"pageSize":   result.PageSize,
-		"totalPages": result.TotalPages,
-		"filters":    filters,
-	}
+	response := controllers.SearchPageResponse(ctx, filters, arrayDomainToResponseMapper(result.Data),
+		result.Total, result.Page, result.PageSize, result.TotalPages, result.NextCursor, result.PrevCursor)
 
 	c.Logger.Info("Successfully searched users",
 		zap.Int64("total", result.Total),
@@ -258,33 +232,14 @@ func (c *UserController) SearchPaginated(ctx *gin.Context) {
 }
 
 func (c *UserController) SearchByProperty(ctx *gin.Context) {
-	property := ctx.Query("property")
-	searchText := ctx.Query("searchText")
-
-	if property == "" || searchText == "" {
-		c.Logger.Error("Missing property or searchText parameter")
-		appError := domainErrors.NewAppError(errors.New("missing property or searchText parameter"), domainErrors.ValidationError)
-		_ = ctx.Error(appError)
-		return
-	}
-
-	// Validate property
-	allowed := map[string]bool{
-		"userName":  true,
-		"email":     true,
-		"role":      true,
-		"firstName": true,
-		"lastName":  true,
-		"status":    true,
-	}
-	if !allowed[property] {
-		c.Logger.Error("Invalid property for search", zap.String("property", property))
-		appError := domainErrors.NewAppError(errors.New("invalid property"), domainErrors.ValidationError)
-		_ = ctx.Error(appError)
+	property, searchText, err := controllers.ParseSearchProperty(ctx, searchProperties)
+	if err != nil {
+		c.Logger.Error("Invalid property search", zap.Error(err), zap.String("property", ctx.Query("property")))
+		_ = ctx.Error(err)
 		return
 	}
 
-	coincidences, err := c.userService.SearchByProperty(property, searchText)
+	coincidences, err := c.userService.SearchByProperty(ctx.GetInt(middlewares.AuthUserIDKey), property, searchText)
 	if err != nil {
 		c.Logger.Error("Error searching by property", zap.Error(err), zap.String("property", property))
 		_ = ctx.Error(err)
@@ -297,6 +252,70 @@ func (c *UserController) SearchByProperty(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, coincidences)
 }
 
+func (c *UserController) GetMe(ctx *gin.Context) {
+	p, _ := middlewares.GetPrincipal(ctx)
+	c.Logger.Info("Getting current user", zap.Int("id", p.UserID))
+	user, err := c.userService.GetByID(p.UserID, p.UserID)
+	if err != nil {
+		c.Logger.Error("Error getting current user", zap.Error(err), zap.Int("id", p.UserID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(user))
+}
+
+func (c *UserController) UpdateMe(ctx *gin.Context) {
+	p, _ := middlewares.GetPrincipal(ctx)
+	c.Logger.Info("Updating current user", zap.Int("id", p.UserID))
+	var requestMap map[string]any
+	err := controllers.BindJSONMap(ctx, &requestMap)
+	if err != nil {
+		c.Logger.Error("Error binding JSON for current user update", zap.Error(err), zap.Int("id", p.UserID))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	for field := range requestMap {
+		if !selfUpdatableFields[field] {
+			appError := domainErrors.NewAppError(fmt.Errorf("%s cannot be changed on your own account", field), domainErrors.ValidationError)
+			_ = ctx.Error(appError)
+			return
+		}
+	}
+	err = updateValidation(requestMap)
+	if err != nil {
+		c.Logger.Error("Validation error for current user update", zap.Error(err), zap.Int("id", p.UserID))
+		_ = ctx.Error(err)
+		return
+	}
+	userUpdated, err := c.userService.Update(p.UserID, p.UserID, requestMap)
+	if err != nil {
+		c.Logger.Error("Error updating current user", zap.Error(err), zap.Int("id", p.UserID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Current user updated successfully", zap.Int("id", p.UserID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(userUpdated))
+}
+
+func (c *UserController) ChangeMyPassword(ctx *gin.Context) {
+	p, _ := middlewares.GetPrincipal(ctx)
+	var request ChangePasswordRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for password change", zap.Error(err), zap.Int("id", p.UserID))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	if err := c.userService.ChangePassword(p.UserID, request.CurrentPassword, request.NewPassword); err != nil {
+		c.Logger.Error("Error changing password", zap.Error(err), zap.Int("id", p.UserID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Password changed successfully", zap.Int("id", p.UserID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
 // Mappers
 func domainToResponseMapper(domainUser *domainUser.User) *ResponseUser {
 	return &ResponseUser{
@@ -312,6 +331,15 @@ func domainToResponseMapper(domainUser *domainUser.User) *ResponseUser {
 	}
 }
 
+func domainToPublicResponseMapper(domainUser *domainUser.User) *PublicResponseUser {
+	return &PublicResponseUser{
+		ID:        domainUser.ID,
+		UserName:  domainUser.UserName,
+		FirstName: domainUser.FirstName,
+		LastName:  domainUser.LastName,
+	}
+}
+
 func arrayDomainToResponseMapper(users *[]domainUser.User) *[]ResponseUser {
 	res := make([]ResponseUser, len(*users))
 	for i, u := range *users {
@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/gbrayhan/microservices-go/src/domain"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainPrincipal "github.com/gbrayhan/microservices-go/src/domain/principal"
 	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
 	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,18 +25,23 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetAll() (*[]domainUser.User, error) {
-	args := m.Called()
+func (m *MockUserService) GetAll(actorID int) (*[]domainUser.User, error) {
+	args := m.Called(actorID)
 	return args.Get(0).(*[]domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) GetByID(id int) (*domainUser.User, error) {
+func (m *MockUserService) GetByID(actorID int, id int) (*domainUser.User, error) {
+	args := m.Called(actorID, id)
+	return args.Get(0).(*domainUser.User), args.Error(1)
+}
+
+func (m *MockUserService) GetPublicProfile(id int) (*domainUser.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Create(user *domainUser.User) (*domainUser.User, error) {
-	args := m.Called(user)
+func (m *MockUserService) Create(actorID int, user *domainUser.User) (*domainUser.User, error) {
+	args := m.Called(actorID, user)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
@@ -42,26 +50,31 @@ func (m *MockUserService) GetOneByMap(userMap map[string]interface{}) (*domainUs
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
-	args := m.Called(id, userMap)
+func (m *MockUserService) Update(actorID int, id int, userMap map[string]interface{}) (*domainUser.User, error) {
+	args := m.Called(actorID, id, userMap)
 	return args.Get(0).(*domainUser.User), args.Error(1)
 }
 
-func (m *MockUserService) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockUserService) Delete(actorID int, id int) error {
+	args := m.Called(actorID, id)
 	return args.Error(0)
 }
 
-func (m *MockUserService) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
-	args := m.Called(filters)
+func (m *MockUserService) SearchPaginated(actorID int, filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	args := m.Called(actorID, filters)
 	return args.Get(0).(*domainUser.SearchResultUser), args.Error(1)
 }
 
-func (m *MockUserService) SearchByProperty(property string, searchText string) (*[]string, error) {
-	args := m.Called(property, searchText)
+func (m *MockUserService) SearchByProperty(actorID int, property string, searchText string) (*[]string, error) {
+	args := m.Called(actorID, property, searchText)
 	return args.Get(0).(*[]string), args.Error(1)
 }
 
+func (m *MockUserService) ChangePassword(id int, currentPassword string, newPassword string) error {
+	args := m.Called(id, currentPassword, newPassword)
+	return args.Error(0)
+}
+
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
 	if err != nil {
@@ -257,7 +270,7 @@ func TestUserController_NewUser(t *testing.T) {
 			HashPassword: "hashedpassword",
 		}
 
-		mockService.On("Create", mock.Anything).Return(expectedUser, nil)
+		mockService.On("Create", 0, mock.Anything).Return(expectedUser, nil)
 
 		controller.NewUser(c)
 
@@ -289,7 +302,7 @@ func TestUserController_NewUser(t *testing.T) {
 		c.Request = httptest.NewRequest("POST", "/users", bytes.NewBuffer(jsonData))
 		c.Request.Header.Set("Content-Type", "application/json")
 
-		mockService.On("Create", mock.Anything).Return((*domainUser.User)(nil), errors.New("service error"))
+		mockService.On("Create", 0, mock.Anything).Return((*domainUser.User)(nil), errors.New("service error"))
 
 		controller.NewUser(c)
 
@@ -305,6 +318,7 @@ func TestUserController_GetAllUsers(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("GET", "/users", nil)
 
 		expectedUsers := &[]domainUser.User{
@@ -312,7 +326,7 @@ func TestUserController_GetAllUsers(t *testing.T) {
 			{ID: 2, UserName: "user2", Email: "user2@example.com"},
 		}
 
-		mockService.On("GetAll").Return(expectedUsers, nil)
+		mockService.On("GetAll", 1).Return(expectedUsers, nil)
 
 		controller.GetAllUsers(c)
 
@@ -322,15 +336,33 @@ func TestUserController_GetAllUsers(t *testing.T) {
 
 	t.Run("Service Error", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("GET", "/users", nil)
 
-		mockService.On("GetAll").Return((*[]domainUser.User)(nil), errors.New("service error"))
+		mockService.On("GetAll", 1).Return((*[]domainUser.User)(nil), errors.New("service error"))
 
 		controller.GetAllUsers(c)
 
 		assert.Equal(t, http.StatusOK, w.Code) // Gin returns 200 even on errors
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("Not Admin", func(t *testing.T) {
+		mockService := &MockUserService{}
+		controller := NewUserController(mockService, loggerInstance)
+		c, _ := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 2)
+		c.Request = httptest.NewRequest("GET", "/users", nil)
+
+		mockService.On("GetAll", 2).Return((*[]domainUser.User)(nil), domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized))
+
+		controller.GetAllUsers(c)
+
+		assert.Len(t, c.Errors, 1)
+		var appErr *domainErrors.AppError
+		assert.ErrorAs(t, c.Errors.Last().Err, &appErr)
+		assert.Equal(t, domainErrors.NotAuthorized, appErr.Type)
+	})
 }
 
 func TestUserController_GetUsersByID(t *testing.T) {
@@ -342,6 +374,7 @@ func TestUserController_GetUsersByID(t *testing.T) {
 		c, w := setupGinContext()
 		c.Request = httptest.NewRequest("GET", "/users/1", nil)
 		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Set(middlewares.AuthUserIDKey, 1)
 
 		expectedUser := &domainUser.User{
 			ID:       1,
@@ -349,11 +382,34 @@ func TestUserController_GetUsersByID(t *testing.T) {
 			Email:    "user1@example.com",
 		}
 
-		mockService.On("GetByID", 1).Return(expectedUser, nil)
+		mockService.On("GetByID", 1, 1).Return(expectedUser, nil)
 
 		controller.GetUsersByID(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "user1@example.com", response["email"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Other users get the public profile", func(t *testing.T) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/users/2", nil)
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+		c.Set(middlewares.AuthUserIDKey, 3)
+
+		mockService.On("GetByID", 3, 2).Return((*domainUser.User)(nil), domainErrors.NewAppErrorWithType(domainErrors.NotAuthorized))
+		mockService.On("GetPublicProfile", 2).Return(&domainUser.User{ID: 2, UserName: "user2"}, nil)
+
+		controller.GetUsersByID(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "user2", response["user"])
+		assert.NotContains(t, response, "email")
+		assert.NotContains(t, response, "role")
 		mockService.AssertExpectations(t)
 	})
 
@@ -369,14 +425,15 @@ func TestUserController_GetUsersByID(t *testing.T) {
 
 	t.Run("Service Error", func(t *testing.T) {
 		c, w := setupGinContext()
-		c.Request = httptest.NewRequest("GET", "/users/1", nil)
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest("GET", "/users/9", nil)
+		c.Params = gin.Params{{Key: "id", Value: "9"}}
 
-		mockService.On("GetByID", 1).Return(nil, errors.New("service error"))
+		mockService.On("GetPublicProfile", 9).Return((*domainUser.User)(nil), errors.New("service error"))
 
 		controller.GetUsersByID(c)
 
 		assert.Equal(t, http.StatusOK, w.Code) // Gin returns 200 even on errors
+		assert.Len(t, c.Errors, 1)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -388,6 +445,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		updateData := map[string]any{
 			"user_name": "updateduser",
 			"email":     "updated@example.com",
@@ -403,7 +461,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 			Email:    "updated@example.com",
 		}
 
-		mockService.On("Update", 1, updateData).Return(expectedUser, nil)
+		mockService.On("Update", 1, 1, updateData).Return(expectedUser, nil)
 
 		controller.UpdateUser(c)
 
@@ -413,6 +471,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 
 	t.Run("Invalid ID", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("PUT", "/users/invalid", nil)
 		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
 
@@ -423,6 +482,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 
 	t.Run("Invalid JSON", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("PUT", "/users/1", bytes.NewBufferString("invalid json"))
 		c.Request.Header.Set("Content-Type", "application/json")
 		c.Params = gin.Params{{Key: "id", Value: "1"}}
@@ -434,13 +494,14 @@ func TestUserController_UpdateUser(t *testing.T) {
 
 	t.Run("Service Error", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		updateData := map[string]any{"user_name": "updateduser"}
 		jsonData, _ := json.Marshal(updateData)
 		c.Request = httptest.NewRequest("PUT", "/users/1", bytes.NewBuffer(jsonData))
 		c.Request.Header.Set("Content-Type", "application/json")
 		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		mockService.On("Update", 1, updateData).Return((*domainUser.User)(nil), errors.New("service error"))
+		mockService.On("Update", 1, 1, updateData).Return((*domainUser.User)(nil), errors.New("service error"))
 
 		controller.UpdateUser(c)
 
@@ -456,10 +517,11 @@ func TestUserController_DeleteUser(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("DELETE", "/users/1", nil)
 		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		mockService.On("Delete", 1).Return(nil)
+		mockService.On("Delete", 1, 1).Return(nil)
 
 		controller.DeleteUser(c)
 
@@ -469,6 +531,7 @@ func TestUserController_DeleteUser(t *testing.T) {
 
 	t.Run("Invalid ID", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("DELETE", "/users/invalid", nil)
 		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
 
@@ -479,10 +542,11 @@ func TestUserController_DeleteUser(t *testing.T) {
 
 	t.Run("Service Error", func(t *testing.T) {
 		c, w := setupGinContext()
+		c.Set(middlewares.AuthUserIDKey, 1)
 		c.Request = httptest.NewRequest("DELETE", "/users/1", nil)
 		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		mockService.On("Delete", 1).Return(errors.New("service error"))
+		mockService.On("Delete", 1, 1).Return(errors.New("service error"))
 
 		controller.DeleteUser(c)
 
@@ -490,3 +554,75 @@ func TestUserController_DeleteUser(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 }
+
+func setupMeContext(method string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest(method, "/user/me", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(middlewares.AuthPrincipalKey, &domainPrincipal.Principal{UserID: 7, Role: domainUser.RoleSubscriber})
+	return c, w
+}
+
+func TestUserController_GetMe(t *testing.T) {
+	mockService := &MockUserService{}
+	controller := NewUserController(mockService, setupLogger(t))
+
+	c, w := setupMeContext("GET", "")
+	mockService.On("GetByID", 7, 7).Return(&domainUser.User{ID: 7, Email: "me@example.com"}, nil)
+
+	controller.GetMe(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ResponseUser
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 7, response.ID)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserController_UpdateMe(t *testing.T) {
+	mockService := &MockUserService{}
+	controller := NewUserController(mockService, setupLogger(t))
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupMeContext("PATCH", `{"firstName":"Jane"}`)
+		mockService.On("Update", 7, 7, map[string]any{"firstName": "Jane"}).Return(&domainUser.User{ID: 7, FirstName: "Jane"}, nil)
+
+		controller.UpdateMe(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Role cannot be self-assigned", func(t *testing.T) {
+		c, _ := setupMeContext("PATCH", `{"role":"ADMIN"}`)
+
+		controller.UpdateMe(c)
+
+		assert.Len(t, c.Errors, 1)
+		mockService.AssertNotCalled(t, "Update", 7, map[string]any{"role": "ADMIN"})
+	})
+}
+
+func TestUserController_ChangeMyPassword(t *testing.T) {
+	mockService := &MockUserService{}
+	controller := NewUserController(mockService, setupLogger(t))
+
+	t.Run("Success", func(t *testing.T) {
+		c, w := setupMeContext("POST", `{"currentPassword":"old-password","newPassword":"new-password"}`)
+		mockService.On("ChangePassword", 7, "old-password", "new-password").Return(nil).Once()
+
+		controller.ChangeMyPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, c.Errors)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing fields", func(t *testing.T) {
+		c, _ := setupMeContext("POST", `{"newPassword":"new-password"}`)
+
+		controller.ChangeMyPassword(c)
+
+		assert.Len(t, c.Errors, 1)
+	})
+}
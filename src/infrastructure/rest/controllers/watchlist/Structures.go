@@ -0,0 +1,33 @@
+package watchlist
+
+import (
+	"time"
+)
+
+// WatchlistRequest is used both to create and to replace a watchlist
+type WatchlistRequest struct {
+	Name  string   `json:"name" binding:"required"`
+	Pairs []string `json:"pairs"`
+}
+
+type WatchlistResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Pairs     []string  `json:"pairs"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PairRateResponse struct {
+	Pair      string     `json:"pair"`
+	Base      string     `json:"base"`
+	Quote     string     `json:"quote"`
+	Rate      *float64   `json:"rate"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type WatchlistRatesResponse struct {
+	ID    int                `json:"id"`
+	Name  string             `json:"name"`
+	Rates []PairRateResponse `json:"rates"`
+}
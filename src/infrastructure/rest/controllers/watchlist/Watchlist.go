@@ -0,0 +1,195 @@
+package watchlist
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	useCaseWatchlist "github.com/gbrayhan/microservices-go/src/application/usecases/watchlist"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IWatchlistController interface {
+	NewWatchlist(ctx *gin.Context)
+	GetWatchlists(ctx *gin.Context)
+	GetWatchlist(ctx *gin.Context)
+	GetWatchlistRates(ctx *gin.Context)
+	UpdateWatchlist(ctx *gin.Context)
+	DeleteWatchlist(ctx *gin.Context)
+}
+
+type WatchlistController struct {
+	watchlistUseCase useCaseWatchlist.IWatchlistUseCase
+	Logger           *logger.Logger
+}
+
+func NewWatchlistController(watchlistUseCase useCaseWatchlist.IWatchlistUseCase, loggerInstance *logger.Logger) IWatchlistController {
+	return &WatchlistController{watchlistUseCase: watchlistUseCase, Logger: loggerInstance}
+}
+
+func (c *WatchlistController) NewWatchlist(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Creating new watchlist", zap.Int("userID", userID))
+	var request WatchlistRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new watchlist", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	watchlist, err := c.watchlistUseCase.Create(userID, request.Name, request.Pairs)
+	if err != nil {
+		c.Logger.Error("Error creating watchlist", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Watchlist created successfully", zap.Int("userID", userID), zap.Int("id", watchlist.ID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(watchlist))
+}
+
+func (c *WatchlistController) GetWatchlists(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting watchlists", zap.Int("userID", userID))
+	watchlists, err := c.watchlistUseCase.GetByUser(userID)
+	if err != nil {
+		c.Logger.Error("Error getting watchlists", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Successfully retrieved watchlists", zap.Int("count", len(*watchlists)))
+	ctx.JSON(http.StatusOK, arrayDomainToResponseMapper(watchlists))
+}
+
+func (c *WatchlistController) GetWatchlist(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	watchlistID, ok := c.watchlistID(ctx)
+	if !ok {
+		return
+	}
+	watchlist, err := c.watchlistUseCase.GetByID(userID, watchlistID)
+	if err != nil {
+		c.Logger.Error("Error getting watchlist", zap.Error(err), zap.Int("id", watchlistID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(watchlist))
+}
+
+func (c *WatchlistController) GetWatchlistRates(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	watchlistID, ok := c.watchlistID(ctx)
+	if !ok {
+		return
+	}
+	watchlist, rates, err := c.watchlistUseCase.GetRates(userID, watchlistID)
+	if err != nil {
+		c.Logger.Error("Error getting watchlist rates", zap.Error(err), zap.Int("id", watchlistID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := WatchlistRatesResponse{
+		ID:    watchlist.ID,
+		Name:  watchlist.Name,
+		Rates: make([]PairRateResponse, len(rates)),
+	}
+	for i, rate := range rates {
+		response.Rates[i] = pairRateToResponseMapper(rate)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WatchlistController) UpdateWatchlist(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	watchlistID, ok := c.watchlistID(ctx)
+	if !ok {
+		return
+	}
+	var request WatchlistRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for watchlist update", zap.Error(err), zap.Int("id", watchlistID))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	watchlist, err := c.watchlistUseCase.Update(userID, watchlistID, request.Name, request.Pairs)
+	if err != nil {
+		c.Logger.Error("Error updating watchlist", zap.Error(err), zap.Int("id", watchlistID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Watchlist updated successfully", zap.Int("id", watchlistID))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(watchlist))
+}
+
+func (c *WatchlistController) DeleteWatchlist(ctx *gin.Context) {
+	userID := ctx.GetInt(middlewares.AuthUserIDKey)
+	watchlistID, ok := c.watchlistID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.watchlistUseCase.Delete(userID, watchlistID); err != nil {
+		c.Logger.Error("Error deleting watchlist", zap.Error(err), zap.Int("id", watchlistID))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Watchlist deleted successfully", zap.Int("id", watchlistID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+func (c *WatchlistController) watchlistID(ctx *gin.Context) (int, bool) {
+	watchlistID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid watchlist ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return watchlistID, true
+}
+
+// Mappers
+func domainToResponseMapper(watchlist *domainWatchlist.Watchlist) *WatchlistResponse {
+	pairs := make([]string, len(watchlist.Pairs))
+	for i, pair := range watchlist.Pairs {
+		pairs[i] = pair.String()
+	}
+	return &WatchlistResponse{
+		ID:        watchlist.ID,
+		Name:      watchlist.Name,
+		Pairs:     pairs,
+		CreatedAt: watchlist.CreatedAt,
+		UpdatedAt: watchlist.UpdatedAt,
+	}
+}
+
+func arrayDomainToResponseMapper(watchlists *[]domainWatchlist.Watchlist) *[]WatchlistResponse {
+	res := make([]WatchlistResponse, len(*watchlists))
+	for i, w := range *watchlists {
+		res[i] = *domainToResponseMapper(&w)
+	}
+	return &res
+}
+
+// pairRateToResponseMapper leaves rate null for pairs without a stored rate
+func pairRateToResponseMapper(rate domainWatchlist.PairRate) PairRateResponse {
+	response := PairRateResponse{
+		Pair:  rate.Pair.String(),
+		Base:  rate.Pair.Base,
+		Quote: rate.Pair.Quote,
+	}
+	if rate.Available {
+		value := rate.Rate
+		response.Rate = &value
+		if !rate.UpdatedAt.IsZero() {
+			updatedAt := rate.UpdatedAt
+			response.UpdatedAt = &updatedAt
+		}
+	}
+	return response
+}
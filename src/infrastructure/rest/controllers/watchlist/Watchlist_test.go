@@ -0,0 +1,137 @@
+package watchlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWatchlist "github.com/gbrayhan/microservices-go/src/domain/watchlist"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// MockWatchlistUseCase implements IWatchlistUseCase for testing
+type MockWatchlistUseCase struct {
+	createdFor int
+}
+
+func (m *MockWatchlistUseCase) Create(userID int, name string, pairs []string) (*domainWatchlist.Watchlist, error) {
+	m.createdFor = userID
+	parsed := make([]domainWatchlist.Pair, len(pairs))
+	for i, raw := range pairs {
+		pair, err := domainWatchlist.ParsePair(raw)
+		if err != nil {
+			return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+		}
+		parsed[i] = pair
+	}
+	return &domainWatchlist.Watchlist{ID: 1, UserID: userID, Name: name, Pairs: parsed}, nil
+}
+
+func (m *MockWatchlistUseCase) GetByUser(userID int) (*[]domainWatchlist.Watchlist, error) {
+	return &[]domainWatchlist.Watchlist{}, nil
+}
+
+func (m *MockWatchlistUseCase) GetByID(userID int, id int) (*domainWatchlist.Watchlist, error) {
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *MockWatchlistUseCase) Update(userID int, id int, name string, pairs []string) (*domainWatchlist.Watchlist, error) {
+	return &domainWatchlist.Watchlist{ID: id, UserID: userID, Name: name}, nil
+}
+
+func (m *MockWatchlistUseCase) Delete(userID int, id int) error {
+	return nil
+}
+
+func (m *MockWatchlistUseCase) GetRates(userID int, id int) (*domainWatchlist.Watchlist, []domainWatchlist.PairRate, error) {
+	return &domainWatchlist.Watchlist{ID: id, Name: "majors"}, []domainWatchlist.PairRate{
+		{Pair: domainWatchlist.Pair{Base: "EUR", Quote: "USD"}, Rate: 1.08, Available: true, UpdatedAt: time.Now()},
+		{Pair: domainWatchlist.Pair{Base: "EUR", Quote: "XXX"}},
+	}, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func setupContext(method string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/v1/user/me/watchlists", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(middlewares.AuthUserIDKey, 7)
+	return c, w
+}
+
+func TestWatchlistController_NewWatchlist(t *testing.T) {
+	useCase := &MockWatchlistUseCase{}
+	controller := NewWatchlistController(useCase, setupLogger(t))
+
+	c, w := setupContext("POST", `{"name":"majors","pairs":["EUR/USD"]}`)
+	controller.NewWatchlist(c)
+
+	if w.Code != http.StatusOK || useCase.createdFor != 7 {
+		t.Fatalf("expected watchlist created for user 7, got status %d for user %d", w.Code, useCase.createdFor)
+	}
+	var response WatchlistResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(response.Pairs) != 1 || response.Pairs[0] != "EUR/USD" {
+		t.Errorf("unexpected pairs %v", response.Pairs)
+	}
+
+	c, _ = setupContext("POST", `{"pairs":["EUR/USD"]}`)
+	controller.NewWatchlist(c)
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error for a missing name, got %v", c.Errors)
+	}
+}
+
+func TestWatchlistController_GetWatchlistRates(t *testing.T) {
+	controller := NewWatchlistController(&MockWatchlistUseCase{}, setupLogger(t))
+
+	c, w := setupContext("GET", "")
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	controller.GetWatchlistRates(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var response WatchlistRatesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.ID != 3 || len(response.Rates) != 2 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if response.Rates[0].Rate == nil || *response.Rates[0].Rate != 1.08 {
+		t.Errorf("expected EUR/USD rate, got %+v", response.Rates[0])
+	}
+	if response.Rates[1].Rate != nil {
+		t.Errorf("expected no rate for an unknown currency, got %v", *response.Rates[1].Rate)
+	}
+}
+
+func TestWatchlistController_InvalidID(t *testing.T) {
+	controller := NewWatchlistController(&MockWatchlistUseCase{}, setupLogger(t))
+
+	c, _ := setupContext("DELETE", "")
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+	controller.DeleteWatchlist(c)
+
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error, got %v", c.Errors)
+	}
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"time"
+)
+
+type NewWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes" binding:"required"`
+	Currencies []string `json:"currencies"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"eventTypes" binding:"required"`
+	Currencies []string `json:"currencies"`
+	Active     *bool    `json:"active" binding:"required"`
+}
+
+type WebhookResponse struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Currencies []string  `json:"currencies"`
+	Active     bool      `json:"active"`
+	CreatedBy  int       `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// NewWebhookResponse is the only response that ever contains the plain signing secret
+type NewWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type DeliveryResponse struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhookId"`
+	EventID        string     `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	useCaseWebhook "github.com/gbrayhan/microservices-go/src/application/usecases/webhook"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IWebhookController interface {
+	NewWebhook(ctx *gin.Context)
+	GetWebhooks(ctx *gin.Context)
+	GetWebhook(ctx *gin.Context)
+	UpdateWebhook(ctx *gin.Context)
+	DeleteWebhook(ctx *gin.Context)
+	GetWebhookDeliveries(ctx *gin.Context)
+	RetryWebhookDelivery(ctx *gin.Context)
+}
+
+type WebhookController struct {
+	webhookUseCase useCaseWebhook.IWebhookUseCase
+	Logger         *logger.Logger
+}
+
+func NewWebhookController(webhookUseCase useCaseWebhook.IWebhookUseCase, loggerInstance *logger.Logger) IWebhookController {
+	return &WebhookController{webhookUseCase: webhookUseCase, Logger: loggerInstance}
+}
+
+func (c *WebhookController) NewWebhook(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Creating new webhook", zap.Int("adminID", adminID))
+	var request NewWebhookRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for new webhook", zap.Error(err))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	subscription, secret, err := c.webhookUseCase.Create(adminID, request.URL, request.Secret, request.EventTypes, request.Currencies)
+	if err != nil {
+		c.Logger.Error("Error creating webhook", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook created successfully", zap.Int("id", subscription.ID))
+	ctx.JSON(http.StatusOK, NewWebhookResponse{
+		WebhookResponse: *domainToResponseMapper(subscription),
+		Secret:          secret,
+	})
+}
+
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	c.Logger.Info("Getting webhooks", zap.Int("adminID", adminID))
+	subscriptions, err := c.webhookUseCase.GetAll(adminID)
+	if err != nil {
+		c.Logger.Error("Error getting webhooks", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	res := make([]WebhookResponse, len(*subscriptions))
+	for i, subscription := range *subscriptions {
+		res[i] = *domainToResponseMapper(&subscription)
+	}
+	c.Logger.Info("Successfully retrieved webhooks", zap.Int("count", len(res)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, ok := c.paramID(ctx)
+	if !ok {
+		return
+	}
+	subscription, err := c.webhookUseCase.GetByID(adminID, id)
+	if err != nil {
+		c.Logger.Error("Error getting webhook", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponseMapper(subscription))
+}
+
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, ok := c.paramID(ctx)
+	if !ok {
+		return
+	}
+	var request UpdateWebhookRequest
+	if err := controllers.BindJSON(ctx, &request); err != nil {
+		c.Logger.Error("Error binding JSON for webhook update", zap.Error(err), zap.Int("id", id))
+		appError := domainErrors.NewAppError(err, domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return
+	}
+	subscription, err := c.webhookUseCase.Update(adminID, id, request.URL, request.EventTypes, request.Currencies, *request.Active)
+	if err != nil {
+		c.Logger.Error("Error updating webhook", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook updated successfully", zap.Int("id", id))
+	ctx.JSON(http.StatusOK, domainToResponseMapper(subscription))
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, ok := c.paramID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.webhookUseCase.Delete(adminID, id); err != nil {
+		c.Logger.Error("Error deleting webhook", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook deleted successfully", zap.Int("id", id))
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted successfully"})
+}
+
+func (c *WebhookController) GetWebhookDeliveries(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, ok := c.paramID(ctx)
+	if !ok {
+		return
+	}
+	deliveries, err := c.webhookUseCase.GetDeliveries(adminID, id)
+	if err != nil {
+		c.Logger.Error("Error getting webhook deliveries", zap.Error(err), zap.Int("id", id))
+		_ = ctx.Error(err)
+		return
+	}
+	res := make([]DeliveryResponse, len(*deliveries))
+	for i, delivery := range *deliveries {
+		res[i] = deliveryToResponseMapper(&delivery)
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *WebhookController) RetryWebhookDelivery(ctx *gin.Context) {
+	adminID := ctx.GetInt(middlewares.AuthUserIDKey)
+	id, ok := c.paramID(ctx)
+	if !ok {
+		return
+	}
+	delivery, err := c.webhookUseCase.RetryDelivery(adminID, id)
+	if err != nil {
+		c.Logger.Error("Error retrying webhook delivery", zap.Error(err), zap.Int("deliveryID", id))
+		_ = ctx.Error(err)
+		return
+	}
+	c.Logger.Info("Webhook delivery requeued", zap.Int("deliveryID", id))
+	ctx.JSON(http.StatusOK, deliveryToResponseMapper(delivery))
+}
+
+func (c *WebhookController) paramID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.Logger.Error("Invalid webhook ID parameter", zap.Error(err), zap.String("id", ctx.Param("id")))
+		appError := domainErrors.NewAppError(errors.New("param id is necessary"), domainErrors.ValidationError)
+		_ = ctx.Error(appError)
+		return 0, false
+	}
+	return id, true
+}
+
+// Mappers
+func domainToResponseMapper(subscription *domainWebhook.Subscription) *WebhookResponse {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	currencies := subscription.Currencies
+	if currencies == nil {
+		currencies = []string{}
+	}
+	return &WebhookResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: eventTypes,
+		Currencies: currencies,
+		Active:     subscription.Active,
+		CreatedBy:  subscription.CreatedBy,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func deliveryToResponseMapper(delivery *domainWebhook.Delivery) DeliveryResponse {
+	return DeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
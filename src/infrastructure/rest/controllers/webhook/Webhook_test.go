@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domainCurrency "github.com/gbrayhan/microservices-go/src/domain/currency"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainWebhook "github.com/gbrayhan/microservices-go/src/domain/webhook"
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// MockWebhookUseCase implements IWebhookUseCase for testing
+type MockWebhookUseCase struct {
+	adminID int
+	active  bool
+}
+
+func (m *MockWebhookUseCase) Create(adminID int, targetURL string, secret string, eventTypes []string, currencies []string) (*domainWebhook.Subscription, string, error) {
+	m.adminID = adminID
+	return &domainWebhook.Subscription{ID: 1, URL: targetURL, EventTypes: eventTypes, Active: true}, "plain-secret", nil
+}
+func (m *MockWebhookUseCase) GetAll(adminID int) (*[]domainWebhook.Subscription, error) {
+	return &[]domainWebhook.Subscription{}, nil
+}
+func (m *MockWebhookUseCase) GetByID(adminID int, id int) (*domainWebhook.Subscription, error) {
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+func (m *MockWebhookUseCase) Update(adminID int, id int, targetURL string, eventTypes []string, currencies []string, active bool) (*domainWebhook.Subscription, error) {
+	m.active = active
+	return &domainWebhook.Subscription{ID: id, URL: targetURL, EventTypes: eventTypes, Active: active}, nil
+}
+func (m *MockWebhookUseCase) Delete(adminID int, id int) error {
+	return nil
+}
+func (m *MockWebhookUseCase) GetDeliveries(adminID int, subscriptionID int) (*[]domainWebhook.Delivery, error) {
+	return &[]domainWebhook.Delivery{
+		{ID: 4, SubscriptionID: subscriptionID, EventType: domainWebhook.EventRatesUpdated, Status: domainWebhook.DeliveryDead, Attempts: 6, LastError: "endpoint responded with status 500"},
+	}, nil
+}
+func (m *MockWebhookUseCase) RetryDelivery(adminID int, deliveryID int) (*domainWebhook.Delivery, error) {
+	return &domainWebhook.Delivery{ID: deliveryID, Status: domainWebhook.DeliveryPending}, nil
+}
+func (m *MockWebhookUseCase) OnRatesUpdated(currencies []domainCurrency.Currency) {}
+func (m *MockWebhookUseCase) OnCurrencyDeleted(currency domainCurrency.Currency)  {}
+func (m *MockWebhookUseCase) ProcessDue()                                         {}
+func (m *MockWebhookUseCase) Run(ctx context.Context)                             {}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func setupContext(method string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/v1/webhook", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(middlewares.AuthUserIDKey, 1)
+	return c, w
+}
+
+func TestWebhookController_NewWebhook(t *testing.T) {
+	useCase := &MockWebhookUseCase{}
+	controller := NewWebhookController(useCase, setupLogger(t))
+
+	c, w := setupContext("POST", `{"url":"https://example.com/hook","eventTypes":["rates.updated"]}`)
+	controller.NewWebhook(c)
+
+	if w.Code != http.StatusOK || useCase.adminID != 1 {
+		t.Fatalf("expected webhook created by admin 1, got status %d for %d", w.Code, useCase.adminID)
+	}
+	var response NewWebhookResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Secret != "plain-secret" || response.Currencies == nil {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	c, _ = setupContext("POST", `{"url":"https://example.com/hook"}`)
+	controller.NewWebhook(c)
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error for missing event types, got %v", c.Errors)
+	}
+}
+
+func TestWebhookController_UpdateWebhook(t *testing.T) {
+	useCase := &MockWebhookUseCase{active: true}
+	controller := NewWebhookController(useCase, setupLogger(t))
+
+	c, w := setupContext("PUT", `{"url":"https://example.com/hook","eventTypes":["rates.updated"],"active":false}`)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	controller.UpdateWebhook(c)
+	if w.Code != http.StatusOK || useCase.active {
+		t.Errorf("expected the webhook to be disabled, got status %d", w.Code)
+	}
+
+	c, _ = setupContext("PUT", `{"url":"https://example.com/hook","eventTypes":["rates.updated"]}`)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	controller.UpdateWebhook(c)
+	if len(c.Errors) != 1 {
+		t.Errorf("expected a validation error for a missing active flag, got %v", c.Errors)
+	}
+}
+
+func TestWebhookController_GetWebhookDeliveries(t *testing.T) {
+	controller := NewWebhookController(&MockWebhookUseCase{}, setupLogger(t))
+
+	c, w := setupContext("GET", "")
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	controller.GetWebhookDeliveries(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var response []DeliveryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(response) != 1 || response[0].WebhookID != 2 || response[0].Status != "dead" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
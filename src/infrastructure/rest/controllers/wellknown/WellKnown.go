@@ -0,0 +1,32 @@
+package wellknown
+
+import (
+	"net/http"
+
+	logger "github.com/gbrayhan/microservices-go/src/infrastructure/logger"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IWellKnownController interface {
+	JWKS(ctx *gin.Context)
+}
+
+type WellKnownController struct {
+	jwtService security.IJWTService
+	Logger     *logger.Logger
+}
+
+func NewWellKnownController(jwtService security.IJWTService, loggerInstance *logger.Logger) IWellKnownController {
+	return &WellKnownController{jwtService: jwtService, Logger: loggerInstance}
+}
+
+// JWKS publishes the public keys used to verify access tokens. Verifiers may cache the
+// document briefly; a rotated-in key is published before it becomes the active one.
+func (c *WellKnownController) JWKS(ctx *gin.Context) {
+	jwks := c.jwtService.JWKS()
+	c.Logger.Info("Serving JWKS", zap.Int("keys", len(jwks.Keys)))
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
@@ -9,13 +9,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxLoggedBodySize caps the captured response so long-lived streams do not grow it forever
+const maxLoggedBodySize = 64 << 10
+
 type bodyLogWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
 }
 
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if remaining := maxLoggedBodySize - w.body.Len(); remaining > 0 {
+		w.body.Write(b[:min(len(b), remaining)])
+	}
 	return w.ResponseWriter.Write(b)
 }
 
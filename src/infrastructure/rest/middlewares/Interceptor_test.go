@@ -162,6 +162,30 @@ func TestBodyLogWriter_Write(t *testing.T) {
 	}
 }
 
+func TestBodyLogWriter_WriteCapsCapturedBody(t *testing.T) {
+	mockWriter := &MockResponseWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+	}
+	blw := &bodyLogWriter{
+		ResponseWriter: mockWriter,
+		body:           bytes.NewBufferString(""),
+	}
+
+	chunk := []byte(strings.Repeat("a", 40<<10))
+	for i := 0; i < 3; i++ {
+		if _, err := blw.Write(chunk); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if blw.body.Len() != maxLoggedBodySize {
+		t.Errorf("Expected captured body to be capped at %d bytes, got %d", maxLoggedBodySize, blw.body.Len())
+	}
+	if mockWriter.Body.Len() != 3*len(chunk) {
+		t.Errorf("Expected the full response to be written, got %d bytes", mockWriter.Body.Len())
+	}
+}
+
 func TestGinBodyLogMiddleware_LargeBody(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
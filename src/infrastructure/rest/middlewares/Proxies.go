@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies returns the addresses or CIDRs in TRUSTED_PROXIES, comma separated, whose
+// X-Forwarded-For headers the router believes. None are trusted by default, so the client IP the
+// login throttle keys on is the peer address unless a proxy in front of the service is listed.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clientIP := func(proxies string) string {
+		t.Setenv("TRUSTED_PROXIES", proxies)
+		router := gin.New()
+		if err := router.SetTrustedProxies(TrustedProxies()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ip string
+		router.GET("/", func(c *gin.Context) { ip = c.ClientIP() })
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		return ip
+	}
+
+	if ip := clientIP(""); ip != "192.0.2.1" {
+		t.Errorf("expected the peer address without trusted proxies, got %s", ip)
+	}
+	if ip := clientIP("10.0.0.0/8, 192.0.2.1"); ip != "203.0.113.1" {
+		t.Errorf("expected the forwarded address from a trusted proxy, got %s", ip)
+	}
+}
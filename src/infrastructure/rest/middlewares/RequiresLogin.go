@@ -1,63 +1,212 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainPrincipal "github.com/gbrayhan/microservices-go/src/domain/principal"
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-func AuthJWTMiddleware() gin.HandlerFunc {
+const (
+	// AuthUserIDKey is the gin.Context key holding the id of the authenticated user
+	AuthUserIDKey = "authUserID"
+	// AuthScopesKey is the gin.Context key holding the scopes granted to a machine client.
+	// It is absent for interactive user sessions, which are not scope-restricted.
+	AuthScopesKey = "authScopes"
+	// AuthClientIDKey is the gin.Context key holding the OAuth2 client id of a client_credentials token
+	AuthClientIDKey = "authClientID"
+	// AuthPrincipalKey is the gin.Context key holding the resolved *principal.Principal
+	AuthPrincipalKey = "authPrincipal"
+)
+
+// UserResolver loads the user behind a token so its current role is used rather than a stale claim
+type UserResolver interface {
+	GetByID(id int) (*domainUser.User, error)
+}
+
+// GetPrincipal returns the principal resolved by the auth middleware
+func GetPrincipal(c *gin.Context) (*domainPrincipal.Principal, bool) {
+	value, ok := c.Get(AuthPrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := value.(*domainPrincipal.Principal)
+	return p, ok && p != nil
+}
+
+func setPrincipal(c *gin.Context, p *domainPrincipal.Principal) {
+	c.Set(AuthPrincipalKey, p)
+	if p.UserID != 0 {
+		c.Set(AuthUserIDKey, p.UserID)
+	}
+	if p.ClientID != "" {
+		c.Set(AuthClientIDKey, p.ClientID)
+	}
+	if p.Scopes != nil {
+		c.Set(AuthScopesKey, p.Scopes)
+	}
+}
+
+func resolveUser(users UserResolver, id int) (*domainUser.User, bool) {
+	u, err := users.GetByID(id)
+	if err != nil || u == nil || u.ID == 0 {
+		return nil, false
+	}
+	return u, true
+}
+
+// APITokenAuthenticator resolves personal API tokens presented as bearer tokens
+type APITokenAuthenticator interface {
+	Authenticate(token string) (*domainAPIToken.APIToken, error)
+}
+
+// AuthMiddleware accepts personal API tokens alongside access JWTs
+func AuthMiddleware(jwtService security.IJWTService, users UserResolver, apiTokens APITokenAuthenticator) gin.HandlerFunc {
+	jwtMiddleware := AuthJWTMiddleware(jwtService, users)
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token not provided"})
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !strings.HasPrefix(tokenString, domainAPIToken.TokenPrefix) {
+			jwtMiddleware(c)
+			return
+		}
+
+		token, err := apiTokens.Authenticate(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
 			c.Abort()
 			return
 		}
 
-		accessSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-		if accessSecret == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "JWT_ACCESS_SECRET_KEY not configured"})
+		u, ok := resolveUser(users, token.UserID)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
 			c.Abort()
 			return
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		claims := jwt.MapClaims{}
-		_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-			return []byte(accessSecret), nil
-		})
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		scopes := token.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		setPrincipal(c, &domainPrincipal.Principal{UserID: u.ID, Role: u.Role, Scopes: scopes})
+		c.Next()
+	}
+}
+
+// OptionalAuth runs the given auth middleware only when credentials are presented, so public
+// routes can still tell who is calling
+func OptionalAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// TokenFromQuery lets clients that cannot set headers, such as browser WebSockets, present their
+// bearer token in the given query parameter. The parameter is removed from the request so the
+// token does not end up in request logs; an Authorization header always takes precedence.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		token := query.Get(param)
+		if token == "" {
+			c.Next()
+			return
+		}
+		query.Del(param)
+		c.Request.URL.RawQuery = query.Encode()
+		if c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// RequireScopes rejects scope-restricted callers that were not granted every given scope
+func RequireScopes(required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, restricted := c.Get(AuthScopesKey)
+		if restricted {
+			grantedScopes, _ := granted.([]string)
+			for _, s := range required {
+				if !scope.Contains(grantedScopes, s) {
+					c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient scope"})
+					c.Abort()
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireUser rejects OAuth2 clients on routes that act on behalf of a user
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(AuthUserIDKey); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "User session required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthJWTMiddleware verifies access tokens through the JWT service, so it follows whichever
+// signing keys the service is configured with. User tokens are resolved to their current user.
+func AuthJWTMiddleware(jwtService security.IJWTService, users UserResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.GetHeader("Authorization")
+		if tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token not provided"})
 			c.Abort()
 			return
 		}
 
-		if exp, ok := claims["exp"].(float64); ok {
-			if int64(exp) < jwt.TimeFunc().Unix() {
+		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+		claims, err := jwtService.GetClaimsAndVerifyToken(tokenString, security.Access)
+		if err != nil {
+			switch {
+			case errors.Is(err, security.ErrTokenExpired):
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-				c.Abort()
-				return
+			case errors.Is(err, security.ErrInvalidClaims):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			case errors.Is(err, security.ErrMissingTokenType):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing token type"})
+			case errors.Is(err, security.ErrTokenTypeMismatch):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Token type mismatch"})
+			default:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			}
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
-		if t, ok := claims["type"].(string); ok {
-			if t != "access" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Token type mismatch"})
+		if clientID, ok := claims["client_id"].(string); ok && clientID != "" {
+			scopeClaim, _ := claims["scope"].(string)
+			scopes := strings.Fields(scopeClaim)
+			if scopes == nil {
+				scopes = []string{}
+			}
+			setPrincipal(c, &domainPrincipal.Principal{ClientID: clientID, Scopes: scopes})
+		} else {
+			id, _ := claims["id"].(float64)
+			u, ok := resolveUser(users, int(id))
+			if !ok {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 				c.Abort()
 				return
 			}
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Missing token type"})
-			c.Abort()
-			return
+			setPrincipal(c, &domainPrincipal.Principal{UserID: u.ID, Role: u.Role})
 		}
 
 		c.Next()
@@ -2,12 +2,16 @@ package middlewares
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
+	domainAPIToken "github.com/gbrayhan/microservices-go/src/domain/apitoken"
+	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
+	domainUser "github.com/gbrayhan/microservices-go/src/domain/user"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/security"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
@@ -20,11 +24,35 @@ func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
 	return c, w
 }
 
+func newTestJWTService() security.IJWTService {
+	return security.NewJWTServiceWithConfig(security.JWTConfig{
+		AccessSecret:  "test-secret",
+		RefreshSecret: "test-refresh-secret",
+		AccessTime:    60,
+		RefreshTime:   24,
+	})
+}
+
+// fakeUserResolver knows every user id except those listed in missing
+type fakeUserResolver struct {
+	role    domainUser.Role
+	missing []int
+}
+
+func (f fakeUserResolver) GetByID(id int) (*domainUser.User, error) {
+	for _, m := range f.missing {
+		if m == id {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+	}
+	return &domainUser.User{ID: id, Role: f.role}, nil
+}
+
 func TestAuthJWTMiddleware_NoToken(t *testing.T) {
 	c, w := setupGinContext()
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -35,17 +63,20 @@ func TestAuthJWTMiddleware_NoToken(t *testing.T) {
 	assert.Equal(t, "Token not provided", response["error"])
 }
 
-func TestAuthJWTMiddleware_NoJWTSecret(t *testing.T) {
-	// Clear JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Unsetenv("JWT_ACCESS_SECRET_KEY")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
+func TestAuthJWTMiddleware_WrongSecret(t *testing.T) {
+	claims := jwt.MapClaims{
+		"exp":  time.Now().Add(1 * time.Hour).Unix(),
+		"type": "access",
+		"id":   123,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, _ := token.SignedString([]byte("another-secret"))
 
 	c, w := setupGinContext()
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
-	c.Request.Header.Set("Authorization", "Bearer valid-token")
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -53,20 +84,15 @@ func TestAuthJWTMiddleware_NoJWTSecret(t *testing.T) {
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "JWT_ACCESS_SECRET_KEY not configured", response["error"])
+	assert.Equal(t, "Invalid token", response["error"])
 }
 
 func TestAuthJWTMiddleware_InvalidToken(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	c, w := setupGinContext()
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer invalid-token")
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -78,11 +104,6 @@ func TestAuthJWTMiddleware_InvalidToken(t *testing.T) {
 }
 
 func TestAuthJWTMiddleware_ExpiredToken(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create expired token
 	claims := jwt.MapClaims{
 		"exp":  time.Now().Add(-1 * time.Hour).Unix(), // Expired 1 hour ago
@@ -95,7 +116,7 @@ func TestAuthJWTMiddleware_ExpiredToken(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -108,11 +129,6 @@ func TestAuthJWTMiddleware_ExpiredToken(t *testing.T) {
 }
 
 func TestAuthJWTMiddleware_InvalidTokenClaims(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create token without exp claim
 	claims := jwt.MapClaims{
 		"type": "access",
@@ -124,7 +140,7 @@ func TestAuthJWTMiddleware_InvalidTokenClaims(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -136,11 +152,6 @@ func TestAuthJWTMiddleware_InvalidTokenClaims(t *testing.T) {
 }
 
 func TestAuthJWTMiddleware_WrongTokenType(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create token with wrong type
 	claims := jwt.MapClaims{
 		"exp":  time.Now().Add(1 * time.Hour).Unix(),
@@ -153,7 +164,7 @@ func TestAuthJWTMiddleware_WrongTokenType(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusForbidden, w.Code)
@@ -165,11 +176,6 @@ func TestAuthJWTMiddleware_WrongTokenType(t *testing.T) {
 }
 
 func TestAuthJWTMiddleware_MissingTokenType(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create token without type claim
 	claims := jwt.MapClaims{
 		"exp": time.Now().Add(1 * time.Hour).Unix(),
@@ -181,7 +187,7 @@ func TestAuthJWTMiddleware_MissingTokenType(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusForbidden, w.Code)
@@ -193,16 +199,13 @@ func TestAuthJWTMiddleware_MissingTokenType(t *testing.T) {
 }
 
 func TestAuthJWTMiddleware_ValidToken(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create valid token
 	claims := jwt.MapClaims{
 		"exp":  time.Now().Add(1 * time.Hour).Unix(),
 		"type": "access",
 		"id":   123,
+		"sub":  "123",
+		"jti":  "test-token-id",
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, _ := token.SignedString([]byte("test-secret"))
@@ -211,23 +214,21 @@ func TestAuthJWTMiddleware_ValidToken(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 123, c.GetInt(AuthUserIDKey))
 }
 
 func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
-	// Set JWT_ACCESS_SECRET_KEY
-	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
-	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
-	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
-
 	// Create valid token
 	claims := jwt.MapClaims{
 		"exp":  time.Now().Add(1 * time.Hour).Unix(),
 		"type": "access",
 		"id":   123,
+		"sub":  "123",
+		"jti":  "test-token-id",
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, _ := token.SignedString([]byte("test-secret"))
@@ -236,10 +237,267 @@ func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/protected", nil)
 	c.Request.Header.Set("Authorization", tokenString) // Without "Bearer " prefix
 
-	middleware := AuthJWTMiddleware()
+	middleware := AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})
 	middleware(c)
 
 	// The middleware should still process the token even without "Bearer " prefix
 	// because strings.TrimPrefix handles this case
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+type fakeAPITokenAuthenticator struct {
+	token *domainAPIToken.APIToken
+	err   error
+}
+
+func (f *fakeAPITokenAuthenticator) Authenticate(token string) (*domainAPIToken.APIToken, error) {
+	return f.token, f.err
+}
+
+func TestAuthMiddleware_APIToken(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer pat_valid")
+
+	authenticator := &fakeAPITokenAuthenticator{token: &domainAPIToken.APIToken{UserID: 7, Scopes: []string{"currency:read"}}}
+	AuthMiddleware(newTestJWTService(), fakeUserResolver{}, authenticator)(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, 7, c.GetInt(AuthUserIDKey))
+	assert.Equal(t, []string{"currency:read"}, c.GetStringSlice(AuthScopesKey))
+}
+
+func TestAuthMiddleware_InvalidAPIToken(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer pat_revoked")
+
+	AuthMiddleware(newTestJWTService(), fakeUserResolver{}, &fakeAPITokenAuthenticator{err: errors.New("invalid API token")})(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, c.IsAborted())
+}
+
+func TestAuthMiddleware_FallsBackToJWT(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+
+	AuthMiddleware(newTestJWTService(), fakeUserResolver{}, &fakeAPITokenAuthenticator{err: errors.New("should not be called")})(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Token not provided", response["error"])
+}
+
+func TestRequireScopes(t *testing.T) {
+	tests := []struct {
+		name         string
+		scopes       []string
+		restricted   bool
+		expectedCode int
+	}{
+		{name: "user session is not scope restricted", expectedCode: http.StatusOK},
+		{name: "granted scope", scopes: []string{"currency:read", "currency:write"}, restricted: true, expectedCode: http.StatusOK},
+		{name: "missing scope", scopes: []string{"currency:read"}, restricted: true, expectedCode: http.StatusForbidden},
+		{name: "no scopes", restricted: true, expectedCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := setupGinContext()
+			c.Request = httptest.NewRequest("DELETE", "/currency/1", nil)
+			if tt.restricted {
+				c.Set(AuthScopesKey, tt.scopes)
+			}
+
+			RequireScopes("currency:write")(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedCode != http.StatusOK, c.IsAborted())
+		})
+	}
+}
+
+func TestAuthJWTMiddleware_ClientToken(t *testing.T) {
+	claims := jwt.MapClaims{
+		"exp":       time.Now().Add(1 * time.Hour).Unix(),
+		"type":      "access",
+		"id":        0,
+		"client_id": "cli_test",
+		"scope":     "currency:read exchanger:read",
+		"sub":       "cli_test",
+		"jti":       "test-token-id",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, _ := token.SignedString([]byte("test-secret"))
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+
+	AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{})(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cli_test", c.GetString(AuthClientIDKey))
+	assert.Equal(t, []string{"currency:read", "exchanger:read"}, c.GetStringSlice(AuthScopesKey))
+	_, hasUser := c.Get(AuthUserIDKey)
+	assert.False(t, hasUser)
+}
+
+func TestRequireUser(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Set(AuthClientIDKey, "cli_test")
+
+	RequireUser()(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.True(t, c.IsAborted())
+
+	c, w = setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Set(AuthUserIDKey, 7)
+
+	RequireUser()(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+}
+
+func TestAuthJWTMiddleware_IssuerAndAudience(t *testing.T) {
+	service := security.NewJWTServiceWithConfig(security.JWTConfig{
+		AccessSecret: "test-secret",
+		AccessTime:   60,
+		Issuer:       "microservice",
+		Audience:     "microservices-go",
+	})
+	valid, err := service.GenerateJWTToken(123, security.Access)
+	assert.NoError(t, err)
+	otherAudience, err := service.GenerateClientToken("cli_test", []string{"currency:read"}, []string{"billing-api"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{name: "issued for this service", token: valid.Token, expectedCode: http.StatusOK},
+		{name: "issued for another audience", token: otherAudience.Token, expectedCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := setupGinContext()
+			c.Request = httptest.NewRequest("GET", "/protected", nil)
+			c.Request.Header.Set("Authorization", "Bearer "+tt.token)
+
+			AuthJWTMiddleware(service, fakeUserResolver{})(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAuthJWTMiddleware_SetsPrincipal(t *testing.T) {
+	service := newTestJWTService()
+	token, err := service.GenerateJWTToken(123, security.Access)
+	assert.NoError(t, err)
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token.Token)
+
+	AuthJWTMiddleware(service, fakeUserResolver{role: domainUser.RoleAdmin})(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	p, ok := GetPrincipal(c)
+	assert.True(t, ok)
+	assert.Equal(t, 123, p.UserID)
+	assert.True(t, p.IsAdmin())
+	assert.Nil(t, p.Scopes)
+}
+
+func TestAuthJWTMiddleware_UnknownUser(t *testing.T) {
+	service := newTestJWTService()
+	token, err := service.GenerateJWTToken(123, security.Access)
+	assert.NoError(t, err)
+
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token.Token)
+
+	AuthJWTMiddleware(service, fakeUserResolver{missing: []int{123}})(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, c.IsAborted())
+	_, ok := GetPrincipal(c)
+	assert.False(t, ok)
+}
+
+func TestAuthMiddleware_APITokenPrincipal(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer pat_valid")
+
+	authenticator := &fakeAPITokenAuthenticator{token: &domainAPIToken.APIToken{UserID: 7, Scopes: []string{"user:read"}}}
+	AuthMiddleware(newTestJWTService(), fakeUserResolver{role: domainUser.RoleSubscriber}, authenticator)(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	p, ok := GetPrincipal(c)
+	assert.True(t, ok)
+	assert.Equal(t, 7, p.UserID)
+	assert.Equal(t, domainUser.RoleSubscriber, p.Role)
+	assert.Equal(t, []string{"user:read"}, p.Scopes)
+	assert.False(t, p.IsAdmin())
+}
+
+func TestAuthMiddleware_APITokenOfDeletedUser(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/protected", nil)
+	c.Request.Header.Set("Authorization", "Bearer pat_valid")
+
+	authenticator := &fakeAPITokenAuthenticator{token: &domainAPIToken.APIToken{UserID: 7}}
+	AuthMiddleware(newTestJWTService(), fakeUserResolver{missing: []int{7}}, authenticator)(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, c.IsAborted())
+}
+
+func TestOptionalAuth(t *testing.T) {
+	c, w := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/user/1", nil)
+
+	OptionalAuth(AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{}))(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, c.IsAborted())
+	_, ok := GetPrincipal(c)
+	assert.False(t, ok)
+
+	c, w = setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/user/1", nil)
+	c.Request.Header.Set("Authorization", "Bearer invalid")
+
+	OptionalAuth(AuthJWTMiddleware(newTestJWTService(), fakeUserResolver{}))(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, c.IsAborted())
+}
+
+func TestTokenFromQuery(t *testing.T) {
+	c, _ := setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/v1/currency/ws?access_token=abc&x=1", nil)
+
+	TokenFromQuery("access_token")(c)
+
+	assert.Equal(t, "Bearer abc", c.GetHeader("Authorization"))
+	assert.Equal(t, "x=1", c.Request.URL.RawQuery)
+
+	c, _ = setupGinContext()
+	c.Request = httptest.NewRequest("GET", "/v1/currency/ws?access_token=abc", nil)
+	c.Request.Header.Set("Authorization", "Bearer header")
+
+	TokenFromQuery("access_token")(c)
+
+	assert.Equal(t, "Bearer header", c.GetHeader("Authorization"))
+	assert.Empty(t, c.Request.URL.RawQuery)
+}
@@ -17,6 +17,9 @@ func ErrorHandler() gin.HandlerFunc {
 			var appErr *domainErrors.AppError
 			if errors.As(err, &appErr) {
 				status, message := domainErrors.AppErrorToHTTP(appErr)
+				if retryAfter, ok := appErr.Meta["retryAfter"]; ok {
+					c.Header("Retry-After", retryAfter)
+				}
 				c.JSON(status, gin.H{"error": message})
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
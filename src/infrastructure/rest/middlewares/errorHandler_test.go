@@ -265,3 +265,36 @@ func TestErrorHandler_NonAppError(t *testing.T) {
 		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
 	}
 }
+
+func TestErrorHandler_TooManyRequestsSetsRetryAfter(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new Gin router
+	router := gin.New()
+	router.Use(ErrorHandler())
+
+	// Add a test route that generates a throttling error with retry information
+	router.GET("/test", func(c *gin.Context) {
+		appErr := domainErrors.NewAppErrorWithType(domainErrors.TooManyRequests)
+		appErr.Meta = map[string]string{"retryAfter": "30"}
+		_ = c.Error(appErr)
+	})
+
+	// Create a test request
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	// Serve the request
+	router.ServeHTTP(w, req)
+
+	// Check response status
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", w.Code)
+	}
+
+	// Check Retry-After header
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After header 30, got %s", w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/alert"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func AlertRoutes(router *gin.RouterGroup, controller alert.IAlertController, authMiddleware gin.HandlerFunc) {
+	u := router.Group("/user/me/alerts")
+	u.Use(authMiddleware, middlewares.RequireUser())
+	{
+		u.POST("/", middlewares.RequireScopes(scope.AlertWrite), controller.NewAlert)
+		u.GET("/", middlewares.RequireScopes(scope.AlertRead), controller.GetAlerts)
+		u.GET("/events", middlewares.RequireScopes(scope.AlertRead), controller.GetAlertEvents)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.AlertWrite), controller.DeleteAlert)
+	}
+}
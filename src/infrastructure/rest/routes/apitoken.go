@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/apitoken"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenRoutes only accepts user sessions: an API token cannot be used to mint or revoke tokens
+func APITokenRoutes(router *gin.RouterGroup, controller apitoken.IAPITokenController, jwtMiddleware gin.HandlerFunc) {
+	u := router.Group("/user/me/tokens")
+	u.Use(jwtMiddleware, middlewares.RequireUser())
+	{
+		u.POST("/", controller.NewAPIToken)
+		u.GET("/", controller.GetAPITokens)
+		u.DELETE("/:id", controller.RevokeAPIToken)
+	}
+}
@@ -2,10 +2,11 @@ package routes
 
 import (
 	authController "github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/auth"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(router *gin.RouterGroup, controller authController.IAuthController) {
+func AuthRoutes(router *gin.RouterGroup, controller authController.IAuthController, jwtMiddleware gin.HandlerFunc) {
 	routerAuth := router.Group("/auth")
 	{
 		routerAuth.POST("/login", controller.Login)
@@ -13,5 +14,18 @@ func AuthRoutes(router *gin.RouterGroup, controller authController.IAuthControll
 		routerAuth.POST("/register", controller.Register)
 		//routerAuth.POST("/forgot-password", controller.ForgotPassword)
 		routerAuth.POST("/access-token", controller.GetAccessTokenByRefreshToken)
+		routerAuth.POST("/2fa/verify", controller.VerifyTwoFactor)
+		routerAuth.POST("/2fa/challenge/enroll", controller.EnrollTwoFactorWithChallenge)
+		routerAuth.POST("/2fa/challenge/confirm", controller.ConfirmTwoFactorWithChallenge)
+	}
+
+	routerProtected := routerAuth.Group("")
+	routerProtected.Use(jwtMiddleware, middlewares.RequireUser())
+	{
+		routerProtected.POST("/accounts/:id/unlock", controller.UnlockAccount)
+		routerProtected.GET("/login-attempts", controller.GetLoginAttempts)
+		routerProtected.POST("/2fa/enroll", controller.EnrollTwoFactor)
+		routerProtected.POST("/2fa/confirm", controller.ConfirmTwoFactor)
+		routerProtected.POST("/2fa/disable", controller.DisableTwoFactor)
 	}
 }
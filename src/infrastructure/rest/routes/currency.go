@@ -1,21 +1,35 @@
 package routes
 
 import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/currency"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func CurrencyRoutes(router *gin.RouterGroup, controller currency.ICurrencyController) {
+func CurrencyRoutes(router *gin.RouterGroup, controller currency.ICurrencyController, authMiddleware gin.HandlerFunc) {
 	u := router.Group("/currency")
 	{
 		u.GET("/:id", controller.GetCurrenciesByID)
 	}
 
-	u.Use(middlewares.AuthJWTMiddleware())
+	u.Use(authMiddleware)
 	{
-		u.GET("/", controller.GetAllCurrencies)
-		u.DELETE("/:id", controller.DeleteCurrency)
-		u.PUT("/rates", controller.UpdateExchanges)
+		u.GET("/", middlewares.RequireScopes(scope.CurrencyRead), controller.GetAllCurrencies)
+		u.GET("/search", middlewares.RequireScopes(scope.CurrencyRead), controller.SearchPaginated)
+		u.GET("/search-property", middlewares.RequireScopes(scope.CurrencyRead), controller.SearchByProperty)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.CurrencyWrite), controller.DeleteCurrency)
+		u.GET("/:id/ohlc", middlewares.RequireScopes(scope.CurrencyRead), controller.GetOHLC)
+		u.GET("/:id/stats", middlewares.RequireScopes(scope.CurrencyRead), controller.GetStats)
+		u.GET("/:id/history", middlewares.RequireScopes(scope.CurrencyRead), controller.ExportHistory)
+		u.PUT("/rates", middlewares.RequireScopes(scope.CurrencyWrite), controller.UpdateExchanges)
+		u.POST("/import", middlewares.RequireScopes(scope.CurrencyWrite), controller.Import)
+	}
+
+	c := router.Group("/convert")
+	c.Use(authMiddleware)
+	{
+		c.GET("", middlewares.RequireScopes(scope.CurrencyRead), controller.Convert)
+		c.POST("/batch", middlewares.RequireScopes(scope.CurrencyRead), controller.ConvertBatch)
 	}
 }
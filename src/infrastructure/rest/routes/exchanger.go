@@ -1,19 +1,24 @@
 package routes
 
 import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/exchanger"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func ExchangerRoutes(router *gin.RouterGroup, controller exchanger.IExchangerController) {
+func ExchangerRoutes(router *gin.RouterGroup, controller exchanger.IExchangerController, authMiddleware gin.HandlerFunc) {
 	u := router.Group("/exchanger")
-	u.Use(middlewares.AuthJWTMiddleware())
+	u.Use(authMiddleware)
 	{
-		u.GET("/:id", controller.GetExchangersById)
-		u.POST("/", controller.NewExchanger)
-		u.GET("/", controller.GetAllExchangers)
-		u.PATCH("/:id", controller.UpdateExchanger)
-		u.DELETE("/:id", controller.DeleteExchanger)
+		u.GET("/:id", middlewares.RequireScopes(scope.ExchangerRead), controller.GetExchangersById)
+		u.GET("/:id/health", middlewares.RequireScopes(scope.ExchangerRead), controller.GetHealth)
+		u.GET("/:id/usage", middlewares.RequireScopes(scope.ExchangerRead), controller.GetUsage)
+		u.POST("/", middlewares.RequireScopes(scope.ExchangerWrite), controller.NewExchanger)
+		u.GET("/", middlewares.RequireScopes(scope.ExchangerRead), controller.GetAllExchangers)
+		u.GET("/search", middlewares.RequireScopes(scope.ExchangerRead), controller.SearchPaginated)
+		u.GET("/search-property", middlewares.RequireScopes(scope.ExchangerRead), controller.SearchByProperty)
+		u.PATCH("/:id", middlewares.RequireScopes(scope.ExchangerWrite), controller.UpdateExchanger)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.ExchangerWrite), controller.DeleteExchanger)
 	}
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/oauth"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func OAuthRoutes(router *gin.RouterGroup, controller oauth.IOAuthController, jwtMiddleware gin.HandlerFunc) {
+	u := router.Group("/oauth")
+	{
+		u.POST("/token", controller.Token)
+	}
+
+	clients := u.Group("/clients")
+	clients.Use(jwtMiddleware, middlewares.RequireUser())
+	{
+		clients.POST("/", controller.NewClient)
+		clients.GET("/", controller.GetClients)
+		clients.DELETE("/:id", controller.DeleteClient)
+	}
+}
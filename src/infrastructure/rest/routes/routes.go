@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gbrayhan/microservices-go/src/infrastructure/di"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +18,18 @@ func ApplicationRouter(router *gin.Engine, appContext *di.ApplicationContext) {
 		})
 	})
 
-	AuthRoutes(v1, appContext.AuthController)
-	UserRoutes(v1, appContext.UserController)
-	ExchangerRoutes(v1, appContext.ExchangerController)
-	CurrencyRoutes(v1, appContext.CurrencyController)
+	jwtMiddleware := middlewares.AuthJWTMiddleware(appContext.JWTService, appContext.UserRepository)
+	authMiddleware := middlewares.AuthMiddleware(appContext.JWTService, appContext.UserRepository, appContext.APITokenUseCase)
+
+	WellKnownRoutes(router, appContext.WellKnownController)
+	AuthRoutes(v1, appContext.AuthController, jwtMiddleware)
+	UserRoutes(v1, appContext.UserController, authMiddleware)
+	APITokenRoutes(v1, appContext.APITokenController, jwtMiddleware)
+	OAuthRoutes(v1, appContext.OAuthController, jwtMiddleware)
+	ExchangerRoutes(v1, appContext.ExchangerController, authMiddleware)
+	CurrencyRoutes(v1, appContext.CurrencyController, authMiddleware)
+	StreamRoutes(v1, appContext.StreamController, appContext.WebSocketController, authMiddleware)
+	WatchlistRoutes(v1, appContext.WatchlistController, authMiddleware)
+	AlertRoutes(v1, appContext.AlertController, authMiddleware)
+	WebhookRoutes(v1, appContext.WebhookController, authMiddleware)
 }
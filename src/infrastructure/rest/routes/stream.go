@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/stream"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func StreamRoutes(router *gin.RouterGroup, controller stream.IStreamController, webSocketController stream.IWebSocketController, authMiddleware gin.HandlerFunc) {
+	u := router.Group("/currency")
+	u.Use(authMiddleware)
+	{
+		u.GET("/stream", middlewares.RequireScopes(scope.CurrencyRead), controller.StreamRates)
+	}
+
+	// Browsers cannot set headers on a WebSocket handshake, so the token may come as access_token
+	ws := router.Group("/currency/ws")
+	ws.Use(middlewares.TokenFromQuery("access_token"), authMiddleware, middlewares.RequireUser())
+	{
+		ws.GET("", middlewares.RequireScopes(scope.CurrencyRead), webSocketController.Connect)
+	}
+}
@@ -1,25 +1,29 @@
 package routes
 
 import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/user"
 	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(router *gin.RouterGroup, controller user.IUserController) {
+func UserRoutes(router *gin.RouterGroup, controller user.IUserController, authMiddleware gin.HandlerFunc) {
 	u := router.Group("/user")
 	{
-		u.POST("/", controller.NewUser)
+		u.POST("/", middlewares.OptionalAuth(authMiddleware), controller.NewUser)
 
-		u.GET("/:id", controller.GetUsersByID)
+		u.GET("/:id", middlewares.OptionalAuth(authMiddleware), middlewares.RequireScopes(scope.UserRead), controller.GetUsersByID)
 	}
 
-	u.Use(middlewares.AuthJWTMiddleware())
+	u.Use(authMiddleware)
 	{
-		u.GET("/", controller.GetAllUsers)
-		u.PATCH("/:id", controller.UpdateUser)
-		u.DELETE("/:id", controller.DeleteUser)
-		u.GET("/search", controller.SearchPaginated)
-		u.GET("/search-property", controller.SearchByProperty)
+		u.GET("/me", middlewares.RequireUser(), middlewares.RequireScopes(scope.UserRead), controller.GetMe)
+		u.PATCH("/me", middlewares.RequireUser(), middlewares.RequireScopes(scope.UserWrite), controller.UpdateMe)
+		u.POST("/me/password", middlewares.RequireUser(), middlewares.RequireScopes(scope.UserWrite), controller.ChangeMyPassword)
+		u.GET("/", middlewares.RequireScopes(scope.UserRead), controller.GetAllUsers)
+		u.PATCH("/:id", middlewares.RequireScopes(scope.UserWrite), controller.UpdateUser)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.UserWrite), controller.DeleteUser)
+		u.GET("/search", middlewares.RequireScopes(scope.UserRead), controller.SearchPaginated)
+		u.GET("/search-property", middlewares.RequireScopes(scope.UserRead), controller.SearchByProperty)
 	}
 }
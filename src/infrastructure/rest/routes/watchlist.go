@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/watchlist"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func WatchlistRoutes(router *gin.RouterGroup, controller watchlist.IWatchlistController, authMiddleware gin.HandlerFunc) {
+	u := router.Group("/user/me/watchlists")
+	u.Use(authMiddleware, middlewares.RequireUser())
+	{
+		u.POST("/", middlewares.RequireScopes(scope.WatchlistWrite), controller.NewWatchlist)
+		u.GET("/", middlewares.RequireScopes(scope.WatchlistRead), controller.GetWatchlists)
+		u.GET("/:id", middlewares.RequireScopes(scope.WatchlistRead), controller.GetWatchlist)
+		u.GET("/:id/rates", middlewares.RequireScopes(scope.WatchlistRead), controller.GetWatchlistRates)
+		u.PUT("/:id", middlewares.RequireScopes(scope.WatchlistWrite), controller.UpdateWatchlist)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.WatchlistWrite), controller.DeleteWatchlist)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/domain/scope"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/webhook"
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookRoutes(router *gin.RouterGroup, controller webhook.IWebhookController, authMiddleware gin.HandlerFunc) {
+	u := router.Group("/webhook")
+	u.Use(authMiddleware, middlewares.RequireUser())
+	{
+		u.POST("/", middlewares.RequireScopes(scope.WebhookWrite), controller.NewWebhook)
+		u.GET("/", middlewares.RequireScopes(scope.WebhookRead), controller.GetWebhooks)
+		u.GET("/:id", middlewares.RequireScopes(scope.WebhookRead), controller.GetWebhook)
+		u.PUT("/:id", middlewares.RequireScopes(scope.WebhookWrite), controller.UpdateWebhook)
+		u.DELETE("/:id", middlewares.RequireScopes(scope.WebhookWrite), controller.DeleteWebhook)
+		u.GET("/:id/deliveries", middlewares.RequireScopes(scope.WebhookRead), controller.GetWebhookDeliveries)
+		u.POST("/deliveries/:id/retry", middlewares.RequireScopes(scope.WebhookWrite), controller.RetryWebhookDelivery)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gbrayhan/microservices-go/src/infrastructure/rest/controllers/wellknown"
+	"github.com/gin-gonic/gin"
+)
+
+func WellKnownRoutes(router *gin.Engine, controller wellknown.IWellKnownController) {
+	u := router.Group("/.well-known")
+	{
+		u.GET("/jwks.json", controller.JWKS)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"os"
 )
 
@@ -73,7 +74,7 @@ func (s *APIService) DecryptApiKey(cipherKey string) (string, error) {
 
 	nonceSize := gcm.NonceSize()
 	if len(cipherText) < nonceSize {
-		return "", err
+		return "", errors.New("ciphertext too short")
 	}
 
 	nonce, ciphertext := cipherText[:nonceSize], cipherText[nonceSize:]
@@ -82,7 +83,5 @@ func (s *APIService) DecryptApiKey(cipherKey string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	print(string(plaintext))
-
 	return string(plaintext), nil
 }
@@ -1,10 +1,13 @@
 package security
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	domainErrors "github.com/gbrayhan/microservices-go/src/domain/errors"
@@ -14,6 +17,20 @@ import (
 const (
 	Access  = "access"
 	Refresh = "refresh"
+	// TwoFactorChallenge is the short-lived token returned by a password login that still needs a second factor
+	TwoFactorChallenge = "2fa_challenge"
+	// TwoFactorEnrollment is the short-lived token returned when the user must enroll a second factor before logging in
+	TwoFactorEnrollment = "2fa_enrollment"
+)
+
+var (
+	ErrTokenExpired      = errors.New("token expired")
+	ErrTokenTypeMismatch = errors.New("invalid token type")
+	ErrMissingTokenType  = errors.New("token missing type claim")
+	ErrInvalidClaims     = errors.New("invalid token claims")
+	ErrTokenNotYetValid  = errors.New("token not valid yet")
+	ErrInvalidIssuer     = errors.New("invalid token issuer")
+	ErrInvalidAudience   = errors.New("invalid token audience")
 )
 
 type AppToken struct {
@@ -25,33 +42,64 @@ type AppToken struct {
 type Claims struct {
 	ID   int    `json:"id"`
 	Type string `json:"type"`
+	// ClientID and Scope are only set on access tokens issued to OAuth2 clients
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // JWTConfig holds JWT-related configuration
 type JWTConfig struct {
-	AccessSecret  string
-	RefreshSecret string
-	AccessTime    int64
-	RefreshTime   int64
+	AccessSecret    string
+	RefreshSecret   string
+	AccessTime      int64
+	RefreshTime     int64
+	ChallengeSecret string
+	ChallengeTime   int64
+	// SigningKeysDir enables asymmetric access tokens; empty keeps HS256 with AccessSecret
+	SigningKeysDir string
+	SigningKeyID   string
+	// Issuer is set as iss and required on verification; Audience is the default aud and the
+	// audience this service accepts. Empty values are neither set nor checked.
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
 }
 
 // IJWTService defines the interface for JWT operations
 type IJWTService interface {
 	GenerateJWTToken(userID int, tokenType string) (*AppToken, error)
+	GenerateClientToken(clientID string, scopes []string, audiences []string) (*AppToken, error)
 	GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error)
+	JWKS() JSONWebKeySet
 }
 
-// JWTService implements IJWTService
+// JWTService implements IJWTService. When a key set is configured, access tokens are signed
+// with its active RS256 or EdDSA key so other services can verify them through the JWKS
+// document; refresh and challenge tokens are only read by this service and keep their secrets.
 type JWTService struct {
 	config JWTConfig
+	keys   *KeySet
 }
 
 // NewJWTService creates a new JWT service instance
-func NewJWTService() IJWTService {
+func NewJWTService() (IJWTService, error) {
 	config := loadJWTConfig()
+	if config.SigningKeysDir == "" {
+		return &JWTService{config: config}, nil
+	}
+	keys, err := LoadKeySet(config.SigningKeysDir, config.SigningKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTService{config: config, keys: keys}, nil
+}
+
+// NewJWTServiceWithKeys creates a new JWT service signing access tokens with the given key set
+func NewJWTServiceWithKeys(config JWTConfig, keys *KeySet) IJWTService {
 	return &JWTService{
 		config: config,
+		keys:   keys,
 	}
 }
 
@@ -65,10 +113,17 @@ func NewJWTServiceWithConfig(config JWTConfig) IJWTService {
 // loadJWTConfig loads JWT configuration from environment variables
 func loadJWTConfig() JWTConfig {
 	return JWTConfig{
-		AccessSecret:  getEnvOrDefault("JWT_ACCESS_SECRET_KEY", "default_access_secret"),
-		RefreshSecret: getEnvOrDefault("JWT_REFRESH_SECRET_KEY", "default_refresh_secret"),
-		AccessTime:    getEnvAsInt64OrDefault("JWT_ACCESS_TIME_MINUTE", 60),
-		RefreshTime:   getEnvAsInt64OrDefault("JWT_REFRESH_TIME_HOUR", 24),
+		AccessSecret:    getEnvOrDefault("JWT_ACCESS_SECRET_KEY", "default_access_secret"),
+		RefreshSecret:   getEnvOrDefault("JWT_REFRESH_SECRET_KEY", "default_refresh_secret"),
+		AccessTime:      getEnvAsInt64OrDefault("JWT_ACCESS_TIME_MINUTE", 60),
+		RefreshTime:     getEnvAsInt64OrDefault("JWT_REFRESH_TIME_HOUR", 24),
+		ChallengeSecret: getEnvOrDefault("JWT_CHALLENGE_SECRET_KEY", "default_challenge_secret"),
+		ChallengeTime:   getEnvAsInt64OrDefault("JWT_CHALLENGE_TIME_MINUTE", 5),
+		SigningKeysDir:  os.Getenv("JWT_SIGNING_KEYS_DIR"),
+		SigningKeyID:    os.Getenv("JWT_SIGNING_KEY_ID"),
+		Issuer:          getEnvOrDefault("JWT_ISSUER", "microservice"),
+		Audience:        getEnvOrDefault("JWT_AUDIENCE", "microservices-go"),
+		ClockSkew:       time.Duration(getEnvAsInt64OrDefault("JWT_CLOCK_SKEW_SECONDS", 30)) * time.Second,
 	}
 }
 
@@ -84,6 +139,9 @@ func (s *JWTService) GenerateJWTToken(userID int, tokenType string) (*AppToken,
 	case Refresh:
 		secretKey = s.config.RefreshSecret
 		duration = time.Duration(s.config.RefreshTime) * time.Hour
+	case TwoFactorChallenge, TwoFactorEnrollment:
+		secretKey = s.config.ChallengeSecret
+		duration = time.Duration(s.config.ChallengeTime) * time.Minute
 	default:
 		return nil, errors.New("invalid token type")
 	}
@@ -91,23 +149,79 @@ func (s *JWTService) GenerateJWTToken(userID int, tokenType string) (*AppToken,
 	nowTime := time.Now()
 	expirationTokenTime := nowTime.Add(duration)
 
+	registeredClaims, err := s.registeredClaims(strconv.Itoa(userID), nil, nowTime, expirationTokenTime)
+	if err != nil {
+		return nil, err
+	}
 	tokenClaims := &Claims{
-		ID:   userID,
-		Type: tokenType,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTokenTime),
-		},
+		ID:               userID,
+		Type:             tokenType,
+		RegisteredClaims: registeredClaims,
 	}
-	tokenWithClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims)
+	return s.signToken(tokenClaims, secretKey, expirationTokenTime)
+}
+
+// GenerateClientToken generates an access token for an OAuth2 client; the scopes are
+// carried in the space-delimited scope claim and the id claim is left empty. The token is
+// issued for the given audiences, or the default audience when none are configured.
+func (s *JWTService) GenerateClientToken(clientID string, scopes []string, audiences []string) (*AppToken, error) {
+	if clientID == "" {
+		return nil, errors.New("client id is required")
+	}
+	nowTime := time.Now()
+	expirationTokenTime := nowTime.Add(time.Duration(s.config.AccessTime) * time.Minute)
 
-	tokenStr, err := tokenWithClaims.SignedString([]byte(secretKey))
+	registeredClaims, err := s.registeredClaims(clientID, audiences, nowTime, expirationTokenTime)
+	if err != nil {
+		return nil, err
+	}
+	tokenClaims := &Claims{
+		Type:             Access,
+		ClientID:         clientID,
+		Scope:            strings.Join(scopes, " "),
+		RegisteredClaims: registeredClaims,
+	}
+	return s.signToken(tokenClaims, s.config.AccessSecret, expirationTokenTime)
+}
+
+func (s *JWTService) registeredClaims(subject string, audiences []string, issuedAt, expiresAt time.Time) (jwt.RegisteredClaims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return jwt.RegisteredClaims{}, err
+	}
+	if len(audiences) == 0 && s.config.Audience != "" {
+		audiences = []string{s.config.Audience}
+	}
+	return jwt.RegisteredClaims{
+		Issuer:    s.config.Issuer,
+		Subject:   subject,
+		Audience:  audiences,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		NotBefore: jwt.NewNumericDate(issuedAt),
+		IssuedAt:  jwt.NewNumericDate(issuedAt),
+		ID:        tokenID,
+	}, nil
+}
+
+func (s *JWTService) signToken(tokenClaims *Claims, secretKey string, expirationTokenTime time.Time) (*AppToken, error) {
+	var tokenStr string
+	var err error
+	if tokenClaims.Type == Access && s.keys != nil {
+		key := s.keys.Active
+		tokenWithClaims := jwt.NewWithClaims(key.signingMethod(), tokenClaims)
+		tokenWithClaims.Header["kid"] = key.ID
+		tokenStr, err = tokenWithClaims.SignedString(key.PrivateKey)
+	} else {
+		tokenWithClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims)
+		tokenStr, err = tokenWithClaims.SignedString([]byte(secretKey))
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &AppToken{
 		Token:          tokenStr,
-		TokenType:      tokenType,
+		TokenType:      tokenClaims.Type,
 		ExpirationTime: expirationTokenTime,
 	}, nil
 }
@@ -116,18 +230,31 @@ func (s *JWTService) GenerateJWTToken(userID int, tokenType string) (*AppToken,
 func (s *JWTService) GetClaimsAndVerifyToken(tokenString string, tokenType string) (jwt.MapClaims, error) {
 	var secretKey string
 
-	if tokenType == Refresh {
+	switch tokenType {
+	case Refresh:
 		secretKey = s.config.RefreshSecret
-	} else {
+	case TwoFactorChallenge, TwoFactorEnrollment:
+		secretKey = s.config.ChallengeSecret
+	default:
 		secretKey = s.config.AccessSecret
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+	// Time based claims are checked below so that the configured clock skew applies
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		if tokenType == Access && s.keys != nil {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := s.keys.Get(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key: %q", kid)
+			}
+			if token.Method.Alg() != key.Algorithm {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.PublicKey, nil
+		}
 		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-			return nil, domainErrors.NewAppError(
-				fmt.Errorf("unexpected signing method: %v", token.Header["alg"]),
-				domainErrors.NotAuthenticated,
-			)
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(secretKey), nil
 	})
@@ -141,37 +268,108 @@ func (s *JWTService) GetClaimsAndVerifyToken(tokenString string, tokenType strin
 		return nil, domainErrors.NewAppError(errors.New("invalid claims type or token not valid"), domainErrors.NotAuthenticated)
 	}
 
-	if claims["type"] != tokenType {
-		return nil, domainErrors.NewAppError(errors.New("invalid token type"), domainErrors.NotAuthenticated)
-	}
-
-	expVal, ok := claims["exp"]
-	if !ok || expVal == nil {
-		return nil, domainErrors.NewAppError(errors.New("token missing expiration (exp) claim"), domainErrors.NotAuthenticated)
-	}
-	timeExpire, ok := expVal.(float64)
+	claimType, ok := claims["type"].(string)
 	if !ok {
-		return nil, domainErrors.NewAppError(errors.New("token expiration (exp) claim is not a float64"), domainErrors.NotAuthenticated)
+		return nil, domainErrors.NewAppError(ErrMissingTokenType, domainErrors.NotAuthenticated)
 	}
-	if time.Now().Unix() > int64(timeExpire) {
-		return nil, domainErrors.NewAppError(errors.New("token expired"), domainErrors.NotAuthenticated)
+	if claimType != tokenType {
+		return nil, domainErrors.NewAppError(ErrTokenTypeMismatch, domainErrors.NotAuthenticated)
+	}
+
+	if err := s.validateRegisteredClaims(claims, time.Now()); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.NotAuthenticated)
 	}
 
 	idVal, ok := claims["id"]
 	if !ok || idVal == nil {
-		return nil, domainErrors.NewAppError(errors.New("token missing id claim"), domainErrors.NotAuthenticated)
+		return nil, domainErrors.NewAppError(fmt.Errorf("%w: missing id claim", ErrInvalidClaims), domainErrors.NotAuthenticated)
 	}
 	// Accept float64 or int64 for id
 	switch idVal.(type) {
 	case float64, int64, int:
 		// ok
 	default:
-		return nil, domainErrors.NewAppError(errors.New("token id claim is not a number"), domainErrors.NotAuthenticated)
+		return nil, domainErrors.NewAppError(fmt.Errorf("%w: id claim is not a number", ErrInvalidClaims), domainErrors.NotAuthenticated)
 	}
 
 	return claims, nil
 }
 
+// validateRegisteredClaims checks exp, nbf and iat allowing for the configured clock skew,
+// and requires iss, aud, sub and jti
+func (s *JWTService) validateRegisteredClaims(claims jwt.MapClaims, now time.Time) error {
+	skew := s.config.ClockSkew
+
+	expiresAt, ok, err := numericClaim(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: missing expiration (exp) claim", ErrInvalidClaims)
+	}
+	if now.After(expiresAt.Add(skew)) {
+		return ErrTokenExpired
+	}
+
+	notBefore, ok, err := numericClaim(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(skew).Before(notBefore) {
+		return ErrTokenNotYetValid
+	}
+
+	issuedAt, ok, err := numericClaim(claims, "iat")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(skew).Before(issuedAt) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidClaims)
+	}
+
+	if s.config.Issuer != "" && !claims.VerifyIssuer(s.config.Issuer, true) {
+		return ErrInvalidIssuer
+	}
+	if s.config.Audience != "" && !claims.VerifyAudience(s.config.Audience, true) {
+		return ErrInvalidAudience
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("%w: missing subject (sub) claim", ErrInvalidClaims)
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return fmt.Errorf("%w: missing token id (jti) claim", ErrInvalidClaims)
+	}
+	return nil
+}
+
+func numericClaim(claims jwt.MapClaims, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok || value == nil {
+		return time.Time{}, false, nil
+	}
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s claim is not a number", ErrInvalidClaims, name)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// JWKS returns the public keys accepted for access tokens; it is empty while tokens are signed with HS256
+func (s *JWTService) JWKS() JSONWebKeySet {
+	if s.keys == nil {
+		return JSONWebKeySet{Keys: []JSONWebKey{}}
+	}
+	return s.keys.JWKS()
+}
+
 // Helper functions
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -11,7 +11,8 @@ import (
 )
 
 func TestNewJWTService(t *testing.T) {
-	service := NewJWTService()
+	service, err := NewJWTService()
+	require.NoError(t, err)
 	assert.NotNil(t, service)
 	assert.Implements(t, (*IJWTService)(nil), service)
 }
@@ -602,3 +603,163 @@ func TestGetClaimsAndVerifyToken_InvalidClaimsType(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func TestGenerateJWTToken_TwoFactorChallenge(t *testing.T) {
+	config := JWTConfig{
+		AccessSecret:    "test_access_secret",
+		RefreshSecret:   "test_refresh_secret",
+		AccessTime:      30,
+		RefreshTime:     24,
+		ChallengeSecret: "test_challenge_secret",
+		ChallengeTime:   5,
+	}
+	service := NewJWTServiceWithConfig(config)
+
+	token, err := service.GenerateJWTToken(123, TwoFactorChallenge)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), token.ExpirationTime, 5*time.Second)
+
+	claims, err := service.GetClaimsAndVerifyToken(token.Token, TwoFactorChallenge)
+	require.NoError(t, err)
+	assert.Equal(t, float64(123), claims["id"])
+
+	// a challenge token must not be usable as an access token or as an enrollment token
+	_, err = service.GetClaimsAndVerifyToken(token.Token, Access)
+	assert.Error(t, err)
+	_, err = service.GetClaimsAndVerifyToken(token.Token, TwoFactorEnrollment)
+	assert.Error(t, err)
+}
+
+func TestGenerateClientToken(t *testing.T) {
+	config := JWTConfig{
+		AccessSecret:  "test_access_secret",
+		RefreshSecret: "test_refresh_secret",
+		AccessTime:    30,
+		RefreshTime:   24,
+	}
+	service := NewJWTServiceWithConfig(config)
+
+	token, err := service.GenerateClientToken("cli_test", []string{"currency:read", "exchanger:read"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Access, token.TokenType)
+
+	claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
+	require.NoError(t, err)
+	assert.Equal(t, "cli_test", claims["client_id"])
+	assert.Equal(t, "currency:read exchanger:read", claims["scope"])
+
+	_, err = service.GenerateClientToken("", nil, nil)
+	assert.Error(t, err)
+}
+
+func newClaimsTestService(skew time.Duration) IJWTService {
+	return NewJWTServiceWithConfig(JWTConfig{
+		AccessSecret:  "test_access_secret",
+		RefreshSecret: "test_refresh_secret",
+		AccessTime:    30,
+		RefreshTime:   24,
+		Issuer:        "microservice",
+		Audience:      "microservices-go",
+		ClockSkew:     skew,
+	})
+}
+
+func signTestClaims(t *testing.T, overrides jwt.MapClaims) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"id":   123,
+		"type": Access,
+		"iss":  "microservice",
+		"aud":  []string{"microservices-go"},
+		"sub":  "123",
+		"jti":  "test-token-id",
+		"iat":  now.Unix(),
+		"nbf":  now.Unix(),
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_access_secret"))
+	require.NoError(t, err)
+	return tokenString
+}
+
+func TestGenerateJWTToken_RegisteredClaims(t *testing.T) {
+	service := newClaimsTestService(0)
+
+	first, err := service.GenerateJWTToken(123, Access)
+	require.NoError(t, err)
+	second, err := service.GenerateJWTToken(123, Access)
+	require.NoError(t, err)
+
+	claims, err := service.GetClaimsAndVerifyToken(first.Token, Access)
+	require.NoError(t, err)
+	assert.Equal(t, "microservice", claims["iss"])
+	assert.Equal(t, []any{"microservices-go"}, claims["aud"])
+	assert.Equal(t, "123", claims["sub"])
+	assert.NotEmpty(t, claims["iat"])
+	assert.NotEmpty(t, claims["nbf"])
+
+	secondClaims, err := service.GetClaimsAndVerifyToken(second.Token, Access)
+	require.NoError(t, err)
+	assert.NotEqual(t, claims["jti"], secondClaims["jti"])
+
+	clientToken, err := service.GenerateClientToken("cli_test", []string{"currency:read"}, []string{"billing-api", "microservices-go"})
+	require.NoError(t, err)
+	clientClaims, err := service.GetClaimsAndVerifyToken(clientToken.Token, Access)
+	require.NoError(t, err)
+	assert.Equal(t, "cli_test", clientClaims["sub"])
+	assert.Equal(t, []any{"billing-api", "microservices-go"}, clientClaims["aud"])
+}
+
+func TestGetClaimsAndVerifyToken_RegisteredClaimsValidation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+		skew      time.Duration
+		wantErr   error
+	}{
+		{name: "valid", overrides: jwt.MapClaims{}},
+		{name: "expired within skew", overrides: jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}, skew: 30 * time.Second},
+		{name: "expired beyond skew", overrides: jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}, skew: 30 * time.Second, wantErr: ErrTokenExpired},
+		{name: "not before within skew", overrides: jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}, skew: 30 * time.Second},
+		{name: "not before beyond skew", overrides: jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()}, skew: 30 * time.Second, wantErr: ErrTokenNotYetValid},
+		{name: "issued in the future", overrides: jwt.MapClaims{"iat": now.Add(time.Hour).Unix()}, wantErr: ErrInvalidClaims},
+		{name: "wrong issuer", overrides: jwt.MapClaims{"iss": "someone-else"}, wantErr: ErrInvalidIssuer},
+		{name: "missing issuer", overrides: jwt.MapClaims{"iss": nil}, wantErr: ErrInvalidIssuer},
+		{name: "wrong audience", overrides: jwt.MapClaims{"aud": "billing-api"}, wantErr: ErrInvalidAudience},
+		{name: "missing audience", overrides: jwt.MapClaims{"aud": nil}, wantErr: ErrInvalidAudience},
+		{name: "missing subject", overrides: jwt.MapClaims{"sub": nil}, wantErr: ErrInvalidClaims},
+		{name: "missing token id", overrides: jwt.MapClaims{"jti": nil}, wantErr: ErrInvalidClaims},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newClaimsTestService(tt.skew)
+			claims, err := service.GetClaimsAndVerifyToken(signTestClaims(t, tt.overrides), Access)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				assert.NotNil(t, claims)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestLoadJWTConfig_RegisteredClaims(t *testing.T) {
+	t.Setenv("JWT_ISSUER", "exchange-rates")
+	t.Setenv("JWT_AUDIENCE", "gateway")
+	t.Setenv("JWT_CLOCK_SKEW_SECONDS", "45")
+
+	config := loadJWTConfig()
+	assert.Equal(t, "exchange-rates", config.Issuer)
+	assert.Equal(t, "gateway", config.Audience)
+	assert.Equal(t, 45*time.Second, config.ClockSkew)
+}
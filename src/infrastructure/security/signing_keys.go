@@ -0,0 +1,173 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey is an asymmetric key used to sign or verify access tokens. Keys kept only
+// for verification during a rotation have no private part.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey any
+	PublicKey  any
+}
+
+// KeySet holds the key used to sign new access tokens and every key still accepted for verification
+type KeySet struct {
+	Active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS document (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewKeySet builds a key set whose active key, used for signing, is activeKeyID
+func NewKeySet(activeKeyID string, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+	active, ok := set.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeKeyID)
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeKeyID)
+	}
+	set.Active = active
+	return set, nil
+}
+
+// LoadKeySet reads every PEM file in dir; the key id is the file name without its extension.
+// Private keys can sign and verify, public keys only verify tokens signed before a rotation.
+func LoadKeySet(dir string, activeKeyID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(activeKeyID, keys...)
+}
+
+// ParseSigningKey parses a PEM encoded RSA or Ed25519 key, private (PKCS#1 or PKCS#8) or public (PKIX)
+func ParseSigningKey(kid string, pemBytes []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256.Alg(), k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.PublicKey = jwt.SigningMethodRS256.Alg(), k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA.Alg(), k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.PublicKey = jwt.SigningMethodEdDSA.Alg(), k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// Get returns the key with the given id
+func (k *KeySet) Get(kid string) (*SigningKey, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// JWKS returns the public keys of the set, ordered by key id
+func (k *KeySet) JWKS() JSONWebKeySet {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(kids))}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		jwk := JSONWebKey{Use: "sig", Kid: key.ID, Alg: key.Algorithm}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+func (key *SigningKey) signingMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(key.Algorithm)
+}
@@ -0,0 +1,179 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSATestKey(t *testing.T, kid string) *SigningKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &SigningKey{ID: kid, Algorithm: jwt.SigningMethodRS256.Alg(), PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}
+}
+
+func newEd25519TestKey(t *testing.T, kid string) *SigningKey {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &SigningKey{ID: kid, Algorithm: jwt.SigningMethodEdDSA.Alg(), PrivateKey: privateKey, PublicKey: publicKey}
+}
+
+func newKeyTestConfig() JWTConfig {
+	return JWTConfig{
+		AccessSecret:  "test_access_secret",
+		RefreshSecret: "test_refresh_secret",
+		AccessTime:    30,
+		RefreshTime:   24,
+	}
+}
+
+func TestJWTService_AsymmetricAccessTokens(t *testing.T) {
+	for _, key := range []*SigningKey{newRSATestKey(t, "rsa-1"), newEd25519TestKey(t, "ed-1")} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			keys, err := NewKeySet(key.ID, key)
+			require.NoError(t, err)
+			service := NewJWTServiceWithKeys(newKeyTestConfig(), keys)
+
+			token, err := service.GenerateJWTToken(7, Access)
+			require.NoError(t, err)
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token.Token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, key.Algorithm, parsed.Header["alg"])
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
+			require.NoError(t, err)
+			assert.Equal(t, float64(7), claims["id"])
+
+			// Refresh tokens are only read by this service and keep using their secret
+			refresh, err := service.GenerateJWTToken(7, Refresh)
+			require.NoError(t, err)
+			parsed, _, err = new(jwt.Parser).ParseUnverified(refresh.Token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, jwt.SigningMethodHS256.Alg(), parsed.Header["alg"])
+			_, err = service.GetClaimsAndVerifyToken(refresh.Token, Refresh)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	oldKey := newRSATestKey(t, "2025-01")
+	newKey := newEd25519TestKey(t, "2025-06")
+
+	oldKeys, err := NewKeySet(oldKey.ID, oldKey)
+	require.NoError(t, err)
+	oldToken, err := NewJWTServiceWithKeys(newKeyTestConfig(), oldKeys).GenerateJWTToken(7, Access)
+	require.NoError(t, err)
+
+	// After the rotation the old key only verifies, so its private part can be discarded
+	retired := &SigningKey{ID: oldKey.ID, Algorithm: oldKey.Algorithm, PublicKey: oldKey.PublicKey}
+	rotatedKeys, err := NewKeySet(newKey.ID, newKey, retired)
+	require.NoError(t, err)
+	service := NewJWTServiceWithKeys(newKeyTestConfig(), rotatedKeys)
+
+	_, err = service.GetClaimsAndVerifyToken(oldToken.Token, Access)
+	assert.NoError(t, err)
+
+	newToken, err := service.GenerateJWTToken(7, Access)
+	require.NoError(t, err)
+	_, err = service.GetClaimsAndVerifyToken(newToken.Token, Access)
+	assert.NoError(t, err)
+
+	// Once the old key is removed its tokens are rejected
+	finalKeys, err := NewKeySet(newKey.ID, newKey)
+	require.NoError(t, err)
+	_, err = NewJWTServiceWithKeys(newKeyTestConfig(), finalKeys).GetClaimsAndVerifyToken(oldToken.Token, Access)
+	assert.Error(t, err)
+}
+
+func TestJWTService_RejectsHS256WhenKeysConfigured(t *testing.T) {
+	key := newRSATestKey(t, "rsa-1")
+	keys, err := NewKeySet(key.ID, key)
+	require.NoError(t, err)
+	service := NewJWTServiceWithKeys(newKeyTestConfig(), keys)
+
+	hsToken, err := NewJWTServiceWithConfig(newKeyTestConfig()).GenerateJWTToken(7, Access)
+	require.NoError(t, err)
+	_, err = service.GetClaimsAndVerifyToken(hsToken.Token, Access)
+	assert.Error(t, err)
+}
+
+func TestNewKeySet_Errors(t *testing.T) {
+	key := newRSATestKey(t, "rsa-1")
+
+	_, err := NewKeySet("missing", key)
+	assert.Error(t, err)
+
+	_, err = NewKeySet(key.ID, key, key)
+	assert.Error(t, err)
+
+	publicOnly := &SigningKey{ID: "pub", Algorithm: key.Algorithm, PublicKey: key.PublicKey}
+	_, err = NewKeySet(publicOnly.ID, publicOnly)
+	assert.Error(t, err)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "rsa-old.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ed-current.pem"), "PRIVATE KEY", pkcs8)
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(otherPublic)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ed-retired.pem"), "PUBLIC KEY", pkix)
+
+	keys, err := LoadKeySet(dir, "ed-current")
+	require.NoError(t, err)
+	assert.Equal(t, "ed-current", keys.Active.ID)
+	assert.Equal(t, jwt.SigningMethodEdDSA.Alg(), keys.Active.Algorithm)
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 3)
+	assert.Equal(t, "ed-current", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+	assert.Equal(t, "ed-retired", jwks.Keys[1].Kid)
+	assert.Equal(t, "rsa-old", jwks.Keys[2].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[2].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[2].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[2].E)
+	assert.NotEmpty(t, jwks.Keys[2].N)
+	assert.Equal(t, ed25519.PublicKey(edPublic), keys.Active.PublicKey)
+
+	_, err = LoadKeySet(dir, "ed-retired")
+	assert.Error(t, err, "a public key cannot be the active signing key")
+
+	_, err = LoadKeySet(t.TempDir(), "ed-current")
+	assert.Error(t, err)
+}
+
+func TestJWTService_JWKS_HS256(t *testing.T) {
+	service := NewJWTServiceWithConfig(newKeyTestConfig())
+	assert.Empty(t, service.JWKS().Keys)
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
@@ -0,0 +1,136 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPConfig holds TOTP-related configuration (RFC 6238)
+type TOTPConfig struct {
+	Issuer     string
+	Period     int64
+	Digits     int
+	Skew       int64
+	SecretSize int
+}
+
+// ITOTPService defines the interface for time-based one-time password operations
+type ITOTPService interface {
+	GenerateSecret() (string, error)
+	ProvisioningURI(secret string, accountName string) string
+	GenerateCode(secret string, at time.Time) (string, error)
+	ValidateCode(secret string, code string) bool
+	ValidateCodeStep(secret string, code string) (int64, bool)
+}
+
+// TOTPService implements ITOTPService
+type TOTPService struct {
+	config TOTPConfig
+}
+
+// NewTOTPService creates a new TOTP service instance
+func NewTOTPService() ITOTPService {
+	return &TOTPService{
+		config: loadTOTPConfig(),
+	}
+}
+
+// NewTOTPServiceWithConfig creates a new TOTP service with custom configuration
+func NewTOTPServiceWithConfig(config TOTPConfig) ITOTPService {
+	return &TOTPService{
+		config: config,
+	}
+}
+
+// loadTOTPConfig loads TOTP configuration from environment variables
+func loadTOTPConfig() TOTPConfig {
+	return TOTPConfig{
+		Issuer:     getEnvOrDefault("TOTP_ISSUER", "ExchangeRate"),
+		Period:     30,
+		Digits:     6,
+		Skew:       getEnvAsInt64OrDefault("TOTP_SKEW_STEPS", 1),
+		SecretSize: 20,
+	}
+}
+
+// GenerateSecret returns a new random base32 encoded shared secret
+func (s *TOTPService) GenerateSecret() (string, error) {
+	secret := make([]byte, s.config.SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI builds the otpauth:// URI understood by authenticator apps
+func (s *TOTPService) ProvisioningURI(secret string, accountName string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", s.config.Issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", s.config.Digits))
+	params.Set("period", fmt.Sprintf("%d", s.config.Period))
+	label := url.PathEscape(s.config.Issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// GenerateCode returns the code valid for the time step containing at
+func (s *TOTPService) GenerateCode(secret string, at time.Time) (string, error) {
+	return s.codeForCounter(secret, at.Unix()/s.config.Period)
+}
+
+// ValidateCode checks the code against the current time step and the allowed skew on both sides
+func (s *TOTPService) ValidateCode(secret string, code string) bool {
+	_, ok := s.ValidateCodeStep(secret, code)
+	return ok
+}
+
+// ValidateCodeStep is ValidateCode that also returns the time step the code belongs to, so callers
+// can refuse a code that was already accepted
+func (s *TOTPService) ValidateCodeStep(secret string, code string) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != s.config.Digits {
+		return 0, false
+	}
+	counter := time.Now().Unix() / s.config.Period
+	for offset := -s.config.Skew; offset <= s.config.Skew; offset++ {
+		expected, err := s.codeForCounter(secret, counter+offset)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + offset, true
+		}
+	}
+	return 0, false
+}
+
+func (s *TOTPService) codeForCounter(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < s.config.Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", s.config.Digits, value%modulo), nil
+}
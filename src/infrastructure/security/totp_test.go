@@ -0,0 +1,87 @@
+package security
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTOTPService() ITOTPService {
+	return NewTOTPServiceWithConfig(TOTPConfig{
+		Issuer:     "ExchangeRate",
+		Period:     30,
+		Digits:     8,
+		Skew:       1,
+		SecretSize: 20,
+	})
+}
+
+func TestTOTPService_GenerateCode_RFC6238Vectors(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B (SHA1)
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	service := newTestTOTPService()
+
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, want := range vectors {
+		code, err := service.GenerateCode(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestTOTPService_ValidateCode(t *testing.T) {
+	service := newTestTOTPService()
+	secret, err := service.GenerateSecret()
+	require.NoError(t, err)
+
+	current, err := service.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	assert.True(t, service.ValidateCode(secret, current))
+
+	previous, err := service.GenerateCode(secret, time.Now().Add(-30*time.Second))
+	require.NoError(t, err)
+	assert.True(t, service.ValidateCode(secret, previous))
+
+	stale, err := service.GenerateCode(secret, time.Now().Add(-5*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, service.ValidateCode(secret, stale))
+
+	assert.False(t, service.ValidateCode(secret, "123"))
+	assert.False(t, service.ValidateCode("not-base32!", "12345678"))
+}
+
+func TestTOTPService_ValidateCodeStep(t *testing.T) {
+	service := newTestTOTPService()
+	secret, err := service.GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	previous, err := service.GenerateCode(secret, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	step, ok := service.ValidateCodeStep(secret, previous)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30-1, step)
+
+	_, ok = service.ValidateCodeStep(secret, "123")
+	assert.False(t, ok)
+}
+
+func TestTOTPService_ProvisioningURI(t *testing.T) {
+	service := newTestTOTPService()
+	uri := service.ProvisioningURI("JBSWY3DPEHPK3PXP", "admin@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/ExchangeRate:admin@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=ExchangeRate")
+	assert.Contains(t, uri, "digits=8")
+}
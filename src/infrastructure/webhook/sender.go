@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	// signaturePrefix names the algorithm so receivers can tell schemes apart if it ever changes
+	signaturePrefix = "sha256="
+)
+
+// ISender posts signed webhook payloads
+type ISender interface {
+	Send(url string, secret string, eventType string, deliveryID int, payload []byte) (int, error)
+}
+
+type Sender struct {
+	Client *http.Client
+	now    func() time.Time
+}
+
+// NewSender builds a sender whose requests time out after WEBHOOK_TIMEOUT_SECONDS
+func NewSender() ISender {
+	timeoutSeconds := 5
+	if value, err := strconv.Atoi(os.Getenv("WEBHOOK_TIMEOUT_SECONDS")); err == nil && value > 0 {
+		timeoutSeconds = value
+	}
+	return NewSenderWithClient(&http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second})
+}
+
+func NewSenderWithClient(client *http.Client) ISender {
+	return &Sender{Client: client, now: time.Now}
+}
+
+// Send posts the payload and returns the response status code. Any status is returned without
+// error; callers decide what counts as delivered.
+func (s *Sender) Send(url string, secret string, eventType string, deliveryID int, payload []byte) (int, error) {
+	timestamp := s.now().Unix()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(deliveryID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign computes the signature header value: an HMAC-SHA256 over "<timestamp>.<payload>".
+// Including the timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the payload; receivers written in Go can use it directly
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	payload := []byte(`{"type":"rates.updated"}`)
+	signature := Sign("secret", 1700000000, payload)
+
+	assert.True(t, Verify("secret", 1700000000, payload, signature))
+	assert.False(t, Verify("other", 1700000000, payload, signature))
+	assert.False(t, Verify("secret", 1700000001, payload, signature))
+	assert.False(t, Verify("secret", 1700000000, []byte(`{}`), signature))
+}
+
+func TestSender_Send(t *testing.T) {
+	payload := []byte(`{"type":"rates.updated"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, "rates.updated", r.Header.Get(HeaderEvent))
+		assert.Equal(t, "12", r.Header.Get(HeaderDelivery))
+		assert.True(t, Verify("secret", timestamp, body, r.Header.Get(HeaderSignature)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewSenderWithClient(&http.Client{Timeout: time.Second})
+	status, err := sender.Send(server.URL, "secret", "rates.updated", 12, payload)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, status)
+}
+
+func TestSender_SendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	sender := NewSenderWithClient(&http.Client{Timeout: time.Second})
+	_, err := sender.Send(server.URL, "secret", "rates.updated", 1, []byte(`{}`))
+	assert.Error(t, err)
+}